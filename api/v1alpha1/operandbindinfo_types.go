@@ -17,8 +17,10 @@
 package v1alpha1
 
 import (
+	"sort"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -39,6 +41,9 @@ const (
 	BindInfoInit      BindInfoPhase = "Initialized"
 	BindInfoUpdating  BindInfoPhase = "Updating"
 	BindInfoWaiting   BindInfoPhase = "Waiting for Secret and/or Configmap from provider"
+
+	BindInfoOperandNotFound BindInfoPhase = "Operand not found in the OperandRegistry"
+	BindInfoLoopDetected    BindInfoPhase = "BindingLoopDetected"
 )
 
 // OperandBindInfoSpec defines the desired state of OperandBindInfo.
@@ -57,6 +62,36 @@ type OperandBindInfoSpec struct {
 	// The bindings section is used to specify information about the access/configuration data that is to be shared.
 	// +optional
 	Bindings map[string]SecretConfigmap `json:"bindings,omitempty"`
+	// OwnerReference configures the owner reference of the copied secrets and configmaps to the OperandRequest.
+	// +optional
+	OwnerReference *CopyOwnerReference `json:"ownerReference,omitempty"`
+	// NamespaceSelector selects the namespaces the public bindings are copied to, in addition to the
+	// namespaces of the OperandRequests. The copies in these namespaces have no owner,
+	// and they are deleted with the OperandBindInfo.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// CopyOwnerReference defines the options of the owner reference of the copies to the OperandRequest.
+type CopyOwnerReference struct {
+	// Controller marks the OperandRequest as the controller of the copies.
+	// The default is true.
+	// +optional
+	Controller *bool `json:"controller,omitempty"`
+	// BlockOwnerDeletion blocks the foreground deletion of the OperandRequest until the copies are deleted.
+	// The default is true.
+	// +optional
+	BlockOwnerDeletion *bool `json:"blockOwnerDeletion,omitempty"`
+}
+
+// IsController returns if the OperandRequest is the controller of the copies.
+func (o *CopyOwnerReference) IsController() bool {
+	return o == nil || o.Controller == nil || *o.Controller
+}
+
+// IsBlockOwnerDeletion returns if the copies block the foreground deletion of the OperandRequest.
+func (o *CopyOwnerReference) IsBlockOwnerDeletion() bool {
+	return o == nil || o.BlockOwnerDeletion == nil || *o.BlockOwnerDeletion
 }
 
 // SecretConfigmap is a pair of Secret and/or Configmap.
@@ -67,6 +102,26 @@ type SecretConfigmap struct {
 	// The configmap identifies an existing configmap object. if it exists, the ODLM will share to the namespace of the OperandRequest.
 	// +optional
 	Configmap string `json:"configmap,omitempty"`
+	// GrantAccess creates a Role and a RoleBinding in the target namespace, granting the service accounts
+	// the get and list access to the copied secret and configmap. It is only honored in the OperandBindInfo.
+	// +optional
+	GrantAccess *BindingAccess `json:"grantAccess,omitempty"`
+}
+
+// BindingAccess defines the service accounts granted the access to the copies of a binding.
+type BindingAccess struct {
+	// ServiceAccounts are the names of the service accounts in the target namespace.
+	// The default is the default service account.
+	// +optional
+	ServiceAccounts []string `json:"serviceAccounts,omitempty"`
+}
+
+// GetServiceAccounts returns the service accounts granted the access to the copies.
+func (a *BindingAccess) GetServiceAccounts() []string {
+	if len(a.ServiceAccounts) == 0 {
+		return []string{"default"}
+	}
+	return a.ServiceAccounts
 }
 
 // OperandBindInfoStatus defines the observed state of OperandBindInfo.
@@ -78,6 +133,25 @@ type OperandBindInfoStatus struct {
 	// RequestNamespaces defines the namespaces of OperandRequest.
 	// +optional
 	RequestNamespaces []string `json:"requestNamespaces,omitempty"`
+	// Conditions represents the current state of the OperandBindInfo.
+	// +optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Conditions",xDescriptors="urn:alm:descriptor:io.kubernetes.conditions"
+	Conditions []Condition `json:"conditions,omitempty"`
+	// CopiedBindings records the checksum of the data of each copy, to detect the copies edited in the target namespaces.
+	// +optional
+	CopiedBindings []CopiedBinding `json:"copiedBindings,omitempty"`
+}
+
+// CopiedBinding is the checksum of the data of a Secret or ConfigMap copied by the OperandBindInfo.
+type CopiedBinding struct {
+	// Kind of the copy, Secret or ConfigMap.
+	Kind string `json:"kind"`
+	// Namespace of the copy.
+	Namespace string `json:"namespace"`
+	// Name of the copy.
+	Name string `json:"name"`
+	// Checksum is the sha256 checksum of the data written to the copy.
+	Checksum string `json:"checksum"`
 }
 
 // +kubebuilder:object:root=true
@@ -117,6 +191,78 @@ func (r *OperandBindInfo) InitBindInfoStatus() bool {
 	return isInitialized
 }
 
+// SetPrivateBindingsWithheldCondition records the namespaces the private bindings are not copied to,
+// the condition is removed once there is none.
+func (r *OperandBindInfo) SetPrivateBindingsWithheldCondition(namespaces []string) {
+	message := "The private bindings are not copied to the namespaces " + strings.Join(namespaces, ", ")
+	// Keep the condition unchanged, so that the status isn't patched on every reconcile
+	if pos, _ := getCondition(&r.Status.Conditions, ConditionPrivateBindingsWithheld, message); pos >= 0 && len(namespaces) != 0 {
+		return
+	}
+	removeConditions(&r.Status.Conditions, ConditionPrivateBindingsWithheld, "")
+	if len(namespaces) == 0 {
+		return
+	}
+	c := newCondition(ConditionPrivateBindingsWithheld, corev1.ConditionTrue, "Private bindings withheld", message)
+	r.Status.Conditions = append(r.Status.Conditions, *c)
+}
+
+// SetCopyDriftDetectedCondition records the copies edited in the target namespaces and restored by the reconcile,
+// the condition is removed once there is none.
+func (r *OperandBindInfo) SetCopyDriftDetectedCondition(copies []string) {
+	message := "The copies are edited and restored: " + strings.Join(copies, ", ")
+	// Keep the condition unchanged, so that the status isn't patched on every reconcile
+	if pos, _ := getCondition(&r.Status.Conditions, ConditionCopyDriftDetected, message); pos >= 0 && len(copies) != 0 {
+		return
+	}
+	removeConditions(&r.Status.Conditions, ConditionCopyDriftDetected, "")
+	if len(copies) == 0 {
+		return
+	}
+	c := newCondition(ConditionCopyDriftDetected, corev1.ConditionTrue, "Copy drift detected", message)
+	r.Status.Conditions = append(r.Status.Conditions, *c)
+}
+
+// GetCopiedBinding returns the recorded checksum of the copy, or nil if there is none.
+func (r *OperandBindInfo) GetCopiedBinding(kind, namespace, name string) *CopiedBinding {
+	for i := range r.Status.CopiedBindings {
+		b := &r.Status.CopiedBindings[i]
+		if b.Kind == kind && b.Namespace == namespace && b.Name == name {
+			return b
+		}
+	}
+	return nil
+}
+
+// SetCopiedBinding records the checksum of the copy, the copies are kept sorted so that the status is stable.
+func (r *OperandBindInfo) SetCopiedBinding(kind, namespace, name, checksum string) {
+	if b := r.GetCopiedBinding(kind, namespace, name); b != nil {
+		b.Checksum = checksum
+		return
+	}
+	r.Status.CopiedBindings = append(r.Status.CopiedBindings, CopiedBinding{Kind: kind, Namespace: namespace, Name: name, Checksum: checksum})
+	sort.Slice(r.Status.CopiedBindings, func(i, j int) bool {
+		a, b := r.Status.CopiedBindings[i], r.Status.CopiedBindings[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+}
+
+// RemoveCopiedBinding removes the recorded checksum of the copy.
+func (r *OperandBindInfo) RemoveCopiedBinding(kind, namespace, name string) {
+	for i, b := range r.Status.CopiedBindings {
+		if b.Kind == kind && b.Namespace == namespace && b.Name == name {
+			r.Status.CopiedBindings = append(r.Status.CopiedBindings[:i], r.Status.CopiedBindings[i+1:]...)
+			return
+		}
+	}
+}
+
 // GetRegistryKey sets the default value for Request spec.
 func (r *OperandBindInfo) GetRegistryKey() types.NamespacedName {
 	if r.Spec.RegistryNamespace != "" {
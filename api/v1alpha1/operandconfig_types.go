@@ -17,8 +17,14 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"strings"
+
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
@@ -29,6 +35,11 @@ type OperandConfigSpec struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Operand Services Config List"
 	// +optional
 	Services []ConfigService `json:"services,omitempty"`
+	// Defaults is the configuration merged under the spec of every custom resource of the services,
+	// e.g. the imagePullSecrets or the storageClass. The values in the spec of a service win over the defaults.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Defaults *runtime.RawExtension `json:"defaults,omitempty"`
 }
 
 // ConfigService defines the configuration of the service.
@@ -36,11 +47,105 @@ type ConfigService struct {
 	// Name is the subscription name.
 	Name string `json:"name"`
 	// Spec is the configuration map of custom resource.
+	// The key is the kind of the custom resource, matching the custom resources of any group,
+	// or its group/version/kind, e.g. `etcd.database.coreos.com/v1beta2/EtcdCluster`, matching only this group.
+	// The key of a named instance of the kind is suffixed with the instance name, e.g. `etcdCluster:backup`,
+	// the instance is created from the alm-example of the kind with the instance name.
+	// A value in the spec can be set from a key of a Secret or a ConfigMap in the namespace of the custom resource
+	// by using `valueFrom: {secretKeyRef: {name: <secret>, key: <key>}}` or `valueFrom: {configMapKeyRef: {name: <configmap>, key: <key>}}`.
 	Spec map[string]runtime.RawExtension `json:"spec"`
 	// State is a flag to enable or disable service.
 	State string `json:"state,omitempty"`
+	// ReadinessPath is the path of the field in the custom resource used to check readiness.
+	// It is either a JSON pointer, e.g. "/status/phase", or a JSONPath expression, e.g. "{.status.phase}".
+	// +optional
+	ReadinessPath string `json:"readinessPath,omitempty"`
+	// UpdateStrategy is the strategy to apply the changes to the existing custom resources.
+	// Valid values are:
+	// - "Patch" (default): update the custom resources in place;
+	// - "Recreate": delete the custom resources and create them with the new spec.
+	// +optional
+	UpdateStrategy UpdateStrategy `json:"updateStrategy,omitempty"`
+	// IgnoredSpecPaths are the dotted paths of the spec fields written by the operators, e.g. "replicas" or "storage.size".
+	// Their differences don't update the custom resources, and their values in the existing custom resources are kept.
+	// +optional
+	IgnoredSpecPaths []string `json:"ignoredSpecPaths,omitempty"`
+	// Overrides are the configurations applied on top of the spec when the cluster version matches.
+	// +optional
+	Overrides []ConfigOverride `json:"overrides,omitempty"`
+	// TargetNamespace is the namespace the custom resources are created in for an operator
+	// installed in AllNamespaces mode, it defaults to the default target namespace of ODLM,
+	// or the namespace of the operator in the OperandRegistry.
+	// It is ignored for the operators installed in OwnNamespace mode, which only watch their own namespace.
+	// +optional
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+	// PostInstallValidation is the template of the Job run in the namespace of the custom resources
+	// once the operand is Running. The operand is Validated only when the Job completes successfully,
+	// otherwise it is ValidationFailed. The Job is deleted once it is finished.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	PostInstallValidation *batchv1beta1.JobTemplateSpec `json:"postInstallValidation,omitempty"`
+	// DeletionPropagation is the propagation policy to delete the custom resources of the service,
+	// it defaults to the deletion propagation policy of ODLM, which is Background by default.
+	// Use Foreground for the stateful custom resources, so they are gone only after their dependents.
+	// +kubebuilder:validation:Enum=Foreground;Background;Orphan
+	// +optional
+	DeletionPropagation metav1.DeletionPropagation `json:"deletionPropagation,omitempty"`
+	// MergeStrategy is the strategy to merge the configuration into the spec of the custom resources.
+	// The key is the kind of the custom resource or its group/version/kind, like the key of the spec.
+	// Valid values are:
+	// - "Merge" (default): merge the objects, the lists in the configuration replace the lists in the spec;
+	// - "StrategicMerge": merge the objects, and merge the lists of objects, e.g. the containers or the env,
+	// by the name of their items.
+	// +optional
+	MergeStrategy map[string]MergeStrategy `json:"mergeStrategy,omitempty"`
+	// IncludeCRs are the alm-examples the service manages, matched by their kind, group/version/kind or name.
+	// All the alm-examples are managed when it is empty.
+	// +optional
+	IncludeCRs []string `json:"includeCRs,omitempty"`
+	// ExcludeCRs are the alm-examples the service doesn't manage, matched like IncludeCRs.
+	// It takes precedence over IncludeCRs.
+	// +optional
+	ExcludeCRs []string `json:"excludeCRs,omitempty"`
+	// ManageCRs creates and updates the custom resources of the service. When it is false, only the operator
+	// is installed, and the custom resources are applied by the user. The default is true.
+	// +optional
+	ManageCRs *bool `json:"manageCRs,omitempty"`
+}
+
+// ConfigOverride defines the configuration of the service for a range of cluster versions.
+type ConfigOverride struct {
+	// ClusterVersion is the semver range of the cluster version, e.g. ">=4.6.0 <4.8.0".
+	// The cluster version is the OpenShift version, or the Kubernetes version on other clusters.
+	ClusterVersion string `json:"clusterVersion"`
+	// Spec is the configuration map of custom resource merged into the spec of the service.
+	Spec map[string]runtime.RawExtension `json:"spec"`
 }
 
+// UpdateStrategy defines how the custom resources are updated.
+// +kubebuilder:validation:Enum=Patch;Recreate
+type UpdateStrategy string
+
+const (
+	// UpdateStrategyPatch means the custom resources are updated in place.
+	UpdateStrategyPatch UpdateStrategy = "Patch"
+	// UpdateStrategyRecreate means the custom resources are deleted and created again.
+	UpdateStrategyRecreate UpdateStrategy = "Recreate"
+)
+
+// MergeStrategy defines how the configuration is merged into the spec of the custom resources.
+// +kubebuilder:validation:Enum=Merge;StrategicMerge
+type MergeStrategy string
+
+const (
+	// MergeStrategyMerge means the lists in the configuration replace the lists in the spec.
+	MergeStrategyMerge MergeStrategy = "Merge"
+	// MergeStrategyStrategicMerge means the lists of objects are merged by the name of their items.
+	MergeStrategyStrategicMerge MergeStrategy = "StrategicMerge"
+)
+
 // OperandConfigStatus defines the observed state of OperandConfig.
 type OperandConfigStatus struct {
 	// Phase describes the overall phase of operands in the OperandConfig.
@@ -56,6 +161,13 @@ type OperandConfigStatus struct {
 type CrStatus struct {
 	// +optional
 	CrStatus map[string]ServicePhase `json:"customResourceStatus,omitempty"`
+	// Phase is Failed when the custom resources of the service can't be checked, e.g. the alm-examples of its
+	// ClusterServiceVersion are invalid.
+	// +optional
+	Phase ServicePhase `json:"phase,omitempty"`
+	// Message is the details of the failure of the service.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // OperandConfig is the Schema for the operandconfigs API.
@@ -96,9 +208,26 @@ const (
 	ServiceFailed  ServicePhase = "Failed"
 	ServiceInit    ServicePhase = "Initialized"
 	ServiceNone    ServicePhase = ""
+
+	// ServicePendingDeletion is the phase of the dropped operands waiting for the confirmation of their removal.
+	ServicePendingDeletion ServicePhase = "PendingDeletion"
+	// ServiceConfigMissing is the phase of the operands whose service is missing from the OperandConfig.
+	ServiceConfigMissing ServicePhase = "ConfigServiceMissing"
+	// ServiceSpecEmpty is the phase of the operands whose service in the OperandConfig doesn't configure any custom resource.
+	ServiceSpecEmpty ServicePhase = "EmptyServiceSpec"
+	// ServiceUserManaged is the phase of the operands whose custom resources are created by the user instead of ODLM.
+	ServiceUserManaged ServicePhase = "UserManaged"
+	// ServiceCRManagementDisabled is the phase of the operands whose custom resources aren't managed by ODLM
+	// because the service or the request disables it.
+	ServiceCRManagementDisabled ServicePhase = "CRManagementDisabled"
+	// ServiceWaitingForDependencies is the phase of the operands whose custom resources wait for their dependencies to be Running.
+	ServiceWaitingForDependencies ServicePhase = "WaitingForDependencies"
+	// ServiceWaitingForOperatorReady is the phase of the operands whose custom resources wait for the ClusterServiceVersion of their operator to be Succeeded.
+	ServiceWaitingForOperatorReady ServicePhase = "WaitingForOperatorReady"
 )
 
 // GetService obtains the service definition with the operand name.
+// If the service name is duplicated, the first one is returned.
 func (r *OperandConfig) GetService(operandName string) *ConfigService {
 	for _, s := range r.Spec.Services {
 		if s.Name == operandName {
@@ -108,6 +237,164 @@ func (r *OperandConfig) GetService(operandName string) *ConfigService {
 	return nil
 }
 
+// GetCRNamespace returns the namespace of the custom resources of the service for the operator.
+// For an operator installed in AllNamespaces mode, the target namespace of the service takes
+// precedence over the default target namespace of ODLM.
+func (s *ConfigService) GetCRNamespace(op *Operator, defaultTargetNamespace string) string {
+	if op.InstallMode != InstallModeCluster {
+		return op.Namespace
+	}
+	if s != nil && s.TargetNamespace != "" {
+		return s.TargetNamespace
+	}
+	if defaultTargetNamespace != "" {
+		return defaultTargetNamespace
+	}
+	return op.Namespace
+}
+
+// GetCRSpec returns the key and the configuration of the custom resource with the GroupVersionKind in the service.
+// The group/version/kind key takes precedence over the kind key.
+func (s *ConfigService) GetCRSpec(gvk schema.GroupVersionKind) (string, runtime.RawExtension, bool) {
+	var (
+		matchedKey string
+		found      bool
+	)
+	for key := range s.Spec {
+		if !CRSpecKeyMatches(key, gvk) {
+			continue
+		}
+		if strings.Contains(key, "/") {
+			return key, s.Spec[key], true
+		}
+		matchedKey, found = key, true
+	}
+	if !found {
+		return "", runtime.RawExtension{}, false
+	}
+	return matchedKey, s.Spec[matchedKey], true
+}
+
+// CRInstanceSeparator separates the kind and the instance name in the key of a named instance of a custom resource.
+const CRInstanceSeparator = ":"
+
+// ParseCRSpecKey splits the key of a custom resource configuration into the kind or group/version/kind key
+// and the instance name, which is empty for the custom resource of the alm-examples.
+func ParseCRSpecKey(key string) (string, string) {
+	if i := strings.LastIndex(key, CRInstanceSeparator); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return key, ""
+}
+
+// GetCRInstances returns the keys of the named instances of the custom resource with the GroupVersionKind
+// in the service, by instance name. The group/version/kind key takes precedence over the kind key.
+func (s *ConfigService) GetCRInstances(gvk schema.GroupVersionKind) map[string]string {
+	instances := make(map[string]string)
+	for key := range s.Spec {
+		kindKey, instance := ParseCRSpecKey(key)
+		if instance == "" || !CRSpecKeyMatches(kindKey, gvk) {
+			continue
+		}
+		if matchedKey, found := instances[instance]; found && strings.Contains(matchedKey, "/") {
+			continue
+		}
+		instances[instance] = key
+	}
+	return instances
+}
+
+// GetMergeStrategy returns the merge strategy of the custom resource with the GroupVersionKind in the service,
+// it defaults to Merge. The group/version/kind key takes precedence over the kind key.
+func (s *ConfigService) GetMergeStrategy(gvk schema.GroupVersionKind) MergeStrategy {
+	strategy := MergeStrategyMerge
+	for key, value := range s.MergeStrategy {
+		if !CRSpecKeyMatches(key, gvk) {
+			continue
+		}
+		if strings.Contains(key, "/") {
+			return value
+		}
+		strategy = value
+	}
+	return strategy
+}
+
+// IsManageCRs returns if ODLM manages the custom resources of the service.
+func (s *ConfigService) IsManageCRs() bool {
+	return s.ManageCRs == nil || *s.ManageCRs
+}
+
+// ManagesCR checks if the alm-example with the GroupVersionKind and the name is managed by the service
+// according to its IncludeCRs and ExcludeCRs.
+func (s *ConfigService) ManagesCR(gvk schema.GroupVersionKind, name string) bool {
+	matches := func(filters []string) bool {
+		for _, filter := range filters {
+			if filter == name || CRSpecKeyMatches(filter, gvk) {
+				return true
+			}
+		}
+		return false
+	}
+	if matches(s.ExcludeCRs) {
+		return false
+	}
+	return len(s.IncludeCRs) == 0 || matches(s.IncludeCRs)
+}
+
+// CRSpecKeyMatches checks if the key of a custom resource configuration matches the GroupVersionKind.
+// A kind key matches the custom resources of any group, a group/version/kind key only matches its group and version.
+func CRSpecKeyMatches(key string, gvk schema.GroupVersionKind) bool {
+	i := strings.LastIndex(key, "/")
+	if i < 0 {
+		return strings.EqualFold(key, gvk.Kind)
+	}
+	gv, err := schema.ParseGroupVersion(key[:i])
+	if err != nil {
+		return false
+	}
+	return gv == gvk.GroupVersion() && strings.EqualFold(key[i+1:], gvk.Kind)
+}
+
+// GetDuplicateServices returns the service names defined more than once.
+func (r *OperandConfig) GetDuplicateServices() []string {
+	var duplicates []string
+	count := make(map[string]int)
+	for _, s := range r.Spec.Services {
+		count[s.Name]++
+		if count[s.Name] == 2 {
+			duplicates = append(duplicates, s.Name)
+		}
+	}
+	return duplicates
+}
+
+// GetInvalidSpecValues returns the errors of the spec values which are not JSON
+// objects, naming the service and the key.
+func (r *OperandConfig) GetInvalidSpecValues() []error {
+	var errs []error
+	for _, s := range r.Spec.Services {
+		if err := s.ValidateSpecValues(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// ValidateSpecValues checks if the spec values and the override spec values
+// of the service are JSON objects.
+func (s *ConfigService) ValidateSpecValues() error {
+	if errs := validateSpecValues(field.NewPath("spec"), s.Spec); len(errs) != 0 {
+		return fmt.Errorf("invalid spec of the service %s: %v", s.Name, errs.ToAggregate())
+	}
+	for i, override := range s.Overrides {
+		if errs := validateSpecValues(field.NewPath("overrides").Index(i).Child("spec"), override.Spec); len(errs) != 0 {
+			return fmt.Errorf("invalid spec of the service %s: %v", s.Name, errs.ToAggregate())
+		}
+	}
+	return nil
+}
+
 //InitConfigServiceStatus initializes service status in the OperandConfig instance.
 func (r *OperandConfig) InitConfigServiceStatus() {
 	r.Status.ServiceStatus = make(map[string]CrStatus)
@@ -133,6 +420,9 @@ func (r *OperandConfig) UpdateOperandPhase() {
 		failedNum:   0,
 	}
 	for _, operator := range r.Status.ServiceStatus {
+		if operator.Phase == ServiceFailed {
+			operandStatusStat.failedNum++
+		}
 		for _, service := range operator.CrStatus {
 			switch service {
 			case ServiceRunning:
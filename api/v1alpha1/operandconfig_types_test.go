@@ -0,0 +1,122 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ = Describe("OperandConfig service", func() {
+
+	DescribeTable("Get the namespace of the custom resources",
+		func(installMode, targetNamespace, defaultTargetNamespace, expected string) {
+			op := &Operator{Name: "etcd", Namespace: "ibm-common-services", InstallMode: installMode}
+			service := &ConfigService{Name: "etcd", TargetNamespace: targetNamespace}
+			Expect(service.GetCRNamespace(op, defaultTargetNamespace)).Should(Equal(expected))
+		},
+		Entry("AllNamespaces without the override", InstallModeCluster, "", "", "ibm-common-services"),
+		Entry("AllNamespaces with the override", InstallModeCluster, "ibm-workloads", "", "ibm-workloads"),
+		Entry("AllNamespaces with the default", InstallModeCluster, "", "ibm-apps", "ibm-apps"),
+		Entry("AllNamespaces with the override and the default", InstallModeCluster, "ibm-workloads", "ibm-apps", "ibm-workloads"),
+		Entry("OwnNamespace without the override", InstallModeNamespace, "", "", "ibm-common-services"),
+		Entry("OwnNamespace ignoring the override", InstallModeNamespace, "ibm-workloads", "", "ibm-common-services"),
+		Entry("OwnNamespace ignoring the default", InstallModeNamespace, "", "ibm-apps", "ibm-common-services"),
+	)
+
+	It("Should use the namespace of the operator without the service", func() {
+		var service *ConfigService
+		op := &Operator{Name: "etcd", Namespace: "ibm-common-services", InstallMode: InstallModeCluster}
+		Expect(service.GetCRNamespace(op, "")).Should(Equal("ibm-common-services"))
+		Expect(service.GetCRNamespace(op, "ibm-apps")).Should(Equal("ibm-apps"))
+	})
+
+	DescribeTable("Match the key of the custom resource configuration",
+		func(key string, matched bool) {
+			gvk := schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"}
+			Expect(CRSpecKeyMatches(key, gvk)).Should(Equal(matched))
+		},
+		Entry("Kind", "etcdCluster", true),
+		Entry("Group/version/kind", "etcd.database.coreos.com/v1beta2/EtcdCluster", true),
+		Entry("Other kind", "etcdBackup", false),
+		Entry("Other group", "etcd.example.com/v1beta2/EtcdCluster", false),
+		Entry("Other version", "etcd.database.coreos.com/v1/EtcdCluster", false),
+		Entry("Core group", "v1beta2/EtcdCluster", false),
+	)
+
+	It("Should prefer the group/version/kind key to the kind key", func() {
+		service := &ConfigService{
+			Name: "etcd",
+			Spec: map[string]runtime.RawExtension{
+				"etcdCluster": {Raw: []byte(`{"size": 1}`)},
+				"etcd.database.coreos.com/v1beta2/EtcdCluster": {Raw: []byte(`{"size": 3}`)},
+			},
+		}
+		key, spec, found := service.GetCRSpec(schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"})
+		Expect(found).Should(BeTrue())
+		Expect(key).Should(Equal("etcd.database.coreos.com/v1beta2/EtcdCluster"))
+		Expect(string(spec.Raw)).Should(Equal(`{"size": 3}`))
+
+		By("Falling back to the kind key for the other groups")
+		key, _, found = service.GetCRSpec(schema.GroupVersionKind{Group: "etcd.example.com", Version: "v1", Kind: "EtcdCluster"})
+		Expect(found).Should(BeTrue())
+		Expect(key).Should(Equal("etcdCluster"))
+
+		_, _, found = service.GetCRSpec(schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdBackup"})
+		Expect(found).Should(BeFalse())
+	})
+
+	It("Should get the named instances of the custom resource", func() {
+		service := &ConfigService{
+			Name: "etcd",
+			Spec: map[string]runtime.RawExtension{
+				"etcdCluster":        {Raw: []byte(`{"size": 1}`)},
+				"etcdCluster:backup": {Raw: []byte(`{"size": 3}`)},
+				"etcdCluster:audit":  {Raw: []byte(`{"size": 1}`)},
+				"etcd.database.coreos.com/v1beta2/EtcdCluster:audit": {Raw: []byte(`{"size": 5}`)},
+				"etcdBackup:daily": {Raw: []byte(`{}`)},
+			},
+		}
+		Expect(service.GetCRInstances(schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"})).Should(Equal(map[string]string{
+			"backup": "etcdCluster:backup",
+			"audit":  "etcd.database.coreos.com/v1beta2/EtcdCluster:audit",
+		}))
+
+		By("Leaving the named instances out of the custom resource of the alm-examples")
+		key, _, found := service.GetCRSpec(schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"})
+		Expect(found).Should(BeTrue())
+		Expect(key).Should(Equal("etcdCluster"))
+		_, _, found = service.GetCRSpec(schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdBackup"})
+		Expect(found).Should(BeFalse())
+	})
+
+	It("Should get the merge strategy of the custom resource", func() {
+		service := &ConfigService{
+			Name: "etcd",
+			MergeStrategy: map[string]MergeStrategy{
+				"etcdCluster":                     MergeStrategyStrategicMerge,
+				"etcd.example.com/v1/EtcdCluster": MergeStrategyMerge,
+			},
+		}
+		Expect(service.GetMergeStrategy(schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"})).Should(Equal(MergeStrategyStrategicMerge))
+		Expect(service.GetMergeStrategy(schema.GroupVersionKind{Group: "etcd.example.com", Version: "v1", Kind: "EtcdCluster"})).Should(Equal(MergeStrategyMerge))
+		Expect(service.GetMergeStrategy(schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdBackup"})).Should(Equal(MergeStrategyMerge))
+	})
+})
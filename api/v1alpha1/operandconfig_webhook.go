@@ -0,0 +1,185 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/util/jsonpath"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager registers the OperandConfig webhook with the manager.
+func (r *OperandConfig) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-operator-ibm-com-v1alpha1-operandconfig,mutating=false,failurePolicy=fail,sideEffects=None,groups=operator.ibm.com,resources=operandconfigs,verbs=create;update,versions=v1alpha1,name=voperandconfig.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &OperandConfig{}
+
+// ValidateCreate implements webhook.Validator.
+func (r *OperandConfig) ValidateCreate() error {
+	return r.validateOperandConfig()
+}
+
+// ValidateUpdate implements webhook.Validator.
+func (r *OperandConfig) ValidateUpdate(old runtime.Object) error {
+	return r.validateOperandConfig()
+}
+
+// ValidateDelete implements webhook.Validator.
+func (r *OperandConfig) ValidateDelete() error {
+	return nil
+}
+
+func (r *OperandConfig) validateOperandConfig() error {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	if r.Spec.Defaults != nil && len(r.Spec.Defaults.Raw) != 0 {
+		if err := ValidateObjectValue(r.Spec.Defaults.Raw); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("defaults"), string(r.Spec.Defaults.Raw), err.Error()))
+		}
+	}
+	servicesPath := specPath.Child("services")
+	serviceNames := make(map[string]bool)
+	for i, service := range r.Spec.Services {
+		if serviceNames[service.Name] {
+			allErrs = append(allErrs, field.Duplicate(servicesPath.Index(i).Child("name"), service.Name))
+		}
+		serviceNames[service.Name] = true
+		allErrs = append(allErrs, validateSpecValues(servicesPath.Index(i).Child("spec"), service.Spec)...)
+		for j, override := range service.Overrides {
+			if _, err := semver.ParseRange(override.ClusterVersion); err != nil {
+				allErrs = append(allErrs, field.Invalid(servicesPath.Index(i).Child("overrides").Index(j).Child("clusterVersion"), override.ClusterVersion, err.Error()))
+			}
+			allErrs = append(allErrs, validateSpecValues(servicesPath.Index(i).Child("overrides").Index(j).Child("spec"), override.Spec)...)
+		}
+		if service.TargetNamespace != "" {
+			for _, msg := range validation.IsDNS1123Label(service.TargetNamespace) {
+				allErrs = append(allErrs, field.Invalid(servicesPath.Index(i).Child("targetNamespace"), service.TargetNamespace, msg))
+			}
+		}
+		if service.ReadinessPath == "" {
+			continue
+		}
+		if err := ValidateReadinessPath(service.ReadinessPath); err != nil {
+			allErrs = append(allErrs, field.Invalid(servicesPath.Index(i).Child("readinessPath"), service.ReadinessPath, err.Error()))
+		}
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("OperandConfig").GroupKind(), r.Name, allErrs)
+}
+
+func validateSpecValues(path *field.Path, spec map[string]runtime.RawExtension) field.ErrorList {
+	var allErrs field.ErrorList
+	keys := make([]string, 0, len(spec))
+	for key := range spec {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if kindKey, instance := ParseCRSpecKey(key); kindKey != key {
+			for _, msg := range validation.IsDNS1123Subdomain(instance) {
+				allErrs = append(allErrs, field.Invalid(path.Key(key), instance, "invalid instance name: "+msg))
+			}
+		}
+		if err := ValidateObjectValue(spec[key].Raw); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Key(key), string(spec[key].Raw), err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// ValidateObjectValue checks if the raw value is a JSON object, the spec values
+// of the OperandConfig are merged into the custom resources as objects.
+// An empty value is valid.
+func ValidateObjectValue(raw []byte) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	switch value.(type) {
+	case map[string]interface{}, nil:
+		return nil
+	case []interface{}:
+		return fmt.Errorf("must be a JSON object, got an array")
+	case string:
+		return fmt.Errorf("must be a JSON object, got a string")
+	case float64:
+		return fmt.Errorf("must be a JSON object, got a number")
+	case bool:
+		return fmt.Errorf("must be a JSON object, got a boolean")
+	default:
+		return fmt.Errorf("must be a JSON object, got %T", value)
+	}
+}
+
+// ValidateReadinessPath checks if the path is a valid JSON pointer (RFC 6901)
+// or a valid JSONPath expression.
+func ValidateReadinessPath(path string) error {
+	if strings.HasPrefix(path, "/") {
+		return validateJSONPointer(path)
+	}
+	return validateJSONPath(path)
+}
+
+func validateJSONPointer(pointer string) error {
+	for i := 0; i < len(pointer); i++ {
+		if pointer[i] != '~' {
+			continue
+		}
+		if i+1 >= len(pointer) || (pointer[i+1] != '0' && pointer[i+1] != '1') {
+			return fmt.Errorf("invalid escape sequence at position %d, '~' must be followed by '0' or '1'", i)
+		}
+	}
+	return nil
+}
+
+func validateJSONPath(path string) error {
+	expr := strings.TrimSpace(path)
+	if strings.HasPrefix(expr, "{") && strings.HasSuffix(expr, "}") {
+		expr = expr[1 : len(expr)-1]
+	}
+	if expr == "" {
+		return fmt.Errorf("empty JSONPath expression")
+	}
+	if !strings.HasPrefix(expr, ".") && !strings.HasPrefix(expr, "$") {
+		return fmt.Errorf("JSONPath expression must start with '.', '$' or '/' for a JSON pointer")
+	}
+	parser := jsonpath.New("readinessPath")
+	if err := parser.Parse("{" + expr + "}"); err != nil {
+		return fmt.Errorf("invalid JSONPath expression: %v", err)
+	}
+	return nil
+}
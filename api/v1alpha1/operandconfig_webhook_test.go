@@ -0,0 +1,286 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var _ = Describe("OperandConfig webhook", func() {
+
+	Context("Validate readiness path", func() {
+		It("Should accept valid JSON pointers and JSONPath expressions", func() {
+			validPaths := []string{
+				"/status/phase",
+				"/status/conditions/0/type",
+				"/metadata/annotations/operator.ibm.com~1ready",
+				"/a~0b",
+				"{.status.phase}",
+				".status.phase",
+				"{.status.conditions[0].type}",
+				"{.status.conditions[?(@.type==\"Ready\")].status}",
+				"$.status.phase",
+			}
+			for _, path := range validPaths {
+				Expect(ValidateReadinessPath(path)).Should(Succeed(), "path %q should be valid", path)
+			}
+		})
+
+		It("Should reject malformed JSON pointers and JSONPath expressions", func() {
+			invalidPaths := []string{
+				"/status/~2phase",
+				"/status/phase~",
+				"{}",
+				"status.phase",
+				"{.status.conditions[0}",
+				"{.status.conditions[?(@.type==\"Ready\"].status}",
+			}
+			for _, path := range invalidPaths {
+				Expect(ValidateReadinessPath(path)).ShouldNot(Succeed(), "path %q should be invalid", path)
+			}
+		})
+
+		It("Should return a field error for an invalid readiness path", func() {
+			config := &OperandConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "common-service",
+					Namespace: "ibm-common-services",
+				},
+				Spec: OperandConfigSpec{
+					Services: []ConfigService{
+						{
+							Name:          "etcd",
+							ReadinessPath: "/status/phase",
+						},
+						{
+							Name:          "jenkins",
+							ReadinessPath: "{.status.conditions[0}",
+						},
+					},
+				},
+			}
+
+			err := config.ValidateCreate()
+			Expect(err).Should(HaveOccurred())
+			Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+			statusErr, ok := err.(*apierrors.StatusError)
+			Expect(ok).Should(BeTrue())
+			Expect(statusErr.ErrStatus.Details.Causes).Should(HaveLen(1))
+			Expect(statusErr.ErrStatus.Details.Causes[0].Field).Should(Equal("spec.services[1].readinessPath"))
+
+			config.Spec.Services[1].ReadinessPath = "{.status.phase}"
+			Expect(config.ValidateUpdate(config.DeepCopy())).Should(Succeed())
+		})
+	})
+	Context("Validate service names", func() {
+		var config *OperandConfig
+
+		BeforeEach(func() {
+			config = &OperandConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "common-service",
+					Namespace: "ibm-common-services",
+				},
+				Spec: OperandConfigSpec{
+					Services: []ConfigService{
+						{Name: "etcd", State: "first"},
+						{Name: "jenkins"},
+						{Name: "etcd", State: "second"},
+					},
+				},
+			}
+		})
+
+		It("Should detect the duplicate service names", func() {
+			Expect(config.GetDuplicateServices()).Should(Equal([]string{"etcd"}))
+			Expect(config.GetService("etcd").State).Should(Equal("first"))
+
+			config.Spec.Services = config.Spec.Services[:2]
+			Expect(config.GetDuplicateServices()).Should(BeEmpty())
+		})
+
+		It("Should reject the duplicate service names", func() {
+			err := config.ValidateCreate()
+			Expect(err).Should(HaveOccurred())
+			statusErr, ok := err.(*apierrors.StatusError)
+			Expect(ok).Should(BeTrue())
+			Expect(statusErr.ErrStatus.Details.Causes).Should(HaveLen(1))
+			Expect(statusErr.ErrStatus.Details.Causes[0].Field).Should(Equal("spec.services[2].name"))
+
+			config.Spec.Services[2].Name = "etcd-2"
+			Expect(config.ValidateUpdate(config.DeepCopy())).Should(Succeed())
+		})
+	})
+	Context("Validate overrides", func() {
+		It("Should reject an invalid cluster version range", func() {
+			config := &OperandConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "common-service",
+					Namespace: "ibm-common-services",
+				},
+				Spec: OperandConfigSpec{
+					Services: []ConfigService{
+						{
+							Name: "etcd",
+							Overrides: []ConfigOverride{
+								{ClusterVersion: ">=4.6.0 <4.8.0"},
+								{ClusterVersion: "4.6.x-latest"},
+							},
+						},
+					},
+				},
+			}
+
+			err := config.ValidateCreate()
+			Expect(err).Should(HaveOccurred())
+			statusErr, ok := err.(*apierrors.StatusError)
+			Expect(ok).Should(BeTrue())
+			Expect(statusErr.ErrStatus.Details.Causes).Should(HaveLen(1))
+			Expect(statusErr.ErrStatus.Details.Causes[0].Field).Should(Equal("spec.services[0].overrides[1].clusterVersion"))
+
+			config.Spec.Services[0].Overrides[1].ClusterVersion = "<4.6.0 || >=4.8.0"
+			Expect(config.ValidateUpdate(config.DeepCopy())).Should(Succeed())
+		})
+	})
+	Context("Validate target namespace", func() {
+		It("Should reject an invalid target namespace", func() {
+			config := &OperandConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "common-service",
+					Namespace: "ibm-common-services",
+				},
+				Spec: OperandConfigSpec{
+					Services: []ConfigService{
+						{Name: "etcd", TargetNamespace: "IBM_Workloads"},
+					},
+				},
+			}
+
+			err := config.ValidateCreate()
+			Expect(err).Should(HaveOccurred())
+			statusErr, ok := err.(*apierrors.StatusError)
+			Expect(ok).Should(BeTrue())
+			Expect(statusErr.ErrStatus.Details.Causes).ShouldNot(BeEmpty())
+			Expect(statusErr.ErrStatus.Details.Causes[0].Field).Should(Equal("spec.services[0].targetNamespace"))
+
+			config.Spec.Services[0].TargetNamespace = "ibm-workloads"
+			Expect(config.ValidateUpdate(config.DeepCopy())).Should(Succeed())
+		})
+	})
+	Context("Validate instance names", func() {
+		It("Should reject an invalid instance name", func() {
+			config := &OperandConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "common-service",
+					Namespace: "ibm-common-services",
+				},
+				Spec: OperandConfigSpec{
+					Services: []ConfigService{
+						{
+							Name: "etcd",
+							Spec: map[string]runtime.RawExtension{
+								"etcdCluster:backup": {Raw: []byte(`{"size": 3}`)},
+								"etcdCluster:Audit":  {Raw: []byte(`{"size": 1}`)},
+							},
+						},
+					},
+				},
+			}
+
+			err := config.ValidateCreate()
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("etcdCluster:Audit"))
+			Expect(err.Error()).ShouldNot(ContainSubstring("etcdCluster:backup"))
+		})
+	})
+	Context("Validate spec values", func() {
+		DescribeTable("Should only accept JSON objects",
+			func(raw string, valid bool) {
+				err := ValidateObjectValue([]byte(raw))
+				if valid {
+					Expect(err).ShouldNot(HaveOccurred())
+				} else {
+					Expect(err).Should(HaveOccurred())
+				}
+			},
+			Entry("object", `{"replicas": 3}`, true),
+			Entry("empty object", `{}`, true),
+			Entry("null", `null`, true),
+			Entry("empty value", ``, true),
+			Entry("array", `[{"replicas": 3}]`, false),
+			Entry("string", `"etcd"`, false),
+			Entry("number", `3`, false),
+			Entry("boolean", `true`, false),
+		)
+
+		It("Should reject the spec values which are not JSON objects", func() {
+			config := &OperandConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "common-service",
+					Namespace: "ibm-common-services",
+				},
+				Spec: OperandConfigSpec{
+					Defaults: &runtime.RawExtension{Raw: []byte(`"default"`)},
+					Services: []ConfigService{
+						{
+							Name: "etcd",
+							Spec: map[string]runtime.RawExtension{
+								"etcdCluster": {Raw: []byte(`{"size": 3}`)},
+								"etcdBackup":  {Raw: []byte(`[1, 2]`)},
+							},
+							Overrides: []ConfigOverride{
+								{
+									ClusterVersion: ">=4.6.0",
+									Spec: map[string]runtime.RawExtension{
+										"etcdCluster": {Raw: []byte(`3`)},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			err := config.ValidateCreate()
+			Expect(err).Should(HaveOccurred())
+			statusErr, ok := err.(*apierrors.StatusError)
+			Expect(ok).Should(BeTrue())
+			Expect(statusErr.ErrStatus.Details.Causes).Should(HaveLen(3))
+			Expect(statusErr.ErrStatus.Details.Causes[0].Field).Should(Equal("spec.defaults"))
+			Expect(statusErr.ErrStatus.Details.Causes[1].Field).Should(Equal("spec.services[0].spec[etcdBackup]"))
+			Expect(statusErr.ErrStatus.Details.Causes[2].Field).Should(Equal("spec.services[0].overrides[0].spec[etcdCluster]"))
+
+			Expect(config.GetInvalidSpecValues()).Should(HaveLen(1))
+			err = config.Spec.Services[0].ValidateSpecValues()
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("etcd"))
+			Expect(err.Error()).Should(ContainSubstring("etcdBackup"))
+
+			config.Spec.Defaults = &runtime.RawExtension{Raw: []byte(`{"storageClass": "fast"}`)}
+			config.Spec.Services[0].Spec["etcdBackup"] = runtime.RawExtension{Raw: []byte(`{"schedule": "@daily"}`)}
+			config.Spec.Services[0].Overrides[0].Spec["etcdCluster"] = runtime.RawExtension{Raw: []byte(`{"size": 5}`)}
+			Expect(config.ValidateUpdate(config.DeepCopy())).Should(Succeed())
+			Expect(config.Spec.Services[0].ValidateSpecValues()).Should(Succeed())
+		})
+	})
+})
@@ -17,10 +17,17 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -50,7 +57,14 @@ type Operator struct {
 	SourceName string `json:"sourceName,omitempty"`
 	// The Kubernetes namespace where the CatalogSource used is located.
 	SourceNamespace string `json:"sourceNamespace,omitempty"`
+	// CatalogOverrides replace the CatalogSource of the operator in the namespaces and the install mode they select,
+	// at most one of them can select the operator. The SourceName and SourceNamespace are used when none selects it.
+	// +optional
+	CatalogOverrides []CatalogOverride `json:"catalogOverrides,omitempty"`
 	// The target namespace of the OperatorGroups.
+	// It defaults to the namespace of the operator in the namespace install mode,
+	// and it can't be set in the cluster install mode, in which the operator watches all the namespaces.
+	// +optional
 	TargetNamespaces []string `json:"targetNamespaces,omitempty"`
 	// Name of the package that defines the applications.
 	PackageName string `json:"packageName"`
@@ -64,13 +78,36 @@ type Operator struct {
 	// Valid values are:
 	// - "Automatic" (default): operator will be installed automatically;
 	// - "Manual": operator installation will be pending until users approve it;
+	// +kubebuilder:validation:Enum=Automatic;Manual
 	InstallPlanApproval olmv1alpha1.Approval `json:"installPlanApproval,omitempty"`
 	// StartingCSV of the installation.
 	// +optional
 	StartingCSV string `json:"startingCSV,omitempty"`
+	// Defaults are the default specs of the custom resources of the operator, keyed like the spec of the
+	// OperandConfig services. They are merged under the configuration of the OperandConfig,
+	// whose defaults, spec and overrides win over them.
+	// +optional
+	Defaults map[string]runtime.RawExtension `json:"defaults,omitempty"`
 }
 
 // +kubebuilder:validation:Enum=public;private
+// CatalogOverride is a CatalogSource to install the operator from in the namespaces and the install mode it selects.
+type CatalogOverride struct {
+	// InstallMode selects the install mode of the operator, either namespace or cluster.
+	// Both install modes are selected when it is empty.
+	// +optional
+	// +kubebuilder:validation:Enum=namespace;cluster
+	InstallMode string `json:"installMode,omitempty"`
+	// NamespaceSelector selects the namespace the operator is installed in by its labels.
+	// All the namespaces are selected when it is empty.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Name of the CatalogSource the operator is installed from.
+	SourceName string `json:"sourceName"`
+	// The Kubernetes namespace where the CatalogSource is located.
+	SourceNamespace string `json:"sourceNamespace"`
+}
+
 type scope string
 
 const (
@@ -209,6 +246,106 @@ func (r *OperandRegistry) GetOperator(operandName string) *Operator {
 	return nil
 }
 
+// ValidateTargetNamespaces checks if the target namespaces of the operator are compatible with its install mode.
+func (o *Operator) ValidateTargetNamespaces() error {
+	if o.InstallMode == InstallModeCluster && len(o.TargetNamespaces) != 0 {
+		return fmt.Errorf("the target namespaces %s of the operator %s can't be set in the %s install mode", strings.Join(o.TargetNamespaces, ", "), o.Name, InstallModeCluster)
+	}
+	return nil
+}
+
+// ValidateNamespace checks if the namespace of the operator is set when its install mode requires it.
+// Only the operators in the cluster install mode are installed without their namespace.
+func (o *Operator) ValidateNamespace() error {
+	if o.InstallMode != InstallModeCluster && strings.TrimSpace(o.Namespace) == "" {
+		return fmt.Errorf("the namespace of the operator %s is required in the %s install mode", o.Name, InstallModeNamespace)
+	}
+	return nil
+}
+
+// GetCatalogOverride returns the catalog override selecting the operator installed in the namespace with the labels,
+// or nil if none selects it. It fails when more than one override selects the operator.
+func (o *Operator) GetCatalogOverride(namespaceLabels map[string]string) (*CatalogOverride, error) {
+	installMode := o.InstallMode
+	if installMode == "" {
+		installMode = InstallModeNamespace
+	}
+	var (
+		matched *CatalogOverride
+		indexes []string
+	)
+	for i := range o.CatalogOverrides {
+		override := &o.CatalogOverrides[i]
+		if override.InstallMode != "" && override.InstallMode != installMode {
+			continue
+		}
+		if override.NamespaceSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(override.NamespaceSelector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid namespace selector of the catalog override %d of the operator %s: %v", i, o.Name, err)
+			}
+			if !selector.Matches(labels.Set(namespaceLabels)) {
+				continue
+			}
+		}
+		if matched == nil {
+			matched = override
+		}
+		indexes = append(indexes, strconv.Itoa(i))
+	}
+	if len(indexes) > 1 {
+		return nil, fmt.Errorf("the catalog overrides %s of the operator %s select it at the same time", strings.Join(indexes, ", "), o.Name)
+	}
+	return matched, nil
+}
+
+// ValidateOperators checks if the names of the operators are unique, and if their required fields are set.
+// An empty source name is allowed, the catalog source is then resolved from the package.
+func (r *OperandRegistry) ValidateOperators() field.ErrorList {
+	var allErrs field.ErrorList
+	operatorsPath := field.NewPath("spec").Child("operators")
+	names := make(map[string]bool)
+	for i, o := range r.Spec.Operators {
+		path := operatorsPath.Index(i)
+		if o.Name == "" {
+			allErrs = append(allErrs, field.Required(path.Child("name"), "the name of the operator is required"))
+		} else if names[o.Name] {
+			allErrs = append(allErrs, field.Duplicate(path.Child("name"), o.Name))
+		}
+		names[o.Name] = true
+		if strings.TrimSpace(o.PackageName) == "" {
+			allErrs = append(allErrs, field.Required(path.Child("packageName"), "the package of the operator is required"))
+		}
+		if strings.TrimSpace(o.Channel) == "" {
+			allErrs = append(allErrs, field.Required(path.Child("channel"), "the channel of the operator is required"))
+		}
+		if err := o.ValidateNamespace(); err != nil {
+			allErrs = append(allErrs, field.Required(path.Child("namespace"), err.Error()))
+		}
+		if o.SourceName != "" && o.SourceNamespace == "" {
+			allErrs = append(allErrs, field.Required(path.Child("sourceNamespace"), "the namespace of the catalog source is required with its name"))
+		}
+		for j, override := range o.CatalogOverrides {
+			overridePath := path.Child("catalogOverrides").Index(j)
+			if override.SourceName == "" {
+				allErrs = append(allErrs, field.Required(overridePath.Child("sourceName"), "the name of the catalog source is required"))
+			}
+			if override.SourceNamespace == "" {
+				allErrs = append(allErrs, field.Required(overridePath.Child("sourceNamespace"), "the namespace of the catalog source is required"))
+			}
+			if override.InstallMode != "" && override.InstallMode != InstallModeNamespace && override.InstallMode != InstallModeCluster {
+				allErrs = append(allErrs, field.NotSupported(overridePath.Child("installMode"), override.InstallMode, []string{InstallModeNamespace, InstallModeCluster}))
+			}
+			if override.NamespaceSelector != nil {
+				if _, err := metav1.LabelSelectorAsSelector(override.NamespaceSelector); err != nil {
+					allErrs = append(allErrs, field.Invalid(overridePath.Child("namespaceSelector"), override.NamespaceSelector, err.Error()))
+				}
+			}
+		}
+	}
+	return allErrs
+}
+
 // GetAllReconcileRequest gets all the ReconcileRequest from OperandRegistry status.
 func (r *OperandRegistry) GetAllReconcileRequest() []reconcile.Request {
 	maprrs := make(map[string]reconcile.Request)
@@ -17,6 +17,8 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -25,6 +27,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -35,6 +38,19 @@ type OperandRequestSpec struct {
 	// Requests defines a list of operands installation.
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Operators Request List"
 	Requests []Request `json:"requests"`
+	// ConfirmRemoval defers the deletion of the operands dropped from the requests.
+	// The dropped operands are kept in the PendingDeletion phase until they are listed
+	// in the operator.ibm.com/confirmed-removals annotation of the OperandRequest.
+	// +optional
+	ConfirmRemoval bool `json:"confirmRemoval,omitempty"`
+	// InstallTimeout overrides the default install timeout of ODLM, the OperandRequest is marked Failed
+	// when it isn't Running within the timeout. 0 disables the timeout.
+	// +optional
+	InstallTimeout *metav1.Duration `json:"installTimeout,omitempty"`
+	// RetryBudget overrides the default retry budget of ODLM, the OperandRequest isn't retried once it
+	// fails the budget of reconciles in a row, until its spec is changed. 0 disables the budget.
+	// +optional
+	RetryBudget *int32 `json:"retryBudget,omitempty"`
 }
 
 // Request identifies a operand detail.
@@ -47,9 +63,26 @@ type Request struct {
 	// The default is the current namespace in which the request is defined.
 	// +optional
 	RegistryNamespace string `json:"registryNamespace,omitempty"`
+	// Specifies the namespace in which the OperandConfig reside, the OperandConfig has the same name as the OperandRegistry.
+	// The default is the namespace of the OperandRegistry.
+	// +optional
+	ConfigNamespace string `json:"configNamespace,omitempty"`
 	// Description is an optional description for the request.
 	// +optional
 	Description string `json:"description,omitempty"`
+	// ManageCRs creates and updates the custom resources of the operands in the request. When it is false,
+	// only the operators are installed, and the custom resources are applied by the user. The default is true.
+	// +optional
+	ManageCRs *bool `json:"manageCRs,omitempty"`
+	// Parameters are the values referred by the templates in the OperandConfig services of the operands,
+	// e.g. `{{ .Parameters.size }}`, so the same OperandConfig serves the requests with small differences.
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// IsManageCRs returns if ODLM manages the custom resources of the operands in the request.
+func (r *Request) IsManageCRs() bool {
+	return r.ManageCRs == nil || *r.ManageCRs
 }
 
 // Operand defines the name and binding information for one operator.
@@ -75,6 +108,25 @@ type Operand struct {
 	// +nullable
 	// +optional
 	Spec *runtime.RawExtension `json:"spec,omitempty"`
+	// StartingCSV pins the operator of the operand to this ClusterServiceVersion.
+	// The Subscription is created with it as the startingCSV and the manual approval, only the InstallPlan of
+	// the pinned ClusterServiceVersion is approved, and the custom resources aren't created until it is installed.
+	// The operand is Failed when another ClusterServiceVersion is installed.
+	// +optional
+	StartingCSV string `json:"startingCSV,omitempty"`
+	// InstallCR creates the custom resources of the operand. When it is false, only the operator is installed
+	// and the custom resources are managed by the user. The default is true.
+	// +optional
+	InstallCR *bool `json:"installCR,omitempty"`
+	// DependsOn lists the operands of the OperandRequest whose custom resources must be Running
+	// before the custom resources of this operand are created. The operand is removed before its dependencies.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// IsInstallCR returns if ODLM creates the custom resources of the operand.
+func (o *Operand) IsInstallCR() bool {
+	return o.InstallCR == nil || *o.InstallCR
 }
 
 // ConditionType is the condition of a service.
@@ -95,12 +147,31 @@ const (
 	// when an OperandRequest is deleted.
 	RequestFinalizer = "finalizer.request.ibm.com"
 
-	ConditionCreating   ConditionType = "Creating"
-	ConditionUpdating   ConditionType = "Updating"
-	ConditionDeleting   ConditionType = "Deleting"
-	ConditionNotFound   ConditionType = "NotFound"
-	ConditionOutofScope ConditionType = "OutofScope"
-	ConditionReady      ConditionType = "Ready"
+	ConditionCreating    ConditionType = "Creating"
+	ConditionUpdating    ConditionType = "Updating"
+	ConditionDeleting    ConditionType = "Deleting"
+	ConditionNotFound    ConditionType = "NotFound"
+	ConditionOutofScope  ConditionType = "OutofScope"
+	ConditionReady       ConditionType = "Ready"
+	ConditionMemberReady ConditionType = "MemberReady"
+	ConditionMigrated    ConditionType = "Migrated"
+
+	ConditionInsufficientPermissions  ConditionType = "InsufficientPermissions"
+	ConditionNamespaceQuotaExceeded   ConditionType = "NamespaceQuotaExceeded"
+	ConditionRequestInstallTimeout    ConditionType = "RequestInstallTimeout"
+	ConditionRetryBudgetExhausted     ConditionType = "RetryBudgetExhausted"
+	ConditionDependencyCycle          ConditionType = "DependencyCycle"
+	ConditionMissingDependency        ConditionType = "MissingDependency"
+	ConditionSubscriptionsReady       ConditionType = "SubscriptionsReady"
+	ConditionOperandsReady            ConditionType = "OperandsReady"
+	ConditionReapplied                ConditionType = "Reapplied"
+	ConditionMissingRequestAnnotation ConditionType = "MissingRequestAnnotation"
+	ConditionMissingConfigReference   ConditionType = "MissingConfigReference"
+	ConditionCSVMismatch              ConditionType = "CSVMismatch"
+	ConditionPrivateBindingsWithheld  ConditionType = "PrivateBindingsWithheld"
+	ConditionCopyDriftDetected        ConditionType = "CopyDriftDetected"
+	ConditionWaitingForCSV            ConditionType = "WaitingForClusterServiceVersion"
+	ConditionTargetNamespaceMissing   ConditionType = "TargetNamespaceMissing"
 
 	OperatorReady      OperatorPhase = "Ready for Deployment"
 	OperatorRunning    OperatorPhase = "Running"
@@ -116,6 +187,9 @@ const (
 	ClusterPhaseUpdating   ClusterPhase = "Updating"
 	ClusterPhaseRunning    ClusterPhase = "Running"
 	ClusterPhaseFailed     ClusterPhase = "Failed"
+	ClusterPhaseDeleting   ClusterPhase = "Deleting"
+
+	ClusterPhaseWaitingForApproval ClusterPhase = "WaitingForApproval"
 
 	ResourceTypeOperandRegistry ResourceType = "operandregistry"
 	ResourceTypeCatalogSource   ResourceType = "catalogsource"
@@ -159,6 +233,18 @@ type OperandRequestStatus struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Phase",xDescriptors="urn:alm:descriptor:io.kubernetes.phase"
 	// +optional
 	Phase ClusterPhase `json:"phase,omitempty"`
+	// RetryCount is the number of the failed reconciles in a row, it is reset once a reconcile succeeds.
+	// +optional
+	RetryCount int32 `json:"retryCount,omitempty"`
+	// ObservedGeneration is the generation of the spec the RetryCount is counted for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// InstallStartTime is the time the OperandRequest started waiting to be Running, it is reset once it is Running.
+	// +optional
+	InstallStartTime *metav1.Time `json:"installStartTime,omitempty"`
+	// OperandResults are the actions taken on the operands during the last reconcile.
+	// +optional
+	OperandResults []OperandResult `json:"operandResults,omitempty"`
 }
 
 // MemberPhase shows the phase of the operator and operator instance.
@@ -169,8 +255,22 @@ type MemberPhase struct {
 	// OperandPhase shows the deploy phase of the operator instance.
 	// +optional
 	OperandPhase ServicePhase `json:"operandPhase,omitempty"`
+	// ValidationPhase shows the phase of the post-install validation of the operator instance.
+	// +optional
+	ValidationPhase ValidationPhase `json:"validationPhase,omitempty"`
 }
 
+// ValidationPhase defines the phase of the post-install validation Job of the operands.
+type ValidationPhase string
+
+// Validation Phase of the operands
+const (
+	ValidationNone      ValidationPhase = ""
+	ValidationRunning   ValidationPhase = "Validating"
+	ValidationSucceeded ValidationPhase = "Validated"
+	ValidationFailed    ValidationPhase = "ValidationFailed"
+)
+
 // OperandCRMember defines a custom resource created by OperandRequest.
 type OperandCRMember struct {
 	// Name is the name of the custom resource.
@@ -194,6 +294,112 @@ type MemberStatus struct {
 	// OperandCRList shows the list of custom resource created by OperandRequest.
 	// +optional
 	OperandCRList []OperandCRMember `json:"operandCRList,omitempty"`
+	// InstallPlanRef shows the InstallPlan waiting for approval of the operator.
+	// +optional
+	InstallPlanRef *InstallPlanReference `json:"installPlanRef,omitempty"`
+	// CatalogSourceHealth shows the unhealthy CatalogSource of the subscription while the operator isn't installed.
+	// +optional
+	CatalogSourceHealth *CatalogSourceHealth `json:"catalogSourceHealth,omitempty"`
+	// DryRunChanges shows the changes of the custom resources ODLM would apply
+	// while the OperandRequest is in dry-run mode.
+	// +optional
+	DryRunChanges []DryRunChange `json:"dryRunChanges,omitempty"`
+}
+
+// DryRunChange is a change of a custom resource skipped in dry-run mode.
+type DryRunChange struct {
+	// APIVersion is the APIVersion of the custom resource.
+	APIVersion string `json:"apiVersion"`
+	// Kind is the kind of the custom resource.
+	Kind string `json:"kind"`
+	// Name is the name of the custom resource.
+	Name string `json:"name"`
+	// Namespace is the namespace of the custom resource.
+	Namespace string `json:"namespace"`
+	// Action is Create, Update or Delete.
+	Action DryRunAction `json:"action"`
+	// Fields are the fields of the spec changed from the alm-example for a created custom resource,
+	// or changed from the existing spec for an updated one.
+	// +optional
+	Fields []string `json:"fields,omitempty"`
+}
+
+// DryRunAction is the change skipped in dry-run mode.
+// +kubebuilder:validation:Enum=Create;Update;Delete
+type DryRunAction string
+
+// Actions of the dry-run changes
+const (
+	DryRunCreate DryRunAction = "Create"
+	DryRunUpdate DryRunAction = "Update"
+	DryRunDelete DryRunAction = "Delete"
+)
+
+// OperandResult is an action taken on a custom resource of an operand during the last reconcile,
+// or the Skipped action of an operand whose custom resources weren't changed.
+type OperandResult struct {
+	// Name is the name of the operand.
+	Name string `json:"name"`
+	// Action is Created, Updated, Deleted, Skipped or Failed.
+	Action OperandAction `json:"action"`
+	// Kind is the kind of the custom resource.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+	// ResourceName is the name of the custom resource.
+	// +optional
+	ResourceName string `json:"resourceName,omitempty"`
+	// ResourceNamespace is the namespace of the custom resource.
+	// +optional
+	ResourceNamespace string `json:"resourceNamespace,omitempty"`
+	// Reason is the reason of the action, in CamelCase.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Message is the details about the action.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Time is the time the action was first taken with the same result.
+	// +optional
+	Time metav1.Time `json:"time,omitempty"`
+}
+
+// OperandAction is the action taken on an operand.
+// +kubebuilder:validation:Enum=Created;Updated;Deleted;Skipped;Failed
+type OperandAction string
+
+// Actions of the operand results
+const (
+	OperandCreated OperandAction = "Created"
+	OperandUpdated OperandAction = "Updated"
+	OperandDeleted OperandAction = "Deleted"
+	OperandSkipped OperandAction = "Skipped"
+	OperandFailed  OperandAction = "Failed"
+)
+
+// CatalogSourceHealth is the health of the CatalogSource of the subscription.
+type CatalogSourceHealth struct {
+	// Name is the name of the CatalogSource.
+	Name string `json:"name"`
+	// Namespace is the namespace of the CatalogSource.
+	Namespace string `json:"namespace"`
+	// Reason is CatalogSourceUnhealthy or CatalogSourceNotFound.
+	Reason string `json:"reason"`
+	// Message is the details about the health of the CatalogSource.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// Reasons of the CatalogSource health
+const (
+	CatalogSourceUnhealthy = "CatalogSourceUnhealthy"
+	CatalogSourceNotFound  = "CatalogSourceNotFound"
+)
+
+// InstallPlanReference is the reference to an InstallPlan of the subscription.
+type InstallPlanReference struct {
+	// Name is the name of the InstallPlan.
+	Name string `json:"name"`
+	// Namespace is the namespace of the InstallPlan.
+	Namespace string `json:"namespace"`
 }
 
 // +kubebuilder:object:root=true
@@ -201,6 +407,7 @@ type MemberStatus struct {
 // +kubebuilder:resource:path=operandrequests,shortName=opreq,scope=Namespaced
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=.metadata.creationTimestamp
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=.status.phase,description="Current Phase"
+// +kubebuilder:printcolumn:name="Retries",type=integer,JSONPath=.status.retryCount,description="Number of the failed reconciles in a row"
 // +kubebuilder:printcolumn:name="Created At",type=string,JSONPath=.metadata.creationTimestamp
 // +operator-sdk:csv:customresourcedefinitions:displayName="OperandRequest"
 
@@ -278,24 +485,310 @@ func (r *OperandRequest) SetNotFoundOperandRegistryCondition(name string, rt Res
 	r.setCondition(*c)
 }
 
-// setReadyCondition creates a Condition to claim Ready.
+// SetInsufficientPermissionsCondition records the permission ODLM misses to reconcile the operands,
+// the condition is removed once the permission is granted.
+func (r *OperandRequest) SetInsufficientPermissionsCondition(name, verb, resource, namespace string, missing bool, mu sync.Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	message := "Missing the permission to " + verb + " " + resource + " in the namespace " + namespace + " for " + name
+	if missing {
+		c := newCondition(ConditionInsufficientPermissions, corev1.ConditionTrue, "Insufficient permissions", message)
+		r.setCondition(*c)
+		return
+	}
+	removeConditions(&r.Status.Conditions, ConditionInsufficientPermissions, message)
+}
+
+// SetNamespaceQuotaExceededCondition records the operand rejected by the operand quota of the namespace,
+// the condition is removed once the operand is admitted.
+func (r *OperandRequest) SetNamespaceQuotaExceededCondition(name string, exceeded bool, mu sync.Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	message := "Rejected " + name + ", the namespace " + r.Namespace + " reached its operand quota"
+	if exceeded {
+		c := newCondition(ConditionNamespaceQuotaExceeded, corev1.ConditionTrue, "Namespace quota exceeded", message)
+		r.setCondition(*c)
+		return
+	}
+	removeConditions(&r.Status.Conditions, ConditionNamespaceQuotaExceeded, message)
+}
+
+// SetReappliedCondition records the custom resource of the operand reapplied after the schema of its CRD changed.
+func (r *OperandRequest) SetReappliedCondition(name, kind, namespace, crName string, mu sync.Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	c := newCondition(ConditionReapplied, corev1.ConditionTrue, "CRD schema changed", "Reapplied the "+kind+" "+namespace+"/"+crName+" of "+name+" after the schema of its CRD changed")
+	r.setCondition(*c)
+}
+
+// SetMissingRequestAnnotationCondition records the annotations of the OperandRequest referred by the OperandConfig of the operand
+// but missing from the OperandRequest, the condition is removed once the annotations are added.
+func (r *OperandRequest) SetMissingRequestAnnotationCondition(name string, annotations []string, mu sync.Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	suffix := " for " + name
+	if len(annotations) == 0 {
+		removeConditions(&r.Status.Conditions, ConditionMissingRequestAnnotation, suffix)
+		return
+	}
+	c := newCondition(ConditionMissingRequestAnnotation, corev1.ConditionTrue, "Missing request annotation", "Missing the annotations "+strings.Join(annotations, ", ")+" of the OperandRequest"+suffix)
+	r.replaceCondition(*c, suffix)
+}
+
+// SetTargetNamespaceMissingCondition records the target namespaces of the operator missing from the cluster,
+// the condition is removed once the namespaces are created.
+func (r *OperandRequest) SetTargetNamespaceMissingCondition(name string, namespaces []string, mu sync.Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	suffix := " for " + name
+	if len(namespaces) == 0 {
+		removeConditions(&r.Status.Conditions, ConditionTargetNamespaceMissing, suffix)
+		return
+	}
+	c := newCondition(ConditionTargetNamespaceMissing, corev1.ConditionTrue, "Target namespace missing", "Missing the target namespaces "+strings.Join(namespaces, ", ")+" of the OperatorGroup"+suffix)
+	r.replaceCondition(*c, suffix)
+}
+
+// SetMissingConfigReferenceCondition records the secret or configmap key referenced by the OperandConfig of the operand
+// is missing, the condition is removed once the reference is resolved.
+func (r *OperandRequest) SetMissingConfigReferenceCondition(name, reference string, mu sync.Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	suffix := " for " + name
+	if reference == "" {
+		removeConditions(&r.Status.Conditions, ConditionMissingConfigReference, suffix)
+		return
+	}
+	c := newCondition(ConditionMissingConfigReference, corev1.ConditionTrue, "Missing config reference", "Missing "+reference+" referenced by the OperandConfig"+suffix)
+	r.replaceCondition(*c, suffix)
+}
+
+// SetCSVMismatchCondition records the ClusterServiceVersion installed for the operand isn't the pinned one,
+// the condition is removed once they match.
+func (r *OperandRequest) SetCSVMismatchCondition(name, installedCSV, pinnedCSV string, mu sync.Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	suffix := " for " + name
+	if installedCSV == pinnedCSV {
+		removeConditions(&r.Status.Conditions, ConditionCSVMismatch, suffix)
+		return
+	}
+	c := newCondition(ConditionCSVMismatch, corev1.ConditionTrue, "ClusterServiceVersion mismatch", "The ClusterServiceVersion "+installedCSV+" is installed instead of the pinned "+pinnedCSV+suffix)
+	r.replaceCondition(*c, suffix)
+}
+
+// SetWaitingForCSVCondition records the operand waits for the ClusterServiceVersion of its Subscription to be resolved,
+// and returns since when it waits. The condition is removed once the ClusterServiceVersion is found.
+func (r *OperandRequest) SetWaitingForCSVCondition(name, subscription string, waiting bool, mu sync.Locker) time.Time {
+	mu.Lock()
+	defer mu.Unlock()
+	suffix := " for " + name
+	if !waiting {
+		removeConditions(&r.Status.Conditions, ConditionWaitingForCSV, suffix)
+		return time.Time{}
+	}
+	c := newCondition(ConditionWaitingForCSV, corev1.ConditionTrue, "ClusterServiceVersion not resolved", "Waiting for the ClusterServiceVersion of the Subscription "+subscription+suffix)
+	r.setCondition(*c)
+	if _, cp := getCondition(&r.Status.Conditions, c.Type, c.Message); cp != nil {
+		return conditionTime(cp.LastTransitionTime)
+	}
+	return conditionTime(c.LastTransitionTime)
+}
+
+// SetRequestInstallTimeoutCondition records the OperandRequest isn't Running within the install timeout,
+// the condition is removed once it is Running.
+func (r *OperandRequest) SetRequestInstallTimeoutCondition(timeout time.Duration, timedOut bool) {
+	if timedOut {
+		c := newCondition(ConditionRequestInstallTimeout, corev1.ConditionTrue, "Install timeout", "The OperandRequest isn't Running within the install timeout "+timeout.String())
+		r.replaceCondition(*c, "")
+		return
+	}
+	removeConditions(&r.Status.Conditions, ConditionRequestInstallTimeout, "")
+}
+
+// SetRetryBudgetExhaustedCondition records the OperandRequest isn't retried since it exhausted the retry budget,
+// the condition is removed once the budget is reset.
+func (r *OperandRequest) SetRetryBudgetExhaustedCondition(budget int32, exhausted bool) {
+	if exhausted {
+		c := newCondition(ConditionRetryBudgetExhausted, corev1.ConditionTrue, "Retry budget exhausted", fmt.Sprintf("The OperandRequest failed %d reconciles in a row, it isn't retried until its spec is changed", budget))
+		r.replaceCondition(*c, "")
+		return
+	}
+	removeConditions(&r.Status.Conditions, ConditionRetryBudgetExhausted, "")
+}
+
+// SetDependencyCycleCondition records the cycle in the dependencies of the operands,
+// the condition is removed once there is no cycle.
+func (r *OperandRequest) SetDependencyCycleCondition(cycle []string) {
+	if len(cycle) != 0 {
+		c := newCondition(ConditionDependencyCycle, corev1.ConditionTrue, "Dependency cycle", "The dependencies of the operands form a cycle "+strings.Join(cycle, " -> "))
+		r.replaceCondition(*c, "")
+		return
+	}
+	removeConditions(&r.Status.Conditions, ConditionDependencyCycle, "")
+}
+
+// SetMissingDependencyCondition records the dependencies of the operand missing from the OperandRequest,
+// the condition is removed once all the dependencies are requested.
+func (r *OperandRequest) SetMissingDependencyCondition(name string, missing []string, mu sync.Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	suffix := " for " + name
+	if len(missing) == 0 {
+		removeConditions(&r.Status.Conditions, ConditionMissingDependency, suffix)
+		return
+	}
+	c := newCondition(ConditionMissingDependency, corev1.ConditionTrue, "Missing dependency", "The dependencies "+strings.Join(missing, ", ")+" aren't requested"+suffix)
+	r.replaceCondition(*c, suffix)
+}
+
+// setReadyCondition creates a Condition to claim the operator or the operands of a member Ready.
 func (r *OperandRequest) setReadyCondition(name string, rt ResourceType, cs corev1.ConditionStatus) {
 	c := &Condition{}
 	if rt == ResourceTypeOperator {
-		c = newCondition(ConditionReady, cs, string(rt)+" is ready", string(rt)+" "+name+" is ready")
+		c = newCondition(ConditionMemberReady, cs, string(rt)+" is ready", string(rt)+" "+name+" is ready")
 	} else if rt == ResourceTypeOperand {
-		c = newCondition(ConditionReady, cs, string(rt)+" are created", string(rt)+" from "+name+" are created")
+		c = newCondition(ConditionMemberReady, cs, string(rt)+" are created", string(rt)+" from "+name+" are created")
 	}
 	r.setCondition(*c)
 }
 
+// setRequestReadyCondition sets the single Ready condition of the OperandRequest, which is True only when
+// the operators and the operands of all the members are Running, or UserManaged or CRManagementDisabled for the operands.
+// It is the condition expected by `kubectl wait --for=condition=Ready`, which only checks the first condition of the type.
+// The SubscriptionsReady and the OperandsReady conditions tell which of the operators and the operands aren't Running.
+func (r *OperandRequest) setRequestReadyCondition() {
+	var notRunning, subscriptionsNotReady, operandsNotReady []string
+	for _, m := range r.Status.Members {
+		operandRunning := m.Phase.OperandPhase == ServiceRunning || m.Phase.OperandPhase == ServiceUserManaged ||
+			m.Phase.OperandPhase == ServiceCRManagementDisabled
+		if m.Phase.OperatorPhase != OperatorRunning || !operandRunning ||
+			(m.Phase.ValidationPhase != ValidationNone && m.Phase.ValidationPhase != ValidationSucceeded) {
+			notRunning = append(notRunning, m.Name)
+		}
+		if m.Phase.OperatorPhase != OperatorRunning {
+			subscriptionsNotReady = append(subscriptionsNotReady, m.Name)
+		}
+		if !operandRunning {
+			operandsNotReady = append(operandsNotReady, m.Name)
+		}
+	}
+	c := newCondition(ConditionReady, corev1.ConditionTrue, "AllMembersRunning", "All the operators and operands are running")
+	if len(r.Status.Members) == 0 {
+		c = newCondition(ConditionReady, corev1.ConditionFalse, "NoMembers", "No operator or operand is running")
+	} else if len(notRunning) != 0 {
+		c = newCondition(ConditionReady, corev1.ConditionFalse, "MembersNotRunning", "Not running: "+strings.Join(notRunning, ", "))
+	}
+	r.setSingleCondition(*c)
+
+	c = newCondition(ConditionSubscriptionsReady, corev1.ConditionTrue, "AllSubscriptionsReady", "All the operators are running")
+	if len(r.Status.Members) == 0 {
+		c = newCondition(ConditionSubscriptionsReady, corev1.ConditionFalse, "NoMembers", "No operator is running")
+	} else if len(subscriptionsNotReady) != 0 {
+		c = newCondition(ConditionSubscriptionsReady, corev1.ConditionFalse, "SubscriptionsNotReady", "Not running: "+strings.Join(subscriptionsNotReady, ", "))
+	}
+	r.setSingleCondition(*c)
+
+	c = newCondition(ConditionOperandsReady, corev1.ConditionTrue, "AllOperandsReady", "All the operands are running")
+	if len(r.Status.Members) == 0 {
+		c = newCondition(ConditionOperandsReady, corev1.ConditionFalse, "NoMembers", "No operand is running")
+	} else if len(operandsNotReady) != 0 {
+		c = newCondition(ConditionOperandsReady, corev1.ConditionFalse, "OperandsNotReady", "Not running: "+strings.Join(operandsNotReady, ", "))
+	}
+	r.setSingleCondition(*c)
+}
+
+// setSingleCondition replaces all the conditions of the type with the condition, keeping the times of the existing one
+// when the condition doesn't change.
+func (r *OperandRequest) setSingleCondition(c Condition) {
+	conditions := []Condition{}
+	var existing *Condition
+	for i, cond := range r.Status.Conditions {
+		if cond.Type != c.Type {
+			conditions = append(conditions, cond)
+		} else if existing == nil {
+			existing = &r.Status.Conditions[i]
+		}
+	}
+	if existing != nil {
+		keepConditionTimes(&c, existing)
+	}
+	r.Status.Conditions = append(conditions, c)
+}
+
+// MaxConditionsPerType is the maximum number of the conditions of a type kept in the status of the OperandRequest,
+// the least recently updated ones are dropped first.
+const MaxConditionsPerType = 50
+
+// setCondition sets the condition of the type and the message, the times of the existing condition are kept
+// when the condition doesn't change.
 func (r *OperandRequest) setCondition(c Condition) {
 	pos, cp := getCondition(&r.Status.Conditions, c.Type, c.Message)
 	if cp != nil {
+		keepConditionTimes(&c, cp)
 		r.Status.Conditions[pos] = c
-	} else {
-		r.Status.Conditions = append(r.Status.Conditions, c)
+		return
+	}
+	r.Status.Conditions = append(r.Status.Conditions, c)
+
+	var sameType []int
+	for i, cond := range r.Status.Conditions {
+		if cond.Type == c.Type {
+			sameType = append(sameType, i)
+		}
+	}
+	if len(sameType) <= MaxConditionsPerType {
+		return
+	}
+	oldest := sameType[0]
+	for _, i := range sameType[1:] {
+		if conditionTime(r.Status.Conditions[i].LastUpdateTime).Before(conditionTime(r.Status.Conditions[oldest].LastUpdateTime)) {
+			oldest = i
+		}
+	}
+	r.Status.Conditions = append(r.Status.Conditions[:oldest], r.Status.Conditions[oldest+1:]...)
+}
+
+// replaceCondition sets the condition in place of the other conditions of its type whose message ends with the suffix.
+// The condition unchanged keeps its times, so that the status isn't patched on every reconcile.
+func (r *OperandRequest) replaceCondition(c Condition, suffix string) {
+	if pos, _ := getCondition(&r.Status.Conditions, c.Type, c.Message); pos < 0 {
+		removeConditions(&r.Status.Conditions, c.Type, suffix)
+	}
+	r.setCondition(c)
+}
+
+// removeConditions removes the conditions of the type whose message ends with the suffix,
+// an empty suffix removes all the conditions of the type
+func removeConditions(conds *[]Condition, t ConditionType, suffix string) {
+	kept := (*conds)[:0]
+	for _, c := range *conds {
+		if c.Type != t || !strings.HasSuffix(c.Message, suffix) {
+			kept = append(kept, c)
+		}
+	}
+	*conds = kept
+}
+
+// keepConditionTimes keeps the transition time of the existing condition when the status doesn't change,
+// and its update time when the reason and the message don't change either.
+func keepConditionTimes(c, existing *Condition) {
+	if existing.Status != c.Status {
+		return
+	}
+	c.LastTransitionTime = existing.LastTransitionTime
+	if existing.Reason == c.Reason && existing.Message == c.Message {
+		c.LastUpdateTime = existing.LastUpdateTime
+	}
+}
+
+// conditionTime parses the time of a condition, the time which can't be parsed is the oldest
+func conditionTime(t string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, t)
+	if err != nil {
+		return time.Time{}
 	}
+	return parsed
 }
 
 func getCondition(conds *[]Condition, t ConditionType, msg string) (int, *Condition) {
@@ -320,18 +813,29 @@ func newCondition(condType ConditionType, status corev1.ConditionStatus, reason,
 }
 
 // SetMemberStatus appends a Member status in the Member status list.
+// The invalid phase transitions are logged and rejected.
 func (r *OperandRequest) SetMemberStatus(name string, operatorPhase OperatorPhase, operandPhase ServicePhase, mu sync.Locker) {
 	mu.Lock()
 	defer mu.Unlock()
 	pos, m := getMemberStatus(&r.Status, name)
 	if m != nil {
 		if operatorPhase != "" && operatorPhase != m.Phase.OperatorPhase {
-			r.Status.Members[pos].Phase.OperatorPhase = operatorPhase
-			r.setOperatorReadyCondition(operatorPhase, name)
+			if err := ValidateOperatorPhaseTransition(m.Phase.OperatorPhase, operatorPhase); err != nil {
+				klog.Warningf("Reject the operator phase of %s in the OperandRequest %s/%s: %v", name, r.Namespace, r.Name, err)
+			} else {
+				r.Status.Members[pos].Phase.OperatorPhase = operatorPhase
+				r.setOperatorReadyCondition(operatorPhase, name)
+			}
 		}
 		if operandPhase != "" && operandPhase != m.Phase.OperandPhase {
-			r.Status.Members[pos].Phase.OperandPhase = operandPhase
-			r.setOperandReadyCondition(operandPhase, name)
+			if err := ValidateOperandPhaseTransition(m.Phase.OperandPhase, operandPhase); err != nil {
+				klog.Warningf("Reject the operand phase of %s in the OperandRequest %s/%s: %v", name, r.Namespace, r.Name, err)
+			} else {
+				r.Status.Members[pos].Phase.OperandPhase = operandPhase
+				// The operands are validated again once they are Running again
+				r.Status.Members[pos].Phase.ValidationPhase = ValidationNone
+				r.setOperandReadyCondition(operandPhase, name)
+			}
 		}
 	} else {
 		newM := newMemberStatus(name, operatorPhase, operandPhase)
@@ -340,6 +844,60 @@ func (r *OperandRequest) SetMemberStatus(name string, operatorPhase OperatorPhas
 	}
 }
 
+// operatorPhaseTransitions are the valid transitions of the operator phase of a member.
+// The transition to Failed is valid from any phase.
+var operatorPhaseTransitions = map[OperatorPhase][]OperatorPhase{
+	OperatorNone:       {OperatorInit, OperatorReady, OperatorInstalling, OperatorUpdating, OperatorRunning},
+	OperatorInit:       {OperatorReady, OperatorInstalling, OperatorUpdating, OperatorRunning},
+	OperatorReady:      {OperatorInstalling, OperatorUpdating, OperatorRunning},
+	OperatorInstalling: {OperatorUpdating, OperatorRunning},
+	OperatorUpdating:   {OperatorInstalling, OperatorRunning},
+	OperatorRunning:    {OperatorInstalling, OperatorUpdating},
+	OperatorFailed:     {OperatorReady, OperatorInstalling, OperatorUpdating, OperatorRunning},
+}
+
+// operandPhaseTransitions are the valid transitions of the operand phase of a member.
+// The transition to Failed is valid from any phase.
+var operandPhaseTransitions = map[ServicePhase][]ServicePhase{
+	ServiceNone:                    {ServiceInit, ServiceRunning, ServicePendingDeletion, ServiceConfigMissing, ServiceSpecEmpty, ServiceUserManaged, ServiceCRManagementDisabled, ServiceWaitingForDependencies, ServiceWaitingForOperatorReady},
+	ServiceInit:                    {ServiceRunning, ServicePendingDeletion, ServiceConfigMissing, ServiceSpecEmpty, ServiceUserManaged, ServiceCRManagementDisabled, ServiceWaitingForDependencies, ServiceWaitingForOperatorReady},
+	ServiceRunning:                 {ServicePendingDeletion, ServiceConfigMissing, ServiceSpecEmpty, ServiceUserManaged, ServiceCRManagementDisabled},
+	ServiceFailed:                  {ServiceInit, ServiceRunning, ServicePendingDeletion, ServiceConfigMissing, ServiceSpecEmpty, ServiceUserManaged, ServiceCRManagementDisabled, ServiceWaitingForDependencies, ServiceWaitingForOperatorReady},
+	ServicePendingDeletion:         {ServiceInit, ServiceRunning},
+	ServiceConfigMissing:           {ServiceInit, ServiceRunning, ServicePendingDeletion, ServiceSpecEmpty, ServiceUserManaged, ServiceCRManagementDisabled, ServiceWaitingForDependencies, ServiceWaitingForOperatorReady},
+	ServiceSpecEmpty:               {ServiceInit, ServiceRunning, ServicePendingDeletion, ServiceConfigMissing, ServiceUserManaged, ServiceCRManagementDisabled, ServiceWaitingForDependencies, ServiceWaitingForOperatorReady},
+	ServiceUserManaged:             {ServiceInit, ServiceRunning, ServicePendingDeletion, ServiceConfigMissing, ServiceSpecEmpty, ServiceCRManagementDisabled, ServiceWaitingForDependencies, ServiceWaitingForOperatorReady},
+	ServiceWaitingForDependencies:  {ServiceInit, ServiceRunning, ServicePendingDeletion, ServiceConfigMissing, ServiceSpecEmpty, ServiceUserManaged, ServiceCRManagementDisabled, ServiceWaitingForOperatorReady},
+	ServiceWaitingForOperatorReady: {ServiceInit, ServiceRunning, ServicePendingDeletion, ServiceConfigMissing, ServiceSpecEmpty, ServiceUserManaged, ServiceCRManagementDisabled, ServiceWaitingForDependencies},
+	ServiceCRManagementDisabled:    {ServiceInit, ServiceRunning, ServicePendingDeletion, ServiceConfigMissing, ServiceSpecEmpty, ServiceUserManaged, ServiceWaitingForDependencies, ServiceWaitingForOperatorReady},
+}
+
+// ValidateOperatorPhaseTransition checks if the operator phase of a member can change from one phase to another.
+func ValidateOperatorPhaseTransition(from, to OperatorPhase) error {
+	if from == to || to == OperatorFailed {
+		return nil
+	}
+	for _, phase := range operatorPhaseTransitions[from] {
+		if phase == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid operator phase transition from %q to %q", from, to)
+}
+
+// ValidateOperandPhaseTransition checks if the operand phase of a member can change from one phase to another.
+func ValidateOperandPhaseTransition(from, to ServicePhase) error {
+	if from == to || to == ServiceFailed {
+		return nil
+	}
+	for _, phase := range operandPhaseTransitions[from] {
+		if phase == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid operand phase transition from %q to %q", from, to)
+}
+
 // SetMemberCRStatus appends a Member CR in the Member status list.
 func (r *OperandRequest) SetMemberCRStatus(name, CRName, CRKind, CRAPIVersion string, mu sync.Locker) {
 	mu.Lock()
@@ -355,6 +913,118 @@ func (r *OperandRequest) SetMemberCRStatus(name, CRName, CRKind, CRAPIVersion st
 	}
 }
 
+// MigrateMemberCRStatus updates the APIVersion of a Member CR in the Member status list,
+// and records the migration in a Migrated condition.
+func (r *OperandRequest) MigrateMemberCRStatus(name, CRName, CRKind, CRAPIVersion string, mu sync.Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	pos, m := getMemberStatus(&r.Status, name)
+	if m == nil {
+		return
+	}
+	for index, OperandCR := range r.Status.Members[pos].OperandCRList {
+		if OperandCR.Kind == CRKind && OperandCR.Name == CRName && OperandCR.APIVersion != CRAPIVersion {
+			r.Status.Members[pos].OperandCRList[index].APIVersion = CRAPIVersion
+			c := newCondition(ConditionMigrated, corev1.ConditionTrue, "Migrated "+string(ResourceTypeOperand), "Migrated "+CRKind+" "+CRName+" from "+OperandCR.APIVersion+" to "+CRAPIVersion)
+			r.setCondition(*c)
+		}
+	}
+}
+
+// SetMemberInstallPlanRef sets the InstallPlan pending approval in the Member status,
+// a nil reference removes it.
+func (r *OperandRequest) SetMemberInstallPlanRef(name string, ref *InstallPlanReference, mu sync.Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	pos, m := getMemberStatus(&r.Status, name)
+	if m != nil {
+		r.Status.Members[pos].InstallPlanRef = ref
+	}
+}
+
+// SetMemberCatalogSourceHealth sets the unhealthy CatalogSource in the Member status,
+// a nil health removes it.
+func (r *OperandRequest) SetMemberCatalogSourceHealth(name string, health *CatalogSourceHealth, mu sync.Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	pos, m := getMemberStatus(&r.Status, name)
+	if m != nil {
+		r.Status.Members[pos].CatalogSourceHealth = health
+	}
+}
+
+// SetMemberDryRunChanges sets the changes skipped in dry-run mode in the Member status,
+// nil changes remove them.
+func (r *OperandRequest) SetMemberDryRunChanges(name string, changes []DryRunChange, mu sync.Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	pos, m := getMemberStatus(&r.Status, name)
+	if m != nil {
+		r.Status.Members[pos].DryRunChanges = changes
+	}
+}
+
+// SetOperandResults replaces the operand results with the results of the last reconcile, sorted by operand and
+// custom resource. A result repeating the previous one keeps its time, so an unchanged result isn't rewritten.
+func (r *OperandRequest) SetOperandResults(results []OperandResult, now metav1.Time) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		if results[i].Kind != results[j].Kind {
+			return results[i].Kind < results[j].Kind
+		}
+		if results[i].ResourceNamespace != results[j].ResourceNamespace {
+			return results[i].ResourceNamespace < results[j].ResourceNamespace
+		}
+		return results[i].ResourceName < results[j].ResourceName
+	})
+	for i := range results {
+		results[i].Time = now
+		for _, previous := range r.Status.OperandResults {
+			previousTime := previous.Time
+			previous.Time = now
+			if previous == results[i] {
+				results[i].Time = previousTime
+				break
+			}
+		}
+	}
+	r.Status.OperandResults = results
+}
+
+// SetMemberValidationPhase sets the phase of the post-install validation in the Member status.
+func (r *OperandRequest) SetMemberValidationPhase(name string, phase ValidationPhase, mu sync.Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	pos, m := getMemberStatus(&r.Status, name)
+	if m != nil {
+		r.Status.Members[pos].Phase.ValidationPhase = phase
+	}
+}
+
+// GetMemberValidationPhase returns the phase of the post-install validation in the Member status.
+func (r *OperandRequest) GetMemberValidationPhase(name string, mu sync.Locker) ValidationPhase {
+	mu.Lock()
+	defer mu.Unlock()
+	_, m := getMemberStatus(&r.Status, name)
+	if m == nil {
+		return ValidationNone
+	}
+	return m.Phase.ValidationPhase
+}
+
+// GetMemberOperandPhase returns the operand phase of a member.
+func (r *OperandRequest) GetMemberOperandPhase(name string, mu sync.Locker) ServicePhase {
+	mu.Lock()
+	defer mu.Unlock()
+	_, m := getMemberStatus(&r.Status, name)
+	if m == nil {
+		return ServiceNone
+	}
+	return m.Phase.OperandPhase
+}
+
 // RemoveMemberCRStatus removes a Member CR in the Member status list.
 func (r *OperandRequest) RemoveMemberCRStatus(name, CRName, CRKind string, mu sync.Locker) {
 	mu.Lock()
@@ -385,11 +1055,22 @@ func (r *OperandRequest) setOperandReadyCondition(operandPhase ServicePhase, nam
 	}
 }
 
+// RemoveMemberStatus removes a Member status from the Member status list.
+func (r *OperandRequest) RemoveMemberStatus(name string, mu sync.Locker) {
+	mu.Lock()
+	defer mu.Unlock()
+	pos, m := getMemberStatus(&r.Status, name)
+	if m != nil {
+		r.Status.Members = append(r.Status.Members[:pos], r.Status.Members[pos+1:]...)
+	}
+}
+
 // FreshMemberStatus cleanup Member status from the Member status list.
+// The members pending deletion are kept until their removal is confirmed.
 func (r *OperandRequest) FreshMemberStatus() {
 	newMembers := []MemberStatus{}
 	for index, m := range r.Status.Members {
-		if foundOperand(r.Spec.Requests, m.Name) {
+		if foundOperand(r.Spec.Requests, m.Name) || m.Phase.OperandPhase == ServicePendingDeletion {
 			newMembers = append(newMembers, r.Status.Members[index])
 		}
 	}
@@ -431,56 +1112,177 @@ func (r *OperandRequest) SetClusterPhase(p ClusterPhase) {
 	r.Status.Phase = p
 }
 
+// DefaultClusterPhasePrecedence is the order the phases of the members take precedence in the cluster phase,
+// the Updating members count as Running by default.
+var DefaultClusterPhasePrecedence = []ClusterPhase{
+	ClusterPhaseFailed,
+	ClusterPhaseWaitingForApproval,
+	ClusterPhaseInstalling,
+	ClusterPhaseCreating,
+	ClusterPhaseRunning,
+}
+
+// ParseClusterPhasePrecedence parses the phases separated by commas into the cluster phase precedence.
+// All the phases of the default precedence are required, otherwise the members in the missing phases would be ignored.
+func ParseClusterPhasePrecedence(s string) ([]ClusterPhase, error) {
+	valid := map[ClusterPhase]bool{
+		ClusterPhaseFailed:             true,
+		ClusterPhaseWaitingForApproval: true,
+		ClusterPhaseInstalling:         true,
+		ClusterPhaseCreating:           true,
+		ClusterPhaseUpdating:           true,
+		ClusterPhaseRunning:            true,
+	}
+	var precedence []ClusterPhase
+	found := make(map[ClusterPhase]bool)
+	for _, item := range strings.Split(s, ",") {
+		phase := ClusterPhase(strings.TrimSpace(item))
+		if !valid[phase] {
+			return nil, fmt.Errorf("invalid phase %q in the cluster phase precedence", phase)
+		}
+		if found[phase] {
+			return nil, fmt.Errorf("duplicate phase %q in the cluster phase precedence", phase)
+		}
+		found[phase] = true
+		precedence = append(precedence, phase)
+	}
+	for _, phase := range DefaultClusterPhasePrecedence {
+		if !found[phase] {
+			return nil, fmt.Errorf("missing phase %q in the cluster phase precedence", phase)
+		}
+	}
+	return precedence, nil
+}
+
 // UpdateClusterPhase will collect the phase of all the operators and operands.
 // Then summarize the cluster phase of the OperandRequest.
 func (r *OperandRequest) UpdateClusterPhase() {
-	clusterStatusStat := struct {
-		creatingNum   int
-		runningNum    int
-		installingNum int
-		failedNum     int
-	}{
-		creatingNum:   0,
-		runningNum:    0,
-		installingNum: 0,
-		failedNum:     0,
-	}
+	r.UpdateClusterPhaseWithPrecedence(DefaultClusterPhasePrecedence)
+}
 
+// UpdateClusterPhaseWithPrecedence summarizes the cluster phase of the OperandRequest with the first phase
+// of the precedence found in its members, nil means the default precedence.
+func (r *OperandRequest) UpdateClusterPhaseWithPrecedence(precedence []ClusterPhase) {
+	if precedence == nil {
+		precedence = DefaultClusterPhasePrecedence
+	}
+	found := make(map[ClusterPhase]bool)
 	for _, m := range r.Status.Members {
 		switch m.Phase.OperatorPhase {
 		case OperatorReady:
-			clusterStatusStat.creatingNum++
+			found[ClusterPhaseCreating] = true
 		case OperatorFailed:
-			clusterStatusStat.failedNum++
+			found[ClusterPhaseFailed] = true
 		case OperatorRunning:
-			clusterStatusStat.runningNum++
+			found[ClusterPhaseRunning] = true
 		case OperatorInstalling:
-			clusterStatusStat.installingNum++
+			found[ClusterPhaseInstalling] = true
+		case OperatorUpdating:
+			found[ClusterPhaseUpdating] = true
 		default:
 		}
 
 		switch m.Phase.OperandPhase {
-		case ServiceRunning:
-			clusterStatusStat.runningNum++
+		case ServiceRunning, ServiceUserManaged, ServiceCRManagementDisabled:
+			found[ClusterPhaseRunning] = true
 		case ServiceFailed:
-			clusterStatusStat.failedNum++
+			found[ClusterPhaseFailed] = true
+		case ServiceWaitingForDependencies, ServiceWaitingForOperatorReady:
+			found[ClusterPhaseInstalling] = true
+		default:
+		}
+
+		switch m.Phase.ValidationPhase {
+		case ValidationRunning:
+			found[ClusterPhaseInstalling] = true
+		case ValidationFailed:
+			found[ClusterPhaseFailed] = true
 		default:
 		}
+
+		// The InstallPlan of the operator is pending a manual approval
+		if m.InstallPlanRef != nil {
+			found[ClusterPhaseWaitingForApproval] = true
+		}
 	}
 
-	var clusterPhase ClusterPhase
-	if clusterStatusStat.failedNum > 0 {
-		clusterPhase = ClusterPhaseFailed
-	} else if clusterStatusStat.installingNum > 0 {
-		clusterPhase = ClusterPhaseInstalling
-	} else if clusterStatusStat.creatingNum > 0 {
-		clusterPhase = ClusterPhaseCreating
-	} else if clusterStatusStat.runningNum > 0 {
-		clusterPhase = ClusterPhaseRunning
-	} else {
-		clusterPhase = ClusterPhaseNone
+	clusterPhase := ClusterPhaseNone
+	for _, phase := range precedence {
+		if found[phase] {
+			clusterPhase = phase
+			break
+		}
 	}
 	r.SetClusterPhase(clusterPhase)
+	r.setRequestReadyCondition()
+}
+
+// DependencyCycle returns the first cycle found in the dependencies of the operands,
+// from the operand back to itself, nil means there is no cycle.
+func (r *OperandRequest) DependencyCycle() []string {
+	var names []string
+	dependencies := make(map[string][]string)
+	for _, req := range r.Spec.Requests {
+		for _, operand := range req.Operands {
+			if _, ok := dependencies[operand.Name]; !ok {
+				names = append(names, operand.Name)
+			}
+			dependencies[operand.Name] = append(dependencies[operand.Name], operand.DependsOn...)
+		}
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return append(append([]string{}, path[i:]...), name)
+				}
+			}
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dependency := range dependencies[name] {
+			if cycle := visit(dependency); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, name := range names {
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// MissingDependencies returns the dependencies of the operand which aren't requested by the OperandRequest.
+func (r *OperandRequest) MissingDependencies(operand Operand) []string {
+	requested := make(map[string]bool)
+	for _, req := range r.Spec.Requests {
+		for _, o := range req.Operands {
+			requested[o.Name] = true
+		}
+	}
+	var missing []string
+	for _, dependency := range operand.DependsOn {
+		if !requested[dependency] {
+			missing = append(missing, dependency)
+		}
+	}
+	return missing
 }
 
 // GetRegistryKey Set the default value for Request spec.
@@ -493,7 +1295,16 @@ func (r *OperandRequest) GetRegistryKey(req Request) types.NamespacedName {
 	return types.NamespacedName{Namespace: regNs, Name: regName}
 }
 
-//InitRequestStatus OperandConfig status.
+// GetConfigKey gets the key of the OperandConfig of the request, it defaults to the key of the OperandRegistry.
+func (r *OperandRequest) GetConfigKey(req Request) types.NamespacedName {
+	configKey := r.GetRegistryKey(req)
+	if req.ConfigNamespace != "" {
+		configKey.Namespace = req.ConfigNamespace
+	}
+	return configKey
+}
+
+// InitRequestStatus OperandConfig status.
 func (r *OperandRequest) InitRequestStatus() bool {
 	isInitialized := true
 	if r.Status.Phase == "" {
@@ -508,8 +1319,9 @@ func (r *OperandRequest) GenerateLabels() map[string]string {
 	labels := make(map[string]string)
 	for _, req := range r.Spec.Requests {
 		registryKey := r.GetRegistryKey(req)
+		configKey := r.GetConfigKey(req)
 		labels[registryKey.Namespace+"."+registryKey.Name+"/registry"] = "true"
-		labels[registryKey.Namespace+"."+registryKey.Name+"/config"] = "true"
+		labels[configKey.Namespace+"."+configKey.Name+"/config"] = "true"
 	}
 	return labels
 }
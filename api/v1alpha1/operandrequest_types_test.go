@@ -0,0 +1,483 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package v1alpha1
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("OperandRequest member status", func() {
+
+	DescribeTable("Validate operator phase transitions",
+		func(from, to OperatorPhase, valid bool) {
+			err := ValidateOperatorPhaseTransition(from, to)
+			if valid {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(HaveOccurred())
+			}
+		},
+		Entry("None to Installing", OperatorNone, OperatorInstalling, true),
+		Entry("Installing to Running", OperatorInstalling, OperatorRunning, true),
+		Entry("Running to Updating", OperatorRunning, OperatorUpdating, true),
+		Entry("Updating to Running", OperatorUpdating, OperatorRunning, true),
+		Entry("Running to Failed", OperatorRunning, OperatorFailed, true),
+		Entry("Installing to Failed", OperatorInstalling, OperatorFailed, true),
+		Entry("Failed to Installing", OperatorFailed, OperatorInstalling, true),
+		Entry("Running to Running", OperatorRunning, OperatorRunning, true),
+		Entry("Running to None", OperatorRunning, OperatorNone, false),
+		Entry("Running to Initialized", OperatorRunning, OperatorInit, false),
+		Entry("Installing to Ready for Deployment", OperatorInstalling, OperatorReady, false),
+		Entry("Failed to None", OperatorFailed, OperatorNone, false),
+	)
+
+	DescribeTable("Validate operand phase transitions",
+		func(from, to ServicePhase, valid bool) {
+			err := ValidateOperandPhaseTransition(from, to)
+			if valid {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(HaveOccurred())
+			}
+		},
+		Entry("None to Running", ServiceNone, ServiceRunning, true),
+		Entry("Initialized to Running", ServiceInit, ServiceRunning, true),
+		Entry("Running to Failed", ServiceRunning, ServiceFailed, true),
+		Entry("Failed to Running", ServiceFailed, ServiceRunning, true),
+		Entry("Running to None", ServiceRunning, ServiceNone, false),
+		Entry("Running to Initialized", ServiceRunning, ServiceInit, false),
+		Entry("Running to Pending Deletion", ServiceRunning, ServicePendingDeletion, true),
+		Entry("Pending Deletion to Running", ServicePendingDeletion, ServiceRunning, true),
+		Entry("Pending Deletion to None", ServicePendingDeletion, ServiceNone, false),
+		Entry("Running to Config Service Missing", ServiceRunning, ServiceConfigMissing, true),
+		Entry("Config Service Missing to Running", ServiceConfigMissing, ServiceRunning, true),
+		Entry("Pending Deletion to Config Service Missing", ServicePendingDeletion, ServiceConfigMissing, false),
+		Entry("Running to Empty Service Spec", ServiceRunning, ServiceSpecEmpty, true),
+		Entry("Empty Service Spec to Running", ServiceSpecEmpty, ServiceRunning, true),
+		Entry("Running to User Managed", ServiceRunning, ServiceUserManaged, true),
+		Entry("User Managed to Running", ServiceUserManaged, ServiceRunning, true),
+		Entry("None to Waiting For Dependencies", ServiceNone, ServiceWaitingForDependencies, true),
+		Entry("Waiting For Dependencies to Running", ServiceWaitingForDependencies, ServiceRunning, true),
+		Entry("Running to Waiting For Dependencies", ServiceRunning, ServiceWaitingForDependencies, false),
+		Entry("None to Waiting For Operator Ready", ServiceNone, ServiceWaitingForOperatorReady, true),
+		Entry("Waiting For Operator Ready to Running", ServiceWaitingForOperatorReady, ServiceRunning, true),
+		Entry("Running to Waiting For Operator Ready", ServiceRunning, ServiceWaitingForOperatorReady, false),
+	)
+
+	It("Should keep the members pending deletion when refreshing the member status", func() {
+		var mu sync.Mutex
+		request := &OperandRequest{Spec: OperandRequestSpec{Requests: []Request{{Operands: []Operand{{Name: "jenkins"}}}}}}
+		request.SetMemberStatus("etcd", OperatorRunning, ServiceRunning, &mu)
+		request.SetMemberStatus("jenkins", OperatorRunning, ServiceRunning, &mu)
+		request.SetMemberStatus("mongodb", OperatorRunning, ServiceRunning, &mu)
+		request.SetMemberStatus("etcd", "", ServicePendingDeletion, &mu)
+		request.FreshMemberStatus()
+		Expect(request.Status.Members).Should(HaveLen(2))
+		Expect(request.Status.Members[0].Name).Should(Equal("etcd"))
+		Expect(request.Status.Members[1].Name).Should(Equal("jenkins"))
+
+		request.RemoveMemberStatus("etcd", &mu)
+		Expect(request.Status.Members).Should(HaveLen(1))
+		Expect(request.Status.Members[0].Name).Should(Equal("jenkins"))
+	})
+
+	It("Should reject the invalid transitions when setting the member status", func() {
+		var mu sync.Mutex
+		request := &OperandRequest{}
+		request.SetMemberStatus("etcd", OperatorInstalling, "", &mu)
+		request.SetMemberStatus("etcd", OperatorRunning, ServiceRunning, &mu)
+		Expect(request.Status.Members[0].Phase.OperatorPhase).Should(Equal(OperatorRunning))
+		Expect(request.Status.Members[0].Phase.OperandPhase).Should(Equal(ServiceRunning))
+
+		By("Rejecting the regression to the initial phases")
+		request.SetMemberStatus("etcd", OperatorInit, ServiceInit, &mu)
+		Expect(request.Status.Members[0].Phase.OperatorPhase).Should(Equal(OperatorRunning))
+		Expect(request.Status.Members[0].Phase.OperandPhase).Should(Equal(ServiceRunning))
+
+		By("Accepting the genuine failures")
+		request.SetMemberStatus("etcd", OperatorFailed, ServiceFailed, &mu)
+		Expect(request.Status.Members[0].Phase.OperatorPhase).Should(Equal(OperatorFailed))
+		Expect(request.Status.Members[0].Phase.OperandPhase).Should(Equal(ServiceFailed))
+	})
+
+	It("Should set the Ready condition only when all the members are running", func() {
+		var mu sync.Mutex
+		request := &OperandRequest{}
+		readyConditions := func() []Condition {
+			var conditions []Condition
+			for _, c := range request.Status.Conditions {
+				if c.Type == ConditionReady {
+					conditions = append(conditions, c)
+				}
+			}
+			return conditions
+		}
+
+		By("Not being ready without any member")
+		request.UpdateClusterPhase()
+		Expect(readyConditions()).Should(HaveLen(1))
+		Expect(readyConditions()[0].Status).Should(Equal(corev1.ConditionFalse))
+
+		By("Not being ready until the operands of all the members are running")
+		request.SetMemberStatus("etcd", OperatorRunning, ServiceRunning, &mu)
+		request.SetMemberStatus("jenkins", OperatorRunning, "", &mu)
+		request.UpdateClusterPhase()
+		Expect(readyConditions()).Should(HaveLen(1))
+		Expect(readyConditions()[0].Status).Should(Equal(corev1.ConditionFalse))
+		Expect(readyConditions()[0].Message).Should(ContainSubstring("jenkins"))
+		Expect(readyConditions()[0].Message).ShouldNot(ContainSubstring("etcd"))
+
+		By("Being ready once all the members are running")
+		request.SetMemberStatus("jenkins", "", ServiceRunning, &mu)
+		request.UpdateClusterPhase()
+		Expect(readyConditions()).Should(HaveLen(1))
+		Expect(readyConditions()[0].Status).Should(Equal(corev1.ConditionTrue))
+		readyCondition := readyConditions()[0]
+		request.UpdateClusterPhase()
+		Expect(readyConditions()).Should(ConsistOf(readyCondition))
+
+		By("Not being ready when a member fails")
+		request.SetMemberStatus("etcd", "", ServiceFailed, &mu)
+		request.UpdateClusterPhase()
+		Expect(readyConditions()).Should(HaveLen(1))
+		Expect(readyConditions()[0].Status).Should(Equal(corev1.ConditionFalse))
+	})
+
+	It("Should wait for the approval of the pending InstallPlan", func() {
+		var mu sync.Mutex
+		request := &OperandRequest{}
+		request.SetMemberStatus("etcd", OperatorRunning, ServiceRunning, &mu)
+		request.SetMemberStatus("jenkins", OperatorInstalling, "", &mu)
+		request.SetMemberInstallPlanRef("jenkins", &InstallPlanReference{Name: "jenkins-install-plan", Namespace: "ibm-operators"}, &mu)
+		request.UpdateClusterPhase()
+		Expect(request.Status.Phase).Should(Equal(ClusterPhaseWaitingForApproval))
+
+		By("Reporting the upgrade of a running operator waiting for approval")
+		request.SetMemberStatus("jenkins", OperatorRunning, ServiceRunning, &mu)
+		request.UpdateClusterPhase()
+		Expect(request.Status.Phase).Should(Equal(ClusterPhaseWaitingForApproval))
+
+		By("Running once the InstallPlan is approved")
+		request.SetMemberInstallPlanRef("jenkins", nil, &mu)
+		request.UpdateClusterPhase()
+		Expect(request.Status.Phase).Should(Equal(ClusterPhaseRunning))
+
+		By("Reporting the failure first")
+		request.SetMemberInstallPlanRef("jenkins", &InstallPlanReference{Name: "jenkins-install-plan", Namespace: "ibm-operators"}, &mu)
+		request.SetMemberStatus("etcd", "", ServiceFailed, &mu)
+		request.UpdateClusterPhase()
+		Expect(request.Status.Phase).Should(Equal(ClusterPhaseFailed))
+	})
+})
+
+var _ = Describe("OperandRequest operand dependencies", func() {
+	operand := func(name string, dependsOn ...string) Operand {
+		return Operand{Name: name, DependsOn: dependsOn}
+	}
+
+	DescribeTable("Find the dependency cycle",
+		func(operands []Operand, cycle []string) {
+			request := &OperandRequest{Spec: OperandRequestSpec{Requests: []Request{{Operands: operands}}}}
+			Expect(request.DependencyCycle()).Should(Equal(cycle))
+		},
+		Entry("No dependency", []Operand{operand("etcd"), operand("jenkins")}, nil),
+		Entry("A chain", []Operand{operand("jenkins", "etcd"), operand("etcd", "mongodb"), operand("mongodb")}, nil),
+		Entry("A dependency not requested", []Operand{operand("jenkins", "etcd")}, nil),
+		Entry("A cycle", []Operand{operand("etcd", "jenkins"), operand("jenkins", "etcd")}, []string{"etcd", "jenkins", "etcd"}),
+		Entry("A cycle after a chain", []Operand{operand("mongodb", "etcd"), operand("etcd", "jenkins"), operand("jenkins", "etcd")}, []string{"etcd", "jenkins", "etcd"}),
+		Entry("A self dependency", []Operand{operand("etcd", "etcd")}, []string{"etcd", "etcd"}),
+	)
+
+	DescribeTable("Find the dependencies not requested",
+		func(operands []Operand, missing []string) {
+			request := &OperandRequest{Spec: OperandRequestSpec{Requests: []Request{{Operands: operands}}}}
+			Expect(request.MissingDependencies(operands[0])).Should(Equal(missing))
+		},
+		Entry("No dependency", []Operand{operand("jenkins")}, nil),
+		Entry("A dependency requested", []Operand{operand("jenkins", "etcd"), operand("etcd")}, nil),
+		Entry("A dependency not requested", []Operand{operand("jenkins", "etcd", "mongodb"), operand("etcd")}, []string{"mongodb"}),
+	)
+
+	It("Should remove the missing dependency condition once the dependencies are requested", func() {
+		var mu sync.Mutex
+		request := &OperandRequest{}
+		request.SetMissingDependencyCondition("jenkins", []string{"etcd", "mongodb"}, &mu)
+		request.SetMissingDependencyCondition("etcd", []string{"mongodb"}, &mu)
+		Expect(request.Status.Conditions).Should(HaveLen(2))
+		Expect(request.Status.Conditions[0].Message).Should(Equal("The dependencies etcd, mongodb aren't requested for jenkins"))
+
+		request.SetMissingDependencyCondition("jenkins", nil, &mu)
+		Expect(request.Status.Conditions).Should(HaveLen(1))
+		Expect(request.Status.Conditions[0].Message).Should(HaveSuffix(" for etcd"))
+	})
+
+	It("Should be Installing while an operand is waiting for its dependencies", func() {
+		var mu sync.Mutex
+		request := &OperandRequest{}
+		request.SetMemberStatus("etcd", OperatorRunning, ServiceRunning, &mu)
+		request.SetMemberStatus("jenkins", OperatorRunning, ServiceWaitingForDependencies, &mu)
+		request.UpdateClusterPhase()
+		Expect(request.Status.Phase).Should(Equal(ClusterPhaseInstalling))
+	})
+})
+
+var _ = Describe("OperandRequest cluster phase precedence", func() {
+	// etcd is being updated while jenkins failed
+	newRequest := func() *OperandRequest {
+		var mu sync.Mutex
+		request := &OperandRequest{}
+		request.SetMemberStatus("etcd", OperatorUpdating, ServiceRunning, &mu)
+		request.SetMemberStatus("jenkins", OperatorRunning, ServiceFailed, &mu)
+		return request
+	}
+
+	It("Should report the failure first by default", func() {
+		request := newRequest()
+		request.UpdateClusterPhase()
+		Expect(request.Status.Phase).Should(Equal(ClusterPhaseFailed))
+
+		request = newRequest()
+		request.UpdateClusterPhaseWithPrecedence(nil)
+		Expect(request.Status.Phase).Should(Equal(ClusterPhaseFailed))
+
+		By("Parsing the default precedence of the flag")
+		precedence, err := ParseClusterPhasePrecedence("Failed,WaitingForApproval,Installing,Creating,Running")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(precedence).Should(Equal(DefaultClusterPhasePrecedence))
+	})
+
+	It("Should report the update first when it takes precedence over the failure", func() {
+		precedence, err := ParseClusterPhasePrecedence("Updating, Failed,WaitingForApproval,Installing,Creating,Running")
+		Expect(err).NotTo(HaveOccurred())
+		request := newRequest()
+		request.UpdateClusterPhaseWithPrecedence(precedence)
+		Expect(request.Status.Phase).Should(Equal(ClusterPhaseUpdating))
+
+		By("Reporting the failure once the update is done")
+		request.SetMemberStatus("etcd", OperatorRunning, "", &sync.Mutex{})
+		request.UpdateClusterPhaseWithPrecedence(precedence)
+		Expect(request.Status.Phase).Should(Equal(ClusterPhaseFailed))
+	})
+
+	It("Should be Pending when none of the phases of the members takes precedence", func() {
+		request := newRequest()
+		request.UpdateClusterPhaseWithPrecedence([]ClusterPhase{ClusterPhaseInstalling})
+		Expect(request.Status.Phase).Should(Equal(ClusterPhaseNone))
+	})
+
+	DescribeTable("Reject the invalid precedence",
+		func(precedence string) {
+			_, err := ParseClusterPhasePrecedence(precedence)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("An unknown phase", "Failed,Unknown"),
+		Entry("A phase which isn't aggregated", "Failed,Deleting"),
+		Entry("A duplicate phase", "Failed,Running,Failed"),
+		Entry("An empty precedence", ""),
+		Entry("A partial precedence", "Updating,Running"),
+		Entry("A precedence missing a default phase", "Updating,Failed,WaitingForApproval,Installing,Running"),
+	)
+})
+
+var _ = Describe("OperandRequest conditions", func() {
+	const past = "2021-01-01T00:00:00Z"
+
+	findCondition := func(request *OperandRequest, t ConditionType) *Condition {
+		for i, c := range request.Status.Conditions {
+			if c.Type == t {
+				return &request.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	It("Should only update the transition time when the status changes", func() {
+		var mu sync.Mutex
+		request := &OperandRequest{}
+		request.SetMemberStatus("etcd", OperatorInstalling, "", &mu)
+		request.UpdateClusterPhase()
+		for _, t := range []ConditionType{ConditionMemberReady, ConditionReady, ConditionSubscriptionsReady} {
+			c := findCondition(request, t)
+			Expect(c).NotTo(BeNil())
+			c.LastTransitionTime, c.LastUpdateTime = past, past
+		}
+
+		By("Keeping the times when nothing changes")
+		request.SetMemberStatus("etcd", OperatorInstalling, "", &mu)
+		request.setOperatorReadyCondition(OperatorInstalling, "etcd")
+		request.UpdateClusterPhase()
+		for _, t := range []ConditionType{ConditionMemberReady, ConditionReady, ConditionSubscriptionsReady} {
+			Expect(findCondition(request, t).LastTransitionTime).Should(Equal(past))
+			Expect(findCondition(request, t).LastUpdateTime).Should(Equal(past))
+		}
+
+		By("Updating the times when the status changes")
+		request.SetMemberStatus("etcd", OperatorRunning, "", &mu)
+		request.UpdateClusterPhase()
+		Expect(findCondition(request, ConditionMemberReady).Status).Should(Equal(corev1.ConditionTrue))
+		Expect(findCondition(request, ConditionMemberReady).LastTransitionTime).ShouldNot(Equal(past))
+		Expect(findCondition(request, ConditionSubscriptionsReady).Status).Should(Equal(corev1.ConditionTrue))
+		Expect(findCondition(request, ConditionSubscriptionsReady).LastTransitionTime).ShouldNot(Equal(past))
+
+		By("Keeping the transition time when only the message changes")
+		Expect(findCondition(request, ConditionReady).Status).Should(Equal(corev1.ConditionFalse))
+		Expect(findCondition(request, ConditionReady).LastTransitionTime).Should(Equal(past))
+	})
+
+	It("Should report the subscriptions and the operands not ready", func() {
+		var mu sync.Mutex
+		request := &OperandRequest{}
+		request.SetMemberStatus("etcd", OperatorRunning, ServiceRunning, &mu)
+		request.SetMemberStatus("jenkins", OperatorInstalling, "", &mu)
+		request.SetMemberStatus("mongodb", OperatorRunning, ServiceFailed, &mu)
+		request.UpdateClusterPhase()
+		Expect(findCondition(request, ConditionSubscriptionsReady).Status).Should(Equal(corev1.ConditionFalse))
+		Expect(findCondition(request, ConditionSubscriptionsReady).Message).Should(Equal("Not running: jenkins"))
+		Expect(findCondition(request, ConditionOperandsReady).Status).Should(Equal(corev1.ConditionFalse))
+		Expect(findCondition(request, ConditionOperandsReady).Message).Should(Equal("Not running: jenkins, mongodb"))
+
+		request.SetMemberStatus("jenkins", OperatorRunning, ServiceUserManaged, &mu)
+		request.SetMemberStatus("mongodb", "", ServiceRunning, &mu)
+		request.UpdateClusterPhase()
+		Expect(findCondition(request, ConditionSubscriptionsReady).Status).Should(Equal(corev1.ConditionTrue))
+		Expect(findCondition(request, ConditionOperandsReady).Status).Should(Equal(corev1.ConditionTrue))
+	})
+
+	It("Should keep at most MaxConditionsPerType conditions of a type", func() {
+		request := &OperandRequest{}
+		for i := 0; i < MaxConditionsPerType; i++ {
+			c := newCondition(ConditionMemberReady, corev1.ConditionTrue, "operator is ready", fmt.Sprintf("operator member-%d is ready", i))
+			c.LastUpdateTime = fmt.Sprintf("2021-01-01T00:%02d:00Z", i)
+			request.setCondition(*c)
+		}
+		request.SetNamespaceQuotaExceededCondition("etcd", true, &sync.Mutex{})
+		// member-1 is the least recently updated once member-0 is updated
+		c := newCondition(ConditionMemberReady, corev1.ConditionFalse, "operator is ready", "operator member-0 is ready")
+		request.setCondition(*c)
+		request.setOperatorReadyCondition(OperatorRunning, "etcd")
+
+		var messages []string
+		for _, c := range request.Status.Conditions {
+			if c.Type == ConditionMemberReady {
+				messages = append(messages, c.Message)
+			}
+		}
+		Expect(messages).Should(HaveLen(MaxConditionsPerType))
+		Expect(messages).Should(ContainElements("operator member-0 is ready", "operator etcd is ready"))
+		Expect(messages).ShouldNot(ContainElement("operator member-1 is ready"))
+		Expect(findCondition(request, ConditionNamespaceQuotaExceeded)).NotTo(BeNil())
+	})
+
+	It("Should keep the times of the operand conditions still applying", func() {
+		var mu sync.Mutex
+		request := &OperandRequest{}
+		setConditions := func(missing string) {
+			request.SetMissingRequestAnnotationCondition("etcd", []string{"example.com/tier"}, &mu)
+			request.SetTargetNamespaceMissingCondition("etcd", []string{"etcd-ns"}, &mu)
+			request.SetMissingConfigReferenceCondition("etcd", "secret etcd-secret", &mu)
+			request.SetCSVMismatchCondition("etcd", "etcd.v0.0.2", "etcd.v0.0.1", &mu)
+			request.SetMissingDependencyCondition("etcd", []string{missing}, &mu)
+			request.SetMissingDependencyCondition("jenkins", []string{"etcd"}, &mu)
+		}
+		setConditions("mongodb")
+		Expect(request.Status.Conditions).Should(HaveLen(6))
+		for i := range request.Status.Conditions {
+			request.Status.Conditions[i].LastTransitionTime, request.Status.Conditions[i].LastUpdateTime = past, past
+		}
+
+		By("Keeping the times when the conditions don't change")
+		setConditions("mongodb")
+		Expect(request.Status.Conditions).Should(HaveLen(6))
+		for _, c := range request.Status.Conditions {
+			Expect(c.LastTransitionTime).Should(Equal(past))
+			Expect(c.LastUpdateTime).Should(Equal(past))
+		}
+
+		By("Replacing the condition of the operand when its message changes")
+		setConditions("redis")
+		Expect(request.Status.Conditions).Should(HaveLen(6))
+		var messages []string
+		for _, c := range request.Status.Conditions {
+			if c.Type == ConditionMissingDependency {
+				messages = append(messages, c.Message)
+			}
+		}
+		Expect(messages).Should(ConsistOf("The dependencies redis aren't requested for etcd", "The dependencies etcd aren't requested for jenkins"))
+		Expect(findCondition(request, ConditionCSVMismatch).LastUpdateTime).Should(Equal(past))
+
+		By("Removing the conditions of the operand once they don't apply")
+		request.SetCSVMismatchCondition("etcd", "etcd.v0.0.1", "etcd.v0.0.1", &mu)
+		request.SetMissingDependencyCondition("etcd", nil, &mu)
+		Expect(findCondition(request, ConditionCSVMismatch)).To(BeNil())
+		Expect(request.Status.Conditions).Should(HaveLen(4))
+	})
+})
+
+var _ = Describe("OperandRequest config namespace", func() {
+	request := &OperandRequest{ObjectMeta: metav1.ObjectMeta{Name: "ibm-cloudpak-name", Namespace: "ibm-cloudpak"}}
+
+	It("Should default the config namespace to the registry namespace", func() {
+		req := Request{Registry: "common-service", RegistryNamespace: "ibm-common-services"}
+		Expect(request.GetConfigKey(req)).Should(Equal(types.NamespacedName{Name: "common-service", Namespace: "ibm-common-services"}))
+		req.RegistryNamespace = ""
+		Expect(request.GetConfigKey(req)).Should(Equal(types.NamespacedName{Name: "common-service", Namespace: "ibm-cloudpak"}))
+	})
+
+	It("Should label the OperandRequest with the OperandConfig in the config namespace", func() {
+		request := request.DeepCopy()
+		request.Spec.Requests = []Request{{Registry: "common-service", RegistryNamespace: "ibm-common-services", ConfigNamespace: "ibm-common-configs"}}
+		Expect(request.GetConfigKey(request.Spec.Requests[0])).Should(Equal(types.NamespacedName{Name: "common-service", Namespace: "ibm-common-configs"}))
+		Expect(request.GenerateLabels()).Should(Equal(map[string]string{
+			"ibm-common-services.common-service/registry": "true",
+			"ibm-common-configs.common-service/config":    "true",
+		}))
+	})
+})
+
+var _ = Describe("OperandRequest operand results", func() {
+	It("Should sort the results and keep the time of the unchanged ones", func() {
+		request := &OperandRequest{}
+		firstTime := metav1.NewTime(metav1.Now().Add(-time.Hour))
+		request.SetOperandResults([]OperandResult{
+			{Name: "jenkins", Action: OperandSkipped, Reason: "Running"},
+			{Name: "etcd", Action: OperandCreated, Kind: "EtcdCluster", ResourceName: "example", ResourceNamespace: "ibm-operators", Reason: "CreatedCustomResource"},
+		}, firstTime)
+		Expect(request.Status.OperandResults).Should(HaveLen(2))
+		Expect(request.Status.OperandResults[0].Name).Should(Equal("etcd"))
+		Expect(request.Status.OperandResults[1].Time).Should(Equal(firstTime))
+
+		secondTime := metav1.Now()
+		request.SetOperandResults([]OperandResult{
+			{Name: "etcd", Action: OperandSkipped, Reason: "Running"},
+			{Name: "jenkins", Action: OperandSkipped, Reason: "Running"},
+		}, secondTime)
+		Expect(request.Status.OperandResults).Should(Equal([]OperandResult{
+			{Name: "etcd", Action: OperandSkipped, Reason: "Running", Time: secondTime},
+			{Name: "jenkins", Action: OperandSkipped, Reason: "Running", Time: firstTime},
+		}))
+	})
+})
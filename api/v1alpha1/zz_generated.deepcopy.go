@@ -21,9 +21,66 @@
 package v1alpha1
 
 import (
+	"k8s.io/api/batch/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BindingAccess) DeepCopyInto(out *BindingAccess) {
+	*out = *in
+	if in.ServiceAccounts != nil {
+		in, out := &in.ServiceAccounts, &out.ServiceAccounts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BindingAccess.
+func (in *BindingAccess) DeepCopy() *BindingAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(BindingAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogOverride) DeepCopyInto(out *CatalogOverride) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogOverride.
+func (in *CatalogOverride) DeepCopy() *CatalogOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogSourceHealth) DeepCopyInto(out *CatalogSourceHealth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogSourceHealth.
+func (in *CatalogSourceHealth) DeepCopy() *CatalogSourceHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogSourceHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
@@ -39,6 +96,28 @@ func (in *Condition) DeepCopy() *Condition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigOverride) DeepCopyInto(out *ConfigOverride) {
+	*out = *in
+	if in.Spec != nil {
+		in, out := &in.Spec, &out.Spec
+		*out = make(map[string]runtime.RawExtension, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigOverride.
+func (in *ConfigOverride) DeepCopy() *ConfigOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConfigService) DeepCopyInto(out *ConfigService) {
 	*out = *in
@@ -49,6 +128,45 @@ func (in *ConfigService) DeepCopyInto(out *ConfigService) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.IgnoredSpecPaths != nil {
+		in, out := &in.IgnoredSpecPaths, &out.IgnoredSpecPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make([]ConfigOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PostInstallValidation != nil {
+		in, out := &in.PostInstallValidation, &out.PostInstallValidation
+		*out = new(v1beta1.JobTemplateSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MergeStrategy != nil {
+		in, out := &in.MergeStrategy, &out.MergeStrategy
+		*out = make(map[string]MergeStrategy, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.IncludeCRs != nil {
+		in, out := &in.IncludeCRs, &out.IncludeCRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeCRs != nil {
+		in, out := &in.ExcludeCRs, &out.ExcludeCRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ManageCRs != nil {
+		in, out := &in.ManageCRs, &out.ManageCRs
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigService.
@@ -61,6 +179,46 @@ func (in *ConfigService) DeepCopy() *ConfigService {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CopiedBinding) DeepCopyInto(out *CopiedBinding) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CopiedBinding.
+func (in *CopiedBinding) DeepCopy() *CopiedBinding {
+	if in == nil {
+		return nil
+	}
+	out := new(CopiedBinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CopyOwnerReference) DeepCopyInto(out *CopyOwnerReference) {
+	*out = *in
+	if in.Controller != nil {
+		in, out := &in.Controller, &out.Controller
+		*out = new(bool)
+		**out = **in
+	}
+	if in.BlockOwnerDeletion != nil {
+		in, out := &in.BlockOwnerDeletion, &out.BlockOwnerDeletion
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CopyOwnerReference.
+func (in *CopyOwnerReference) DeepCopy() *CopyOwnerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CopyOwnerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CrStatus) DeepCopyInto(out *CrStatus) {
 	*out = *in
@@ -83,6 +241,41 @@ func (in *CrStatus) DeepCopy() *CrStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunChange) DeepCopyInto(out *DryRunChange) {
+	*out = *in
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunChange.
+func (in *DryRunChange) DeepCopy() *DryRunChange {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstallPlanReference) DeepCopyInto(out *InstallPlanReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstallPlanReference.
+func (in *InstallPlanReference) DeepCopy() *InstallPlanReference {
+	if in == nil {
+		return nil
+	}
+	out := new(InstallPlanReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MemberPhase) DeepCopyInto(out *MemberPhase) {
 	*out = *in
@@ -107,6 +300,23 @@ func (in *MemberStatus) DeepCopyInto(out *MemberStatus) {
 		*out = make([]OperandCRMember, len(*in))
 		copy(*out, *in)
 	}
+	if in.InstallPlanRef != nil {
+		in, out := &in.InstallPlanRef, &out.InstallPlanRef
+		*out = new(InstallPlanReference)
+		**out = **in
+	}
+	if in.CatalogSourceHealth != nil {
+		in, out := &in.CatalogSourceHealth, &out.CatalogSourceHealth
+		*out = new(CatalogSourceHealth)
+		**out = **in
+	}
+	if in.DryRunChanges != nil {
+		in, out := &in.DryRunChanges, &out.DryRunChanges
+		*out = make([]DryRunChange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberStatus.
@@ -134,6 +344,16 @@ func (in *Operand) DeepCopyInto(out *Operand) {
 		*out = new(runtime.RawExtension)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.InstallCR != nil {
+		in, out := &in.InstallCR, &out.InstallCR
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Operand.
@@ -215,6 +435,16 @@ func (in *OperandBindInfoSpec) DeepCopyInto(out *OperandBindInfoSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.OwnerReference != nil {
+		in, out := &in.OwnerReference, &out.OwnerReference
+		*out = new(CopyOwnerReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperandBindInfoSpec.
@@ -230,11 +460,21 @@ func (in *OperandBindInfoSpec) DeepCopy() *OperandBindInfoSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OperandBindInfoStatus) DeepCopyInto(out *OperandBindInfoStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		copy(*out, *in)
+	}
 	if in.RequestNamespaces != nil {
 		in, out := &in.RequestNamespaces, &out.RequestNamespaces
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.CopiedBindings != nil {
+		in, out := &in.CopiedBindings, &out.CopiedBindings
+		*out = make([]CopiedBinding, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperandBindInfoStatus.
@@ -331,6 +571,11 @@ func (in *OperandConfigSpec) DeepCopyInto(out *OperandConfigSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Defaults != nil {
+		in, out := &in.Defaults, &out.Defaults
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperandConfigSpec.
@@ -473,6 +718,22 @@ func (in *OperandRegistryStatus) DeepCopy() *OperandRegistryStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperandResult) DeepCopyInto(out *OperandResult) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperandResult.
+func (in *OperandResult) DeepCopy() *OperandResult {
+	if in == nil {
+		return nil
+	}
+	out := new(OperandResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OperandRequest) DeepCopyInto(out *OperandRequest) {
 	*out = *in
@@ -542,6 +803,16 @@ func (in *OperandRequestSpec) DeepCopyInto(out *OperandRequestSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.InstallTimeout != nil {
+		in, out := &in.InstallTimeout, &out.InstallTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RetryBudget != nil {
+		in, out := &in.RetryBudget, &out.RetryBudget
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperandRequestSpec.
@@ -569,6 +840,17 @@ func (in *OperandRequestStatus) DeepCopyInto(out *OperandRequestStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.InstallStartTime != nil {
+		in, out := &in.InstallStartTime, &out.InstallStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.OperandResults != nil {
+		in, out := &in.OperandResults, &out.OperandResults
+		*out = make([]OperandResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperandRequestStatus.
@@ -584,11 +866,25 @@ func (in *OperandRequestStatus) DeepCopy() *OperandRequestStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Operator) DeepCopyInto(out *Operator) {
 	*out = *in
+	if in.CatalogOverrides != nil {
+		in, out := &in.CatalogOverrides, &out.CatalogOverrides
+		*out = make([]CatalogOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.TargetNamespaces != nil {
 		in, out := &in.TargetNamespaces, &out.TargetNamespaces
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Defaults != nil {
+		in, out := &in.Defaults, &out.Defaults
+		*out = make(map[string]runtime.RawExtension, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Operator.
@@ -646,6 +942,18 @@ func (in *Request) DeepCopyInto(out *Request) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ManageCRs != nil {
+		in, out := &in.ManageCRs, &out.ManageCRs
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Request.
@@ -0,0 +1,82 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Actor is the actor of all the audit records
+const Actor = "ODLM"
+
+// Operation is the mutation recorded by an audit record
+type Operation string
+
+const (
+	// OperationCreate records the creation of a resource
+	OperationCreate Operation = "create"
+	// OperationUpdate records the update of a resource
+	OperationUpdate Operation = "update"
+	// OperationPatch records the patch of a resource
+	OperationPatch Operation = "patch"
+	// OperationDelete records the deletion of a resource
+	OperationDelete Operation = "delete"
+	// OperationDeleteAllOf records the deletion of a collection of resources
+	OperationDeleteAllOf Operation = "deletecollection"
+)
+
+// Reference is the reference to the resource being reconciled, which owns the mutations
+type Reference struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// Record is the audit record of a mutation performed by ODLM
+type Record struct {
+	Time        time.Time  `json:"time"`
+	Actor       string     `json:"actor"`
+	Operation   Operation  `json:"operation"`
+	APIVersion  string     `json:"apiVersion"`
+	Kind        string     `json:"kind"`
+	Namespace   string     `json:"namespace,omitempty"`
+	Name        string     `json:"name,omitempty"`
+	Subresource string     `json:"subresource,omitempty"`
+	Request     *Reference `json:"request,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// Sink writes the audit records
+type Sink interface {
+	Write(ctx context.Context, record Record) error
+}
+
+type requestKey struct{}
+
+// WithRequest returns a context recording the resource being reconciled as the owner of the mutations
+func WithRequest(ctx context.Context, kind string, key types.NamespacedName) context.Context {
+	return context.WithValue(ctx, requestKey{}, &Reference{Kind: kind, Namespace: key.Namespace, Name: key.Name})
+}
+
+// RequestFrom returns the resource being reconciled from the context, nil if there isn't any
+func RequestFrom(ctx context.Context) *Reference {
+	ref, _ := ctx.Value(requestKey{}).(*Reference)
+	return ref
+}
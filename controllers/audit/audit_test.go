@@ -0,0 +1,167 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// memorySink keeps the audit records in memory
+type memorySink struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (s *memorySink) Write(ctx context.Context, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+var _ = Describe("Audit", func() {
+	var (
+		ctx  context.Context
+		sink *memorySink
+		c    client.Client
+	)
+
+	configMap := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "ibm-common-services"},
+			Data:       map[string]string{"key": "value"},
+		}
+	}
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+		ctx = WithRequest(context.Background(), "OperandRequest", types.NamespacedName{Namespace: "ibm-cloudpak", Name: "request"})
+		sink = &memorySink{}
+		c = NewClient(fake.NewClientBuilder().WithScheme(scheme).Build(), sink)
+	})
+
+	It("Should write one audit record for each mutation", func() {
+		cm := configMap()
+		Expect(c.Create(ctx, cm)).Should(Succeed())
+		Expect(sink.records).Should(HaveLen(1))
+
+		cm.Data["key"] = "updated"
+		Expect(c.Update(ctx, cm)).Should(Succeed())
+		Expect(sink.records).Should(HaveLen(2))
+
+		original := cm.DeepCopy()
+		cm.Labels = map[string]string{"app": "audit"}
+		Expect(c.Patch(ctx, cm, client.MergeFrom(original))).Should(Succeed())
+		Expect(sink.records).Should(HaveLen(3))
+
+		Expect(c.Delete(ctx, cm)).Should(Succeed())
+		Expect(sink.records).Should(HaveLen(4))
+
+		Expect(c.DeleteAllOf(ctx, &corev1.ConfigMap{}, client.InNamespace("ibm-common-services"))).Should(Succeed())
+		Expect(sink.records).Should(HaveLen(5))
+
+		var operations []Operation
+		for _, record := range sink.records {
+			operations = append(operations, record.Operation)
+			Expect(record.Actor).Should(Equal(Actor))
+			Expect(record.APIVersion).Should(Equal("v1"))
+			Expect(record.Kind).Should(Equal("ConfigMap"))
+			Expect(record.Namespace).Should(Equal("ibm-common-services"))
+			Expect(record.Request).Should(Equal(&Reference{Kind: "OperandRequest", Namespace: "ibm-cloudpak", Name: "request"}))
+			Expect(record.Error).Should(BeEmpty())
+		}
+		Expect(operations).Should(Equal([]Operation{OperationCreate, OperationUpdate, OperationPatch, OperationDelete, OperationDeleteAllOf}))
+		Expect(sink.records[0].Name).Should(Equal("cm"))
+		Expect(sink.records[4].Name).Should(BeEmpty())
+	})
+
+	It("Should write the audit records of the status mutations", func() {
+		cm := configMap()
+		Expect(c.Create(ctx, cm)).Should(Succeed())
+		Expect(c.Status().Update(ctx, cm)).Should(Succeed())
+		Expect(c.Status().Patch(ctx, cm, client.MergeFrom(cm.DeepCopy()))).Should(Succeed())
+		Expect(sink.records).Should(HaveLen(3))
+		Expect(sink.records[1].Operation).Should(Equal(OperationUpdate))
+		Expect(sink.records[1].Subresource).Should(Equal("status"))
+		Expect(sink.records[2].Operation).Should(Equal(OperationPatch))
+		Expect(sink.records[2].Subresource).Should(Equal("status"))
+	})
+
+	It("Should record the failed mutation with its error", func() {
+		Expect(c.Create(ctx, configMap())).Should(Succeed())
+		Expect(c.Create(ctx, configMap())).ShouldNot(Succeed())
+		Expect(sink.records).Should(HaveLen(2))
+		Expect(sink.records[1].Error).ShouldNot(BeEmpty())
+	})
+
+	It("Should write the audit records as JSON lines", func() {
+		var buf bytes.Buffer
+		c = NewClient(fake.NewClientBuilder().Build(), NewLogSink(&buf))
+		Expect(c.Create(ctx, configMap())).Should(Succeed())
+		Expect(c.Delete(ctx, configMap())).Should(Succeed())
+
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		Expect(lines).Should(HaveLen(2))
+		record := Record{}
+		Expect(json.Unmarshal(lines[1], &record)).Should(Succeed())
+		Expect(record.Operation).Should(Equal(OperationDelete))
+		Expect(record.Name).Should(Equal("cm"))
+		Expect(record.Request.Name).Should(Equal("request"))
+	})
+
+	It("Should post the audit records to the webhook", func() {
+		received := make(chan Record, 10)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			record := Record{}
+			Expect(json.NewDecoder(req.Body).Decode(&record)).Should(Succeed())
+			received <- record
+		}))
+		defer server.Close()
+
+		sink := NewWebhookSink(server.URL)
+		Expect(sink.Write(ctx, Record{Actor: Actor, Operation: OperationCreate, Kind: "ConfigMap", Name: "cm"})).Should(Succeed())
+		Expect(received).Should(HaveLen(1))
+		record := <-received
+		Expect(record.Operation).Should(Equal(OperationCreate))
+		Expect(record.Name).Should(Equal("cm"))
+	})
+
+	It("Should fail when the webhook rejects the audit record", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		Expect(NewWebhookSink(server.URL).Write(ctx, Record{Actor: Actor})).ShouldNot(Succeed())
+	})
+})
@@ -0,0 +1,114 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// NewClient wraps the client to write an audit record of each mutation to the sink
+func NewClient(c client.Client, sink Sink) client.Client {
+	return &auditClient{Client: c, sink: sink}
+}
+
+type auditClient struct {
+	client.Client
+	sink Sink
+}
+
+func (c *auditClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	err := c.Client.Create(ctx, obj, opts...)
+	c.record(ctx, OperationCreate, obj, obj.GetNamespace(), "", err)
+	return err
+}
+
+func (c *auditClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	err := c.Client.Update(ctx, obj, opts...)
+	c.record(ctx, OperationUpdate, obj, obj.GetNamespace(), "", err)
+	return err
+}
+
+func (c *auditClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	err := c.Client.Patch(ctx, obj, patch, opts...)
+	c.record(ctx, OperationPatch, obj, obj.GetNamespace(), "", err)
+	return err
+}
+
+func (c *auditClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	err := c.Client.Delete(ctx, obj, opts...)
+	c.record(ctx, OperationDelete, obj, obj.GetNamespace(), "", err)
+	return err
+}
+
+func (c *auditClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	err := c.Client.DeleteAllOf(ctx, obj, opts...)
+	deleteAllOfOpts := &client.DeleteAllOfOptions{}
+	deleteAllOfOpts.ApplyOptions(opts)
+	c.record(ctx, OperationDeleteAllOf, obj, deleteAllOfOpts.Namespace, "", err)
+	return err
+}
+
+func (c *auditClient) Status() client.StatusWriter {
+	return &auditStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+type auditStatusWriter struct {
+	client.StatusWriter
+	client *auditClient
+}
+
+func (w *auditStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	err := w.StatusWriter.Update(ctx, obj, opts...)
+	w.client.record(ctx, OperationUpdate, obj, obj.GetNamespace(), "status", err)
+	return err
+}
+
+func (w *auditStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	err := w.StatusWriter.Patch(ctx, obj, patch, opts...)
+	w.client.record(ctx, OperationPatch, obj, obj.GetNamespace(), "status", err)
+	return err
+}
+
+// record writes the audit record of the mutation, a failure to write it doesn't fail the mutation
+func (c *auditClient) record(ctx context.Context, op Operation, obj client.Object, namespace, subresource string, mutationErr error) {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		gvk = obj.GetObjectKind().GroupVersionKind()
+	}
+	record := Record{
+		Time:        time.Now().UTC(),
+		Actor:       Actor,
+		Operation:   op,
+		APIVersion:  gvk.GroupVersion().String(),
+		Kind:        gvk.Kind,
+		Namespace:   namespace,
+		Name:        obj.GetName(),
+		Subresource: subresource,
+		Request:     RequestFrom(ctx),
+	}
+	if mutationErr != nil {
+		record.Error = mutationErr.Error()
+	}
+	if err := c.sink.Write(ctx, record); err != nil {
+		klog.Errorf("failed to write the audit record of %s %s %s/%s: %v", op, gvk.Kind, namespace, obj.GetName(), err)
+	}
+}
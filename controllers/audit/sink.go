@@ -0,0 +1,87 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultWebhookTimeout is the timeout to send an audit record to the webhook
+const DefaultWebhookTimeout = 10 * time.Second
+
+// LogSink writes the audit records as JSON lines
+type LogSink struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewLogSink is the method to initialize a LogSink writing to w
+func NewLogSink(w io.Writer) *LogSink {
+	return &LogSink{encoder: json.NewEncoder(w)}
+}
+
+// Write writes the audit record as a JSON line
+func (s *LogSink) Write(ctx context.Context, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.encoder.Encode(record)
+}
+
+// WebhookSink posts the audit records in JSON to a webhook
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookSink is the method to initialize a WebhookSink posting to url
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		URL:    url,
+		Client: &http.Client{Timeout: DefaultWebhookTimeout},
+	}
+}
+
+// Write posts the audit record to the webhook
+func (s *WebhookSink) Write(ctx context.Context, record Record) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the audit record")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the request to the audit webhook %s", s.URL)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to send the audit record to the webhook %s", s.URL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the audit webhook %s responded with %s", s.URL, resp.Status)
+	}
+	return nil
+}
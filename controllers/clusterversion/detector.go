@@ -0,0 +1,120 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package clusterversion
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// openShiftClusterVersion is the OpenShift ClusterVersion holding the version of the cluster
+var openShiftClusterVersion = schema.GroupVersionKind{Group: "config.openshift.io", Version: "v1", Kind: "ClusterVersion"}
+
+// Detector detects the version of the cluster
+type Detector interface {
+	Detect(ctx context.Context) (semver.Version, error)
+}
+
+// DefaultTTL is how long a detected cluster version is reused, the cluster is rarely upgraded
+const DefaultTTL = 10 * time.Minute
+
+// NewDetector returns a Detector reading the OpenShift version from the ClusterVersion,
+// and falling back to the Kubernetes version on other clusters
+func NewDetector(reader client.Reader, dc discovery.ServerVersionInterface) Detector {
+	return &detector{reader: reader, discovery: dc}
+}
+
+type detector struct {
+	reader    client.Reader
+	discovery discovery.ServerVersionInterface
+}
+
+func (d *detector) Detect(ctx context.Context) (semver.Version, error) {
+	cv := &unstructured.Unstructured{}
+	cv.SetGroupVersionKind(openShiftClusterVersion)
+	err := d.reader.Get(ctx, types.NamespacedName{Name: "version"}, cv)
+	if err == nil {
+		version, _, err := unstructured.NestedString(cv.Object, "status", "desired", "version")
+		if err != nil || version == "" {
+			return semver.Version{}, errors.Errorf("failed to find the desired version in the ClusterVersion %s", cv.GetName())
+		}
+		return Parse(version)
+	}
+	if !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return semver.Version{}, errors.Wrap(err, "failed to get the OpenShift ClusterVersion")
+	}
+
+	info, err := d.discovery.ServerVersion()
+	if err != nil {
+		return semver.Version{}, errors.Wrap(err, "failed to get the Kubernetes server version")
+	}
+	return Parse(info.GitVersion)
+}
+
+// NewCachedDetector returns a Detector reusing the version detected by the detector for the ttl,
+// the errors aren't cached so that the detection is retried on the next call
+func NewCachedDetector(detector Detector, ttl time.Duration) Detector {
+	return &cachedDetector{detector: detector, ttl: ttl, now: time.Now}
+}
+
+type cachedDetector struct {
+	detector Detector
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu         sync.Mutex
+	version    semver.Version
+	detectedAt time.Time
+}
+
+func (d *cachedDetector) Detect(ctx context.Context) (semver.Version, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	if !d.detectedAt.IsZero() && now.Sub(d.detectedAt) < d.ttl {
+		return d.version, nil
+	}
+	version, err := d.detector.Detect(ctx)
+	if err != nil {
+		return semver.Version{}, err
+	}
+	d.version, d.detectedAt = version, now
+	return version, nil
+}
+
+// Parse parses a cluster version, dropping the pre-release and build metadata
+// added by the distributions, e.g. "v1.20.5+k3s1" or "v1.20.5-gke.1000"
+func Parse(version string) (semver.Version, error) {
+	v, err := semver.ParseTolerant(version)
+	if err != nil {
+		return semver.Version{}, errors.Wrapf(err, "failed to parse the cluster version %s", version)
+	}
+	v.Pre = nil
+	v.Build = nil
+	return v, nil
+}
@@ -0,0 +1,98 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package clusterversion
+
+import (
+	"context"
+	"time"
+
+	"github.com/blang/semver/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Cluster version detector", func() {
+	var dc *fakediscovery.FakeDiscovery
+
+	BeforeEach(func() {
+		dc = &fakediscovery.FakeDiscovery{
+			Fake:               &clienttesting.Fake{},
+			FakedServerVersion: &version.Info{GitVersion: "v1.20.5+k3s1"},
+		}
+	})
+
+	It("Should detect the OpenShift version", func() {
+		cv := &unstructured.Unstructured{}
+		cv.SetGroupVersionKind(openShiftClusterVersion)
+		cv.SetName("version")
+		Expect(unstructured.SetNestedField(cv.Object, "4.6.8", "status", "desired", "version")).Should(Succeed())
+		reader := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithObjects(cv).Build()
+
+		v, err := NewDetector(reader, dc).Detect(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v).Should(Equal(semver.MustParse("4.6.8")))
+	})
+
+	It("Should fall back to the Kubernetes version", func() {
+		reader := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+
+		v, err := NewDetector(reader, dc).Detect(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v).Should(Equal(semver.MustParse("1.20.5")))
+	})
+
+	It("Should reuse the detected version until the ttl expires", func() {
+		reader := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+		now := time.Now()
+		detector := NewCachedDetector(NewDetector(reader, dc), time.Minute).(*cachedDetector)
+		detector.now = func() time.Time { return now }
+
+		v, err := detector.Detect(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v).Should(Equal(semver.MustParse("1.20.5")))
+
+		By("Keeping the version while the cluster is upgraded")
+		dc.FakedServerVersion = &version.Info{GitVersion: "v1.21.0"}
+		now = now.Add(30 * time.Second)
+		v, err = detector.Detect(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v).Should(Equal(semver.MustParse("1.20.5")))
+		Expect(dc.Actions()).Should(HaveLen(1))
+
+		By("Detecting the version again once the ttl expires")
+		now = now.Add(time.Minute)
+		v, err = detector.Detect(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v).Should(Equal(semver.MustParse("1.21.0")))
+	})
+
+	It("Should drop the pre-release and build metadata of the distributions", func() {
+		for _, gitVersion := range []string{"v1.20.5", "v1.20.5+k3s1", "v1.20.5-gke.1000", "1.20.5-eks-6b7464"} {
+			v, err := Parse(gitVersion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v).Should(Equal(semver.MustParse("1.20.5")), "version %q", gitVersion)
+		}
+		_, err := Parse("unknown")
+		Expect(err).Should(HaveOccurred())
+	})
+})
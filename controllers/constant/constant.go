@@ -40,12 +40,57 @@ const (
 	//OpbiTypeLabel is the label used to label if secrets/configmaps are "original" or "copy"
 	OpbiTypeLabel string = "operator.ibm.com/managedBy-opbi"
 
+	//OpbiBindingAnnotation is the annotation recording the binding key of the OperandBindInfo the secrets/configmaps are copied for
+	OpbiBindingAnnotation string = "operator.ibm.com/opbi-binding"
+
+	//OpbiAccessAnnotation is the annotation recording the name of the Role and RoleBinding granting the access to the secrets/configmaps copied by ODLM
+	OpbiAccessAnnotation string = "operator.ibm.com/opbi-access"
+
 	//NamespaceScopeCrName is the name use to get NamespaceScopeCrName instance
 	NamespaceScopeCrName string = "nss-managedby-odlm"
 
 	//OdlmScopeNssCrName is the name use to get OdlmScopeNssCrName instance
 	OdlmScopeNssCrName string = "odlm-scope-managedby-odlm"
 
+	//OperandRequestsAnnotation is the annotation used to list the OperandRequests referencing the subscription
+	OperandRequestsAnnotation string = "operator.ibm.com/operandrequests"
+
+	//ConfirmedRemovalsAnnotation is the annotation listing the operands whose removal is confirmed, separated by commas
+	ConfirmedRemovalsAnnotation string = "operator.ibm.com/confirmed-removals"
+
+	//OperandQuotaAnnotation is the annotation on a namespace limiting the number of operands its OperandRequests may install
+	OperandQuotaAnnotation string = "operator.ibm.com/operand-quota"
+
+	//CRDSchemaHashAnnotation is the annotation recording the hash of the CRD schema the custom resource was last applied with
+	CRDSchemaHashAnnotation string = "operator.ibm.com/crd-schema-hash"
+
+	//UpgradesSuspendedAnnotation is the annotation used to mark the subscription whose upgrades are suspended by ODLM
+	UpgradesSuspendedAnnotation string = "operator.ibm.com/upgrades-suspended"
+
+	//DryRunAnnotation is the annotation on an OperandRequest reporting the changes of the custom resources instead of applying them
+	DryRunAnnotation string = "operator.ibm.com/dry-run"
+
+	//PausedAnnotation is the annotation on an OperandRequest stopping the reconcile of its custom resources and status
+	PausedAnnotation string = "operator.ibm.com/paused"
+
+	//LastAppliedChangesAnnotation is the annotation listing the spec paths changed by the last update of ODLM on the custom resource
+	LastAppliedChangesAnnotation string = "operator.ibm.com/last-applied-changes"
+
+	//ReinstallAnnotation is the annotation on an OperandRequest listing the operands whose operator is reinstalled once, separated by commas
+	ReinstallAnnotation string = "operator.ibm.com/reinstall"
+
+	//ReinstallDeleteCRsAnnotation is the annotation on an OperandRequest deleting the custom resources of the reinstalled operands as well
+	ReinstallDeleteCRsAnnotation string = "operator.ibm.com/reinstall-delete-crs"
+
+	//SkipSourceValidationAnnotation is the annotation on an OperandBindInfo accepting the sources not created yet at admission
+	SkipSourceValidationAnnotation string = "operator.ibm.com/skip-source-validation"
+
+	//CRInstanceLabel is the label used to label the named instances of the custom resources with the name of their OperandConfig service
+	CRInstanceLabel string = "operator.ibm.com/opconfig-instance-of"
+
+	//FailedCRLabel is the label used to label the configmaps keeping the custom resources failed to be created by ODLM
+	FailedCRLabel string = "operator.ibm.com/failed-custom-resource"
+
 	//FindOperandRegistry is the key for checking if the OperandRegistry is found
 	FindOperandRegistry string = "operator.ibm.com/operandregistry-is-not-found"
 
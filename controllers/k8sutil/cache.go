@@ -80,6 +80,7 @@ func buildInformerMap(config *rest.Config, opts cache.Options, resync time.Durat
 		{Group: "operator.ibm.com", Kind: "OperandRegistry", Version: "v1alpha1"},
 		{Group: "operator.ibm.com", Kind: "OperandConfig", Version: "v1alpha1"},
 		{Group: "operator.ibm.com", Kind: "OperandBindInfo", Version: "v1alpha1"},
+		{Group: "", Kind: "Namespace", Version: "v1"},
 	}
 
 	for _, gvk := range clusterGVKList {
@@ -419,6 +420,7 @@ func kindToResource(kind string) string {
 		"OperandRegistry": "operandregistries",
 		"OperandConfig":   "operandconfigs",
 		"OperandBindInfo": "operandbindinfos",
+		"Namespace":       "namespaces",
 	}
 	return kindToResourceMap[kind]
 }
@@ -464,6 +466,10 @@ func getClientForGVK(gvk schema.GroupVersionKind, config *rest.Config, scheme *r
 	cfg := rest.CopyConfig(config)
 	cfg.GroupVersion = &gv
 	cfg.APIPath = "/apis"
+	if gv.Group == "" {
+		// The core group is served under /api
+		cfg.APIPath = "/api"
+	}
 	if cfg.UserAgent == "" {
 		cfg.UserAgent = rest.DefaultKubernetesUserAgent()
 	}
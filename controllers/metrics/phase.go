@@ -0,0 +1,159 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+)
+
+var (
+	requestPhaseDesc = prometheus.NewDesc(
+		"odlm_operandrequest_phase",
+		"The phase of the OperandRequest, the value is always 1.",
+		[]string{"name", "namespace", "phase"}, nil,
+	)
+	configServicePhaseDesc = prometheus.NewDesc(
+		"odlm_operandconfig_service_phase",
+		"The phase of the custom resources of an operand in the OperandConfig, the value is always 1.",
+		[]string{"name", "namespace", "operand", "phase"}, nil,
+	)
+	subscriptionPhaseDesc = prometheus.NewDesc(
+		"odlm_subscription_csv_phase",
+		"The phase of the Subscription and ClusterServiceVersion of an operand requested by the OperandRequest, the value is always 1.",
+		[]string{"name", "namespace", "operand", "phase"}, nil,
+	)
+)
+
+// PhaseCollector exports the phases of the OperandRequests, the OperandConfigs and their operands,
+// so that the phases stuck in Failed or Installing can be alerted on
+type PhaseCollector struct {
+	mu       sync.RWMutex
+	requests map[types.NamespacedName]requestPhases
+	configs  map[types.NamespacedName]map[string]operatorv1alpha1.ServicePhase
+}
+
+type requestPhases struct {
+	phase     operatorv1alpha1.ClusterPhase
+	operators map[string]operatorv1alpha1.OperatorPhase
+}
+
+// NewPhaseCollector returns an empty PhaseCollector, it has to be registered in a prometheus registry
+func NewPhaseCollector() *PhaseCollector {
+	return &PhaseCollector{
+		requests: make(map[types.NamespacedName]requestPhases),
+		configs:  make(map[types.NamespacedName]map[string]operatorv1alpha1.ServicePhase),
+	}
+}
+
+// SetRequest records the phase of the OperandRequest and the operator phases of its members,
+// it replaces the phases recorded before
+func (c *PhaseCollector) SetRequest(request *operatorv1alpha1.OperandRequest) {
+	if c == nil {
+		return
+	}
+	phases := requestPhases{
+		phase:     request.Status.Phase,
+		operators: make(map[string]operatorv1alpha1.OperatorPhase),
+	}
+	for _, member := range request.Status.Members {
+		phases.operators[member.Name] = member.Phase.OperatorPhase
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests[types.NamespacedName{Namespace: request.Namespace, Name: request.Name}] = phases
+}
+
+// DeleteRequest removes the phases of the OperandRequest once it is deleted
+func (c *PhaseCollector) DeleteRequest(key types.NamespacedName) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.requests, key)
+}
+
+// SetConfig records the phases of the operands in the OperandConfig, an operand is Failed when any of its
+// custom resources failed, Running when all of them are running, and Initialized otherwise
+func (c *PhaseCollector) SetConfig(config *operatorv1alpha1.OperandConfig) {
+	if c == nil {
+		return
+	}
+	phases := make(map[string]operatorv1alpha1.ServicePhase)
+	for operand, status := range config.Status.ServiceStatus {
+		phases[operand] = operandPhase(status)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.configs[types.NamespacedName{Namespace: config.Namespace, Name: config.Name}] = phases
+}
+
+// DeleteConfig removes the phases of the OperandConfig once it is deleted
+func (c *PhaseCollector) DeleteConfig(key types.NamespacedName) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.configs, key)
+}
+
+func operandPhase(status operatorv1alpha1.CrStatus) operatorv1alpha1.ServicePhase {
+	if status.Phase == operatorv1alpha1.ServiceFailed {
+		return operatorv1alpha1.ServiceFailed
+	}
+	phase := operatorv1alpha1.ServiceRunning
+	for _, crPhase := range status.CrStatus {
+		switch crPhase {
+		case operatorv1alpha1.ServiceFailed:
+			return operatorv1alpha1.ServiceFailed
+		case operatorv1alpha1.ServiceRunning:
+		default:
+			phase = operatorv1alpha1.ServiceInit
+		}
+	}
+	return phase
+}
+
+// Describe implements prometheus.Collector
+func (c *PhaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- requestPhaseDesc
+	ch <- configServicePhaseDesc
+	ch <- subscriptionPhaseDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *PhaseCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for key, phases := range c.requests {
+		ch <- prometheus.MustNewConstMetric(requestPhaseDesc, prometheus.GaugeValue, 1, key.Name, key.Namespace, string(phases.phase))
+		for operand, phase := range phases.operators {
+			ch <- prometheus.MustNewConstMetric(subscriptionPhaseDesc, prometheus.GaugeValue, 1, key.Name, key.Namespace, operand, string(phase))
+		}
+	}
+	for key, operands := range c.configs {
+		for operand, phase := range operands {
+			ch <- prometheus.MustNewConstMetric(configServicePhaseDesc, prometheus.GaugeValue, 1, key.Name, key.Namespace, operand, string(phase))
+		}
+	}
+}
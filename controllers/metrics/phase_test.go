@@ -0,0 +1,109 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package metrics
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	odlmtestutil "github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Exporting the phases", func() {
+	var (
+		collector *PhaseCollector
+		registry  *prometheus.Registry
+	)
+
+	BeforeEach(func() {
+		collector = NewPhaseCollector()
+		registry = prometheus.NewRegistry()
+		Expect(registry.Register(collector)).Should(Succeed())
+	})
+
+	It("Should export the phases of the OperandRequest and its operators", func() {
+		request := odlmtestutil.OperandRequestObj("common-service", "ibm-common-services", "ibm-cloudpak-name", "ibm-cloudpak")
+		request.Status.Phase = operatorv1alpha1.ClusterPhaseInstalling
+		request.Status.Members = []operatorv1alpha1.MemberStatus{
+			{Name: "etcd", Phase: operatorv1alpha1.MemberPhase{OperatorPhase: operatorv1alpha1.OperatorRunning}},
+			{Name: "jenkins", Phase: operatorv1alpha1.MemberPhase{OperatorPhase: operatorv1alpha1.OperatorInstalling}},
+		}
+		collector.SetRequest(request)
+
+		Expect(testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP odlm_operandrequest_phase The phase of the OperandRequest, the value is always 1.
+# TYPE odlm_operandrequest_phase gauge
+odlm_operandrequest_phase{name="ibm-cloudpak-name",namespace="ibm-cloudpak",phase="Installing"} 1
+# HELP odlm_subscription_csv_phase The phase of the Subscription and ClusterServiceVersion of an operand requested by the OperandRequest, the value is always 1.
+# TYPE odlm_subscription_csv_phase gauge
+odlm_subscription_csv_phase{name="ibm-cloudpak-name",namespace="ibm-cloudpak",operand="etcd",phase="Running"} 1
+odlm_subscription_csv_phase{name="ibm-cloudpak-name",namespace="ibm-cloudpak",operand="jenkins",phase="Installing"} 1
+`), "odlm_operandrequest_phase", "odlm_subscription_csv_phase")).Should(Succeed())
+
+		By("Replacing the phases on the next reconcile")
+		request.Status.Phase = operatorv1alpha1.ClusterPhaseRunning
+		request.Status.Members = request.Status.Members[:1]
+		collector.SetRequest(request)
+		Expect(testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP odlm_operandrequest_phase The phase of the OperandRequest, the value is always 1.
+# TYPE odlm_operandrequest_phase gauge
+odlm_operandrequest_phase{name="ibm-cloudpak-name",namespace="ibm-cloudpak",phase="Running"} 1
+# HELP odlm_subscription_csv_phase The phase of the Subscription and ClusterServiceVersion of an operand requested by the OperandRequest, the value is always 1.
+# TYPE odlm_subscription_csv_phase gauge
+odlm_subscription_csv_phase{name="ibm-cloudpak-name",namespace="ibm-cloudpak",operand="etcd",phase="Running"} 1
+`), "odlm_operandrequest_phase", "odlm_subscription_csv_phase")).Should(Succeed())
+
+		By("Removing the phases of the deleted OperandRequest")
+		collector.DeleteRequest(types.NamespacedName{Name: "ibm-cloudpak-name", Namespace: "ibm-cloudpak"})
+		Expect(testutil.CollectAndCount(collector)).Should(Equal(0))
+	})
+
+	It("Should export the phases of the operands in the OperandConfig", func() {
+		config := odlmtestutil.OperandConfigObj("common-service", "ibm-common-services")
+		config.Status.ServiceStatus = map[string]operatorv1alpha1.CrStatus{
+			"etcd":    {CrStatus: map[string]operatorv1alpha1.ServicePhase{"etcdCluster": operatorv1alpha1.ServiceRunning}},
+			"jenkins": {CrStatus: map[string]operatorv1alpha1.ServicePhase{"jenkins": operatorv1alpha1.ServiceRunning, "jenkinsAgent": operatorv1alpha1.ServiceFailed}},
+			"mongodb": {CrStatus: map[string]operatorv1alpha1.ServicePhase{"mongodb": operatorv1alpha1.ServiceInit}},
+			"redis":   {Phase: operatorv1alpha1.ServiceFailed, Message: "invalid alm-examples"},
+		}
+		collector.SetConfig(config)
+
+		Expect(testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP odlm_operandconfig_service_phase The phase of the custom resources of an operand in the OperandConfig, the value is always 1.
+# TYPE odlm_operandconfig_service_phase gauge
+odlm_operandconfig_service_phase{name="common-service",namespace="ibm-common-services",operand="etcd",phase="Running"} 1
+odlm_operandconfig_service_phase{name="common-service",namespace="ibm-common-services",operand="jenkins",phase="Failed"} 1
+odlm_operandconfig_service_phase{name="common-service",namespace="ibm-common-services",operand="mongodb",phase="Initialized"} 1
+odlm_operandconfig_service_phase{name="common-service",namespace="ibm-common-services",operand="redis",phase="Failed"} 1
+`), "odlm_operandconfig_service_phase")).Should(Succeed())
+
+		collector.DeleteConfig(types.NamespacedName{Name: "common-service", Namespace: "ibm-common-services"})
+		Expect(testutil.CollectAndCount(collector)).Should(Equal(0))
+	})
+
+	It("Should ignore the phases without a collector", func() {
+		var collector *PhaseCollector
+		collector.SetRequest(odlmtestutil.OperandRequestObj("common-service", "ibm-common-services", "ibm-cloudpak-name", "ibm-cloudpak"))
+		collector.DeleteConfig(types.NamespacedName{Name: "common-service", Namespace: "ibm-common-services"})
+	})
+})
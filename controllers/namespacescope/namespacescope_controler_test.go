@@ -18,10 +18,12 @@ package namespacescope
 
 import (
 	"context"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	nssv1 "github.com/IBM/ibm-namespace-scope-operator/api/v1"
 
@@ -144,6 +146,48 @@ var _ = Describe("NamespaceScope controller", func() {
 				}
 				return len(odlmNss.Spec.NamespaceMembers)
 			}, timeout, interval).Should(Equal(1))
+			By("Check the workqueue metrics of the controllers")
+			for _, controller := range []string{"namespacescope", "operandrequest"} {
+				queueMetrics := workqueueMetrics(controller)
+				Expect(queueMetrics[metrics.WorkQueueSubsystem+"_"+metrics.WorkDurationKey]).Should(BeNumerically(">", 0))
+				Expect(queueMetrics).Should(And(
+					HaveKey(metrics.WorkQueueSubsystem+"_"+metrics.DepthKey),
+					HaveKey(metrics.WorkQueueSubsystem+"_"+metrics.AddsKey),
+					HaveKey(metrics.WorkQueueSubsystem+"_"+metrics.RetriesKey),
+					HaveKey(metrics.WorkQueueSubsystem+"_"+metrics.QueueLatencyKey),
+					HaveKey(metrics.WorkQueueSubsystem+"_"+metrics.UnfinishedWorkKey),
+					HaveKey(metrics.WorkQueueSubsystem+"_"+metrics.LongestRunningProcessorKey),
+				))
+			}
 		})
 	})
 })
+
+// workqueueMetrics returns the workqueue metrics of the controller registered in controller-runtime,
+// with the sample count of the histograms and the value of the counters and the gauges
+func workqueueMetrics(controller string) map[string]float64 {
+	families, err := metrics.Registry.Gather()
+	Expect(err).NotTo(HaveOccurred())
+	values := make(map[string]float64)
+	for _, family := range families {
+		if !strings.HasPrefix(family.GetName(), metrics.WorkQueueSubsystem+"_") {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() != "name" || label.GetValue() != controller {
+					continue
+				}
+				switch {
+				case m.GetHistogram() != nil:
+					values[family.GetName()] = float64(m.GetHistogram().GetSampleCount())
+				case m.GetCounter() != nil:
+					values[family.GetName()] = m.GetCounter().GetValue()
+				default:
+					values[family.GetName()] = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return values
+}
@@ -34,6 +34,7 @@ import (
 	nssv1 "github.com/IBM/ibm-namespace-scope-operator/api/v1"
 
 	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/audit"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
 	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/util"
@@ -50,6 +51,9 @@ type Reconciler struct {
 
 // ReconcileOperandRequest reads that state of the cluster for OperandRequest object and update NamespaceScope CR based on the state read
 func (r *Reconciler) ReconcileOperandRequest(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reconcileErr error) {
+	// Record the OperandRequest as the owner of the audited mutations
+	ctx = audit.WithRequest(ctx, "OperandRequest", req.NamespacedName)
+
 	exist, err := r.checkNamespaceScopeAPI()
 	if err != nil {
 		return ctrl.Result{}, err
@@ -232,7 +236,10 @@ func (r *Reconciler) getNamespaceScopeCRList(ctx context.Context) ([]*nssv1.Name
 
 // SetupWithManager adds namespacescope controller to the manager.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// The controller is named explicitly, the default name "operandrequest" is taken by the OperandRequest controller,
+	// and the metrics of their workqueues would be mixed up
 	err := ctrl.NewControllerManagedBy(mgr).
+		Named("namespacescope").
 		For(&operatorv1alpha1.OperandRequest{}).
 		Complete(reconcile.Func(r.ReconcileOperandRequest))
 	if err != nil {
@@ -0,0 +1,139 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandbindinfo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+)
+
+// accessName returns the name of the Role and RoleBinding granting the access to the copies of a binding
+func accessName(bindInfoInstance *operatorv1alpha1.OperandBindInfo, key string) string {
+	return strings.ToLower(bindInfoInstance.Name + "-" + key)
+}
+
+// copyAnnotations returns the annotations of the copies of a binding
+func copyAnnotations(bindInfoInstance *operatorv1alpha1.OperandBindInfo, key string) map[string]string {
+	annotations := map[string]string{
+		constant.OpbiBindingAnnotation: key,
+	}
+	if bindInfoInstance.Spec.Bindings[key].GrantAccess != nil {
+		annotations[constant.OpbiAccessAnnotation] = accessName(bindInfoInstance, key)
+	}
+	return annotations
+}
+
+// grantAccess creates a Role and a RoleBinding in the target namespace, granting the service accounts
+// of the binding the get and list access to the copied secret and configmap.
+// They are owned by the OperandRequest like the copies, and garbage collected with it.
+func (r *Reconciler) grantAccess(ctx context.Context, bindInfoInstance *operatorv1alpha1.OperandBindInfo, requestInstance *operatorv1alpha1.OperandRequest,
+	key, targetNs, secretName, cmName string) error {
+	access := bindInfoInstance.Spec.Bindings[key].GrantAccess
+	if access == nil {
+		return nil
+	}
+	var rules []rbacv1.PolicyRule
+	if secretName != "" {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			ResourceNames: []string{secretName},
+			Verbs:         []string{"get", "list"},
+		})
+	}
+	if cmName != "" {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups:     []string{""},
+			Resources:     []string{"configmaps"},
+			ResourceNames: []string{cmName},
+			Verbs:         []string{"get", "list"},
+		})
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	name := accessName(bindInfoInstance, key)
+	objectMeta := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: targetNs,
+		Labels: map[string]string{
+			bindInfoInstance.Namespace + "." + bindInfoInstance.Name + "/bindinfo": "true",
+		},
+		Annotations: map[string]string{
+			constant.OpbiBindingAnnotation: key,
+		},
+	}
+	role := &rbacv1.Role{
+		ObjectMeta: *objectMeta.DeepCopy(),
+		Rules:      rules,
+	}
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: *objectMeta.DeepCopy(),
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     name,
+		},
+	}
+	for _, sa := range access.GetServiceAccounts() {
+		roleBinding.Subjects = append(roleBinding.Subjects, rbacv1.Subject{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      sa,
+			Namespace: targetNs,
+		})
+	}
+
+	for _, obj := range []client.Object{role, roleBinding} {
+		if err := r.setCopyOwnerReference(bindInfoInstance, requestInstance, obj); err != nil {
+			return errors.Wrapf(err, "failed to set OperandRequest %s as the owner of %s/%s", requestInstance.Name, targetNs, name)
+		}
+		if err := r.Create(ctx, obj); err != nil {
+			if !apierrors.IsAlreadyExists(err) {
+				return errors.Wrapf(err, "failed to create the access %s/%s to the copies of the binding %s", targetNs, name, key)
+			}
+			// If already exist, merge the desired rules and subjects into it
+			if err := r.Patch(ctx, obj, client.Merge); err != nil {
+				return errors.Wrapf(err, "failed to update the access %s/%s to the copies of the binding %s", targetNs, name, key)
+			}
+		}
+	}
+	klog.V(2).Infof("Grant the access %s to the copies of the binding %s in the namespace %s", name, key, targetNs)
+	return nil
+}
+
+// revokeAccess deletes the Role and RoleBinding granting the access to the copies of a binding
+func (r *Reconciler) revokeAccess(ctx context.Context, namespace, name string) error {
+	objectMeta := metav1.ObjectMeta{Name: name, Namespace: namespace}
+	for _, obj := range []client.Object{&rbacv1.RoleBinding{ObjectMeta: objectMeta}, &rbacv1.Role{ObjectMeta: objectMeta}} {
+		if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to revoke the access %s/%s", namespace, name)
+		}
+	}
+	klog.V(2).Infof("Revoke the access %s in the namespace %s", name, namespace)
+	return nil
+}
@@ -0,0 +1,153 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandbindinfo
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Granting the access to the copies", func() {
+	const (
+		operandNamespace  = "ibm-operators"
+		requestNamespace  = "ibm-cloudpak"
+		registryName      = "common-service"
+		registryNamespace = "ibm-common-services"
+	)
+
+	var (
+		ctx       context.Context
+		c         client.Client
+		r         *Reconciler
+		bindInfo  *operatorv1alpha1.OperandBindInfo
+		requests  []operatorv1alpha1.ReconcileRequest
+		accessKey types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(scheme)).Should(Succeed())
+		request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", requestNamespace)
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			testutil.SecretObj("secret1", operandNamespace),
+			testutil.ConfigmapObj("cm1", operandNamespace),
+			request,
+		).Build()
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(100),
+			},
+		}
+		bindInfo = testutil.OperandBindInfoObj("ibm-operators-bindinfo", operandNamespace, registryName, registryNamespace)
+		public := bindInfo.Spec.Bindings["public"]
+		public.GrantAccess = &operatorv1alpha1.BindingAccess{ServiceAccounts: []string{"app"}}
+		bindInfo.Spec.Bindings["public"] = public
+		requests = []operatorv1alpha1.ReconcileRequest{{Name: request.Name, Namespace: requestNamespace}}
+		accessKey = types.NamespacedName{Name: "ibm-operators-bindinfo-public", Namespace: requestNamespace}
+	})
+
+	It("Should create the Role and RoleBinding owned by the OperandRequest", func() {
+		_, merr := r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+
+		By("Checking the Role grants the access to the copies only")
+		role := &rbacv1.Role{}
+		Expect(c.Get(ctx, accessKey, role)).Should(Succeed())
+		Expect(role.Rules).Should(ConsistOf(
+			rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"secret4"}, Verbs: []string{"get", "list"}},
+			rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"cm4"}, Verbs: []string{"get", "list"}},
+		))
+
+		By("Checking the RoleBinding binds the service accounts to the Role")
+		roleBinding := &rbacv1.RoleBinding{}
+		Expect(c.Get(ctx, accessKey, roleBinding)).Should(Succeed())
+		Expect(roleBinding.RoleRef).Should(Equal(rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: accessKey.Name}))
+		Expect(roleBinding.Subjects).Should(ConsistOf(rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "app", Namespace: requestNamespace}))
+
+		By("Checking they are garbage collected with the OperandRequest")
+		for _, obj := range []client.Object{role, roleBinding} {
+			Expect(obj.GetOwnerReferences()).Should(HaveLen(1))
+			Expect(obj.GetOwnerReferences()[0].Kind).Should(Equal("OperandRequest"))
+			Expect(obj.GetOwnerReferences()[0].Name).Should(Equal("ibm-cloudpak-name"))
+		}
+
+		By("Checking the copies record the access")
+		secret := &corev1.Secret{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "secret4", Namespace: requestNamespace}, secret)).Should(Succeed())
+		Expect(secret.Annotations).Should(HaveKeyWithValue(constant.OpbiAccessAnnotation, accessKey.Name))
+	})
+
+	It("Should not grant the access to the bindings without grantAccess", func() {
+		delete(bindInfo.Spec.Bindings, "public")
+		bindInfo.Spec.Bindings["public"] = operatorv1alpha1.SecretConfigmap{Secret: "secret1", Configmap: "cm1"}
+		_, merr := r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+
+		err := c.Get(ctx, accessKey, &rbacv1.Role{})
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+		secret := &corev1.Secret{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "secret4", Namespace: requestNamespace}, secret)).Should(Succeed())
+		Expect(secret.Annotations).ShouldNot(HaveKey(constant.OpbiAccessAnnotation))
+	})
+
+	It("Should update the service accounts and revoke the access when grantAccess is removed", func() {
+		_, merr := r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+
+		By("Adding a service account")
+		bindInfo.Spec.Bindings["public"].GrantAccess.ServiceAccounts = []string{"app", "worker"}
+		_, merr = r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+		roleBinding := &rbacv1.RoleBinding{}
+		Expect(c.Get(ctx, accessKey, roleBinding)).Should(Succeed())
+		Expect(roleBinding.Subjects).Should(HaveLen(2))
+
+		By("Removing grantAccess from the binding")
+		public := bindInfo.Spec.Bindings["public"]
+		public.GrantAccess = nil
+		bindInfo.Spec.Bindings["public"] = public
+		Expect(r.pruneRemovedBindings(ctx, bindInfo)).Should(Succeed())
+		err := c.Get(ctx, accessKey, &rbacv1.Role{})
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+		err = c.Get(ctx, accessKey, &rbacv1.RoleBinding{})
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+
+		By("Checking the copies remain")
+		Expect(c.Get(ctx, types.NamespacedName{Name: "secret4", Namespace: requestNamespace}, &corev1.Secret{})).Should(Succeed())
+	})
+})
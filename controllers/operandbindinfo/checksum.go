@@ -0,0 +1,127 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandbindinfo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+)
+
+const (
+	secretKind    = "Secret"
+	configMapKind = "ConfigMap"
+)
+
+// dataChecksum returns the sha256 checksum of the data of a Secret or ConfigMap, regardless of the order of the keys.
+// The string data overrides the data with the same key, as the API server merges the stringData of a Secret.
+func dataChecksum(data map[string][]byte, stringData map[string]string) string {
+	keys := make([]string, 0, len(data)+len(stringData))
+	for k := range stringData {
+		keys = append(keys, k)
+	}
+	for k := range data {
+		if _, ok := stringData[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		value := data[k]
+		if v, ok := stringData[k]; ok {
+			value = []byte(v)
+		}
+		// Prefix the lengths, so that the keys and the values can't be shifted into each other
+		fmt.Fprintf(h, "%d:%s%d:", len(k), k, len(value))
+		h.Write(value)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// secretChecksum returns the checksum of the data of the Secret
+func secretChecksum(secret *corev1.Secret) string {
+	return dataChecksum(secret.Data, secret.StringData)
+}
+
+// configMapChecksum returns the checksum of the data of the ConfigMap
+func configMapChecksum(cm *corev1.ConfigMap) string {
+	return dataChecksum(cm.BinaryData, cm.Data)
+}
+
+// recordCopiedBinding records the checksum of the copy in the status of the OperandBindInfo,
+// the copies are written by several workers at the same time
+func (r *Reconciler) recordCopiedBinding(bindInfoInstance *operatorv1alpha1.OperandBindInfo, kind, namespace, name, checksum string) {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	bindInfoInstance.SetCopiedBinding(kind, namespace, name, checksum)
+}
+
+// checkCopiedBindings compares the copies with the checksums recorded in the status of the OperandBindInfo,
+// and returns the copies edited since they were copied, which are restored by the following copy.
+// The checksums of the copies that no longer exist are removed.
+func (r *Reconciler) checkCopiedBindings(ctx context.Context, bindInfoInstance *operatorv1alpha1.OperandBindInfo) ([]string, error) {
+	var drifted, removed []operatorv1alpha1.CopiedBinding
+	for _, b := range bindInfoInstance.Status.CopiedBindings {
+		var (
+			obj      client.Object
+			checksum func() string
+		)
+		switch b.Kind {
+		case secretKind:
+			secret := &corev1.Secret{}
+			obj, checksum = secret, func() string { return secretChecksum(secret) }
+		case configMapKind:
+			cm := &corev1.ConfigMap{}
+			obj, checksum = cm, func() string { return configMapChecksum(cm) }
+		default:
+			removed = append(removed, b)
+			continue
+		}
+		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: b.Namespace, Name: b.Name}, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				removed = append(removed, b)
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to get %s %s/%s", b.Kind, b.Namespace, b.Name)
+		}
+		if checksum() != b.Checksum {
+			drifted = append(drifted, b)
+		}
+	}
+	for _, b := range removed {
+		bindInfoInstance.RemoveCopiedBinding(b.Kind, b.Namespace, b.Name)
+	}
+	var copies []string
+	for _, b := range drifted {
+		klog.Warningf("%s %s/%s copied by the OperandBindInfo %s/%s is edited, restore it", b.Kind, b.Namespace, b.Name, bindInfoInstance.Namespace, bindInfoInstance.Name)
+		r.Recorder.Eventf(bindInfoInstance, corev1.EventTypeWarning, "CopyDriftDetected", "%s %s/%s is edited and restored from the source", b.Kind, b.Namespace, b.Name)
+		copies = append(copies, b.Kind+" "+b.Namespace+"/"+b.Name)
+	}
+	return copies, nil
+}
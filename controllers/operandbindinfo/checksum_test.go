@@ -0,0 +1,156 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandbindinfo
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Checking the copies of the OperandBindInfo", func() {
+	const (
+		operandNamespace  = "ibm-operators"
+		requestNamespace  = "ibm-cloudpak"
+		registryName      = "common-service"
+		registryNamespace = "ibm-common-services"
+	)
+
+	var (
+		ctx      context.Context
+		c        client.Client
+		r        *Reconciler
+		bindInfo *operatorv1alpha1.OperandBindInfo
+		requests []operatorv1alpha1.ReconcileRequest
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(scheme)).Should(Succeed())
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			testutil.SecretObj("secret1", operandNamespace),
+			testutil.ConfigmapObj("cm1", operandNamespace),
+			testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", requestNamespace),
+		).Build()
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(100),
+			},
+		}
+		bindInfo = testutil.OperandBindInfoObj("ibm-operators-bindinfo", operandNamespace, registryName, registryNamespace)
+		requests = []operatorv1alpha1.ReconcileRequest{{Name: "ibm-cloudpak-name", Namespace: requestNamespace}}
+	})
+
+	It("Should record the checksums of the copies", func() {
+		_, merr := r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+
+		secret := bindInfo.GetCopiedBinding(secretKind, requestNamespace, "secret4")
+		Expect(secret).ShouldNot(BeNil())
+		Expect(secret.Checksum).Should(Equal(dataChecksum(nil, map[string]string{"test": "secret1"})))
+		cm := bindInfo.GetCopiedBinding(configMapKind, requestNamespace, "cm4")
+		Expect(cm).ShouldNot(BeNil())
+		Expect(cm.Checksum).Should(Equal(dataChecksum(nil, map[string]string{"test": "cm1"})))
+
+		By("Checking the unchanged copies")
+		copies, err := r.checkCopiedBindings(ctx, bindInfo)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(copies).Should(BeEmpty())
+		Expect(r.Recorder.(*record.FakeRecorder).Events).Should(BeEmpty())
+	})
+
+	It("Should flag and restore an edited copy", func() {
+		_, merr := r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+
+		By("Editing the copy of the Secret")
+		secret := &corev1.Secret{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "secret4", Namespace: requestNamespace}, secret)).Should(Succeed())
+		secret.StringData["test"] = "tampered"
+		Expect(c.Update(ctx, secret)).Should(Succeed())
+
+		copies, err := r.checkCopiedBindings(ctx, bindInfo)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(copies).Should(Equal([]string{"Secret " + requestNamespace + "/secret4"}))
+		recorder := r.Recorder.(*record.FakeRecorder)
+		Expect(recorder.Events).Should(HaveLen(1))
+		Expect(<-recorder.Events).Should(And(HavePrefix("Warning CopyDriftDetected"), ContainSubstring("secret4")))
+		bindInfo.SetCopyDriftDetectedCondition(copies)
+		Expect(bindInfo.Status.Conditions).Should(HaveLen(1))
+		Expect(bindInfo.Status.Conditions[0].Type).Should(Equal(operatorv1alpha1.ConditionCopyDriftDetected))
+
+		By("Restoring the copy")
+		_, merr = r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+		Expect(c.Get(ctx, types.NamespacedName{Name: "secret4", Namespace: requestNamespace}, secret)).Should(Succeed())
+		Expect(secret.StringData).Should(HaveKeyWithValue("test", "secret1"))
+
+		copies, err = r.checkCopiedBindings(ctx, bindInfo)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(copies).Should(BeEmpty())
+		bindInfo.SetCopyDriftDetectedCondition(copies)
+		Expect(bindInfo.Status.Conditions).Should(BeEmpty())
+	})
+
+	It("Should not flag a copy updated from the changed source", func() {
+		_, merr := r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+
+		By("Changing the source ConfigMap")
+		cm := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "cm1", Namespace: operandNamespace}, cm)).Should(Succeed())
+		cm.Data["test"] = "rotated"
+		Expect(c.Update(ctx, cm)).Should(Succeed())
+
+		copies, err := r.checkCopiedBindings(ctx, bindInfo)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(copies).Should(BeEmpty())
+		_, merr = r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+		Expect(bindInfo.GetCopiedBinding(configMapKind, requestNamespace, "cm4").Checksum).Should(Equal(dataChecksum(nil, map[string]string{"test": "rotated"})))
+	})
+
+	It("Should remove the checksum of a deleted copy", func() {
+		_, merr := r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+		Expect(bindInfo.Status.CopiedBindings).Should(HaveLen(2))
+
+		Expect(c.Delete(ctx, testutil.ConfigmapObj("cm4", requestNamespace))).Should(Succeed())
+		copies, err := r.checkCopiedBindings(ctx, bindInfo)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(copies).Should(BeEmpty())
+		Expect(bindInfo.Status.CopiedBindings).Should(HaveLen(1))
+		Expect(bindInfo.GetCopiedBinding(configMapKind, requestNamespace, "cm4")).Should(BeNil())
+	})
+})
@@ -0,0 +1,112 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandbindinfo
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Cleaning up orphan copies", func() {
+	const (
+		namespace = "ibm-orphan-copies"
+	)
+
+	var (
+		ctx           context.Context
+		r             *Reconciler
+		namespaceName string
+	)
+
+	copyObj := func(name, namespace, bindInfoNs, bindInfoName string, owned bool) *corev1.Secret {
+		secret := testutil.SecretObj(name, namespace)
+		secret.Labels = map[string]string{
+			bindInfoNs + "." + bindInfoName + "/bindinfo": "true",
+			constant.OpbiTypeLabel:                        "copy",
+		}
+		if owned {
+			controller := true
+			secret.OwnerReferences = []metav1.OwnerReference{
+				{
+					APIVersion: operatorv1alpha1.GroupVersion.String(),
+					Kind:       "OperandRequest",
+					Name:       "ibm-cloudpak-name",
+					UID:        types.UID("operandrequest-uid"),
+					Controller: &controller,
+				},
+			}
+		}
+		return secret
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client: k8sClient,
+				Reader: k8sClient,
+			},
+		}
+		namespaceName = testutil.CreateNSName(namespace)
+		Expect(k8sClient.Create(ctx, testutil.NamespaceObj(namespaceName))).Should(Succeed())
+	})
+
+	It("Should delete the copies of the deleted OperandBindInfo only", func() {
+		By("Creating the OperandBindInfo")
+		bindInfo := &operatorv1alpha1.OperandBindInfo{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "existing-bindinfo",
+				Namespace: namespaceName,
+			},
+			Spec: operatorv1alpha1.OperandBindInfoSpec{
+				Operand:  "jenkins",
+				Registry: "common-service",
+			},
+		}
+		Expect(k8sClient.Create(ctx, bindInfo)).Should(Succeed())
+
+		By("Creating the copies")
+		orphanCopy := copyObj("orphan-copy", namespaceName, namespaceName, "deleted-bindinfo", true)
+		validCopy := copyObj("valid-copy", namespaceName, namespaceName, "existing-bindinfo", true)
+		userSecret := copyObj("user-secret", namespaceName, namespaceName, "deleted-bindinfo", false)
+		Expect(k8sClient.Create(ctx, orphanCopy)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, validCopy)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, userSecret)).Should(Succeed())
+
+		By("Cleaning up the orphan copies")
+		Expect(r.cleanupOrphanCopies(ctx)).Should(Succeed())
+
+		By("Checking the orphan copy is deleted")
+		err := k8sClient.Get(ctx, types.NamespacedName{Name: "orphan-copy", Namespace: namespaceName}, &corev1.Secret{})
+		Expect(errors.IsNotFound(err)).Should(BeTrue())
+
+		By("Checking the valid copy and the user secret remain")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "valid-copy", Namespace: namespaceName}, &corev1.Secret{})).Should(Succeed())
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "user-secret", Namespace: namespaceName}, &corev1.Secret{})).Should(Succeed())
+	})
+})
@@ -0,0 +1,520 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandbindinfo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+// slowClient simulates the latency and the transient failures of the API server on creation
+type slowClient struct {
+	client.Client
+	delay   time.Duration
+	creates int32
+
+	mu sync.Mutex
+	// failures are the number of the creations to fail in each namespace
+	failures map[string]int
+	// namespaceCreates are the number of the creations in each namespace
+	namespaceCreates map[string]int
+}
+
+func (c *slowClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	atomic.AddInt32(&c.creates, 1)
+	time.Sleep(c.delay)
+	c.mu.Lock()
+	if c.namespaceCreates == nil {
+		c.namespaceCreates = make(map[string]int)
+	}
+	c.namespaceCreates[obj.GetNamespace()]++
+	if c.failures[obj.GetNamespace()] > 0 {
+		c.failures[obj.GetNamespace()]--
+		c.mu.Unlock()
+		return apierrors.NewServerTimeout(schema.GroupResource{Resource: "secrets"}, "create", 1)
+	}
+	c.mu.Unlock()
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+var _ = Describe("Copying to the OperandRequest namespaces", func() {
+	const (
+		namespaceCount    = 40
+		operandNamespace  = "ibm-operators"
+		registryName      = "common-service"
+		registryNamespace = "ibm-common-services"
+	)
+
+	var (
+		ctx      context.Context
+		scheme   *runtime.Scheme
+		bindInfo *operatorv1alpha1.OperandBindInfo
+		requests []operatorv1alpha1.ReconcileRequest
+	)
+
+	newReconciler := func(concurrency int, requestInstances ...client.Object) (*Reconciler, *slowClient) {
+		objects := []client.Object{
+			testutil.SecretObj("secret1", operandNamespace),
+			testutil.ConfigmapObj("cm1", operandNamespace),
+		}
+		objects = append(objects, requestInstances...)
+		c := &slowClient{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			delay:  10 * time.Millisecond,
+		}
+		return &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(100),
+			},
+			CopyConcurrency: concurrency,
+		}, c
+	}
+
+	requestObjs := func() []client.Object {
+		var objects []client.Object
+		for _, request := range requests {
+			objects = append(objects, testutil.OperandRequestObj(registryName, registryNamespace, request.Name, request.Namespace))
+		}
+		return objects
+	}
+
+	copyAll := func(concurrency int) (client.Client, time.Duration) {
+		r, c := newReconciler(concurrency, requestObjs()...)
+		start := time.Now()
+		requeue, merr := r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		elapsed := time.Since(start)
+		Expect(merr.Errors).Should(BeEmpty())
+		Expect(requeue).Should(BeFalse())
+		return c, elapsed
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(scheme)).Should(Succeed())
+		bindInfo = testutil.OperandBindInfoObj("ibm-operators-bindinfo", operandNamespace, registryName, registryNamespace)
+		requests = nil
+		for i := 0; i < namespaceCount; i++ {
+			requests = append(requests, operatorv1alpha1.ReconcileRequest{
+				Name:      "ibm-cloudpak-name",
+				Namespace: fmt.Sprintf("ibm-cloudpak-%d", i),
+			})
+		}
+	})
+
+	It("Should copy to many namespaces faster with more workers", func() {
+		_, serialTime := copyAll(1)
+		c, concurrentTime := copyAll(10)
+		By(fmt.Sprintf("Copying to %d namespaces took %v with 1 worker and %v with 10 workers", namespaceCount, serialTime, concurrentTime))
+		Expect(concurrentTime).Should(BeNumerically("<", serialTime/2))
+
+		By("Checking the owner and labels of the copies")
+		for _, request := range requests {
+			secret := &corev1.Secret{}
+			Expect(c.Get(ctx, types.NamespacedName{Name: "secret4", Namespace: request.Namespace}, secret)).Should(Succeed())
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, types.NamespacedName{Name: "cm4", Namespace: request.Namespace}, cm)).Should(Succeed())
+			for _, obj := range []client.Object{secret, cm} {
+				Expect(obj.GetLabels()).Should(HaveKeyWithValue(constant.OpbiTypeLabel, "copy"))
+				Expect(obj.GetLabels()).Should(HaveKeyWithValue(bindInfo.Namespace+"."+bindInfo.Name+"/bindinfo", "true"))
+				Expect(obj.GetOwnerReferences()).Should(HaveLen(1))
+				Expect(obj.GetOwnerReferences()[0].Kind).Should(Equal("OperandRequest"))
+				Expect(obj.GetOwnerReferences()[0].Name).Should(Equal(request.Name))
+				Expect(*obj.GetOwnerReferences()[0].Controller).Should(BeTrue())
+			}
+		}
+
+		By("Checking the labels of the original secret and configmap")
+		secret := &corev1.Secret{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "secret1", Namespace: operandNamespace}, secret)).Should(Succeed())
+		Expect(secret.Labels).Should(HaveKeyWithValue(constant.OpbiTypeLabel, "original"))
+		Expect(secret.Labels).Should(HaveKeyWithValue(constant.OpbiNameLabel, bindInfo.Name))
+		cm := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "cm1", Namespace: operandNamespace}, cm)).Should(Succeed())
+		Expect(cm.Labels).Should(HaveKeyWithValue(constant.OpbiTypeLabel, "original"))
+	})
+
+	It("Should aggregate the errors of all the namespaces", func() {
+		r, _ := newReconciler(5, requestObjs()...)
+		missing := []operatorv1alpha1.ReconcileRequest{
+			{Name: "not-exist", Namespace: "ibm-cloudpak-0"},
+			{Name: "not-exist", Namespace: "ibm-cloudpak-1"},
+		}
+		_, merr := r.copyToRequests(ctx, bindInfo, append(missing, requests...), operandNamespace)
+		Expect(merr.Errors).Should(HaveLen(2))
+	})
+
+	It("Should not copy the public binding into its source namespace", func() {
+		request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", operandNamespace)
+		request.Spec.Requests[0].Operands[1].Bindings = nil
+		r, c := newReconciler(1, request)
+		sameNamespace := []operatorv1alpha1.ReconcileRequest{{Name: request.Name, Namespace: operandNamespace}}
+
+		requeue, merr := r.copyToRequests(ctx, bindInfo, sameNamespace, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+		Expect(requeue).Should(BeFalse())
+		Expect(atomic.LoadInt32(&c.creates)).Should(BeZero())
+
+		By("Copying the public binding renamed by the OperandRequest")
+		renamedRequest := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-renamed", operandNamespace)
+		r, c = newReconciler(1, renamedRequest)
+		sameNamespace = []operatorv1alpha1.ReconcileRequest{{Name: renamedRequest.Name, Namespace: operandNamespace}}
+
+		_, merr = r.copyToRequests(ctx, bindInfo, sameNamespace, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+		Expect(c.Get(ctx, types.NamespacedName{Name: "secret4", Namespace: operandNamespace}, &corev1.Secret{})).Should(Succeed())
+		Expect(c.Get(ctx, types.NamespacedName{Name: "cm4", Namespace: operandNamespace}, &corev1.ConfigMap{})).Should(Succeed())
+	})
+
+	It("Should record the binding keys of the OperandRequest not defined in the OperandBindInfo", func() {
+		requests = requests[:1]
+		r, _ := newReconciler(1, requestObjs()...)
+		_, merr := r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+		Expect(r.Recorder.(*record.FakeRecorder).Events).Should(BeEmpty())
+
+		By("Requesting a binding key unknown to the OperandBindInfo")
+		request := testutil.OperandRequestObj(registryName, registryNamespace, requests[0].Name, requests[0].Namespace)
+		request.Spec.Requests[0].Operands[1].Bindings["public-unknown"] = operatorv1alpha1.SecretConfigmap{Secret: "secret6"}
+		r, c := newReconciler(1, request)
+		_, merr = r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+		recorder := r.Recorder.(*record.FakeRecorder)
+		Expect(recorder.Events).Should(HaveLen(1))
+		Expect(<-recorder.Events).Should(And(HavePrefix("Warning UnknownBindingKey"), ContainSubstring("public-unknown")))
+		Expect(c.Get(ctx, types.NamespacedName{Name: "secret4", Namespace: requests[0].Namespace}, &corev1.Secret{})).Should(Succeed())
+		Expect(apierrors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: "secret6", Namespace: requests[0].Namespace}, &corev1.Secret{}))).Should(BeTrue())
+	})
+
+	It("Should retry the target failing transiently without copying to the others again", func() {
+		requests = requests[:5]
+		r, c := newReconciler(10, requestObjs()...)
+		r.CopyBackoff = wait.Backoff{Duration: 10 * time.Millisecond, Factor: 2, Steps: 3}
+		flakyNamespace := requests[0].Namespace
+		c.failures = map[string]int{flakyNamespace: 1}
+
+		requeue, merr := r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+		Expect(requeue).Should(BeFalse())
+
+		By("Checking the copies in all the namespaces")
+		for _, request := range requests {
+			secret := &corev1.Secret{}
+			Expect(c.Get(ctx, types.NamespacedName{Name: "secret4", Namespace: request.Namespace}, secret)).Should(Succeed())
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, types.NamespacedName{Name: "cm4", Namespace: request.Namespace}, cm)).Should(Succeed())
+		}
+
+		By("Checking only the failed target is copied again")
+		for _, request := range requests[1:] {
+			Expect(c.namespaceCreates[request.Namespace]).Should(Equal(2))
+		}
+		Expect(c.namespaceCreates[flakyNamespace]).Should(Equal(3))
+	})
+
+	It("Should report the errors of the target failing after all the retries", func() {
+		requests = requests[:5]
+		r, c := newReconciler(10, requestObjs()...)
+		r.CopyBackoff = wait.Backoff{Duration: 10 * time.Millisecond, Factor: 2, Steps: 3}
+		c.failures = map[string]int{requests[0].Namespace: 100}
+
+		_, merr := r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+		Expect(merr.Errors).ShouldNot(BeEmpty())
+		Expect(c.namespaceCreates[requests[0].Namespace]).Should(Equal(3))
+		for _, request := range requests[1:] {
+			Expect(c.namespaceCreates[request.Namespace]).Should(Equal(2))
+		}
+	})
+
+	It("Should set the owner reference flags of the copies as configured", func() {
+		requests = requests[:1]
+		enabled, disabled := true, false
+		for _, options := range []*operatorv1alpha1.CopyOwnerReference{
+			nil,
+			{Controller: &disabled},
+			{BlockOwnerDeletion: &disabled},
+			{Controller: &disabled, BlockOwnerDeletion: &enabled},
+		} {
+			bindInfo.Spec.OwnerReference = options
+			r, c := newReconciler(1, requestObjs()...)
+			_, merr := r.copyToRequests(ctx, bindInfo, requests, operandNamespace)
+			Expect(merr.Errors).Should(BeEmpty())
+
+			secret := &corev1.Secret{}
+			Expect(c.Get(ctx, types.NamespacedName{Name: "secret4", Namespace: requests[0].Namespace}, secret)).Should(Succeed())
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, types.NamespacedName{Name: "cm4", Namespace: requests[0].Namespace}, cm)).Should(Succeed())
+			for _, obj := range []client.Object{secret, cm} {
+				Expect(obj.GetOwnerReferences()).Should(HaveLen(1))
+				ref := obj.GetOwnerReferences()[0]
+				Expect(ref.Kind).Should(Equal("OperandRequest"))
+				if options.IsController() {
+					Expect(ref.Controller).ShouldNot(BeNil())
+					Expect(*ref.Controller).Should(BeTrue())
+				} else {
+					Expect(ref.Controller).Should(BeNil())
+				}
+				Expect(ref.BlockOwnerDeletion).ShouldNot(BeNil())
+				Expect(*ref.BlockOwnerDeletion).Should(Equal(options.IsBlockOwnerDeletion()))
+			}
+		}
+	})
+})
+
+var _ = Describe("Copying the private bindings", func() {
+	const (
+		operandNamespace  = "ibm-operators"
+		bindInfoNamespace = "ibm-common-services"
+		foreignNamespace  = "ibm-cloudpak"
+		registryName      = "common-service"
+		registryNamespace = "ibm-common-services"
+	)
+
+	It("Should copy the private bindings to the namespace of the OperandBindInfo only", func() {
+		ctx := context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(scheme)).Should(Succeed())
+
+		// The operand lives in another namespace than the OperandBindInfo
+		bindInfo := testutil.OperandBindInfoObj("ibm-operators-bindinfo", bindInfoNamespace, registryName, registryNamespace)
+		bindInfo.Spec.Bindings = map[string]operatorv1alpha1.SecretConfigmap{
+			"public":  {Secret: "secret1"},
+			"private": {Secret: "secret2"},
+		}
+		registry := testutil.OperandRegistryObj(registryName, registryNamespace, operandNamespace)
+		registry.Status.OperatorsStatus = map[string]operatorv1alpha1.OperatorStatus{
+			"jenkins": {
+				ReconcileRequests: []operatorv1alpha1.ReconcileRequest{
+					{Name: "ibm-cloudpak-name", Namespace: bindInfoNamespace},
+					{Name: "ibm-cloudpak-name", Namespace: foreignNamespace},
+				},
+			},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			bindInfo,
+			registry,
+			testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", bindInfoNamespace),
+			testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", foreignNamespace),
+			testutil.SecretObj("secret1", operandNamespace),
+			testutil.SecretObj("secret2", operandNamespace),
+		).Build()
+		r := &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(100),
+			},
+		}
+
+		By("Reconciling the OperandBindInfo until it is completed")
+		key := types.NamespacedName{Name: bindInfo.Name, Namespace: bindInfo.Namespace}
+		for i := 0; i < 5; i++ {
+			result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			if result == (reconcile.Result{}) {
+				break
+			}
+		}
+
+		By("Checking the private Secret reaches the OperandRequest in the namespace of the OperandBindInfo")
+		Expect(c.Get(ctx, types.NamespacedName{Name: bindInfo.Name + "-secret2", Namespace: bindInfoNamespace}, &corev1.Secret{})).Should(Succeed())
+		Expect(c.Get(ctx, types.NamespacedName{Name: "secret4", Namespace: bindInfoNamespace}, &corev1.Secret{})).Should(Succeed())
+
+		By("Checking the private Secret doesn't reach the OperandRequest in a foreign namespace")
+		err := c.Get(ctx, types.NamespacedName{Name: bindInfo.Name + "-secret2", Namespace: foreignNamespace}, &corev1.Secret{})
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+		Expect(c.Get(ctx, types.NamespacedName{Name: "secret4", Namespace: foreignNamespace}, &corev1.Secret{})).Should(Succeed())
+
+		By("Checking the withheld namespace is recorded in the status")
+		Expect(c.Get(ctx, key, bindInfo)).Should(Succeed())
+		Expect(bindInfo.Status.Phase).Should(Equal(operatorv1alpha1.BindInfoCompleted))
+		Expect(bindInfo.Status.Conditions).Should(HaveLen(1))
+		Expect(bindInfo.Status.Conditions[0].Type).Should(Equal(operatorv1alpha1.ConditionPrivateBindingsWithheld))
+		Expect(bindInfo.Status.Conditions[0].Message).Should(HaveSuffix(foreignNamespace))
+	})
+})
+
+var _ = Describe("Copying the ConfigMap of a binding", func() {
+	const (
+		operandNamespace  = "ibm-operators"
+		targetNamespace   = "ibm-cloudpak"
+		registryName      = "common-service"
+		registryNamespace = "ibm-common-services"
+	)
+
+	var (
+		ctx      context.Context
+		scheme   *runtime.Scheme
+		bindInfo *operatorv1alpha1.OperandBindInfo
+	)
+
+	newReconciler := func(objects ...client.Object) (*Reconciler, client.Client) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+		return &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(100),
+			},
+		}, c
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(scheme)).Should(Succeed())
+		bindInfo = testutil.OperandBindInfoObj("ibm-operators-bindinfo", operandNamespace, registryName, registryNamespace)
+		bindInfo.Spec.Bindings = map[string]operatorv1alpha1.SecretConfigmap{
+			"public": {Secret: "secret1", Configmap: "cm1"},
+		}
+	})
+
+	It("Should copy the ConfigMap named by the binding when the Secret has another name", func() {
+		// A ConfigMap named after the Secret must not be picked up
+		r, c := newReconciler(
+			testutil.SecretObj("secret1", operandNamespace),
+			testutil.ConfigmapObj("cm1", operandNamespace),
+			testutil.ConfigmapObj("secret1", operandNamespace),
+		)
+		requeue, merr := r.copyBindings(ctx, bindInfo, nil, nil, nil, targetNamespace, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+		Expect(requeue).Should(BeFalse())
+
+		cmCopy := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: bindInfo.Name + "-cm1", Namespace: targetNamespace}, cmCopy)).Should(Succeed())
+		Expect(cmCopy.Data).Should(HaveKeyWithValue("test", "cm1"))
+		err := c.Get(ctx, types.NamespacedName{Name: bindInfo.Name + "-secret1", Namespace: targetNamespace}, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+	})
+
+	It("Should record an event and keep copying the Secret when the ConfigMap is missing", func() {
+		r, c := newReconciler(testutil.SecretObj("secret1", operandNamespace))
+		requeue, merr := r.copyBindings(ctx, bindInfo, nil, nil, nil, targetNamespace, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+		Expect(requeue).Should(BeTrue())
+
+		Expect(c.Get(ctx, types.NamespacedName{Name: bindInfo.Name + "-secret1", Namespace: targetNamespace}, &corev1.Secret{})).Should(Succeed())
+		Expect(r.Recorder.(*record.FakeRecorder).Events).Should(Receive(ContainSubstring("No Configmap cm1 in the namespace " + operandNamespace)))
+	})
+})
+
+var _ = Describe("Pruning the copies of the removed bindings", func() {
+	const (
+		operandNamespace  = "ibm-operators"
+		targetNamespace   = "ibm-cloudpak"
+		registryName      = "common-service"
+		registryNamespace = "ibm-common-services"
+	)
+
+	var (
+		ctx      context.Context
+		scheme   *runtime.Scheme
+		bindInfo *operatorv1alpha1.OperandBindInfo
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(scheme)).Should(Succeed())
+		bindInfo = testutil.OperandBindInfoObj("ibm-operators-bindinfo", operandNamespace, registryName, registryNamespace)
+		bindInfo.Spec.Bindings = map[string]operatorv1alpha1.SecretConfigmap{
+			"public":       {Secret: "secret1", Configmap: "cm1"},
+			"public-extra": {Secret: "secret2", Configmap: "cm2"},
+		}
+	})
+
+	It("Should delete the copies of a binding once it is removed", func() {
+		// A secret labeled for the OperandBindInfo without the binding annotation isn't created by ODLM
+		userSecret := testutil.SecretObj("user-secret", targetNamespace)
+		userSecret.Labels = map[string]string{
+			bindInfo.Namespace + "." + bindInfo.Name + "/bindinfo": "true",
+			constant.OpbiTypeLabel:                                 "copy",
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			testutil.SecretObj("secret1", operandNamespace),
+			testutil.ConfigmapObj("cm1", operandNamespace),
+			testutil.SecretObj("secret2", operandNamespace),
+			testutil.ConfigmapObj("cm2", operandNamespace),
+			userSecret,
+		).Build()
+		r := &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(100),
+			},
+		}
+
+		By("Adding the bindings")
+		requeue, merr := r.copyBindings(ctx, bindInfo, nil, nil, nil, targetNamespace, operandNamespace)
+		Expect(merr.Errors).Should(BeEmpty())
+		Expect(requeue).Should(BeFalse())
+		Expect(r.pruneRemovedBindings(ctx, bindInfo)).Should(Succeed())
+		for _, name := range []string{"secret1", "secret2"} {
+			Expect(c.Get(ctx, types.NamespacedName{Name: bindInfo.Name + "-" + name, Namespace: targetNamespace}, &corev1.Secret{})).Should(Succeed())
+		}
+		for _, name := range []string{"cm1", "cm2"} {
+			Expect(c.Get(ctx, types.NamespacedName{Name: bindInfo.Name + "-" + name, Namespace: targetNamespace}, &corev1.ConfigMap{})).Should(Succeed())
+		}
+
+		By("Removing a binding")
+		delete(bindInfo.Spec.Bindings, "public-extra")
+		Expect(r.pruneRemovedBindings(ctx, bindInfo)).Should(Succeed())
+
+		By("Checking only the copies of the removed binding are deleted")
+		err := c.Get(ctx, types.NamespacedName{Name: bindInfo.Name + "-secret2", Namespace: targetNamespace}, &corev1.Secret{})
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+		err = c.Get(ctx, types.NamespacedName{Name: bindInfo.Name + "-cm2", Namespace: targetNamespace}, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+		Expect(c.Get(ctx, types.NamespacedName{Name: bindInfo.Name + "-secret1", Namespace: targetNamespace}, &corev1.Secret{})).Should(Succeed())
+		Expect(c.Get(ctx, types.NamespacedName{Name: bindInfo.Name + "-cm1", Namespace: targetNamespace}, &corev1.ConfigMap{})).Should(Succeed())
+		Expect(c.Get(ctx, types.NamespacedName{Name: "user-secret", Namespace: targetNamespace}, &corev1.Secret{})).Should(Succeed())
+		Expect(c.Get(ctx, types.NamespacedName{Name: "secret2", Namespace: operandNamespace}, &corev1.Secret{})).Should(Succeed())
+	})
+})
@@ -0,0 +1,86 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandbindinfo
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DefaultLoopThreshold is the number of the reconciles without progress, after which an OperandBindInfo is regarded as looping
+const DefaultLoopThreshold = 10
+
+// DefaultLoopWindow is the period in which the reconciles without progress are counted
+const DefaultLoopWindow = time.Minute
+
+// loopDetector counts the reconciles of each OperandBindInfo which make no progress.
+// A reconcile makes no progress when neither the generation nor the status of the OperandBindInfo is changed by it,
+// e.g. the copies to an OperandRequest namespace keep triggering the same OperandBindInfo.
+type loopDetector struct {
+	mu      sync.Mutex
+	history map[types.NamespacedName]*reconcileHistory
+}
+
+type reconcileHistory struct {
+	generation int64
+	since      time.Time
+	// stalled is the number of the reconciles without progress since the start of the window
+	stalled int
+}
+
+// looping returns true and the rest of the window if the OperandBindInfo reached the threshold of the reconciles
+// without progress in the current window
+func (d *loopDetector) looping(key types.NamespacedName, generation int64, threshold int, window time.Duration, now time.Time) (time.Duration, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	h, ok := d.history[key]
+	if !ok || h.generation != generation || h.stalled < threshold {
+		return 0, false
+	}
+	elapsed := now.Sub(h.since)
+	if elapsed >= window {
+		return 0, false
+	}
+	return window - elapsed, true
+}
+
+// record records a reconcile of the OperandBindInfo, a new window is started when the reconcile made progress,
+// the generation is changed or the current window is over
+func (d *loopDetector) record(key types.NamespacedName, generation int64, progressed bool, window time.Duration, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.history == nil {
+		d.history = make(map[types.NamespacedName]*reconcileHistory)
+	}
+	h, ok := d.history[key]
+	if !ok || progressed || h.generation != generation || now.Sub(h.since) >= window {
+		h = &reconcileHistory{generation: generation, since: now}
+		d.history[key] = h
+	}
+	if !progressed {
+		h.stalled++
+	}
+}
+
+// forget removes the history of the deleted OperandBindInfo
+func (d *loopDetector) forget(key types.NamespacedName) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.history, key)
+}
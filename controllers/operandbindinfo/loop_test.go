@@ -0,0 +1,179 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandbindinfo
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Detecting the reconcile loop of the OperandBindInfo", func() {
+	const (
+		registryName      = "common-service"
+		registryNamespace = "ibm-common-services"
+		operandNamespace  = "ibm-operators"
+		requestNamespace  = "ibm-cloudpak"
+		threshold         = 5
+	)
+
+	var (
+		ctx       context.Context
+		c         client.Client
+		r         *Reconciler
+		fakeClock *clock.FakeClock
+		recorder  *record.FakeRecorder
+		key       types.NamespacedName
+		copyKey   types.NamespacedName
+	)
+
+	reconcile := func() ctrl.Result {
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		return result
+	}
+
+	phase := func() operatorv1alpha1.BindInfoPhase {
+		bindInfo := &operatorv1alpha1.OperandBindInfo{}
+		Expect(c.Get(ctx, key, bindInfo)).Should(Succeed())
+		return bindInfo.Status.Phase
+	}
+
+	// deleteCopy simulates the OperandRequest side re-triggering the OperandBindInfo by removing its copy
+	deleteCopy := func() {
+		Expect(c.Delete(ctx, &corev1.Secret{ObjectMeta: ctrl.ObjectMeta{Name: copyKey.Name, Namespace: copyKey.Namespace}})).Should(Succeed())
+	}
+
+	copied := func() bool {
+		err := c.Get(ctx, copyKey, &corev1.Secret{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		Expect(err).NotTo(HaveOccurred())
+		return true
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(scheme)).Should(Succeed())
+
+		bindInfo := testutil.OperandBindInfoObj("looping-bindinfo", operandNamespace, registryName, registryNamespace)
+		bindInfo.Spec.Bindings = map[string]operatorv1alpha1.SecretConfigmap{
+			"public": {Secret: "secret1"},
+		}
+		registry := testutil.OperandRegistryObj(registryName, registryNamespace, operandNamespace)
+		registry.Status.OperatorsStatus = map[string]operatorv1alpha1.OperatorStatus{
+			"jenkins": {
+				ReconcileRequests: []operatorv1alpha1.ReconcileRequest{
+					{Name: "ibm-cloudpak-name", Namespace: requestNamespace},
+				},
+			},
+		}
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			bindInfo,
+			registry,
+			testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", requestNamespace),
+			testutil.SecretObj("secret1", operandNamespace),
+		).Build()
+		fakeClock = clock.NewFakeClock(time.Now())
+		recorder = record.NewFakeRecorder(100)
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Scheme:   scheme,
+				Recorder: recorder,
+			},
+			LoopThreshold: threshold,
+			LoopWindow:    time.Minute,
+			Clock:         fakeClock,
+		}
+		key = types.NamespacedName{Name: bindInfo.Name, Namespace: bindInfo.Namespace}
+		// The OperandRequest renames the public Secret to secret4
+		copyKey = types.NamespacedName{Name: "secret4", Namespace: requestNamespace}
+
+		By("Reconciling the OperandBindInfo until it is completed")
+		// Adding the finalizer, the labels and the initial status are reconciled one at a time
+		for i := 0; i < 4; i++ {
+			reconcile()
+		}
+		Expect(phase()).Should(Equal(operatorv1alpha1.BindInfoCompleted))
+		Expect(copied()).Should(BeTrue())
+	})
+
+	It("Should break the loop of the reconciles without progress", func() {
+		By("Re-triggering the OperandBindInfo without any progress")
+		for i := 0; i < threshold; i++ {
+			deleteCopy()
+			Expect(reconcile().RequeueAfter).Should(BeZero())
+			Expect(copied()).Should(BeTrue())
+			fakeClock.Step(time.Second)
+		}
+
+		By("Checking the loop is broken")
+		deleteCopy()
+		result := reconcile()
+		Expect(result.RequeueAfter).Should(BeNumerically(">", 0))
+		Expect(result.RequeueAfter).Should(BeNumerically("<=", time.Minute))
+		Expect(phase()).Should(Equal(operatorv1alpha1.BindInfoLoopDetected))
+		Expect(copied()).Should(BeFalse())
+		Expect(recorder.Events).Should(Receive(ContainSubstring("BindingLoopDetected")))
+
+		By("Checking the loop stays broken in the window")
+		reconcile()
+		Expect(copied()).Should(BeFalse())
+		Expect(recorder.Events).ShouldNot(Receive())
+
+		By("Resuming copying after the window")
+		fakeClock.Step(time.Minute)
+		reconcile()
+		Expect(copied()).Should(BeTrue())
+		Expect(phase()).Should(Equal(operatorv1alpha1.BindInfoCompleted))
+	})
+
+	It("Should not count the reconciles of a changed OperandBindInfo", func() {
+		for i := 0; i < 2*threshold; i++ {
+			bindInfo := &operatorv1alpha1.OperandBindInfo{}
+			Expect(c.Get(ctx, key, bindInfo)).Should(Succeed())
+			// The generation is managed by the API server, bump it for the fake client
+			bindInfo.Generation++
+			Expect(c.Update(ctx, bindInfo)).Should(Succeed())
+			deleteCopy()
+			reconcile()
+			Expect(copied()).Should(BeTrue())
+		}
+		Expect(phase()).Should(Equal(operatorv1alpha1.BindInfoCompleted))
+	})
+})
@@ -0,0 +1,111 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandbindinfo
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Copying to the namespaces selected by the OperandBindInfo", func() {
+	const (
+		operandNamespace  = "ibm-operators"
+		registryName      = "common-service"
+		registryNamespace = "ibm-common-services"
+	)
+
+	var (
+		ctx      context.Context
+		c        client.Client
+		r        *Reconciler
+		bindInfo *operatorv1alpha1.OperandBindInfo
+	)
+
+	namespaceObj := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(scheme)).Should(Succeed())
+
+		bindInfo = testutil.OperandBindInfoObj("ibm-operators-bindinfo", operandNamespace, registryName, registryNamespace)
+		bindInfo.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"bindinfo": "jenkins"}}
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			testutil.OperandRegistryObj(registryName, registryNamespace, operandNamespace),
+			bindInfo,
+			testutil.SecretObj("secret1", operandNamespace),
+			testutil.ConfigmapObj("cm1", operandNamespace),
+			namespaceObj("ibm-unselected", nil),
+		).Build()
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(100),
+			},
+		}
+	})
+
+	It("Should copy the public bindings to a new matching namespace", func() {
+		By("Creating a namespace matching the selector")
+		selected := namespaceObj("ibm-selected", map[string]string{"bindinfo": "jenkins"})
+		Expect(c.Create(ctx, selected)).Should(Succeed())
+
+		By("Mapping the namespace to the OperandBindInfo")
+		requests := r.getNamespaceToRequestMapper()(selected)
+		Expect(requests).Should(ConsistOf(reconcile.Request{NamespacedName: types.NamespacedName{Name: bindInfo.Name, Namespace: bindInfo.Namespace}}))
+		Expect(r.getNamespaceToRequestMapper()(namespaceObj("ibm-unselected", nil))).Should(BeEmpty())
+
+		By("Reconciling the OperandBindInfo without any OperandRequest")
+		for i := 0; i < 5; i++ {
+			result, err := r.Reconcile(ctx, requests[0])
+			Expect(err).NotTo(HaveOccurred())
+			if result == (ctrl.Result{}) {
+				break
+			}
+		}
+
+		By("Checking the copies in the selected namespace only")
+		secretCopy := &corev1.Secret{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: bindInfo.Name + "-secret1", Namespace: "ibm-selected"}, secretCopy)).Should(Succeed())
+		Expect(secretCopy.OwnerReferences).Should(BeEmpty())
+		Expect(c.Get(ctx, types.NamespacedName{Name: bindInfo.Name + "-cm1", Namespace: "ibm-selected"}, &corev1.ConfigMap{})).Should(Succeed())
+		err := c.Get(ctx, types.NamespacedName{Name: bindInfo.Name + "-secret1", Namespace: "ibm-unselected"}, &corev1.Secret{})
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+	})
+})
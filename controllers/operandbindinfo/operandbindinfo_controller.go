@@ -21,13 +21,20 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -41,6 +48,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/audit"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
 	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/util"
@@ -49,12 +57,39 @@ import (
 // Reconciler reconciles a OperandBindInfo object
 type Reconciler struct {
 	*deploy.ODLMOperator
+	// CopyConcurrency is the maximum number of namespaces the Secrets and ConfigMaps are copied to concurrently
+	CopyConcurrency int
+	// CopyBackoff is the backoff to retry copying to an OperandRequest namespace after a failure
+	CopyBackoff wait.Backoff
+	// LoopThreshold is the number of the reconciles without progress in LoopWindow, after which the OperandBindInfo
+	// is regarded as looping and stops copying until the window is over
+	LoopThreshold int
+	// LoopWindow is the period in which the reconciles without progress are counted
+	LoopWindow time.Duration
+	// Clock times the reconcile loop detection, it defaults to the real clock
+	Clock clock.Clock
+
+	loops loopDetector
+	// statusMu guards the status of the OperandBindInfo updated by the copying workers
+	statusMu sync.Mutex
+}
+
+// DefaultCopyConcurrency is the number of namespaces the Secrets and ConfigMaps are copied to concurrently by default
+const DefaultCopyConcurrency = 10
+
+// DefaultCopyBackoff is the backoff to retry copying to an OperandRequest namespace by default
+var DefaultCopyBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    3,
 }
 
 var (
 	publicPrefix, _    = regexp.Compile(`^public(.*)$`)
 	privatePrefix, _   = regexp.Compile(`^private(.*)$`)
 	protectedPrefix, _ = regexp.Compile(`^protected(.*)$`)
+	bindInfoLabel, _   = regexp.Compile(`^([^.]+)\.(.+)/bindinfo$`)
 )
 
 // Reconcile reads that state of the cluster for a OperandBindInfo object and makes changes based on the state read
@@ -63,6 +98,9 @@ var (
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reconcileErr error) {
+	// Record the OperandBindInfo as the owner of the audited mutations
+	ctx = audit.WithRequest(ctx, "OperandBindInfo", req.NamespacedName)
+
 	// Fetch the OperandBindInfo instance
 	bindInfoInstance := &operatorv1alpha1.OperandBindInfo{}
 	if err := r.Client.Get(ctx, req.NamespacedName, bindInfoInstance); err != nil {
@@ -96,6 +134,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 
 	// Remove finalizer when DeletionTimestamp none zero
 	if !bindInfoInstance.ObjectMeta.DeletionTimestamp.IsZero() {
+		r.loops.forget(req.NamespacedName)
 		if err := r.cleanupCopies(ctx, bindInfoInstance); err != nil {
 			return ctrl.Result{}, err
 		}
@@ -119,6 +158,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	// Delete the copies of the bindings removed from the OperandBindInfo
+	if err := r.pruneRemovedBindings(ctx, bindInfoInstance); err != nil {
+		klog.Errorf("failed to prune the copies of the removed bindings of the OperandBindInfo %s: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
 	// Fetch the OperandRegistry instance
 	registryKey := bindInfoInstance.GetRegistryKey()
 	registryInstance := &operatorv1alpha1.OperandRegistry{}
@@ -130,68 +175,60 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	merr := &util.MultiErr{}
-	// Get the OperandRequest namespace
-	requestNamespaces := registryInstance.Status.OperatorsStatus[bindInfoInstance.Spec.Operand].ReconcileRequests
-	if len(requestNamespaces) == 0 {
-		// There is no operand depend on the current bind info, nothing to do.
-		return ctrl.Result{}, nil
-	}
-	// Get the operand namespace
+	// Get the operand namespace, check it before the OperandRequests, a typo in the operand has no OperandRequest either
 	operandOperator := registryInstance.GetOperator(bindInfoInstance.Spec.Operand)
 	if operandOperator == nil {
 		klog.Errorf("failed to find operator %s in the OperandRegistry %s", bindInfoInstance.Spec.Operand, registryInstance.Name)
 		r.Recorder.Eventf(bindInfoInstance, corev1.EventTypeWarning, "NotFound", "NotFound operator %s in the OperandRegistry %s", bindInfoInstance.Spec.Operand, registryInstance.Name)
+		bindInfoInstance.Status.Phase = operatorv1alpha1.BindInfoOperandNotFound
 		return ctrl.Result{}, nil
 	}
+	if bindInfoInstance.Status.Phase == operatorv1alpha1.BindInfoOperandNotFound {
+		bindInfoInstance.Status.Phase = operatorv1alpha1.BindInfoInit
+	}
 	operandNamespace := operandOperator.Namespace
 
-	// If Secret or ConfigMap not found, reconcile will requeue after 1 min
-	var requeue bool
+	// Get the OperandRequest namespace
+	requestNamespaces := registryInstance.Status.OperatorsStatus[bindInfoInstance.Spec.Operand].ReconcileRequests
+	// Get the other namespaces selected by the OperandBindInfo
+	selectedNamespaces, err := r.getSelectedNamespaces(ctx, bindInfoInstance, requestNamespaces, operandNamespace)
+	if err != nil {
+		klog.Errorf("failed to get the namespaces selected by the OperandBindInfo %s: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+	bindInfoInstance.SetPrivateBindingsWithheldCondition(getWithheldNamespaces(bindInfoInstance, requestNamespaces, selectedNamespaces, operandNamespace))
+	if len(requestNamespaces) == 0 && len(selectedNamespaces) == 0 {
+		// There is no operand depend on the current bind info, nothing to do.
+		return ctrl.Result{}, nil
+	}
 
-	// Get OperandRequest instance and Copy Secret and/or ConfigMap
-	for _, bindRequest := range requestNamespaces {
-		// Get the OperandRequest of operandBindInfo
-		requestInstance := &operatorv1alpha1.OperandRequest{}
-		if err := r.Client.Get(ctx, types.NamespacedName{Name: bindRequest.Name, Namespace: bindRequest.Namespace}, requestInstance); err != nil {
-			if apierrors.IsNotFound(err) {
-				klog.Errorf("failed to find OperandRequest %s in the namespace %s: %v", bindRequest.Name, bindRequest.Namespace, err)
-				r.Recorder.Eventf(bindInfoInstance, corev1.EventTypeWarning, "NotFound", "NotFound OperandRequest %s in the namespace %s", bindRequest.Name, bindRequest.Namespace)
-			}
-			merr.Add(err)
-			continue
-		}
-		// Get binding information from OperandRequest
-		secretReq, cmReq := getBindingInfofromRequest(bindInfoInstance, requestInstance)
-		// Copy Secret and/or ConfigMap to the OperandRequest namespace
-		klog.V(3).Infof("Start to copy secret and/or configmap to the namespace %s", bindRequest.Namespace)
-		for key, binding := range bindInfoInstance.Spec.Bindings {
-			if !privatePrefix.MatchString(key) && !protectedPrefix.MatchString(key) && !publicPrefix.MatchString(key) {
-				klog.Warningf("BindInfo key %s should have one of prefix: private, protected, public", key)
-				continue
-			}
-			if operandNamespace != bindRequest.Namespace {
-				// skip the private bindInfo
-				if privatePrefix.MatchString(key) {
-					continue
-				}
-			}
-			// Copy Secret
-			requeueSec, err := r.copySecret(ctx, binding.Secret, secretReq[key], operandNamespace, bindRequest.Namespace, key, bindInfoInstance, requestInstance)
-			if err != nil {
-				merr.Add(err)
-				continue
-			}
-			requeue = requeue || requeueSec
-			// Copy ConfigMap
-			requeueCm, err := r.copyConfigmap(ctx, binding.Configmap, cmReq[key], operandNamespace, bindRequest.Namespace, key, bindInfoInstance, requestInstance)
-			if err != nil {
-				merr.Add(err)
-				continue
-			}
-			requeue = requeue || requeueCm
+	// Break the reconcile loop, e.g. the copies in a namespace keep triggering the OperandBindInfo without any progress
+	if remaining, looping := r.loops.looping(req.NamespacedName, bindInfoInstance.Generation, r.loopThreshold(), r.loopWindow(), r.clock().Now()); looping {
+		if bindInfoInstance.Status.Phase != operatorv1alpha1.BindInfoLoopDetected {
+			klog.Warningf("OperandBindInfo %s is reconciled repeatedly without progress, stop copying for %s", req.NamespacedName, remaining)
+			r.Recorder.Eventf(bindInfoInstance, corev1.EventTypeWarning, "BindingLoopDetected", "OperandBindInfo is reconciled repeatedly without progress, stop copying for %s", remaining.Round(time.Second))
 		}
+		bindInfoInstance.Status.Phase = operatorv1alpha1.BindInfoLoopDetected
+		return ctrl.Result{RequeueAfter: remaining}, nil
 	}
+	defer func() {
+		progressed := !reflect.DeepEqual(originalInstance.Status, bindInfoInstance.Status)
+		r.loops.record(req.NamespacedName, bindInfoInstance.Generation, progressed, r.loopWindow(), r.clock().Now())
+	}()
+
+	// Flag the copies edited since they were copied, they are restored by copying again
+	driftedCopies, err := r.checkCopiedBindings(ctx, bindInfoInstance)
+	if err != nil {
+		klog.Errorf("failed to check the copies of the OperandBindInfo %s: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+	bindInfoInstance.SetCopyDriftDetectedCondition(driftedCopies)
+
+	// If Secret or ConfigMap not found, reconcile will requeue after 1 min
+	requeue, merr := r.copyToRequests(ctx, bindInfoInstance, requestNamespaces, operandNamespace)
+	requeueSelected, selectedErr := r.copyToNamespaces(ctx, bindInfoInstance, selectedNamespaces, operandNamespace)
+	requeue = requeue || requeueSelected
+	merr.Errors = append(merr.Errors, selectedErr.Errors...)
 	if len(merr.Errors) != 0 {
 		r.updateBindInfoPhase(bindInfoInstance, operatorv1alpha1.BindInfoFailed, requestNamespaces)
 		klog.Errorf("failed to reconcile the OperandBindinfo %s: %v", req.NamespacedName, merr)
@@ -209,24 +246,207 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 	return ctrl.Result{}, nil
 }
 
-// Copy secret `sourceName` from source namespace `sourceNs` to target namespace `targetNs`
-func (r *Reconciler) copySecret(ctx context.Context, sourceName, targetName, sourceNs, targetNs, key string,
-	bindInfoInstance *operatorv1alpha1.OperandBindInfo, requestInstance *operatorv1alpha1.OperandRequest) (requeue bool, err error) {
-	if sourceName == "" || sourceNs == "" || targetNs == "" {
+func (r *Reconciler) loopThreshold() int {
+	if r.LoopThreshold > 0 {
+		return r.LoopThreshold
+	}
+	return DefaultLoopThreshold
+}
+
+func (r *Reconciler) loopWindow() time.Duration {
+	if r.LoopWindow > 0 {
+		return r.LoopWindow
+	}
+	return DefaultLoopWindow
+}
+
+func (r *Reconciler) clock() clock.Clock {
+	if r.Clock != nil {
+		return r.Clock
+	}
+	return clock.RealClock{}
+}
+
+// copyToRequests copies the Secrets and ConfigMaps to the namespaces of the OperandRequests.
+// The namespaces are handled concurrently by at most CopyConcurrency workers, while the
+// OperandRequests in the same namespace are handled in order, so the owner of a copy stays deterministic.
+// A failed OperandRequest is retried with its own backoff, without copying to the others again.
+func (r *Reconciler) copyToRequests(ctx context.Context, bindInfoInstance *operatorv1alpha1.OperandBindInfo, requestNamespaces []operatorv1alpha1.ReconcileRequest, operandNamespace string) (bool, *util.MultiErr) {
+	var namespaces []string
+	requestsByNamespace := make(map[string][]operatorv1alpha1.ReconcileRequest)
+	for _, bindRequest := range requestNamespaces {
+		if _, ok := requestsByNamespace[bindRequest.Namespace]; !ok {
+			namespaces = append(namespaces, bindRequest.Namespace)
+		}
+		requestsByNamespace[bindRequest.Namespace] = append(requestsByNamespace[bindRequest.Namespace], bindRequest)
+	}
+
+	concurrency := r.CopyConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultCopyConcurrency
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		requeue bool
+	)
+	merr := &util.MultiErr{}
+	workers := make(chan struct{}, concurrency)
+	for _, namespace := range namespaces {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(bindRequests []operatorv1alpha1.ReconcileRequest) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			for _, bindRequest := range bindRequests {
+				requeueReq, reqErr := r.copyToRequestWithRetries(ctx, bindInfoInstance, bindRequest, operandNamespace)
+				mu.Lock()
+				requeue = requeue || requeueReq
+				merr.Errors = append(merr.Errors, reqErr.Errors...)
+				mu.Unlock()
+			}
+		}(requestsByNamespace[namespace])
+	}
+	wg.Wait()
+	return requeue, merr
+}
+
+// copyToRequestWithRetries retries copying to the namespace of the OperandRequest with backoff,
+// only the errors of the last attempt are returned
+func (r *Reconciler) copyToRequestWithRetries(ctx context.Context, bindInfoInstance *operatorv1alpha1.OperandBindInfo, bindRequest operatorv1alpha1.ReconcileRequest, operandNamespace string) (bool, *util.MultiErr) {
+	backoff := r.CopyBackoff
+	if backoff.Steps <= 0 {
+		backoff = DefaultCopyBackoff
+	}
+	var (
+		requeue bool
+		merr    *util.MultiErr
+		attempt int
+	)
+	_ = wait.ExponentialBackoff(backoff, func() (bool, error) {
+		attempt++
+		requeue, merr = r.copyToRequest(ctx, bindInfoInstance, bindRequest, operandNamespace)
+		if len(merr.Errors) == 0 {
+			return true, nil
+		}
+		klog.V(2).Infof("Attempt %d to copy the OperandBindInfo %s/%s to the namespace %s failed: %v", attempt, bindInfoInstance.Namespace, bindInfoInstance.Name, bindRequest.Namespace, merr)
 		return false, nil
+	})
+	return requeue, merr
+}
+
+// copyToRequest copies the Secrets and ConfigMaps to the namespace of the OperandRequest
+func (r *Reconciler) copyToRequest(ctx context.Context, bindInfoInstance *operatorv1alpha1.OperandBindInfo, bindRequest operatorv1alpha1.ReconcileRequest, operandNamespace string) (bool, *util.MultiErr) {
+	merr := &util.MultiErr{}
+	// Get the OperandRequest of operandBindInfo
+	requestInstance := &operatorv1alpha1.OperandRequest{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: bindRequest.Name, Namespace: bindRequest.Namespace}, requestInstance); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.Errorf("failed to find OperandRequest %s in the namespace %s: %v", bindRequest.Name, bindRequest.Namespace, err)
+			r.Recorder.Eventf(bindInfoInstance, corev1.EventTypeWarning, "NotFound", "NotFound OperandRequest %s in the namespace %s", bindRequest.Name, bindRequest.Namespace)
+		}
+		merr.Add(err)
+		return false, merr
+	}
+	// Get binding information from OperandRequest
+	secretReq, cmReq := getBindingInfofromRequest(bindInfoInstance, requestInstance)
+	if unknownKeys := getUnknownBindingKeys(bindInfoInstance, secretReq); len(unknownKeys) != 0 {
+		klog.Warningf("The bindings %s of the operand %s in the OperandRequest %s/%s aren't defined in the OperandBindInfo %s/%s", strings.Join(unknownKeys, ", "), bindInfoInstance.Spec.Operand, requestInstance.Namespace, requestInstance.Name, bindInfoInstance.Namespace, bindInfoInstance.Name)
+		r.Recorder.Eventf(requestInstance, corev1.EventTypeWarning, "UnknownBindingKey", "The bindings %s of the operand %s aren't defined in the OperandBindInfo %s/%s", strings.Join(unknownKeys, ", "), bindInfoInstance.Spec.Operand, bindInfoInstance.Namespace, bindInfoInstance.Name)
+	}
+	// Copy Secret and/or ConfigMap to the OperandRequest namespace
+	return r.copyBindings(ctx, bindInfoInstance, requestInstance, secretReq, cmReq, bindRequest.Namespace, operandNamespace)
+}
+
+// copyToNamespaces copies the public Secrets and ConfigMaps to the namespaces selected by the OperandBindInfo,
+// there is no OperandRequest to rename or own the copies
+func (r *Reconciler) copyToNamespaces(ctx context.Context, bindInfoInstance *operatorv1alpha1.OperandBindInfo, namespaces []string, operandNamespace string) (bool, *util.MultiErr) {
+	var requeue bool
+	merr := &util.MultiErr{}
+	for _, namespace := range namespaces {
+		requeueNs, nsErr := r.copyBindings(ctx, bindInfoInstance, nil, nil, nil, namespace, operandNamespace)
+		requeue = requeue || requeueNs
+		merr.Errors = append(merr.Errors, nsErr.Errors...)
+	}
+	return requeue, merr
+}
+
+// copyBindings copies the Secrets and ConfigMaps of the bindings to the target namespace,
+// renamed as requested by the OperandRequest, which is nil for the namespaces selected by the OperandBindInfo
+func (r *Reconciler) copyBindings(ctx context.Context, bindInfoInstance *operatorv1alpha1.OperandBindInfo, requestInstance *operatorv1alpha1.OperandRequest,
+	secretReq, cmReq map[string]string, targetNamespace, operandNamespace string) (bool, *util.MultiErr) {
+	var requeue bool
+	merr := &util.MultiErr{}
+	klog.V(3).Infof("Start to copy secret and/or configmap to the namespace %s", targetNamespace)
+	for key, binding := range bindInfoInstance.Spec.Bindings {
+		if !privatePrefix.MatchString(key) && !protectedPrefix.MatchString(key) && !publicPrefix.MatchString(key) {
+			klog.Warningf("BindInfo key %s should have one of prefix: private, protected, public", key)
+			continue
+		}
+		if operandNamespace != targetNamespace {
+			// skip the private bindInfo outside of the namespace of the OperandBindInfo
+			if privatePrefix.MatchString(key) && targetNamespace != bindInfoInstance.Namespace {
+				continue
+			}
+		} else if publicPrefix.MatchString(key) && secretReq[key] == "" && cmReq[key] == "" {
+			// skip the public bindInfo in its source namespace, unless the OperandRequest renames it
+			klog.V(2).Infof("Skip copying the public binding %s of the OperandBindInfo %s/%s into its source namespace %s", key, bindInfoInstance.Namespace, bindInfoInstance.Name, operandNamespace)
+			continue
+		}
+		// Copy Secret
+		requeueSec, err := r.copySecret(ctx, binding.Secret, secretReq[key], operandNamespace, targetNamespace, key, bindInfoInstance, requestInstance)
+		if err != nil {
+			merr.Add(err)
+			continue
+		}
+		requeue = requeue || requeueSec
+		// Copy ConfigMap
+		requeueCm, err := r.copyConfigmap(ctx, binding.Configmap, cmReq[key], operandNamespace, targetNamespace, key, bindInfoInstance, requestInstance)
+		if err != nil {
+			merr.Add(err)
+			continue
+		}
+		requeue = requeue || requeueCm
+		// Grant the service accounts the access to the copies
+		secretName := copyTargetName(bindInfoInstance, binding.Secret, secretReq[key], operandNamespace, targetNamespace, key)
+		cmName := copyTargetName(bindInfoInstance, binding.Configmap, cmReq[key], operandNamespace, targetNamespace, key)
+		if err := r.grantAccess(ctx, bindInfoInstance, requestInstance, key, targetNamespace, secretName, cmName); err != nil {
+			merr.Add(err)
+		}
+	}
+	return requeue, merr
+}
+
+// copyTargetName returns the name of the copy of `sourceName` in the target namespace `targetNs`,
+// it is empty when the binding isn't copied to the target namespace
+func copyTargetName(bindInfoInstance *operatorv1alpha1.OperandBindInfo, sourceName, targetName, sourceNs, targetNs, key string) string {
+	if sourceName == "" || sourceNs == "" || targetNs == "" {
+		return ""
 	}
 
 	if sourceName == targetName && sourceNs == targetNs {
-		return false, nil
+		return ""
 	}
 
 	if targetName == "" {
-		if publicPrefix.MatchString(key) {
-			targetName = bindInfoInstance.Name + "-" + sourceName
-		} else {
-			return false, nil
+		// The private bindInfo is copied to the namespace of the OperandBindInfo when it isn't the source namespace
+		if publicPrefix.MatchString(key) || (privatePrefix.MatchString(key) && sourceNs != targetNs) {
+			return bindInfoInstance.Name + "-" + sourceName
 		}
 	}
+	return targetName
+}
+
+// Copy secret `sourceName` from source namespace `sourceNs` to target namespace `targetNs`
+func (r *Reconciler) copySecret(ctx context.Context, sourceName, targetName, sourceNs, targetNs, key string,
+	bindInfoInstance *operatorv1alpha1.OperandBindInfo, requestInstance *operatorv1alpha1.OperandRequest) (requeue bool, err error) {
+	targetName = copyTargetName(bindInfoInstance, sourceName, targetName, sourceNs, targetNs, key)
+	if targetName == "" {
+		return false, nil
+	}
 
 	secret := &corev1.Secret{}
 	if err := r.Client.Get(ctx, types.NamespacedName{Name: sourceName, Namespace: sourceNs}, secret); err != nil {
@@ -247,16 +467,17 @@ func (r *Reconciler) copySecret(ctx context.Context, sourceName, targetName, sou
 	secretLabel[constant.OpbiTypeLabel] = "copy"
 	secretCopy := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      targetName,
-			Namespace: targetNs,
-			Labels:    secretLabel,
+			Name:        targetName,
+			Namespace:   targetNs,
+			Labels:      secretLabel,
+			Annotations: copyAnnotations(bindInfoInstance, key),
 		},
 		Type:       secret.Type,
 		Data:       secret.Data,
 		StringData: secret.StringData,
 	}
-	// Set the OperandRequest as the controller of the Secret
-	if err := controllerutil.SetControllerReference(requestInstance, secretCopy, r.Scheme); err != nil {
+	// Set the OperandRequest as the owner of the Secret
+	if err := r.setCopyOwnerReference(bindInfoInstance, requestInstance, secretCopy); err != nil {
 		return false, errors.Wrapf(err, "failed to set OperandRequest %s as the owner of Secret %s", requestInstance.Name, targetName)
 	}
 	// Create the Secret in the OperandRequest namespace
@@ -266,19 +487,22 @@ func (r *Reconciler) copySecret(ctx context.Context, sourceName, targetName, sou
 			if err := r.Update(ctx, secretCopy); err != nil {
 				return false, errors.Wrapf(err, "failed to update secret %s/%s", targetNs, targetName)
 			}
+			r.recordCopiedBinding(bindInfoInstance, secretKind, targetNs, targetName, secretChecksum(secretCopy))
 			return false, nil
 		}
 		return false, errors.Wrapf(err, "failed to create secret %s/%s", targetNs, targetName)
 	}
+	r.recordCopiedBinding(bindInfoInstance, secretKind, targetNs, targetName, secretChecksum(secretCopy))
 
+	originalSecret := secret.DeepCopy()
 	ensureLabelsForSecret(secret, map[string]string{
 		constant.OpbiNsLabel:   bindInfoInstance.Namespace,
 		constant.OpbiNameLabel: bindInfoInstance.Name,
 		constant.OpbiTypeLabel: "original",
 	})
 
-	// Update the operand Secret
-	if err := r.Update(ctx, secret); err != nil {
+	// Patch the labels of the operand Secret, it may be labeled by several workers at the same time
+	if err := r.Patch(ctx, secret, client.MergeFrom(originalSecret)); err != nil {
 		klog.Errorf("failed to update Secret %s in the namespace %s: %v", secret.Name, secret.Namespace, err)
 		return false, err
 	}
@@ -287,24 +511,38 @@ func (r *Reconciler) copySecret(ctx context.Context, sourceName, targetName, sou
 	return false, nil
 }
 
+// setCopyOwnerReference sets the OperandRequest as the owner of the copy with the options of the OperandBindInfo,
+// the copies to the namespaces selected by the OperandBindInfo have no OperandRequest to own them
+func (r *Reconciler) setCopyOwnerReference(bindInfoInstance *operatorv1alpha1.OperandBindInfo, requestInstance *operatorv1alpha1.OperandRequest, object metav1.Object) error {
+	if requestInstance == nil {
+		return nil
+	}
+	options := bindInfoInstance.Spec.OwnerReference
+	if options.IsController() {
+		if err := controllerutil.SetControllerReference(requestInstance, object, r.Scheme); err != nil {
+			return err
+		}
+	} else if err := controllerutil.SetOwnerReference(requestInstance, object, r.Scheme); err != nil {
+		return err
+	}
+	blockOwnerDeletion := options.IsBlockOwnerDeletion()
+	refs := object.GetOwnerReferences()
+	for i := range refs {
+		if refs[i].UID == requestInstance.UID {
+			refs[i].BlockOwnerDeletion = &blockOwnerDeletion
+		}
+	}
+	object.SetOwnerReferences(refs)
+	return nil
+}
+
 // Copy configmap `sourceName` from namespace `sourceNs` to namespace `targetNs`
 // and rename it to `targetName`
 func (r *Reconciler) copyConfigmap(ctx context.Context, sourceName, targetName, sourceNs, targetNs, key string,
 	bindInfoInstance *operatorv1alpha1.OperandBindInfo, requestInstance *operatorv1alpha1.OperandRequest) (requeue bool, err error) {
-	if sourceName == "" || sourceNs == "" || targetNs == "" {
-		return false, nil
-	}
-
-	if sourceName == targetName && sourceNs == targetNs {
-		return false, nil
-	}
-
+	targetName = copyTargetName(bindInfoInstance, sourceName, targetName, sourceNs, targetNs, key)
 	if targetName == "" {
-		if publicPrefix.MatchString(key) {
-			targetName = bindInfoInstance.Name + "-" + sourceName
-		} else {
-			return false, nil
-		}
+		return false, nil
 	}
 
 	cm := &corev1.ConfigMap{}
@@ -326,38 +564,41 @@ func (r *Reconciler) copyConfigmap(ctx context.Context, sourceName, targetName,
 	cmLabel[constant.OpbiTypeLabel] = "copy"
 	cmCopy := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      targetName,
-			Namespace: targetNs,
-			Labels:    cmLabel,
+			Name:        targetName,
+			Namespace:   targetNs,
+			Labels:      cmLabel,
+			Annotations: copyAnnotations(bindInfoInstance, key),
 		},
 		Data:       cm.Data,
 		BinaryData: cm.BinaryData,
 	}
-	// Set the OperandRequest as the controller of the configmap
-	if err := controllerutil.SetControllerReference(requestInstance, cmCopy, r.Scheme); err != nil {
-		return false, errors.Wrapf(err, "failed to set OperandRequest %s as the owner of ConfigMap %s", requestInstance.Name, sourceName)
+	// Set the OperandRequest as the owner of the configmap
+	if err := r.setCopyOwnerReference(bindInfoInstance, requestInstance, cmCopy); err != nil {
+		return false, errors.Wrapf(err, "failed to set OperandRequest %s as the owner of ConfigMap %s", requestInstance.Name, targetName)
 	}
 	// Create the ConfigMap in the OperandRequest namespace
 	if err := r.Create(ctx, cmCopy); err != nil {
 		if apierrors.IsAlreadyExists(err) {
 			// If already exist, update the ConfigMap
 			if err := r.Update(ctx, cmCopy); err != nil {
-				return false, errors.Wrapf(err, "failed to update ConfigMap %s/%s", targetNs, sourceName)
+				return false, errors.Wrapf(err, "failed to update ConfigMap %s/%s", targetNs, targetName)
 			}
+			r.recordCopiedBinding(bindInfoInstance, configMapKind, targetNs, targetName, configMapChecksum(cmCopy))
 			return false, nil
 		}
-		return false, errors.Wrapf(err, "failed to create ConfigMap %s/%s", targetNs, sourceName)
-
+		return false, errors.Wrapf(err, "failed to create ConfigMap %s/%s", targetNs, targetName)
 	}
+	r.recordCopiedBinding(bindInfoInstance, configMapKind, targetNs, targetName, configMapChecksum(cmCopy))
 	// Set the OperandBindInfo label for the ConfigMap
+	originalCm := cm.DeepCopy()
 	ensureLabelsForConfigMap(cm, map[string]string{
 		constant.OpbiNsLabel:   bindInfoInstance.Namespace,
 		constant.OpbiNameLabel: bindInfoInstance.Name,
 		constant.OpbiTypeLabel: "original",
 	})
 
-	// Update the operand Configmap
-	if err := r.Update(ctx, cm); err != nil {
+	// Patch the labels of the operand Configmap, it may be labeled by several workers at the same time
+	if err := r.Patch(ctx, cm, client.MergeFrom(originalCm)); err != nil {
 		return false, errors.Wrapf(err, "failed to update ConfigMap %s/%s", cm.Namespace, cm.Name)
 	}
 	klog.V(2).Infof("Copy configmap %s from the namespace %s to the namespace %s", sourceName, sourceNs, targetNs)
@@ -380,12 +621,22 @@ func (r *Reconciler) cleanupCopies(ctx context.Context, bindInfoInstance *operat
 	}
 
 	for i := range secretList.Items {
+		if access, ok := secretList.Items[i].Annotations[constant.OpbiAccessAnnotation]; ok {
+			if err := r.revokeAccess(ctx, secretList.Items[i].Namespace, access); err != nil {
+				return err
+			}
+		}
 		if err := r.Delete(ctx, &secretList.Items[i]); err != nil {
 			return err
 		}
 	}
 
 	for i := range cmList.Items {
+		if access, ok := cmList.Items[i].Annotations[constant.OpbiAccessAnnotation]; ok {
+			if err := r.revokeAccess(ctx, cmList.Items[i].Namespace, access); err != nil {
+				return err
+			}
+		}
 		if err := r.Delete(ctx, &cmList.Items[i]); err != nil {
 			return err
 		}
@@ -402,6 +653,199 @@ func (r *Reconciler) cleanupCopies(ctx context.Context, bindInfoInstance *operat
 	return nil
 }
 
+// pruneRemovedBindings deletes the secrets and configmaps copied for the binding keys no longer in the OperandBindInfo.
+// Only the copies labeled for the OperandBindInfo and annotated with their binding key are pruned.
+// The access granted to the copies is revoked with them, or when the grantAccess of the binding is removed.
+func (r *Reconciler) pruneRemovedBindings(ctx context.Context, bindInfoInstance *operatorv1alpha1.OperandBindInfo) error {
+	secretList := &corev1.SecretList{}
+	cmList := &corev1.ConfigMapList{}
+
+	opts := []client.ListOption{
+		client.MatchingLabels(map[string]string{
+			bindInfoInstance.Namespace + "." + bindInfoInstance.Name + "/bindinfo": "true",
+			constant.OpbiTypeLabel: "copy",
+		}),
+	}
+	if err := r.Client.List(ctx, secretList, opts...); err != nil {
+		return errors.Wrap(err, "failed to list the copied secrets")
+	}
+	if err := r.Client.List(ctx, cmList, opts...); err != nil {
+		return errors.Wrap(err, "failed to list the copied configmaps")
+	}
+
+	var copies []client.Object
+	for i := range secretList.Items {
+		copies = append(copies, &secretList.Items[i])
+	}
+	for i := range cmList.Items {
+		copies = append(copies, &cmList.Items[i])
+	}
+
+	merr := &util.MultiErr{}
+	for _, obj := range copies {
+		key, ok := obj.GetAnnotations()[constant.OpbiBindingAnnotation]
+		if !ok {
+			continue
+		}
+		binding, exists := bindInfoInstance.Spec.Bindings[key]
+		// Revoke the access granted to the copy, when the binding or its grantAccess is removed
+		if access, granted := obj.GetAnnotations()[constant.OpbiAccessAnnotation]; granted && (!exists || binding.GrantAccess == nil) {
+			if err := r.revokeAccess(ctx, obj.GetNamespace(), access); err != nil {
+				merr.Add(err)
+			}
+		}
+		if exists {
+			continue
+		}
+		klog.V(1).Infof("Deleting the copy %s/%s of the removed binding %s of the OperandBindInfo %s/%s", obj.GetNamespace(), obj.GetName(), key, bindInfoInstance.Namespace, bindInfoInstance.Name)
+		if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			merr.Add(errors.Wrapf(err, "failed to delete the copy %s/%s", obj.GetNamespace(), obj.GetName()))
+		}
+	}
+	if len(merr.Errors) != 0 {
+		return merr
+	}
+	return nil
+}
+
+// cleanupOrphanCopies deletes the secrets and configmaps copied by the OperandBindInfos which no longer exist.
+// It runs once when the manager starts, to clean up the copies left behind while ODLM was not running.
+func (r *Reconciler) cleanupOrphanCopies(ctx context.Context) error {
+	secretList := &corev1.SecretList{}
+	cmList := &corev1.ConfigMapList{}
+
+	opts := []client.ListOption{
+		client.MatchingLabels(map[string]string{constant.OpbiTypeLabel: "copy"}),
+	}
+	if err := r.Client.List(ctx, secretList, opts...); err != nil {
+		return errors.Wrap(err, "failed to list the copied secrets")
+	}
+	if err := r.Client.List(ctx, cmList, opts...); err != nil {
+		return errors.Wrap(err, "failed to list the copied configmaps")
+	}
+
+	var copies []client.Object
+	for i := range secretList.Items {
+		copies = append(copies, &secretList.Items[i])
+	}
+	for i := range cmList.Items {
+		copies = append(copies, &cmList.Items[i])
+	}
+
+	merr := &util.MultiErr{}
+	for _, obj := range copies {
+		orphan, err := r.isOrphanCopy(ctx, obj)
+		if err != nil {
+			merr.Add(err)
+			continue
+		}
+		if !orphan {
+			continue
+		}
+		klog.V(1).Infof("Deleting the orphan copy %s/%s", obj.GetNamespace(), obj.GetName())
+		if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			merr.Add(errors.Wrapf(err, "failed to delete the orphan copy %s/%s", obj.GetNamespace(), obj.GetName()))
+		}
+	}
+	if len(merr.Errors) != 0 {
+		return merr
+	}
+	return nil
+}
+
+// isOrphanCopy checks if the object is a copy created by ODLM and all the OperandBindInfos it is copied for are gone.
+// The object must be controlled by an OperandRequest and carry the bindinfo label, it is never treated as orphan otherwise.
+func (r *Reconciler) isOrphanCopy(ctx context.Context, object client.Object) (bool, error) {
+	if object.GetLabels()[constant.OpbiTypeLabel] != "copy" {
+		return false, nil
+	}
+	owner := metav1.GetControllerOf(object)
+	if owner == nil || owner.Kind != "OperandRequest" || owner.APIVersion != operatorv1alpha1.GroupVersion.String() {
+		return false, nil
+	}
+
+	var bindInfoKeys []types.NamespacedName
+	for key, value := range object.GetLabels() {
+		if value != "true" || !bindInfoLabel.MatchString(key) {
+			continue
+		}
+		match := bindInfoLabel.FindStringSubmatch(key)
+		bindInfoKeys = append(bindInfoKeys, types.NamespacedName{Namespace: match[1], Name: match[2]})
+	}
+	if len(bindInfoKeys) == 0 {
+		return false, nil
+	}
+
+	for _, key := range bindInfoKeys {
+		// Use the API reader to avoid deleting a copy due to a stale cache
+		if err := r.Reader.Get(ctx, key, &operatorv1alpha1.OperandBindInfo{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return false, errors.Wrapf(err, "failed to get the OperandBindInfo %s", key.String())
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// getSelectedNamespaces returns the namespaces matching the namespace selector of the OperandBindInfo,
+// except the operand namespace and the namespaces of the OperandRequests, which are copied to with their OperandRequests
+func (r *Reconciler) getSelectedNamespaces(ctx context.Context, bindInfoInstance *operatorv1alpha1.OperandBindInfo, requestNamespaces []operatorv1alpha1.ReconcileRequest, operandNamespace string) ([]string, error) {
+	if bindInfoInstance.Spec.NamespaceSelector == nil {
+		return nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(bindInfoInstance.Spec.NamespaceSelector)
+	if err != nil {
+		r.Recorder.Eventf(bindInfoInstance, corev1.EventTypeWarning, "InvalidNamespaceSelector", "Invalid namespaceSelector: %v", err)
+		return nil, errors.Wrap(err, "invalid namespaceSelector")
+	}
+	namespaceList := &corev1.NamespaceList{}
+	if err := r.Client.List(ctx, namespaceList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, errors.Wrap(err, "failed to list the namespaces")
+	}
+	skipped := map[string]bool{operandNamespace: true}
+	for _, bindRequest := range requestNamespaces {
+		skipped[bindRequest.Namespace] = true
+	}
+	var namespaces []string
+	for _, namespace := range namespaceList.Items {
+		if skipped[namespace.Name] || !namespace.DeletionTimestamp.IsZero() {
+			continue
+		}
+		namespaces = append(namespaces, namespace.Name)
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// getWithheldNamespaces returns the namespaces the private bindings of the OperandBindInfo are not copied to,
+// they are only copied to the operand namespace and the namespace of the OperandBindInfo
+func getWithheldNamespaces(bindInfoInstance *operatorv1alpha1.OperandBindInfo, requestNamespaces []operatorv1alpha1.ReconcileRequest, selectedNamespaces []string, operandNamespace string) []string {
+	hasPrivate := false
+	for key := range bindInfoInstance.Spec.Bindings {
+		if privatePrefix.MatchString(key) {
+			hasPrivate = true
+			break
+		}
+	}
+	if !hasPrivate {
+		return nil
+	}
+	namespaces := append([]string{}, selectedNamespaces...)
+	for _, bindRequest := range requestNamespaces {
+		namespaces = append(namespaces, bindRequest.Namespace)
+	}
+	var withheld []string
+	for _, namespace := range unique(namespaces) {
+		if namespace != operandNamespace && namespace != bindInfoInstance.Namespace {
+			withheld = append(withheld, namespace)
+		}
+	}
+	sort.Strings(withheld)
+	return withheld
+}
+
 func getBindingInfofromRequest(bindInfoInstance *operatorv1alpha1.OperandBindInfo, requestInstance *operatorv1alpha1.OperandRequest) (map[string]string, map[string]string) {
 	secretReq, cmReq := make(map[string]string), make(map[string]string)
 	for _, req := range requestInstance.Spec.Requests {
@@ -424,6 +868,19 @@ func getBindingInfofromRequest(bindInfoInstance *operatorv1alpha1.OperandBindInf
 	return secretReq, cmReq
 }
 
+// getUnknownBindingKeys returns the sorted binding keys requested for the operand but not defined in the OperandBindInfo,
+// they are never copied
+func getUnknownBindingKeys(bindInfoInstance *operatorv1alpha1.OperandBindInfo, requestedBindings map[string]string) []string {
+	var unknownKeys []string
+	for key := range requestedBindings {
+		if _, ok := bindInfoInstance.Spec.Bindings[key]; !ok {
+			unknownKeys = append(unknownKeys, key)
+		}
+	}
+	sort.Strings(unknownKeys)
+	return unknownKeys
+}
+
 func (r *Reconciler) getOperandRegistryToRequestMapper(mgr manager.Manager) handler.MapFunc {
 	ctx := context.Background()
 
@@ -473,6 +930,31 @@ func (r *Reconciler) getOperandRequestToRequestMapper(mgr manager.Manager) handl
 	}
 }
 
+// getNamespaceToRequestMapper maps a namespace to the OperandBindInfos whose namespace selector matches it
+func (r *Reconciler) getNamespaceToRequestMapper() handler.MapFunc {
+	ctx := context.Background()
+	return func(object client.Object) []reconcile.Request {
+		bindInfoList := &operatorv1alpha1.OperandBindInfoList{}
+		if err := r.Client.List(ctx, bindInfoList); err != nil {
+			klog.Errorf("failed to list the OperandBindInfos for the namespace %s: %v", object.GetName(), err)
+			return nil
+		}
+
+		bindinfos := []reconcile.Request{}
+		for _, bindinfo := range bindInfoList.Items {
+			if bindinfo.Spec.NamespaceSelector == nil {
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsSelector(bindinfo.Spec.NamespaceSelector)
+			if err != nil || !selector.Matches(labels.Set(object.GetLabels())) {
+				continue
+			}
+			bindinfos = append(bindinfos, reconcile.Request{NamespacedName: types.NamespacedName{Name: bindinfo.Name, Namespace: bindinfo.Namespace}})
+		}
+		return bindinfos
+	}
+}
+
 func (r *Reconciler) updateBindInfoPhase(bindInfoInstance *operatorv1alpha1.OperandBindInfo, phase operatorv1alpha1.BindInfoPhase, requestNamespaces []operatorv1alpha1.ReconcileRequest) {
 	var requestNsList []string
 	for _, ns := range requestNamespaces {
@@ -563,6 +1045,32 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 		},
 	}
 
+	// Only a new namespace or a change of its labels can make it selected by an OperandBindInfo
+	namespacePredicates := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return true
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !reflect.DeepEqual(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels())
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+
+	// Clean up the orphan copies once the manager is started
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		if err := r.cleanupOrphanCopies(ctx); err != nil {
+			klog.Errorf("failed to clean up the orphan copies: %v", err)
+		}
+		return nil
+	})); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&operatorv1alpha1.OperandBindInfo{}).
 		Watches(
@@ -584,6 +1092,11 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 			&source.Kind{Type: &operatorv1alpha1.OperandRegistry{}},
 			handler.EnqueueRequestsFromMapFunc(r.getOperandRegistryToRequestMapper(mgr)),
 			builder.WithPredicates(opregPredicates),
+		).
+		Watches(
+			&source.Kind{Type: &corev1.Namespace{}},
+			handler.EnqueueRequestsFromMapFunc(r.getNamespaceToRequestMapper()),
+			builder.WithPredicates(namespacePredicates),
 		).Complete(r)
 }
 
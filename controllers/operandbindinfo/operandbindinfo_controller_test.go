@@ -23,9 +23,15 @@ import (
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
 )
 
@@ -392,3 +398,49 @@ var _ = Describe("OperandBindInfo controller", func() {
 		})
 	})
 })
+
+var _ = Describe("OperandBindInfo operand validation", func() {
+	const (
+		registryName      = "common-service"
+		registryNamespace = "ibm-common-services"
+		bindInfoNamespace = "ibm-operators"
+	)
+
+	reconcileBindInfo := func(operand string) *operatorv1alpha1.OperandBindInfo {
+		ctx := context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(scheme)).Should(Succeed())
+		bindInfo := testutil.OperandBindInfoObj("validated-bindinfo", bindInfoNamespace, registryName, registryNamespace)
+		bindInfo.Spec.Operand = operand
+		registry := testutil.OperandRegistryObj(registryName, registryNamespace, bindInfoNamespace)
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(bindInfo, registry).Build()
+		r := &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(10),
+			},
+		}
+		key := types.NamespacedName{Name: bindInfo.Name, Namespace: bindInfo.Namespace}
+		// Adding the finalizer, the labels and the initial status are reconciled one at a time
+		for i := 0; i < 4; i++ {
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		result := &operatorv1alpha1.OperandBindInfo{}
+		Expect(c.Get(ctx, key, result)).Should(Succeed())
+		return result
+	}
+
+	It("Should accept an operand existing in the OperandRegistry", func() {
+		bindInfo := reconcileBindInfo("jenkins")
+		Expect(bindInfo.Status.Phase).Should(Equal(operatorv1alpha1.BindInfoInit))
+	})
+
+	It("Should flag an operand not existing in the OperandRegistry", func() {
+		bindInfo := reconcileBindInfo("jenkin")
+		Expect(bindInfo.Status.Phase).Should(Equal(operatorv1alpha1.BindInfoOperandNotFound))
+	})
+})
@@ -0,0 +1,121 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandbindinfo
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+)
+
+// ValidatorPath is the path of the webhook validating the sources of the OperandBindInfo
+const ValidatorPath = "/validate-operator-ibm-com-v1alpha1-operandbindinfo"
+
+// +kubebuilder:webhook:path=/validate-operator-ibm-com-v1alpha1-operandbindinfo,mutating=false,failurePolicy=fail,sideEffects=None,groups=operator.ibm.com,resources=operandbindinfos,verbs=create;update,versions=v1alpha1,name=voperandbindinfo.kb.io,admissionReviewVersions={v1,v1beta1}
+
+// Validator rejects the bindings whose source secrets and configmaps don't exist in the namespace of the
+// OperandBindInfo, the OperandBindInfo created before its sources is accepted with the skip annotation
+type Validator struct {
+	Reader  client.Reader
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &Validator{}
+var _ admission.DecoderInjector = &Validator{}
+
+// Handle implements admission.Handler.
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	bindInfo := &operatorv1alpha1.OperandBindInfo{}
+	if err := v.decoder.Decode(req, bindInfo); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	// The OperandBindInfo being deleted must be able to drop its finalizer after its sources are gone
+	if bindInfo.GetAnnotations()[constant.SkipSourceValidationAnnotation] == "true" || bindInfo.GetDeletionTimestamp() != nil {
+		return admission.Allowed("")
+	}
+	// Only the changed sources are validated on update, so that the metadata is still writable once a source is gone
+	oldBindings := map[string]operatorv1alpha1.SecretConfigmap{}
+	if req.Operation == admissionv1.Update {
+		oldBindInfo := &operatorv1alpha1.OperandBindInfo{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldBindInfo); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		oldBindings = oldBindInfo.Spec.Bindings
+	}
+	namespace := bindInfo.Namespace
+	if namespace == "" {
+		namespace = req.Namespace
+	}
+	keys := make([]string, 0, len(bindInfo.Spec.Bindings))
+	for key := range bindInfo.Spec.Bindings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var allErrs field.ErrorList
+	bindingsPath := field.NewPath("spec").Child("bindings")
+	for _, key := range keys {
+		binding := bindInfo.Spec.Bindings[key]
+		oldBinding, existed := oldBindings[key]
+		if binding.Secret != "" && (!existed || oldBinding.Secret != binding.Secret) {
+			if err := v.checkSource(ctx, &corev1.Secret{}, namespace, binding.Secret); err != nil {
+				allErrs = append(allErrs, field.NotFound(bindingsPath.Key(key).Child("secret"), binding.Secret))
+			}
+		}
+		if binding.Configmap != "" && (!existed || oldBinding.Configmap != binding.Configmap) {
+			if err := v.checkSource(ctx, &corev1.ConfigMap{}, namespace, binding.Configmap); err != nil {
+				allErrs = append(allErrs, field.NotFound(bindingsPath.Key(key).Child("configmap"), binding.Configmap))
+			}
+		}
+	}
+	if len(allErrs) == 0 {
+		return admission.Allowed("")
+	}
+	err := apierrors.NewInvalid(operatorv1alpha1.GroupVersion.WithKind("OperandBindInfo").GroupKind(), bindInfo.Name, allErrs)
+	return admission.Response{AdmissionResponse: admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result:  &err.ErrStatus,
+	}}
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *Validator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+	return nil
+}
+
+// checkSource returns a NotFound error when the source doesn't exist, the sources failed to be read are accepted
+func (v *Validator) checkSource(ctx context.Context, obj client.Object, namespace, name string) error {
+	err := v.Reader.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, obj)
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Warningf("failed to get the source %T %s/%s of the OperandBindInfo: %v", obj, namespace, name, err)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandbindinfo
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("OperandBindInfo source webhook", func() {
+	const operandNamespace = "ibm-operands"
+
+	var (
+		ctx       context.Context
+		validator *Validator
+	)
+
+	admissionRequest := func(bindInfo *operatorv1alpha1.OperandBindInfo) admission.Request {
+		raw, err := json.Marshal(bindInfo)
+		Expect(err).NotTo(HaveOccurred())
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			Namespace: operandNamespace,
+			Object:    runtime.RawExtension{Raw: raw},
+		}}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(scheme)).Should(Succeed())
+		// Only the sources of the public binding exist
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret1", Namespace: operandNamespace}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm1", Namespace: operandNamespace}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret2", Namespace: "ibm-other"}},
+		).Build()
+		decoder, err := admission.NewDecoder(scheme)
+		Expect(err).NotTo(HaveOccurred())
+		validator = &Validator{Reader: c}
+		Expect(validator.InjectDecoder(decoder)).Should(Succeed())
+	})
+
+	It("Should accept the bindings whose sources exist", func() {
+		bindInfo := testutil.OperandBindInfoObj("jenkins-public-bindinfo", operandNamespace, "common-service", "ibm-common-services")
+		bindInfo.Spec.Bindings = map[string]operatorv1alpha1.SecretConfigmap{
+			"public": {Secret: "secret1", Configmap: "cm1"},
+		}
+		resp := validator.Handle(ctx, admissionRequest(bindInfo))
+		Expect(resp.Allowed).Should(BeTrue())
+	})
+
+	It("Should reject the missing sources with their field paths", func() {
+		bindInfo := testutil.OperandBindInfoObj("jenkins-public-bindinfo", operandNamespace, "common-service", "ibm-common-services")
+		resp := validator.Handle(ctx, admissionRequest(bindInfo))
+		Expect(resp.Allowed).Should(BeFalse())
+		var fields []string
+		for _, cause := range resp.Result.Details.Causes {
+			fields = append(fields, cause.Field)
+		}
+		// secret2 exists in another namespace only
+		Expect(fields).Should(ConsistOf(
+			"spec.bindings[private].secret",
+			"spec.bindings[private].configmap",
+			"spec.bindings[protected].secret",
+			"spec.bindings[protected].configmap",
+		))
+		Expect(resp.Result.Message).Should(ContainSubstring(`spec.bindings[private].secret: Not found: "secret2"`))
+	})
+
+	updateRequest := func(oldBindInfo, bindInfo *operatorv1alpha1.OperandBindInfo) admission.Request {
+		req := admissionRequest(bindInfo)
+		raw, err := json.Marshal(oldBindInfo)
+		Expect(err).NotTo(HaveOccurred())
+		req.Operation = admissionv1.Update
+		req.OldObject = runtime.RawExtension{Raw: raw}
+		return req
+	}
+
+	It("Should only validate the changed sources on update", func() {
+		oldBindInfo := testutil.OperandBindInfoObj("jenkins-public-bindinfo", operandNamespace, "common-service", "ibm-common-services")
+
+		By("Accepting the finalizer added once the sources are gone")
+		bindInfo := oldBindInfo.DeepCopy()
+		Expect(bindInfo.EnsureFinalizer()).Should(BeTrue())
+		Expect(validator.Handle(ctx, updateRequest(oldBindInfo, bindInfo)).Allowed).Should(BeTrue())
+
+		By("Rejecting the changed source missing")
+		bindInfo.Spec.Bindings["private"] = operatorv1alpha1.SecretConfigmap{Secret: "secret3", Configmap: "cm2"}
+		resp := validator.Handle(ctx, updateRequest(oldBindInfo, bindInfo))
+		Expect(resp.Allowed).Should(BeFalse())
+		var fields []string
+		for _, cause := range resp.Result.Details.Causes {
+			fields = append(fields, cause.Field)
+		}
+		Expect(fields).Should(ConsistOf("spec.bindings[private].secret"))
+	})
+
+	It("Should accept the OperandBindInfo being deleted with missing sources", func() {
+		oldBindInfo := testutil.OperandBindInfoObj("jenkins-public-bindinfo", operandNamespace, "common-service", "ibm-common-services")
+		oldBindInfo.EnsureFinalizer()
+		now := metav1.Now()
+		oldBindInfo.DeletionTimestamp = &now
+		bindInfo := oldBindInfo.DeepCopy()
+		Expect(bindInfo.RemoveFinalizer()).Should(BeTrue())
+		bindInfo.Spec.Bindings["private"] = operatorv1alpha1.SecretConfigmap{Secret: "secret3"}
+		Expect(validator.Handle(ctx, updateRequest(oldBindInfo, bindInfo)).Allowed).Should(BeTrue())
+	})
+
+	It("Should accept the missing sources with the skip annotation", func() {
+		bindInfo := testutil.OperandBindInfoObj("jenkins-public-bindinfo", operandNamespace, "common-service", "ibm-common-services")
+		bindInfo.Annotations = map[string]string{constant.SkipSourceValidationAnnotation: "true"}
+		resp := validator.Handle(ctx, admissionRequest(bindInfo))
+		Expect(resp.Allowed).Should(BeTrue())
+	})
+})
@@ -21,13 +21,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/klog"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -39,25 +44,52 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/audit"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/metrics"
 	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/startup"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/util"
 )
 
 // Reconciler reconciles a OperandConfig object
 type Reconciler struct {
 	*deploy.ODLMOperator
+	// RefreshEvents are the OperandConfigs to be reconciled immediately
+	RefreshEvents <-chan event.GenericEvent
+	// WaitBackoff is the backoff to requeue the OperandConfigs waiting for their services,
+	// they are requeued after DefaultRequeueDuration every time without it
+	WaitBackoff *flowcontrol.Backoff
+	// StartupGate holds the reconcile until the OperandRegistries existing at startup are reconciled
+	StartupGate *startup.Gate
+	// PhaseMetrics exports the phases of the operands in the OperandConfigs, nil means no metrics
+	PhaseMetrics *metrics.PhaseCollector
 }
 
+// DefaultMaxRequeueDuration is the maximum delay to requeue the OperandConfigs waiting for their services by default
+const DefaultMaxRequeueDuration = 5 * time.Minute
+
 // Reconcile reads that state of the cluster for a OperandConfig object and makes changes based on the state read
 // and what is in the OperandConfig.Spec
 // Note:
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reconcileErr error) {
+	// Record the OperandConfig as the owner of the audited mutations
+	ctx = audit.WithRequest(ctx, "OperandConfig", req.NamespacedName)
+
+	// The status is computed from the OperandRegistry, wait for the OperandRegistries to be reconciled at startup
+	if err := r.StartupGate.Wait(ctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Fetch the OperandConfig instance
 	instance := &operatorv1alpha1.OperandConfig{}
 	if err := r.Client.Get(ctx, req.NamespacedName, instance); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.resetWaitDelay(req.String())
+			r.PhaseMetrics.DeleteConfig(req.NamespacedName)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
@@ -67,6 +99,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 
 	// Always attempt to patch the status after each reconciliation.
 	defer func() {
+		r.PhaseMetrics.SetConfig(instance)
 		if reflect.DeepEqual(originalInstance.Status, instance.Status) {
 			return
 		}
@@ -75,6 +108,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 		}
 	}()
 
+	// Duplicate services are shadowed by the first one
+	if duplicates := instance.GetDuplicateServices(); len(duplicates) != 0 {
+		klog.Warningf("Duplicate services %s in the OperandConfig %s, only the first one is used", strings.Join(duplicates, ", "), req.NamespacedName.String())
+		r.Recorder.Eventf(instance, corev1.EventTypeWarning, "DuplicateService", "Duplicate services %s, only the first one is used", strings.Join(duplicates, ", "))
+	}
+
+	// The spec values which are not JSON objects can't be merged into the custom resources
+	for _, err := range instance.GetInvalidSpecValues() {
+		klog.Warningf("Invalid OperandConfig %s: %v", req.NamespacedName.String(), err)
+		r.Recorder.Event(instance, corev1.EventTypeWarning, "InvalidSpecValue", err.Error())
+	}
+
 	// Update status of OperandConfig by checking CRs
 	if err := r.updateStatus(ctx, instance); err != nil {
 		klog.Errorf("failed to update the status for OperandConfig %s : %v", req.NamespacedName.String(), err)
@@ -84,14 +129,53 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 	// Check if all the services are deployed
 	if instance.Status.Phase != operatorv1alpha1.ServiceInit &&
 		instance.Status.Phase != operatorv1alpha1.ServiceRunning {
-		klog.V(2).Info("Waiting for all the services being deployed ...")
-		return ctrl.Result{RequeueAfter: constant.DefaultRequeueDuration}, nil
+		delay := r.waitDelay(req.String())
+		klog.V(2).Infof("Waiting for all the services being deployed, requeue after %v ...", delay)
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+	if instance.Status.Phase == operatorv1alpha1.ServiceRunning {
+		r.resetWaitDelay(req.String())
 	}
 
 	klog.V(2).Infof("Finished reconciling OperandConfig: %s", req.NamespacedName)
 	return ctrl.Result{}, nil
 }
 
+// waitDelay returns the delay to requeue the OperandConfig waiting for its services,
+// it doubles on every wait in a row up to the maximum of WaitBackoff
+func (r *Reconciler) waitDelay(key string) time.Duration {
+	if r.WaitBackoff == nil {
+		return constant.DefaultRequeueDuration
+	}
+	r.WaitBackoff.Next(key, r.WaitBackoff.Clock.Now())
+	return r.WaitBackoff.Get(key)
+}
+
+// resetWaitDelay starts the backoff of the OperandConfig over once its services are running
+func (r *Reconciler) resetWaitDelay(key string) {
+	if r.WaitBackoff != nil {
+		r.WaitBackoff.Reset(key)
+	}
+}
+
+// getRegistryKey gets the key of the OperandRegistry of the OperandConfig, the OperandRegistry is in the same namespace
+// unless an OperandRequest references the OperandConfig from another namespace
+func (r *Reconciler) getRegistryKey(ctx context.Context, instance *operatorv1alpha1.OperandConfig) (types.NamespacedName, error) {
+	configKey := types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}
+	requestList, err := r.ListOperandRequestsByConfig(ctx, configKey)
+	if err != nil {
+		return configKey, errors.Wrapf(err, "failed to list the OperandRequests of the OperandConfig %s", configKey.String())
+	}
+	for _, requestInstance := range requestList {
+		for _, req := range requestInstance.Spec.Requests {
+			if requestInstance.GetConfigKey(req) == configKey {
+				return requestInstance.GetRegistryKey(req), nil
+			}
+		}
+	}
+	return configKey, nil
+}
+
 func (r *Reconciler) updateStatus(ctx context.Context, instance *operatorv1alpha1.OperandConfig) error {
 	// Create an empty ServiceStatus map
 	klog.V(3).Info("Initializing OperandConfig status")
@@ -103,7 +187,11 @@ func (r *Reconciler) updateStatus(ctx context.Context, instance *operatorv1alpha
 
 	instance.Status.ServiceStatus = make(map[string]operatorv1alpha1.CrStatus)
 
-	registryInstance, err := r.GetOperandRegistry(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace})
+	registryKey, err := r.getRegistryKey(ctx, instance)
+	if err != nil {
+		return err
+	}
+	registryInstance, err := r.GetOperandRegistry(ctx, registryKey)
 	if err != nil {
 		return err
 	}
@@ -166,6 +254,17 @@ func (r *Reconciler) updateStatus(ctx context.Context, instance *operatorv1alpha
 			klog.Warningf("Notfound alm-examples in the ClusterServiceVersion %s/%s", csv.Namespace, csv.Name)
 			continue
 		}
+		// The invalid alm-examples only fail their own service
+		if err := util.ValidateALMExamples(almExamples); err != nil {
+			klog.Errorf("Invalid alm-examples in the ClusterServiceVersion %s/%s: %v", csv.Namespace, csv.Name, err)
+			r.Recorder.Eventf(instance, corev1.EventTypeWarning, "InvalidALMExamples", "Invalid alm-examples in the ClusterServiceVersion %s/%s of the service %s: %v", csv.Namespace, csv.Name, op.Name, err)
+			status := instance.Status.ServiceStatus[op.Name]
+			status.Phase = operatorv1alpha1.ServiceFailed
+			status.Message = fmt.Sprintf("invalid alm-examples in the ClusterServiceVersion %s/%s: %v", csv.Namespace, csv.Name, err)
+			instance.Status.ServiceStatus[op.Name] = status
+			continue
+		}
+
 		// Create a slice for crTemplates
 		var crTemplates []interface{}
 
@@ -185,27 +284,31 @@ func (r *Reconciler) updateStatus(ctx context.Context, instance *operatorv1alpha
 			unstruct.Object = crTemplate.(map[string]interface{})
 
 			kind := unstruct.Object["kind"].(string)
-
-			existinConfig := false
-			for crName := range service.Spec {
-				// Compare the name of OperandConfig and CRD name
-				if strings.EqualFold(kind, crName) {
-					existinConfig = true
+			gvk := unstruct.GroupVersionKind()
+			crNamespace := service.GetCRNamespace(&op, r.DefaultTargetNamespace)
+
+			// Each named instance of the kind is tracked separately
+			for crInstance := range service.GetCRInstances(gvk) {
+				instanceKey := kind + operatorv1alpha1.CRInstanceSeparator + crInstance
+				if _, tracked := instance.Status.ServiceStatus[op.Name].CrStatus[instanceKey]; tracked {
+					continue
 				}
+				r.setCRStatus(ctx, instance.Status.ServiceStatus[op.Name].CrStatus, instanceKey, gvk, crInstance, crNamespace)
 			}
 
-			if !existinConfig {
+			// Compare the key of OperandConfig and the GroupVersionKind of the CR
+			if _, _, existinConfig := service.GetCRSpec(gvk); !existinConfig {
 				continue
 			}
 
 			name := unstruct.GetName()
-			if name == "" {
+			if name == "" || !service.ManagesCR(gvk, name) {
 				continue
 			}
 
 			getError := r.Client.Get(ctx, types.NamespacedName{
 				Name:      name,
-				Namespace: op.Namespace,
+				Namespace: crNamespace,
 			}, &unstruct)
 
 			if getError != nil && !apierrors.IsNotFound(getError) {
@@ -218,6 +321,8 @@ func (r *Reconciler) updateStatus(ctx context.Context, instance *operatorv1alpha
 		if len(merr.Errors) != 0 {
 			return merr
 		}
+
+		r.checkUnusedConfigKeys(instance, service, crTemplates)
 	}
 
 	klog.V(2).Info("Updating OperandConfig status")
@@ -226,6 +331,57 @@ func (r *Reconciler) updateStatus(ctx context.Context, instance *operatorv1alpha
 	return nil
 }
 
+// setCRStatus sets the status of the custom resource of the GroupVersionKind with the name in the namespace,
+// a custom resource not created yet has no status
+func (r *Reconciler) setCRStatus(ctx context.Context, crStatus map[string]operatorv1alpha1.ServicePhase, key string, gvk schema.GroupVersionKind, name, namespace string) {
+	cr := &unstructured.Unstructured{}
+	cr.SetGroupVersionKind(gvk)
+	err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, cr)
+	if apierrors.IsNotFound(err) {
+		return
+	}
+	if err != nil {
+		crStatus[key] = operatorv1alpha1.ServiceFailed
+		return
+	}
+	crStatus[key] = operatorv1alpha1.ServiceRunning
+}
+
+// checkUnusedConfigKeys warns about the config keys of the service matching no kind in the alm-examples,
+// since these configurations are never applied
+func (r *Reconciler) checkUnusedConfigKeys(instance *operatorv1alpha1.OperandConfig, service *operatorv1alpha1.ConfigService, crTemplates []interface{}) {
+	unusedKeys := getUnusedConfigKeys(service, crTemplates)
+	if len(unusedKeys) == 0 {
+		return
+	}
+	klog.Warningf("Config keys %s of the service %s in the OperandConfig %s/%s match no kind in the alm-examples", strings.Join(unusedKeys, ", "), service.Name, instance.Namespace, instance.Name)
+	r.Recorder.Eventf(instance, corev1.EventTypeWarning, "ConfigKeyUnused", "Config keys %s of the service %s match no kind in the alm-examples", strings.Join(unusedKeys, ", "), service.Name)
+}
+
+func getUnusedConfigKeys(service *operatorv1alpha1.ConfigService, crTemplates []interface{}) []string {
+	var unusedKeys []string
+	for crName := range service.Spec {
+		kindKey, _ := operatorv1alpha1.ParseCRSpecKey(crName)
+		used := false
+		for _, crTemplate := range crTemplates {
+			template, ok := crTemplate.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			apiVersion, _ := template["apiVersion"].(string)
+			if kind, ok := template["kind"].(string); ok && operatorv1alpha1.CRSpecKeyMatches(kindKey, schema.FromAPIVersionAndKind(apiVersion, kind)) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			unusedKeys = append(unusedKeys, crName)
+		}
+	}
+	sort.Strings(unusedKeys)
+	return unusedKeys
+}
+
 func checkRegistryStatus(opName string, registryInstance *operatorv1alpha1.OperandRegistry) bool {
 	status := registryInstance.Status.OperatorsStatus
 	for opRegistryName := range status {
@@ -254,8 +410,8 @@ func (r *Reconciler) getRequestToConfigMapper(ctx context.Context) handler.MapFu
 
 		// If the OperandRequest exist, reconcile OperandConfigs specific in the OperandRequest instance.
 		for _, request := range opreqInstance.Spec.Requests {
-			registryKey := opreqInstance.GetRegistryKey(request)
-			req := reconcile.Request{NamespacedName: registryKey}
+			configKey := opreqInstance.GetConfigKey(request)
+			req := reconcile.Request{NamespacedName: configKey}
 			requests = append(requests, req)
 		}
 		return requests
@@ -265,7 +421,7 @@ func (r *Reconciler) getRequestToConfigMapper(ctx context.Context) handler.MapFu
 // SetupWithManager adds OperandConfig controller to the manager.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	ctx := context.Background()
-	return ctrl.NewControllerManagedBy(mgr).
+	ctrlBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&operatorv1alpha1.OperandConfig{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Watches(&source.Kind{Type: &operatorv1alpha1.OperandRequest{}}, handler.EnqueueRequestsFromMapFunc(r.getRequestToConfigMapper(ctx)), builder.WithPredicates(predicate.Funcs{
 			CreateFunc: func(e event.CreateEvent) bool {
@@ -280,5 +436,9 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 				newObject := e.ObjectNew.(*operatorv1alpha1.OperandRequest)
 				return !reflect.DeepEqual(oldObject.Status, newObject.Status)
 			},
-		})).Complete(r)
+		}))
+	if r.RefreshEvents != nil {
+		ctrlBuilder = ctrlBuilder.Watches(&source.Channel{Source: r.RefreshEvents}, &handler.EnqueueRequestForObject{})
+	}
+	return ctrlBuilder.Complete(r)
 }
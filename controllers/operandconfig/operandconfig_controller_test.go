@@ -18,13 +18,24 @@ package operandconfig
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
 	testutil "github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
 )
 
@@ -171,3 +182,158 @@ var _ = Describe("OperandConfig controller", func() {
 		})
 	})
 })
+
+var _ = Describe("OperandConfig status", func() {
+	Context("Checking the config keys against the alm-examples", func() {
+		It("Should warn about the config keys matching no example custom resource", func() {
+			recorder := record.NewFakeRecorder(10)
+			r := &Reconciler{ODLMOperator: &deploy.ODLMOperator{Recorder: recorder}}
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(`{"size": 1}`)},
+					"etcdBackup":  {Raw: []byte(`{}`)},
+				},
+			}
+			var crTemplates []interface{}
+			Expect(json.Unmarshal([]byte(testutil.EtcdExample), &crTemplates)).Should(Succeed())
+
+			Expect(getUnusedConfigKeys(service, crTemplates)).Should(Equal([]string{"etcdBackup"}))
+			config := testutil.OperandConfigObj("common-service", "ibm-common-services")
+			r.checkUnusedConfigKeys(config, service, crTemplates)
+			Expect(recorder.Events).Should(Receive(And(ContainSubstring("ConfigKeyUnused"), ContainSubstring("etcdBackup"))))
+
+			delete(service.Spec, "etcdBackup")
+			Expect(getUnusedConfigKeys(service, crTemplates)).Should(BeEmpty())
+			r.checkUnusedConfigKeys(config, service, crTemplates)
+			Expect(recorder.Events).ShouldNot(Receive())
+
+			By("Matching the named instances by their kind")
+			service.Spec["etcdCluster:backup"] = runtime.RawExtension{Raw: []byte(`{}`)}
+			service.Spec["etcdBackup:daily"] = runtime.RawExtension{Raw: []byte(`{}`)}
+			Expect(getUnusedConfigKeys(service, crTemplates)).Should(Equal([]string{"etcdBackup:daily"}))
+		})
+	})
+
+	Context("Checking the services with invalid alm-examples", func() {
+		It("Should fail the service without blocking the other services", func() {
+			const name, namespace, operatorNamespace = "common-service", "ibm-common-services", "ibm-operators"
+			ctx := context.Background()
+			s := runtime.NewScheme()
+			Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+
+			registry := testutil.OperandRegistryObj(name, namespace, operatorNamespace)
+			registry.Status.OperatorsStatus = map[string]operatorv1alpha1.OperatorStatus{
+				"etcd":    {Phase: operatorv1alpha1.OperatorRunning},
+				"jenkins": {Phase: operatorv1alpha1.OperatorRunning},
+			}
+			config := testutil.OperandConfigObj(name, namespace)
+			objs := []client.Object{registry, config}
+			for operand, almExamples := range map[string]string{"etcd": testutil.EtcdExample, "jenkins": `[{"apiVersion": "jenkins.io/v1alpha2",`} {
+				sub := testutil.Subscription(operand, operatorNamespace)
+				sub.Spec.Package = registry.GetOperator(operand).PackageName
+				sub.Status = testutil.SubscriptionStatus(operand, operatorNamespace, "0.0.1")
+				objs = append(objs, sub, testutil.ClusterServiceVersion(sub.Status.CurrentCSV, operatorNamespace, almExamples))
+			}
+			cr := &unstructured.Unstructured{}
+			cr.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+			cr.SetKind("EtcdCluster")
+			cr.SetName("example")
+			cr.SetNamespace(operatorNamespace)
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(append(objs, cr)...).Build()
+			recorder := record.NewFakeRecorder(10)
+			r := &Reconciler{ODLMOperator: &deploy.ODLMOperator{Client: c, Reader: c, Recorder: recorder}}
+
+			Expect(r.updateStatus(ctx, config)).Should(Succeed())
+			Expect(config.Status.ServiceStatus["etcd"].CrStatus).Should(Equal(map[string]operatorv1alpha1.ServicePhase{
+				"EtcdCluster": operatorv1alpha1.ServiceRunning,
+			}))
+			Expect(config.Status.ServiceStatus["etcd"].Phase).Should(BeEmpty())
+			Expect(config.Status.ServiceStatus["jenkins"].Phase).Should(Equal(operatorv1alpha1.ServiceFailed))
+			Expect(config.Status.ServiceStatus["jenkins"].Message).Should(ContainSubstring("the alm-examples are not a JSON array"))
+			Expect(config.Status.Phase).Should(Equal(operatorv1alpha1.ServiceFailed))
+			Expect(recorder.Events).Should(Receive(And(ContainSubstring("InvalidALMExamples"), ContainSubstring("jenkins"))))
+		})
+	})
+
+	Context("Tracking the named instances of the custom resources", func() {
+		It("Should set the status of each instance separately", func() {
+			const name, namespace, operatorNamespace = "common-service", "ibm-common-services", "ibm-operators"
+			ctx := context.Background()
+			s := runtime.NewScheme()
+			Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+
+			registry := testutil.OperandRegistryObj(name, namespace, operatorNamespace)
+			registry.Status.OperatorsStatus = map[string]operatorv1alpha1.OperatorStatus{"etcd": {Phase: operatorv1alpha1.OperatorRunning}}
+			config := testutil.OperandConfigObj(name, namespace)
+			config.Spec.Services[0].Spec["etcdCluster:backup"] = runtime.RawExtension{Raw: []byte(`{"size": 1}`)}
+			config.Spec.Services[0].Spec["etcdCluster:audit"] = runtime.RawExtension{Raw: []byte(`{"size": 1}`)}
+			sub := testutil.Subscription("etcd", operatorNamespace)
+			sub.Status = testutil.SubscriptionStatus("etcd", operatorNamespace, "0.0.1")
+			csv := testutil.ClusterServiceVersion(sub.Status.CurrentCSV, operatorNamespace, testutil.EtcdExample)
+			// The audit instance isn't created yet
+			var crs []client.Object
+			for _, crName := range []string{"example", "backup"} {
+				cr := &unstructured.Unstructured{}
+				cr.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+				cr.SetKind("EtcdCluster")
+				cr.SetName(crName)
+				cr.SetNamespace(operatorNamespace)
+				crs = append(crs, cr)
+			}
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(append(crs, registry, config, sub, csv)...).Build()
+			r := &Reconciler{ODLMOperator: &deploy.ODLMOperator{Client: c, Reader: c, Recorder: record.NewFakeRecorder(10)}}
+
+			Expect(r.updateStatus(ctx, config)).Should(Succeed())
+			Expect(config.Status.ServiceStatus["etcd"].CrStatus).Should(Equal(map[string]operatorv1alpha1.ServicePhase{
+				"EtcdCluster":        operatorv1alpha1.ServiceRunning,
+				"EtcdCluster:backup": operatorv1alpha1.ServiceRunning,
+			}))
+		})
+	})
+})
+
+var _ = Describe("Requeueing the OperandConfig waiting for its services", func() {
+	const key = "ibm-common-services/common-service"
+
+	var (
+		fakeClock *clock.FakeClock
+		r         *Reconciler
+	)
+
+	BeforeEach(func() {
+		fakeClock = clock.NewFakeClock(time.Now())
+		r = &Reconciler{WaitBackoff: flowcontrol.NewFakeBackOff(20*time.Second, 2*time.Minute, fakeClock)}
+	})
+
+	It("Should double the delay on every wait up to the maximum", func() {
+		var delays []time.Duration
+		for i := 0; i < 5; i++ {
+			delays = append(delays, r.waitDelay(key))
+			fakeClock.Step(delays[i])
+		}
+		Expect(delays).Should(Equal([]time.Duration{20 * time.Second, 40 * time.Second, 80 * time.Second, 2 * time.Minute, 2 * time.Minute}))
+	})
+
+	It("Should start over once the services are running", func() {
+		Expect(r.waitDelay(key)).Should(Equal(20 * time.Second))
+		Expect(r.waitDelay(key)).Should(Equal(40 * time.Second))
+		r.resetWaitDelay(key)
+		Expect(r.waitDelay(key)).Should(Equal(20 * time.Second))
+	})
+
+	It("Should start over after waiting no more for twice the maximum", func() {
+		Expect(r.waitDelay(key)).Should(Equal(20 * time.Second))
+		Expect(r.waitDelay(key)).Should(Equal(40 * time.Second))
+		fakeClock.Step(5 * time.Minute)
+		Expect(r.waitDelay(key)).Should(Equal(20 * time.Second))
+	})
+
+	It("Should requeue after the default duration without backoff", func() {
+		r.WaitBackoff = nil
+		Expect(r.waitDelay(key)).Should(Equal(constant.DefaultRequeueDuration))
+		Expect(r.waitDelay(key)).Should(Equal(constant.DefaultRequeueDuration))
+	})
+})
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog"
@@ -34,12 +35,18 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/audit"
 	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/startup"
 )
 
 // Reconciler reconciles a OperandRegistry object
 type Reconciler struct {
 	*deploy.ODLMOperator
+	// RefreshEvents are the OperandRegistries to be reconciled immediately
+	RefreshEvents <-chan event.GenericEvent
+	// StartupGate is opened once the OperandRegistries existing at startup are reconciled
+	StartupGate *startup.Gate
 }
 
 // Reconcile reads that state of the cluster for a OperandRegistry object and makes changes based on the state read
@@ -48,9 +55,15 @@ type Reconciler struct {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reconcileErr error) {
+	// Record the OperandRegistry as the owner of the audited mutations
+	ctx = audit.WithRequest(ctx, "OperandRegistry", req.NamespacedName)
+
 	// Fetch the OperandRegistry instance
 	instance := &operatorv1alpha1.OperandRegistry{}
 	if err := r.Client.Get(ctx, req.NamespacedName, instance); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.StartupGate.MarkReconciled(req.NamespacedName)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
@@ -74,6 +87,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 		return ctrl.Result{}, err
 	}
 
+	// Delete the operators removed from the spec and no longer requested
+	if err := r.deleteRemovedOperators(ctx, originalInstance, instance); err != nil {
+		klog.Errorf("failed to delete the removed operators for OperandRegistry %s : %v", req.NamespacedName.String(), err)
+		return ctrl.Result{}, err
+	}
+
 	// Summarize instance status
 	if instance.Status.OperatorsStatus == nil || len(instance.Status.OperatorsStatus) == 0 {
 		instance.UpdateRegistryPhase(operatorv1alpha1.RegistryReady)
@@ -81,6 +100,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 		instance.UpdateRegistryPhase(operatorv1alpha1.RegistryRunning)
 	}
 
+	r.StartupGate.MarkReconciled(req.NamespacedName)
+
 	klog.V(2).Infof("Finished reconciling OperandRegistry: %s", req.NamespacedName)
 	return ctrl.Result{}, nil
 }
@@ -114,7 +135,7 @@ func (r *Reconciler) updateStatus(ctx context.Context, instance *operatorv1alpha
 
 // SetupWithManager adds OperandRegistry controller to the manager.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	ctrlBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&operatorv1alpha1.OperandRegistry{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Watches(&source.Kind{Type: &operatorv1alpha1.OperandRequest{}}, handler.EnqueueRequestsFromMapFunc(func(a client.Object) []reconcile.Request {
 			or := a.(*operatorv1alpha1.OperandRequest)
@@ -129,5 +150,9 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 				// Evaluates to false if the object has been confirmed deleted.
 				return !e.DeleteStateUnknown
 			},
-		})).Complete(r)
+		}))
+	if r.RefreshEvents != nil {
+		ctrlBuilder = ctrlBuilder.Watches(&source.Channel{Source: r.RefreshEvents}, &handler.EnqueueRequestForObject{})
+	}
+	return ctrlBuilder.Complete(r)
 }
@@ -22,6 +22,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 
 	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
@@ -170,3 +171,21 @@ var _ = Describe("OperandRegistry controller", func() {
 		})
 	})
 })
+
+var _ = Describe("OperandRegistry validation", func() {
+	It("Should reject an unknown InstallPlanApproval", func() {
+		ctx := context.Background()
+		namespaceName := testutil.CreateNSName("ibm-common-services")
+		Expect(k8sClient.Create(ctx, testutil.NamespaceObj(namespaceName))).Should(Succeed())
+
+		registry := testutil.OperandRegistryObj("invalid-approval", namespaceName, namespaceName)
+		registry.Spec.Operators[0].InstallPlanApproval = "Sometimes"
+		err := k8sClient.Create(ctx, registry)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("installPlanApproval"))
+
+		registry.Spec.Operators[0].InstallPlanApproval = olmv1alpha1.ApprovalManual
+		Expect(k8sClient.Create(ctx, registry)).Should(Succeed())
+		Expect(k8sClient.Delete(ctx, registry)).Should(Succeed())
+	})
+})
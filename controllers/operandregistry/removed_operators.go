@@ -0,0 +1,119 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandregistry
+
+import (
+	"context"
+	"regexp"
+
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+)
+
+var registryAnnotation = regexp.MustCompile(`^(.*)\.(.*)\/registry`)
+
+// deleteRemovedOperators deletes the Subscriptions and the ClusterServiceVersions of the operators in the previous status
+// of the OperandRegistry which are removed from its spec, once no OperandRequest refers to them.
+// The status of the operators failing to be deleted is kept, so they are deleted again in the next reconcile.
+func (r *Reconciler) deleteRemovedOperators(ctx context.Context, originalInstance, instance *operatorv1alpha1.OperandRegistry) error {
+	var errs []error
+	for name := range originalInstance.Status.OperatorsStatus {
+		if instance.GetOperator(name) != nil {
+			continue
+		}
+		if _, ok := instance.Status.OperatorsStatus[name]; ok {
+			klog.V(2).Infof("Operator %s is removed from the OperandRegistry %s/%s, but it is still requested", name, instance.Namespace, instance.Name)
+			continue
+		}
+		if err := r.deleteRemovedOperator(ctx, instance, name); err != nil {
+			instance.Status.OperatorsStatus[name] = operatorv1alpha1.OperatorStatus{}
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// deleteRemovedOperator deletes the Subscription created by ODLM for the operator of the OperandRegistry and its ClusterServiceVersion.
+// The Subscription shared with another OperandRegistry is only released by the OperandRegistry.
+func (r *Reconciler) deleteRemovedOperator(ctx context.Context, instance *operatorv1alpha1.OperandRegistry, name string) error {
+	subList := &olmv1alpha1.SubscriptionList{}
+	if err := r.Reader.List(ctx, subList, client.HasLabels{constant.OpreqLabel}); err != nil {
+		return errors.Wrapf(err, "failed to list the Subscriptions of the removed operator %s", name)
+	}
+
+	for i := range subList.Items {
+		sub := &subList.Items[i]
+		if sub.Name != name || sub.Annotations[instance.Namespace+"."+instance.Name+"/registry"] != "true" {
+			continue
+		}
+		if sub.Labels[constant.NotUninstallLabel] == "true" {
+			klog.V(1).Infof("Subscription %s/%s has label %s. Skip the uninstall", sub.Namespace, sub.Name, constant.NotUninstallLabel)
+			continue
+		}
+
+		originalSub := sub.DeepCopy()
+		delete(sub.Annotations, instance.Namespace+"."+instance.Name+"/registry")
+		delete(sub.Annotations, instance.Namespace+"."+instance.Name+"/config")
+		var shared bool
+		for annotation := range sub.Annotations {
+			if registryAnnotation.MatchString(annotation) {
+				shared = true
+			}
+		}
+		if shared {
+			if err := r.Client.Patch(ctx, sub, client.MergeFrom(originalSub)); err != nil {
+				return errors.Wrapf(err, "failed to release the Subscription %s/%s", sub.Namespace, sub.Name)
+			}
+			klog.V(1).Infof("Did not delete Subscription %s/%s which is used by another OperandRegistry", sub.Namespace, sub.Name)
+			continue
+		}
+
+		csvName := sub.Status.InstalledCSV
+		if csvName == "" {
+			csvName = sub.Status.CurrentCSV
+		}
+		if csvName != "" {
+			csv := &olmv1alpha1.ClusterServiceVersion{}
+			if err := r.Reader.Get(ctx, types.NamespacedName{Name: csvName, Namespace: sub.Namespace}, csv); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return errors.Wrapf(err, "failed to get the ClusterServiceVersion %s/%s", sub.Namespace, csvName)
+				}
+			} else {
+				klog.V(1).Infof("Deleting the ClusterServiceVersion, Namespace: %s, Name: %s", csv.Namespace, csv.Name)
+				if err := r.Client.Delete(ctx, csv); client.IgnoreNotFound(err) != nil {
+					return errors.Wrapf(err, "failed to delete the ClusterServiceVersion %s/%s", csv.Namespace, csv.Name)
+				}
+			}
+		}
+
+		klog.V(1).Infof("Deleting the Subscription, Namespace: %s, Name: %s", sub.Namespace, sub.Name)
+		if err := r.Client.Delete(ctx, sub); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "failed to delete the Subscription %s/%s", sub.Namespace, sub.Name)
+		}
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, "OperatorRemoved", "Deleted the Subscription %s/%s of the operator %s removed from the OperandRegistry", sub.Namespace, sub.Name, name)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandregistry
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Deleting the operators removed from the OperandRegistry", func() {
+	const (
+		name              = "common-service"
+		namespace         = "ibm-common-services"
+		operatorNamespace = "ibm-operators"
+	)
+
+	var (
+		ctx         context.Context
+		registry    *operatorv1alpha1.OperandRegistry
+		request     *operatorv1alpha1.OperandRequest
+		sub         *olmv1alpha1.Subscription
+		csv         *olmv1alpha1.ClusterServiceVersion
+		registryKey types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		registryKey = types.NamespacedName{Name: name, Namespace: namespace}
+		registry = testutil.OperandRegistryObj(name, namespace, operatorNamespace)
+		request = testutil.OperandRequestObj(name, namespace, "ibm-cloudpak-name", "ibm-cloudpak")
+		// jenkins was requested before it is removed from the OperandRegistry
+		registry.Status.OperatorsStatus = map[string]operatorv1alpha1.OperatorStatus{
+			"etcd":    {ReconcileRequests: []operatorv1alpha1.ReconcileRequest{{Name: request.Name, Namespace: request.Namespace}}},
+			"jenkins": {ReconcileRequests: []operatorv1alpha1.ReconcileRequest{{Name: request.Name, Namespace: request.Namespace}}},
+		}
+		registry.Spec.Operators = registry.Spec.Operators[:1]
+
+		sub = testutil.Subscription("jenkins", operatorNamespace)
+		sub.Annotations = map[string]string{namespace + "." + name + "/registry": "true", namespace + "." + name + "/config": "true"}
+		sub.Status = testutil.SubscriptionStatus("jenkins", operatorNamespace, "0.0.1")
+		csv = testutil.ClusterServiceVersion(sub.Status.InstalledCSV, operatorNamespace, "[]")
+	})
+
+	reconcileRegistry := func() (client.Client, *record.FakeRecorder) {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+		Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(registry, request, sub, csv).Build()
+		recorder := record.NewFakeRecorder(10)
+		r := &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Recorder: recorder,
+				Scheme:   s,
+			},
+		}
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: registryKey})
+		Expect(err).NotTo(HaveOccurred())
+		return c, recorder
+	}
+
+	expectDeleted := func(c client.Client, obj client.Object, deleted bool) {
+		err := c.Get(ctx, types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}, obj)
+		if deleted {
+			Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+		} else {
+			Expect(err).NotTo(HaveOccurred())
+		}
+	}
+
+	It("Should keep the operator while an OperandRequest still refers to it", func() {
+		c, recorder := reconcileRegistry()
+		expectDeleted(c, sub, false)
+		expectDeleted(c, csv, false)
+		Expect(recorder.Events).Should(BeEmpty())
+
+		updated := &operatorv1alpha1.OperandRegistry{}
+		Expect(c.Get(ctx, registryKey, updated)).Should(Succeed())
+		Expect(updated.Status.OperatorsStatus).Should(HaveKey("jenkins"))
+	})
+
+	It("Should delete the Subscription and the ClusterServiceVersion once no OperandRequest refers to the operator", func() {
+		request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+		c, recorder := reconcileRegistry()
+		expectDeleted(c, sub, true)
+		expectDeleted(c, csv, true)
+		Expect(recorder.Events).Should(Receive(ContainSubstring("OperatorRemoved")))
+
+		updated := &operatorv1alpha1.OperandRegistry{}
+		Expect(c.Get(ctx, registryKey, updated)).Should(Succeed())
+		Expect(updated.Status.OperatorsStatus).ShouldNot(HaveKey("jenkins"))
+	})
+
+	It("Should keep the Subscription not created by ODLM", func() {
+		request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+		sub.Labels = nil
+		c, _ := reconcileRegistry()
+		expectDeleted(c, sub, false)
+		expectDeleted(c, csv, false)
+	})
+
+	It("Should keep the Subscription labeled not to be uninstalled", func() {
+		request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+		sub.Labels[constant.NotUninstallLabel] = "true"
+		c, _ := reconcileRegistry()
+		expectDeleted(c, sub, false)
+		expectDeleted(c, csv, false)
+	})
+
+	It("Should only release the Subscription used by another OperandRegistry", func() {
+		request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+		sub.Annotations["ibm-cloudpak.common-service/registry"] = "true"
+		c, _ := reconcileRegistry()
+		expectDeleted(c, csv, false)
+
+		released := &olmv1alpha1.Subscription{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: sub.Name, Namespace: sub.Namespace}, released)).Should(Succeed())
+		Expect(released.Annotations).Should(Equal(map[string]string{"ibm-cloudpak.common-service/registry": "true"}))
+	})
+})
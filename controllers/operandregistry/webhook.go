@@ -0,0 +1,215 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandregistry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	operatorsv1 "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/operators/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+)
+
+const (
+	// ChannelDefaulterPath is the path of the webhook normalizing the channels of the OperandRegistry
+	ChannelDefaulterPath = "/mutate-operator-ibm-com-v1alpha1-operandregistry"
+	// ValidatorPath is the path of the webhook validating the operators of the OperandRegistry
+	ValidatorPath = "/validate-operator-ibm-com-v1alpha1-operandregistry"
+)
+
+// +kubebuilder:webhook:path=/mutate-operator-ibm-com-v1alpha1-operandregistry,mutating=true,failurePolicy=fail,sideEffects=None,groups=operator.ibm.com,resources=operandregistries,verbs=create;update,versions=v1alpha1,name=moperandregistry.kb.io,admissionReviewVersions={v1,v1beta1}
+
+// ChannelDefaulter trims the whitespace around the channels of the operators, and corrects their casing
+// to the channels of the packages when the packages are resolvable
+type ChannelDefaulter struct {
+	Reader  client.Reader
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &ChannelDefaulter{}
+var _ admission.DecoderInjector = &ChannelDefaulter{}
+
+// Handle implements admission.Handler.
+func (d *ChannelDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	registry := &operatorv1alpha1.OperandRegistry{}
+	if err := d.decoder.Decode(req, registry); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	previous, err := previousOperators(d.decoder, req)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	for i, operator := range registry.Spec.Operators {
+		channel := strings.TrimSpace(operator.Channel)
+		var channels []string
+		if packageChanged(previous, &operator) {
+			if channels, err = packageChannels(ctx, d.Reader, &operator); err != nil {
+				klog.Warningf("failed to get the channels of the package %s: %v", operator.PackageName, err)
+			}
+		}
+		for _, c := range channels {
+			if strings.EqualFold(c, channel) {
+				channel = c
+				break
+			}
+		}
+		registry.Spec.Operators[i].Channel = channel
+	}
+	marshaled, err := json.Marshal(registry)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (d *ChannelDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-operator-ibm-com-v1alpha1-operandregistry,mutating=false,failurePolicy=fail,sideEffects=None,groups=operator.ibm.com,resources=operandregistries,verbs=create;update,versions=v1alpha1,name=voperandregistry.kb.io,admissionReviewVersions={v1,v1beta1}
+
+// Validator rejects the operators with a duplicate name or a missing required field, and the channels unknown
+// to the packages of the operators, the channels of the packages not resolvable are accepted
+type Validator struct {
+	Reader  client.Reader
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &Validator{}
+var _ admission.DecoderInjector = &Validator{}
+
+// Handle implements admission.Handler.
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	registry := &operatorv1alpha1.OperandRegistry{}
+	if err := v.decoder.Decode(req, registry); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	previous, err := previousOperators(v.decoder, req)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	allErrs := registry.ValidateOperators()
+	operatorsPath := field.NewPath("spec").Child("operators")
+	for i, operator := range registry.Spec.Operators {
+		if operator.Channel == "" || !packageChanged(previous, &operator) {
+			continue
+		}
+		channels, err := packageChannels(ctx, v.Reader, &operator)
+		if err != nil {
+			klog.Warningf("failed to get the channels of the package %s: %v", operator.PackageName, err)
+			continue
+		}
+		if len(channels) == 0 || containsString(channels, operator.Channel) {
+			continue
+		}
+		allErrs = append(allErrs, field.NotSupported(operatorsPath.Index(i).Child("channel"), operator.Channel, channels))
+	}
+	if len(allErrs) == 0 {
+		return admission.Allowed("")
+	}
+	invalid := apierrors.NewInvalid(operatorv1alpha1.GroupVersion.WithKind("OperandRegistry").GroupKind(), registry.Name, allErrs)
+	return admission.Response{AdmissionResponse: admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result:  &invalid.ErrStatus,
+	}}
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *Validator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+	return nil
+}
+
+// previousOperators returns the operators of the OperandRegistry before the update by their names, nil on create
+func previousOperators(decoder *admission.Decoder, req admission.Request) (map[string]operatorv1alpha1.Operator, error) {
+	if req.Operation != admissionv1.Update {
+		return nil, nil
+	}
+	oldRegistry := &operatorv1alpha1.OperandRegistry{}
+	if err := decoder.DecodeRaw(req.OldObject, oldRegistry); err != nil {
+		return nil, err
+	}
+	operators := make(map[string]operatorv1alpha1.Operator)
+	for _, operator := range oldRegistry.Spec.Operators {
+		operators[operator.Name] = operator
+	}
+	return operators, nil
+}
+
+// packageChanged tells whether the operator is new, or its channel, package or catalog source changed by the update,
+// the packages of the unchanged operators were looked up when they were set, so that the update doesn't list them again
+func packageChanged(previous map[string]operatorv1alpha1.Operator, operator *operatorv1alpha1.Operator) bool {
+	old, ok := previous[operator.Name]
+	return !ok || old.Channel != operator.Channel || old.PackageName != operator.PackageName || old.Namespace != operator.Namespace ||
+		old.SourceName != operator.SourceName || old.SourceNamespace != operator.SourceNamespace
+}
+
+// packageChannels returns the sorted channels of the package of the operator, from its catalog source
+// when it is set, nil means the package isn't resolvable
+func packageChannels(ctx context.Context, reader client.Reader, operator *operatorv1alpha1.Operator) ([]string, error) {
+	if operator.PackageName == "" {
+		return nil, nil
+	}
+	packageManifestList := &operatorsv1.PackageManifestList{}
+	opts := []client.ListOption{
+		client.MatchingFields{"metadata.name": operator.PackageName},
+		client.InNamespace(operator.Namespace),
+	}
+	if err := reader.List(ctx, packageManifestList, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list the PackageManifests: %v", err)
+	}
+	found := make(map[string]bool)
+	var channels []string
+	for _, pm := range packageManifestList.Items {
+		if pm.Name != operator.PackageName {
+			continue
+		}
+		if operator.SourceName != "" && (pm.Status.CatalogSource != operator.SourceName || pm.Status.CatalogSourceNamespace != operator.SourceNamespace) {
+			continue
+		}
+		for _, channel := range pm.Status.Channels {
+			if !found[channel.Name] {
+				found[channel.Name] = true
+				channels = append(channels, channel.Name)
+			}
+		}
+	}
+	sort.Strings(channels)
+	return channels, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,248 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandregistry
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	operatorsv1 "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/operators/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("OperandRegistry channel webhooks", func() {
+	const operatorNamespace = "ibm-operators"
+
+	var (
+		ctx       context.Context
+		reader    *listCountingReader
+		defaulter *ChannelDefaulter
+		validator *Validator
+	)
+
+	packageManifest := func(name string, channels ...string) *operatorsv1.PackageManifest {
+		pm := &operatorsv1.PackageManifest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: operatorNamespace},
+		}
+		pm.Status.CatalogSource = "community-operators"
+		pm.Status.CatalogSourceNamespace = "openshift-marketplace"
+		for _, channel := range channels {
+			pm.Status.Channels = append(pm.Status.Channels, operatorsv1.PackageChannel{Name: channel})
+		}
+		return pm
+	}
+
+	admissionRequest := func(registry *operatorv1alpha1.OperandRegistry) admission.Request {
+		raw, err := json.Marshal(registry)
+		Expect(err).NotTo(HaveOccurred())
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		}}
+	}
+
+	updateRequest := func(oldRegistry, registry *operatorv1alpha1.OperandRegistry) admission.Request {
+		req := admissionRequest(registry)
+		raw, err := json.Marshal(oldRegistry)
+		Expect(err).NotTo(HaveOccurred())
+		req.Operation = admissionv1.Update
+		req.OldObject = runtime.RawExtension{Raw: raw}
+		return req
+	}
+
+	// The etcd package is resolvable, the jenkins package isn't
+	registryWithChannels := func(etcdChannel, jenkinsChannel string) *operatorv1alpha1.OperandRegistry {
+		registry := testutil.OperandRegistryObj("common-service", "ibm-common-services", operatorNamespace)
+		registry.Spec.Operators[0].Channel = etcdChannel
+		registry.Spec.Operators[1].Channel = jenkinsChannel
+		return registry
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(operatorv1alpha1.AddToScheme(scheme)).Should(Succeed())
+		Expect(operatorsv1.AddToScheme(scheme)).Should(Succeed())
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			packageManifest("etcd", "clusterwide-alpha", "singlenamespace-alpha"),
+		).Build()
+		decoder, err := admission.NewDecoder(scheme)
+		Expect(err).NotTo(HaveOccurred())
+		reader = &listCountingReader{Reader: c}
+		defaulter = &ChannelDefaulter{Reader: reader}
+		Expect(defaulter.InjectDecoder(decoder)).Should(Succeed())
+		validator = &Validator{Reader: reader}
+		Expect(validator.InjectDecoder(decoder)).Should(Succeed())
+	})
+
+	It("Should trim the whitespace and correct the casing of the channels", func() {
+		resp := defaulter.Handle(ctx, admissionRequest(registryWithChannels(" SingleNamespace-Alpha\t", " alpha ")))
+		Expect(resp.Allowed).Should(BeTrue())
+		var channels []interface{}
+		for _, patch := range resp.Patches {
+			channels = append(channels, patch.Value)
+		}
+		Expect(channels).Should(ConsistOf("singlenamespace-alpha", "alpha"))
+	})
+
+	It("Should reject the unknown channels with the valid ones", func() {
+		resp := validator.Handle(ctx, admissionRequest(registryWithChannels("stable", "any")))
+		Expect(resp.Allowed).Should(BeFalse())
+		Expect(resp.Result.Message).Should(ContainSubstring("spec.operators[0].channel"))
+		Expect(resp.Result.Message).Should(ContainSubstring(`"clusterwide-alpha", "singlenamespace-alpha"`))
+		Expect(resp.Result.Message).ShouldNot(ContainSubstring("spec.operators[1].channel"))
+	})
+
+	It("Should accept the known channels", func() {
+		resp := validator.Handle(ctx, admissionRequest(registryWithChannels("singlenamespace-alpha", "alpha")))
+		Expect(resp.Allowed).Should(BeTrue())
+	})
+
+	It("Should reject the duplicate operator names and the missing required fields", func() {
+		registry := registryWithChannels("singlenamespace-alpha", "alpha")
+		registry.Spec.Operators[1].Name = registry.Spec.Operators[0].Name
+		registry.Spec.Operators[1].PackageName = ""
+		registry.Spec.Operators[1].Channel = " "
+		registry.Spec.Operators[0].SourceNamespace = ""
+		resp := validator.Handle(ctx, admissionRequest(registry))
+		Expect(resp.Allowed).Should(BeFalse())
+		var fields []string
+		for _, cause := range resp.Result.Details.Causes {
+			fields = append(fields, cause.Field)
+		}
+		Expect(fields).Should(ConsistOf(
+			"spec.operators[1].name",
+			"spec.operators[1].packageName",
+			"spec.operators[1].channel",
+			"spec.operators[0].sourceNamespace",
+		))
+	})
+
+	It("Should reject the invalid catalog overrides", func() {
+		registry := registryWithChannels("singlenamespace-alpha", "alpha")
+		registry.Spec.Operators[0].CatalogOverrides = []operatorv1alpha1.CatalogOverride{
+			{SourceName: "mirrored-operators", SourceNamespace: "mirror-marketplace", InstallMode: operatorv1alpha1.InstallModeCluster},
+			{SourceName: "mirrored-operators", InstallMode: "all"},
+			{
+				SourceName:        "mirrored-operators",
+				SourceNamespace:   "mirror-marketplace",
+				NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "network", Operator: "Unknown"}}},
+			},
+		}
+		resp := validator.Handle(ctx, admissionRequest(registry))
+		Expect(resp.Allowed).Should(BeFalse())
+		var fields []string
+		for _, cause := range resp.Result.Details.Causes {
+			fields = append(fields, cause.Field)
+		}
+		Expect(fields).Should(ConsistOf(
+			"spec.operators[0].catalogOverrides[1].sourceNamespace",
+			"spec.operators[0].catalogOverrides[1].installMode",
+			"spec.operators[0].catalogOverrides[2].namespaceSelector",
+		))
+	})
+
+	It("Should reject the operators without namespace in the namespace install mode", func() {
+		registry := registryWithChannels("singlenamespace-alpha", "alpha")
+		registry.Spec.Operators[0].Namespace = ""
+		registry.Spec.Operators[1].Namespace = " "
+		registry.Spec.Operators[1].InstallMode = operatorv1alpha1.InstallModeNamespace
+		resp := validator.Handle(ctx, admissionRequest(registry))
+		Expect(resp.Allowed).Should(BeFalse())
+		var fields []string
+		for _, cause := range resp.Result.Details.Causes {
+			fields = append(fields, cause.Field)
+			Expect(cause.Message).Should(ContainSubstring("is required in the namespace install mode"))
+		}
+		Expect(fields).Should(ConsistOf(
+			"spec.operators[0].namespace",
+			"spec.operators[1].namespace",
+		))
+
+		By("Accepting the operators without namespace in the cluster install mode")
+		registry.Spec.Operators[0].InstallMode = operatorv1alpha1.InstallModeCluster
+		registry.Spec.Operators[1].InstallMode = operatorv1alpha1.InstallModeCluster
+		resp = validator.Handle(ctx, admissionRequest(registry))
+		Expect(resp.Allowed).Should(BeTrue())
+	})
+
+	It("Should accept the operators without the catalog source", func() {
+		registry := registryWithChannels("singlenamespace-alpha", "alpha")
+		registry.Spec.Operators[1].SourceName = ""
+		registry.Spec.Operators[1].SourceNamespace = ""
+		resp := validator.Handle(ctx, admissionRequest(registry))
+		Expect(resp.Allowed).Should(BeTrue())
+	})
+
+	It("Should only look up the packages of the operators changed on update", func() {
+		// The channel of etcd was accepted before the PackageManifest dropped it
+		oldRegistry := registryWithChannels("stable", "alpha")
+		registry := registryWithChannels("stable", "beta")
+
+		By("Skipping the unchanged operators in the validation")
+		resp := validator.Handle(ctx, updateRequest(oldRegistry, registry))
+		Expect(resp.Allowed).Should(BeTrue())
+		Expect(reader.lists).Should(Equal(1))
+
+		By("Skipping the unchanged operators in the defaulting")
+		reader.lists = 0
+		resp = defaulter.Handle(ctx, updateRequest(oldRegistry, registry))
+		Expect(resp.Allowed).Should(BeTrue())
+		Expect(reader.lists).Should(Equal(1))
+
+		By("Validating the operator whose channel is changed")
+		registry.Spec.Operators[0].Channel = "beta"
+		resp = validator.Handle(ctx, updateRequest(oldRegistry, registry))
+		Expect(resp.Allowed).Should(BeFalse())
+		Expect(resp.Result.Message).Should(ContainSubstring("spec.operators[0].channel"))
+
+		By("Looking up the operator whose catalog source is changed")
+		registry = registryWithChannels("stable", "alpha")
+		registry.Spec.Operators[0].SourceName = "mirrored-operators"
+		reader.lists = 0
+		resp = validator.Handle(ctx, updateRequest(oldRegistry, registry))
+		Expect(resp.Allowed).Should(BeTrue())
+		Expect(reader.lists).Should(Equal(1))
+
+		By("Validating the operator whose package is changed")
+		oldRegistry.Spec.Operators[0].PackageName = "etcd-operator"
+		resp = validator.Handle(ctx, updateRequest(oldRegistry, registryWithChannels("stable", "alpha")))
+		Expect(resp.Allowed).Should(BeFalse())
+		Expect(resp.Result.Message).Should(ContainSubstring("spec.operators[0].channel"))
+	})
+})
+
+// listCountingReader counts the lists of the PackageManifests
+type listCountingReader struct {
+	client.Reader
+	lists int
+}
+
+func (r *listCountingReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	r.lists++
+	return r.Reader.List(ctx, list, opts...)
+}
@@ -0,0 +1,167 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+)
+
+// Bundle is the set of objects driving an OperandRequest
+type Bundle struct {
+	Request    *operatorv1alpha1.OperandRequest
+	Registries []operatorv1alpha1.OperandRegistry
+	Configs    []operatorv1alpha1.OperandConfig
+	BindInfos  []operatorv1alpha1.OperandBindInfo
+	// ClusterServiceVersions maps the operands to the names of their resolved ClusterServiceVersions
+	ClusterServiceVersions map[string]string
+}
+
+// ExportBundle gathers the OperandRegistries, OperandConfigs and OperandBindInfos referenced by the OperandRequest,
+// and resolves the ClusterServiceVersions of its operands. It only reads from the cluster.
+func (r *Reconciler) ExportBundle(ctx context.Context, key types.NamespacedName) (*Bundle, error) {
+	requestInstance := &operatorv1alpha1.OperandRequest{}
+	if err := r.Reader.Get(ctx, key, requestInstance); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the OperandRequest %s", key.String())
+	}
+	bindInfoList := &operatorv1alpha1.OperandBindInfoList{}
+	if err := r.Reader.List(ctx, bindInfoList); err != nil {
+		return nil, errors.Wrap(err, "failed to list OperandBindInfos")
+	}
+
+	bundle := &Bundle{Request: requestInstance, ClusterServiceVersions: make(map[string]string)}
+	gathered := make(map[string]bool)
+	for _, req := range requestInstance.Spec.Requests {
+		registryKey := requestInstance.GetRegistryKey(req)
+
+		registryInstance := &operatorv1alpha1.OperandRegistry{}
+		if err := r.Reader.Get(ctx, registryKey, registryInstance); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "failed to get the OperandRegistry %s", registryKey.String())
+			}
+			klog.Warningf("OperandRegistry %s referenced by the OperandRequest %s is not found", registryKey.String(), key.String())
+			registryInstance = nil
+		} else if !gathered["OperandRegistry/"+registryKey.String()] {
+			gathered["OperandRegistry/"+registryKey.String()] = true
+			bundle.Registries = append(bundle.Registries, *registryInstance)
+		}
+
+		configKey := requestInstance.GetConfigKey(req)
+		configInstance := &operatorv1alpha1.OperandConfig{}
+		if err := r.Reader.Get(ctx, configKey, configInstance); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "failed to get the OperandConfig %s", configKey.String())
+			}
+			klog.Warningf("OperandConfig %s referenced by the OperandRequest %s is not found", configKey.String(), key.String())
+		} else if !gathered["OperandConfig/"+configKey.String()] {
+			gathered["OperandConfig/"+configKey.String()] = true
+			bundle.Configs = append(bundle.Configs, *configInstance)
+		}
+
+		operands := make(map[string]bool)
+		for _, operand := range req.Operands {
+			operands[operand.Name] = true
+			if registryInstance == nil {
+				continue
+			}
+			opt := registryInstance.GetOperator(operand.Name)
+			if opt == nil {
+				continue
+			}
+			sub, err := r.GetSubscription(ctx, opt.Name, r.GetOperatorNamespace(opt.InstallMode, opt.Namespace), opt.PackageName)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return nil, err
+			}
+			csvName := sub.Status.InstalledCSV
+			if csvName == "" {
+				csvName = sub.Status.CurrentCSV
+			}
+			if csvName != "" {
+				bundle.ClusterServiceVersions[operand.Name] = csvName
+			}
+		}
+
+		for _, bindInfo := range bindInfoList.Items {
+			bindInfoKey := "OperandBindInfo/" + bindInfo.Namespace + "/" + bindInfo.Name
+			if bindInfo.GetRegistryKey() != registryKey || !operands[bindInfo.Spec.Operand] || gathered[bindInfoKey] {
+				continue
+			}
+			gathered[bindInfoKey] = true
+			bundle.BindInfos = append(bundle.BindInfos, bindInfo)
+		}
+	}
+	return bundle, nil
+}
+
+// Manifest renders the bundle as a multi-document YAML manifest,
+// the resolved ClusterServiceVersions are listed in the leading comment
+func (b *Bundle) Manifest() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("# ClusterServiceVersions resolved for the operands:\n")
+	operands := make([]string, 0, len(b.ClusterServiceVersions))
+	for operand := range b.ClusterServiceVersions {
+		operands = append(operands, operand)
+	}
+	sort.Strings(operands)
+	for _, operand := range operands {
+		fmt.Fprintf(&buf, "#   %s: %s\n", operand, b.ClusterServiceVersions[operand])
+	}
+
+	objects := []client.Object{b.Request}
+	kinds := []string{"OperandRequest"}
+	for i := range b.Registries {
+		objects = append(objects, &b.Registries[i])
+		kinds = append(kinds, "OperandRegistry")
+	}
+	for i := range b.Configs {
+		objects = append(objects, &b.Configs[i])
+		kinds = append(kinds, "OperandConfig")
+	}
+	for i := range b.BindInfos {
+		objects = append(objects, &b.BindInfos[i])
+		kinds = append(kinds, "OperandBindInfo")
+	}
+	for i, obj := range objects {
+		obj = obj.DeepCopyObject().(client.Object)
+		obj.GetObjectKind().SetGroupVersionKind(operatorv1alpha1.GroupVersion.WithKind(kinds[i]))
+		// Drop the server side fields, so the manifest can be applied to another cluster
+		obj.SetManagedFields(nil)
+		obj.SetResourceVersion("")
+		obj.SetUID("")
+		document, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal the %s %s/%s", kinds[i], obj.GetNamespace(), obj.GetName())
+		}
+		buf.WriteString("---\n")
+		buf.Write(document)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,116 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Request bundle", func() {
+	const (
+		requestName       = "ibm-cloudpak-name"
+		requestNamespace  = "ibm-cloudpak"
+		registryName      = "common-service"
+		registryNamespace = "ibm-common-services"
+		operatorNamespace = "ibm-operators"
+	)
+
+	var (
+		ctx context.Context
+		r   *Reconciler
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		s := runtime.NewScheme()
+		Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+		Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+
+		etcdSub := testutil.Subscription("etcd", operatorNamespace)
+		etcdSub.Status = testutil.SubscriptionStatus("etcd", operatorNamespace, "0.0.1")
+		// The jenkins operator is still being installed
+		jenkinsSub := testutil.Subscription("jenkins-operator", operatorNamespace)
+		jenkinsSub.Status.CurrentCSV = "jenkins-csv.v0.0.2"
+
+		objects := []client.Object{
+			testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespace),
+			testutil.OperandConfigObj(registryName, registryNamespace),
+			testutil.OperandRequestObj(registryName, registryNamespace, requestName, requestNamespace),
+			testutil.OperandBindInfoObj("jenkins-bindinfo", registryNamespace, registryName, registryNamespace),
+			// The OperandBindInfo of another OperandRegistry is left out
+			testutil.OperandBindInfoObj("other-bindinfo", registryNamespace, "other-registry", registryNamespace),
+			etcdSub,
+			jenkinsSub,
+		}
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(objects...).Build()
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client: c,
+				Reader: c,
+			},
+		}
+	})
+
+	It("Should gather all the objects referenced by the OperandRequest", func() {
+		bundle, err := r.ExportBundle(ctx, types.NamespacedName{Name: requestName, Namespace: requestNamespace})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundle.Request.Name).Should(Equal(requestName))
+		Expect(bundle.Registries).Should(HaveLen(1))
+		Expect(bundle.Registries[0].Name).Should(Equal(registryName))
+		Expect(bundle.Configs).Should(HaveLen(1))
+		Expect(bundle.Configs[0].Name).Should(Equal(registryName))
+		Expect(bundle.BindInfos).Should(HaveLen(1))
+		Expect(bundle.BindInfos[0].Name).Should(Equal("jenkins-bindinfo"))
+		Expect(bundle.ClusterServiceVersions).Should(Equal(map[string]string{
+			"etcd":    "etcd-csv.v0.0.1",
+			"jenkins": "jenkins-csv.v0.0.2",
+		}))
+	})
+
+	It("Should render the bundle as a multi-document manifest", func() {
+		bundle, err := r.ExportBundle(ctx, types.NamespacedName{Name: requestName, Namespace: requestNamespace})
+		Expect(err).NotTo(HaveOccurred())
+		manifest, err := bundle.Manifest()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(manifest)).Should(HavePrefix("# ClusterServiceVersions resolved for the operands:\n#   etcd: etcd-csv.v0.0.1\n#   jenkins: jenkins-csv.v0.0.2\n---\n"))
+
+		// The first document is the leading comment
+		var kinds []string
+		for _, document := range bytes.Split(manifest, []byte("\n---\n"))[1:] {
+			obj := map[string]interface{}{}
+			Expect(yaml.Unmarshal(document, &obj)).Should(Succeed())
+			Expect(obj["apiVersion"]).Should(Equal("operator.ibm.com/v1alpha1"))
+			Expect(obj["metadata"]).ShouldNot(HaveKey("resourceVersion"))
+			kinds = append(kinds, obj["kind"].(string))
+		}
+		Expect(kinds).Should(Equal([]string{"OperandRequest", "OperandRegistry", "OperandConfig", "OperandBindInfo"}))
+	})
+})
@@ -0,0 +1,84 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+)
+
+var _ = Describe("Deleting a custom resource", func() {
+	const namespace = "ibm-operators"
+
+	var (
+		ctx context.Context
+		c   client.Client
+		r   *Reconciler
+	)
+
+	etcdCluster := func(name, namespace string) *unstructured.Unstructured {
+		cr := &unstructured.Unstructured{}
+		cr.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+		cr.SetKind("EtcdCluster")
+		cr.SetName(name)
+		cr.SetNamespace(namespace)
+		cr.SetLabels(map[string]string{constant.OpreqLabel: "true"})
+		return cr
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+		c = fake.NewClientBuilder().WithScheme(s).WithObjects(
+			etcdCluster("example", namespace), etcdCluster("sibling", namespace), etcdCluster("example", "ibm-cloudpak"),
+		).Build()
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Recorder: record.NewFakeRecorder(10),
+			},
+		}
+	})
+
+	It("Should delete only the named custom resource", func() {
+		Expect(r.deleteCustomResource(ctx, *etcdCluster("example", ""), namespace, metav1.DeletePropagationBackground)).Should(Succeed())
+
+		Expect(c.Get(ctx, types.NamespacedName{Name: "example", Namespace: namespace}, etcdCluster("", ""))).ShouldNot(Succeed())
+		Expect(c.Get(ctx, types.NamespacedName{Name: "sibling", Namespace: namespace}, etcdCluster("", ""))).Should(Succeed())
+		Expect(c.Get(ctx, types.NamespacedName{Name: "example", Namespace: "ibm-cloudpak"}, etcdCluster("", ""))).Should(Succeed())
+	})
+
+	It("Should succeed when the custom resource is already absent", func() {
+		Expect(r.deleteCustomResource(ctx, *etcdCluster("absent", ""), namespace, metav1.DeletePropagationBackground)).Should(Succeed())
+
+		Expect(c.Get(ctx, types.NamespacedName{Name: "sibling", Namespace: namespace}, etcdCluster("", ""))).Should(Succeed())
+	})
+})
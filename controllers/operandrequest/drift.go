@@ -0,0 +1,320 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/util"
+)
+
+// DriftReportPath is the path of the endpoint serving the drift report
+const DriftReportPath = "/drift"
+
+// DriftReportInterval is the minimum interval between two computations of the served drift report
+const DriftReportInterval = time.Minute
+
+// DriftReport lists the custom resources managed by ODLM drifted from their desired spec
+type DriftReport struct {
+	Resources []DriftedResource `json:"resources"`
+	// Errors are the errors of the operands skipped by the report
+	Errors []string `json:"errors,omitempty"`
+}
+
+// DriftedResource is a custom resource drifted from its desired spec
+type DriftedResource struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	// OperandRequest is the namespaced name of the OperandRequest requesting the custom resource
+	OperandRequest string `json:"operandRequest"`
+	// Paths are the paths of the spec fields differing from the desired spec
+	Paths []string `json:"paths"`
+}
+
+// DriftReport recomputes the desired spec of the custom resources managed by ODLM
+// and reports the custom resources whose spec differs from it. The operands failed to be checked are skipped,
+// and their errors are listed in the report.
+func (r *Reconciler) DriftReport(ctx context.Context) (*DriftReport, error) {
+	requestList := &operatorv1alpha1.OperandRequestList{}
+	if err := r.Client.List(ctx, requestList); err != nil {
+		return nil, errors.Wrap(err, "failed to list OperandRequests")
+	}
+
+	report := &DriftReport{Resources: []DriftedResource{}}
+	reported := make(map[string]bool)
+	for i := range requestList.Items {
+		requestInstance := &requestList.Items[i]
+		if !requestInstance.DeletionTimestamp.IsZero() {
+			continue
+		}
+		for _, req := range requestInstance.Spec.Requests {
+			drifted, errs := r.requestDrift(ctx, requestInstance, req)
+			for _, err := range errs {
+				klog.Warningf("Skip the drift of the OperandRequest %s/%s: %v", requestInstance.Namespace, requestInstance.Name, err)
+				report.Errors = append(report.Errors, err.Error())
+			}
+			for _, d := range drifted {
+				// The custom resources from the OperandConfig can be requested by several OperandRequests
+				key := strings.Join([]string{d.APIVersion, d.Kind, d.Namespace, d.Name}, "/")
+				if reported[key] {
+					continue
+				}
+				reported[key] = true
+				report.Resources = append(report.Resources, d)
+			}
+		}
+	}
+
+	sort.Slice(report.Resources, func(i, j int) bool {
+		a, b := report.Resources[i], report.Resources[j]
+		return strings.Join([]string{a.Namespace, a.Kind, a.Name}, "/") < strings.Join([]string{b.Namespace, b.Kind, b.Name}, "/")
+	})
+	return report, nil
+}
+
+// requestDrift returns the drifted custom resources of the operands in a request of the OperandRequest,
+// with the errors of the operands failed to be checked
+func (r *Reconciler) requestDrift(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest, req operatorv1alpha1.Request) ([]DriftedResource, []error) {
+	registryKey := requestInstance.GetRegistryKey(req)
+	registryInstance, err := r.GetOperandRegistry(ctx, registryKey)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, []error{errors.Wrapf(err, "failed to get the OperandRegistry %s", registryKey.String())}
+	}
+
+	var drifted []DriftedResource
+	var errs []error
+	for i, operand := range req.Operands {
+		opt := registryInstance.GetOperator(operand.Name)
+		if opt == nil {
+			continue
+		}
+		operandDrifted, err := r.operandDrift(ctx, requestInstance, req, opt, operand, i)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		drifted = append(drifted, operandDrifted...)
+	}
+	return drifted, errs
+}
+
+// operandDrift returns the drifted custom resources of the operand, the custom resources failed to be checked
+// are skipped and their errors returned with the drifted ones
+func (r *Reconciler) operandDrift(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest, req operatorv1alpha1.Request, opt *operatorv1alpha1.Operator, operand operatorv1alpha1.Operand, index int) ([]DriftedResource, error) {
+	requestKey := types.NamespacedName{Namespace: requestInstance.Namespace, Name: requestInstance.Name}
+	if operand.Kind != "" {
+		apiVersion, err := r.servedAPIVersion(operand.APIVersion, operand.Kind)
+		if err != nil {
+			return nil, err
+		}
+		existingCR, err := r.managedCustomResource(ctx, apiVersion, operand.Kind, requestInstance.Namespace, requestCRName(requestInstance.Name, operand, index))
+		if err != nil || existingCR == nil {
+			return nil, err
+		}
+		paths, err := specDrift(existingCR, map[string]interface{}{}, operand.Spec.Raw, operatorv1alpha1.MergeStrategyMerge, nil)
+		if err != nil || len(paths) == 0 {
+			return nil, err
+		}
+		return []DriftedResource{newDriftedResource(existingCR, requestKey, paths)}, nil
+	}
+
+	configKey := requestInstance.GetConfigKey(req)
+	configInstance, err := r.GetOperandConfig(ctx, configKey)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get the OperandConfig %s", configKey.String())
+	}
+	service := configInstance.GetService(operand.Name)
+	if service == nil {
+		return nil, nil
+	}
+	service, err = applyDefaults(configInstance.Spec.Defaults, service)
+	if err != nil {
+		return nil, err
+	}
+	service, err = applyOperatorDefaults(opt, service)
+	if err != nil {
+		return nil, err
+	}
+	crNamespace := service.GetCRNamespace(opt, r.DefaultTargetNamespace)
+
+	sub, err := r.GetSubscription(ctx, opt.Name, r.GetOperatorNamespace(opt.InstallMode, opt.Namespace), opt.PackageName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	csv, err := r.GetClusterServiceVersion(ctx, sub)
+	if err != nil || csv == nil {
+		return nil, err
+	}
+
+	service, err = r.applyOverrides(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	service, err = r.resolveKeyRefs(ctx, service, crNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var almExampleList []interface{}
+	if err := json.Unmarshal([]byte(csv.GetAnnotations()["alm-examples"]), &almExampleList); err != nil {
+		return nil, errors.Wrapf(err, "failed to convert alm-examples in the ClusterServiceVersion %s/%s to slice", csv.Namespace, csv.Name)
+	}
+	merr := &util.MultiErr{}
+	var drifted []DriftedResource
+	for _, almExample := range almExampleList {
+		crFromALM := unstructured.Unstructured{Object: almExample.(map[string]interface{})}
+		specFromALM, ok := crFromALM.Object["spec"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		_, crConfig, found := service.GetCRSpec(crFromALM.GroupVersionKind())
+		if !found {
+			continue
+		}
+		apiVersion, err := r.servedAPIVersion(crFromALM.GetAPIVersion(), crFromALM.GetKind())
+		if err != nil {
+			merr.Add(err)
+			continue
+		}
+		existingCR, err := r.managedCustomResource(ctx, apiVersion, crFromALM.GetKind(), crNamespace, crFromALM.GetName())
+		if err != nil {
+			merr.Add(err)
+			continue
+		}
+		if existingCR == nil {
+			continue
+		}
+		paths, err := specDrift(existingCR, specFromALM, crConfig.Raw, service.GetMergeStrategy(crFromALM.GroupVersionKind()), service.IgnoredSpecPaths)
+		if err != nil {
+			merr.Add(err)
+			continue
+		}
+		if len(paths) != 0 {
+			drifted = append(drifted, newDriftedResource(existingCR, requestKey, paths))
+		}
+	}
+	if len(merr.Errors) != 0 {
+		return drifted, merr
+	}
+	return drifted, nil
+}
+
+// managedCustomResource gets the custom resource created by ODLM, it returns nil if the custom resource doesn't exist or isn't managed by ODLM
+func (r *Reconciler) managedCustomResource(ctx context.Context, apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error) {
+	cr := &unstructured.Unstructured{}
+	cr.SetAPIVersion(apiVersion)
+	cr.SetKind(kind)
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cr); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get custom resource -- Kind: %s, NamespacedName: %s/%s", kind, namespace, name)
+	}
+	if !checkLabel(*cr, map[string]string{constant.OpreqLabel: "true"}) {
+		return nil, nil
+	}
+	return cr, nil
+}
+
+// specDrift returns the paths of the spec fields of the custom resource differing from its desired spec,
+// the fields at the ignored paths never drift
+func specDrift(existingCR *unstructured.Unstructured, specFromALM map[string]interface{}, crConfig []byte, mergeStrategy operatorv1alpha1.MergeStrategy, ignoredPaths []string) ([]string, error) {
+	desiredSpec, err := desiredCRSpec(specFromALM, existingCR.Object["spec"], crConfig, mergeStrategy)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to compute the desired spec of custom resource -- Kind: %s, NamespacedName: %s/%s", existingCR.GetKind(), existingCR.GetNamespace(), existingCR.GetName())
+	}
+	keepIgnoredSpecPaths(desiredSpec, existingCR.Object["spec"], ignoredPaths)
+	existingSpecRaw, err := json.Marshal(existingCR.Object["spec"])
+	if err != nil {
+		return nil, err
+	}
+	desiredSpecRaw, err := json.Marshal(desiredSpec)
+	if err != nil {
+		return nil, err
+	}
+	return util.DiffCR(existingSpecRaw, desiredSpecRaw), nil
+}
+
+func newDriftedResource(cr *unstructured.Unstructured, requestKey types.NamespacedName, paths []string) DriftedResource {
+	return DriftedResource{
+		APIVersion:     cr.GetAPIVersion(),
+		Kind:           cr.GetKind(),
+		Namespace:      cr.GetNamespace(),
+		Name:           cr.GetName(),
+		OperandRequest: requestKey.String(),
+		Paths:          paths,
+	}
+}
+
+// DriftReportHandler serves the drift report in JSON. The report is computed at most once per DriftReportInterval,
+// the requests in between are served the last report.
+func (r *Reconciler) DriftReportHandler() http.Handler {
+	var (
+		mu         sync.Mutex
+		report     *DriftReport
+		computedAt time.Time
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		now := r.clock().Now()
+		if report == nil || now.Sub(computedAt) >= DriftReportInterval {
+			computed, err := r.DriftReport(req.Context())
+			if err != nil {
+				klog.Errorf("failed to compute the drift report: %v", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			report, computedAt = computed, now
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			klog.Errorf("failed to write the drift report: %v", err)
+		}
+	})
+}
@@ -0,0 +1,170 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Drift report", func() {
+	const (
+		registryName      = "common-service"
+		registryNamespace = "ibm-common-services"
+		operatorNamespace = "ibm-operators"
+	)
+
+	var (
+		ctx context.Context
+		r   *Reconciler
+		c   client.Client
+	)
+
+	managedCR := func(apiVersion, kind string, spec map[string]interface{}) *unstructured.Unstructured {
+		cr := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+		cr.SetAPIVersion(apiVersion)
+		cr.SetKind(kind)
+		cr.SetName("example")
+		cr.SetNamespace(operatorNamespace)
+		cr.SetLabels(map[string]string{constant.OpreqLabel: "true"})
+		return cr
+	}
+
+	installedOperator := func(name, example string) []client.Object {
+		sub := testutil.Subscription(name, operatorNamespace)
+		sub.Spec.Package = testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespace).GetOperator(name).PackageName
+		sub.Status = testutil.SubscriptionStatus(name, operatorNamespace, "0.0.1")
+		csv := testutil.ClusterServiceVersion(sub.Status.CurrentCSV, operatorNamespace, example)
+		return []client.Object{sub, csv}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		s := runtime.NewScheme()
+		Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+		Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+
+		objects := []client.Object{
+			testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespace),
+			testutil.OperandConfigObj(registryName, registryNamespace),
+			testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak"),
+			// The size of the etcd cluster is scaled down manually
+			managedCR("etcd.database.coreos.com/v1beta2", "EtcdCluster", map[string]interface{}{"size": int64(1), "version": "3.2.13"}),
+			managedCR("jenkins.io/v1alpha2", "Jenkins", map[string]interface{}{"service": map[string]interface{}{"port": int64(8081)}}),
+		}
+		objects = append(objects, installedOperator("etcd", testutil.EtcdExample)...)
+		objects = append(objects, installedOperator("jenkins", testutil.JenkinsExample)...)
+
+		c = fake.NewClientBuilder().WithScheme(s).WithObjects(objects...).Build()
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client: c,
+				Reader: c,
+			},
+		}
+	})
+
+	It("Should report the drifted custom resources with their differing paths", func() {
+		report, err := r.DriftReport(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Resources).Should(Equal([]DriftedResource{
+			{
+				APIVersion:     "etcd.database.coreos.com/v1beta2",
+				Kind:           "EtcdCluster",
+				Namespace:      operatorNamespace,
+				Name:           "example",
+				OperandRequest: "ibm-cloudpak/ibm-cloudpak-name",
+				Paths:          []string{"size"},
+			},
+		}))
+	})
+
+	It("Should skip the custom resources failed to be checked", func() {
+		r.Client = failingGetClient{Client: c, kind: "Jenkins"}
+		report, err := r.DriftReport(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Resources).Should(HaveLen(1))
+		Expect(report.Resources[0].Kind).Should(Equal("EtcdCluster"))
+		Expect(report.Errors).Should(ConsistOf(ContainSubstring("Kind: Jenkins")))
+	})
+
+	It("Should serve the drift report in JSON", func() {
+		recorder := httptest.NewRecorder()
+		r.DriftReportHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, DriftReportPath, nil))
+		Expect(recorder.Code).Should(Equal(http.StatusOK))
+
+		report := &DriftReport{}
+		Expect(json.Unmarshal(recorder.Body.Bytes(), report)).Should(Succeed())
+		Expect(report.Resources).Should(HaveLen(1))
+		Expect(report.Resources[0].Kind).Should(Equal("EtcdCluster"))
+
+		By("Serving the last report until the interval passes")
+		fakeClock := clock.NewFakeClock(time.Now())
+		r.Clock = fakeClock
+		handler := r.DriftReportHandler()
+		get := func() *DriftReport {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, DriftReportPath, nil))
+			Expect(recorder.Code).Should(Equal(http.StatusOK))
+			report := &DriftReport{}
+			Expect(json.Unmarshal(recorder.Body.Bytes(), report)).Should(Succeed())
+			return report
+		}
+		Expect(get().Resources).Should(HaveLen(1))
+		cluster := managedCR("etcd.database.coreos.com/v1beta2", "EtcdCluster", nil)
+		Expect(c.Delete(ctx, cluster)).Should(Succeed())
+		Expect(get().Resources).Should(HaveLen(1))
+		fakeClock.Step(DriftReportInterval)
+		Expect(get().Resources).Should(BeEmpty())
+
+		By("Rejecting the other methods")
+		recorder = httptest.NewRecorder()
+		r.DriftReportHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, DriftReportPath, nil))
+		Expect(recorder.Code).Should(Equal(http.StatusMethodNotAllowed))
+	})
+})
+
+// failingGetClient fails to get the custom resources of the kind as if their CRD isn't installed
+type failingGetClient struct {
+	client.Client
+	kind string
+}
+
+func (c failingGetClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if obj.GetObjectKind().GroupVersionKind().Kind == c.kind {
+		return &meta.NoKindMatchError{GroupKind: obj.GetObjectKind().GroupVersionKind().GroupKind()}
+	}
+	return c.Client.Get(ctx, key, obj)
+}
@@ -0,0 +1,278 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	constant "github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	util "github.com/IBM/operand-deployment-lifecycle-manager/controllers/util"
+)
+
+// isDryRun returns true if the OperandRequest only reports the changes of its custom resources
+func isDryRun(requestInstance *operatorv1alpha1.OperandRequest) bool {
+	return requestInstance.GetAnnotations()[constant.DryRunAnnotation] == "true"
+}
+
+// dryRunCRwithConfig returns the changes reconcileCRwithConfig would apply to the custom resources,
+// nothing is written to the cluster
+func (r *Reconciler) dryRunCRwithConfig(ctx context.Context, service *operatorv1alpha1.ConfigService, namespace string, csv *olmv1alpha1.ClusterServiceVersion) ([]operatorv1alpha1.DryRunChange, error) {
+	almExamples := csv.GetAnnotations()["alm-examples"]
+
+	var almExampleList []interface{}
+	if err := json.Unmarshal([]byte(almExamples), &almExampleList); err != nil {
+		return nil, errors.Wrapf(err, "failed to convert alm-examples in the Subscription %s/%s to slice", namespace, service.Name)
+	}
+
+	service, err := r.applyOverrides(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	service, err = r.resolveKeyRefs(ctx, service, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	merr := &util.MultiErr{}
+	var changes []operatorv1alpha1.DryRunChange
+	// The named instances are created from the first alm-example of their kind
+	instancesReconciled := make(map[schema.GroupVersionKind]bool)
+	for _, almExample := range almExampleList {
+		var crFromALM unstructured.Unstructured
+		crFromALM.Object = almExample.(map[string]interface{})
+
+		name := crFromALM.GetName()
+		specFromALM, ok := crFromALM.Object["spec"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		gvk := crFromALM.GroupVersionKind()
+		if !service.ManagesCR(gvk, name) {
+			continue
+		}
+
+		apiVersion, err := r.servedAPIVersion(crFromALM.GetAPIVersion(), crFromALM.GetKind())
+		if err != nil {
+			merr.Add(err)
+			continue
+		}
+
+		if !instancesReconciled[gvk] {
+			instancesReconciled[gvk] = true
+			instanceChanges, err := r.dryRunCRInstances(ctx, specFromALM, gvk, apiVersion, service, namespace)
+			if err != nil {
+				merr.Add(err)
+			}
+			changes = append(changes, instanceChanges...)
+		}
+
+		existingCR := unstructured.Unstructured{}
+		existingCR.SetAPIVersion(apiVersion)
+		existingCR.SetKind(crFromALM.GetKind())
+
+		_, crConfig, configured := service.GetCRSpec(gvk)
+		change := operatorv1alpha1.DryRunChange{
+			APIVersion: apiVersion,
+			Kind:       crFromALM.GetKind(),
+			Name:       name,
+			Namespace:  namespace,
+		}
+
+		err = r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &existingCR)
+		if err != nil && !apierrors.IsNotFound(err) {
+			merr.Add(errors.Wrapf(err, "failed to get the custom resource %s/%s", namespace, name))
+			continue
+		} else if apierrors.IsNotFound(err) {
+			if !configured {
+				continue
+			}
+			specFromALMRaw, _ := json.Marshal(specFromALM)
+			mergedSpecRaw, _ := json.Marshal(mergeCRSpec(specFromALMRaw, crConfig.Raw, service.GetMergeStrategy(gvk)))
+			change.Action = operatorv1alpha1.DryRunCreate
+			change.Fields = util.DiffCR(specFromALMRaw, mergedSpecRaw)
+		} else {
+			if !checkLabel(existingCR, map[string]string{constant.OpreqLabel: "true"}) {
+				klog.V(2).Info("Skip the custom resource not created by ODLM")
+				continue
+			}
+			if !configured {
+				change.Action = operatorv1alpha1.DryRunDelete
+			} else {
+				fields, err := updatedFields(existingCR, specFromALM, crConfig.Raw, service.GetMergeStrategy(gvk), service.IgnoredSpecPaths)
+				if err != nil {
+					merr.Add(err)
+					continue
+				}
+				if len(fields) == 0 {
+					continue
+				}
+				change.Action = operatorv1alpha1.DryRunUpdate
+				change.Fields = fields
+			}
+		}
+		changes = append(changes, change)
+	}
+	if len(merr.Errors) != 0 {
+		return nil, merr
+	}
+	return changes, nil
+}
+
+// dryRunCRInstances returns the changes reconcileCRInstances would apply to the named instances of the custom resource
+func (r *Reconciler) dryRunCRInstances(ctx context.Context, specFromALM map[string]interface{}, gvk schema.GroupVersionKind, apiVersion string, service *operatorv1alpha1.ConfigService, namespace string) ([]operatorv1alpha1.DryRunChange, error) {
+	instances := service.GetCRInstances(gvk)
+	mergeStrategy := service.GetMergeStrategy(gvk)
+
+	merr := &util.MultiErr{}
+	var changes []operatorv1alpha1.DryRunChange
+	for instance, key := range instances {
+		existingCR := unstructured.Unstructured{}
+		existingCR.SetAPIVersion(apiVersion)
+		existingCR.SetKind(gvk.Kind)
+		change := operatorv1alpha1.DryRunChange{
+			APIVersion: apiVersion,
+			Kind:       gvk.Kind,
+			Name:       instance,
+			Namespace:  namespace,
+		}
+
+		err := r.Client.Get(ctx, types.NamespacedName{Name: instance, Namespace: namespace}, &existingCR)
+		if err != nil && !apierrors.IsNotFound(err) {
+			merr.Add(errors.Wrapf(err, "failed to get the custom resource %s/%s", namespace, instance))
+			continue
+		} else if apierrors.IsNotFound(err) {
+			specFromALMRaw, _ := json.Marshal(specFromALM)
+			mergedSpecRaw, _ := json.Marshal(mergeCRSpec(specFromALMRaw, service.Spec[key].Raw, mergeStrategy))
+			change.Action = operatorv1alpha1.DryRunCreate
+			change.Fields = util.DiffCR(specFromALMRaw, mergedSpecRaw)
+			changes = append(changes, change)
+			continue
+		}
+		if !checkLabel(existingCR, map[string]string{constant.OpreqLabel: "true"}) {
+			klog.V(2).Info("Skip the custom resource not created by ODLM")
+			continue
+		}
+		fields, err := updatedFields(existingCR, specFromALM, service.Spec[key].Raw, mergeStrategy, service.IgnoredSpecPaths)
+		if err != nil {
+			merr.Add(err)
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		change.Action = operatorv1alpha1.DryRunUpdate
+		change.Fields = fields
+		changes = append(changes, change)
+	}
+
+	// The instances removed from the service would be deleted
+	existingInstances, err := r.listCRInstances(ctx, schema.FromAPIVersionAndKind(apiVersion, gvk.Kind), service.Name, namespace)
+	if err != nil {
+		merr.Add(err)
+	}
+	for _, cr := range existingInstances {
+		if _, found := instances[cr.GetName()]; found {
+			continue
+		}
+		changes = append(changes, operatorv1alpha1.DryRunChange{
+			APIVersion: apiVersion,
+			Kind:       gvk.Kind,
+			Name:       cr.GetName(),
+			Namespace:  namespace,
+			Action:     operatorv1alpha1.DryRunDelete,
+		})
+	}
+
+	if len(merr.Errors) != 0 {
+		return changes, merr
+	}
+	return changes, nil
+}
+
+// dryRunCRwithRequest returns the change reconcileCRwithRequest would apply to the custom resource,
+// nil if the custom resource is up to date
+func (r *Reconciler) dryRunCRwithRequest(ctx context.Context, operand operatorv1alpha1.Operand, requestKey types.NamespacedName, index int) (*operatorv1alpha1.DryRunChange, error) {
+	if operand.APIVersion == "" || operand.Kind == "" {
+		return nil, errors.Errorf("the APIVersion or the Kind of operand is empty for operator %s", operand.Name)
+	}
+
+	name := requestCRName(requestKey.Name, operand, index)
+	apiVersion, err := r.servedAPIVersion(operand.APIVersion, operand.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	existingCR := unstructured.Unstructured{}
+	existingCR.SetAPIVersion(apiVersion)
+	existingCR.SetKind(operand.Kind)
+
+	change := &operatorv1alpha1.DryRunChange{
+		APIVersion: apiVersion,
+		Kind:       operand.Kind,
+		Name:       name,
+		Namespace:  requestKey.Namespace,
+	}
+
+	err = r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: requestKey.Namespace}, &existingCR)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "failed to get custom resource %s/%s", requestKey.Namespace, name)
+	} else if apierrors.IsNotFound(err) {
+		mergedSpecRaw, _ := json.Marshal(util.MergeCR(nil, operand.Spec.Raw))
+		change.Action = operatorv1alpha1.DryRunCreate
+		change.Fields = util.DiffCR(nil, mergedSpecRaw)
+		return change, nil
+	}
+
+	if !checkLabel(existingCR, map[string]string{constant.OpreqLabel: "true"}) {
+		klog.V(2).Info("Skip the custom resource not created by ODLM")
+		return nil, nil
+	}
+	fields, err := updatedFields(existingCR, map[string]interface{}{}, operand.Spec.Raw, operatorv1alpha1.MergeStrategyMerge, nil)
+	if err != nil || len(fields) == 0 {
+		return nil, err
+	}
+	change.Action = operatorv1alpha1.DryRunUpdate
+	change.Fields = fields
+	return change, nil
+}
+
+// updatedFields returns the fields of the spec updateCustomResource would change in the existing custom resource
+func updatedFields(existingCR unstructured.Unstructured, specFromALM map[string]interface{}, crConfig []byte, mergeStrategy operatorv1alpha1.MergeStrategy, ignoredPaths []string) ([]string, error) {
+	updatedCRSpec, err := desiredCRSpec(specFromALM, existingCR.Object["spec"], crConfig, mergeStrategy)
+	if err != nil {
+		return nil, err
+	}
+	keepIgnoredSpecPaths(updatedCRSpec, existingCR.Object["spec"], ignoredPaths)
+	if reflect.DeepEqual(existingCR.Object["spec"], updatedCRSpec) {
+		return nil, nil
+	}
+	existingCRRaw, _ := json.Marshal(existingCR.Object["spec"])
+	updatedCRRaw, _ := json.Marshal(updatedCRSpec)
+	return util.DiffCR(existingCRRaw, updatedCRRaw), nil
+}
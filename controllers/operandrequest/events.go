@@ -0,0 +1,162 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+)
+
+// crEvent describes the events of an action on the custom resources
+type crEvent struct {
+	verb         string
+	done         string
+	reason       string
+	failedReason string
+	result       operatorv1alpha1.OperandAction
+}
+
+// The actions on the custom resources recorded by the events
+var (
+	crCreate   = crEvent{verb: "create", done: "Created", reason: "CreatedCustomResource", failedReason: "CreateCustomResourceFailed", result: operatorv1alpha1.OperandCreated}
+	crUpdate   = crEvent{verb: "update", done: "Updated", reason: "UpdatedCustomResource", failedReason: "UpdateCustomResourceFailed", result: operatorv1alpha1.OperandUpdated}
+	crRecreate = crEvent{verb: "recreate", done: "Recreated", reason: "UpdatedCustomResource", failedReason: "UpdateCustomResourceFailed", result: operatorv1alpha1.OperandUpdated}
+	crDelete   = crEvent{verb: "delete", done: "Deleted", reason: "DeletedCustomResource", failedReason: "DeleteCustomResourceFailed", result: operatorv1alpha1.OperandDeleted}
+)
+
+type eventObjectKey struct{}
+
+// withEventObject returns a context recording the events of the custom resources on the OperandRequest
+func withEventObject(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest) context.Context {
+	return context.WithValue(ctx, eventObjectKey{}, requestInstance)
+}
+
+// recordCREvent records the event of the action on the custom resource on the OperandRequest of the context,
+// it is a Warning with the error when err isn't nil
+func (r *Reconciler) recordCREvent(ctx context.Context, action crEvent, kind, namespace, name string, err error) {
+	recordOperandResult(ctx, action, kind, namespace, name, err)
+	requestInstance, _ := ctx.Value(eventObjectKey{}).(*operatorv1alpha1.OperandRequest)
+	if requestInstance == nil || r.Recorder == nil {
+		return
+	}
+	if namespace != "" {
+		name = namespace + "/" + name
+	}
+	if err != nil {
+		r.Recorder.Eventf(requestInstance, corev1.EventTypeWarning, action.failedReason, "Failed to %s %s %s: %v", action.verb, kind, name, err)
+		return
+	}
+	r.Recorder.Eventf(requestInstance, corev1.EventTypeNormal, action.reason, "%s %s %s", action.done, kind, name)
+}
+
+type operandResultsKey struct{}
+
+type operandNameKey struct{}
+
+// operandResults collects the actions taken on the custom resources of the operands during a reconcile
+type operandResults struct {
+	mu      sync.Mutex
+	results []operatorv1alpha1.OperandResult
+}
+
+// withOperandResults returns a context collecting the actions taken on the custom resources
+func withOperandResults(ctx context.Context) (context.Context, *operandResults) {
+	results := &operandResults{}
+	return context.WithValue(ctx, operandResultsKey{}, results), results
+}
+
+// withOperandName returns a context recording the actions taken on the custom resources for the operand
+func withOperandName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, operandNameKey{}, name)
+}
+
+// recordOperandResult records the action on the custom resource for the operand of the context,
+// it is Failed with the error when err isn't nil
+func recordOperandResult(ctx context.Context, action crEvent, kind, namespace, name string, err error) {
+	results, _ := ctx.Value(operandResultsKey{}).(*operandResults)
+	operandName, _ := ctx.Value(operandNameKey{}).(string)
+	if results == nil || operandName == "" {
+		return
+	}
+	result := operatorv1alpha1.OperandResult{
+		Name:              operandName,
+		Action:            action.result,
+		Kind:              kind,
+		ResourceName:      name,
+		ResourceNamespace: namespace,
+		Reason:            action.reason,
+		Message:           fmt.Sprintf("%s %s", action.done, kind),
+	}
+	if err != nil {
+		result.Action = operatorv1alpha1.OperandFailed
+		result.Reason = action.failedReason
+		result.Message = fmt.Sprintf("Failed to %s %s: %v", action.verb, kind, err)
+	}
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	results.results = append(results.results, result)
+}
+
+// recordOperandFailure records the failure of the operand of the context before any custom resource is changed
+func recordOperandFailure(ctx context.Context, reason string, err error) {
+	results, _ := ctx.Value(operandResultsKey{}).(*operandResults)
+	operandName, _ := ctx.Value(operandNameKey{}).(string)
+	if results == nil || operandName == "" {
+		return
+	}
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	results.results = append(results.results, operatorv1alpha1.OperandResult{
+		Name:    operandName,
+		Action:  operatorv1alpha1.OperandFailed,
+		Reason:  reason,
+		Message: err.Error(),
+	})
+}
+
+// list returns the collected results, and a Skipped result for every member of the OperandRequest without any,
+// whose reason is the phase of its operand, or OperatorNotReady before the operator is running
+func (c *operandResults) list(requestInstance *operatorv1alpha1.OperandRequest) []operatorv1alpha1.OperandResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	results := append([]operatorv1alpha1.OperandResult{}, c.results...)
+	acted := make(map[string]bool)
+	for _, result := range c.results {
+		acted[result.Name] = true
+	}
+	for _, member := range requestInstance.Status.Members {
+		if acted[member.Name] {
+			continue
+		}
+		reason := string(member.Phase.OperandPhase)
+		if reason == "" {
+			reason = "OperatorNotReady"
+		}
+		results = append(results, operatorv1alpha1.OperandResult{
+			Name:    member.Name,
+			Action:  operatorv1alpha1.OperandSkipped,
+			Reason:  reason,
+			Message: "No custom resource was changed",
+		})
+	}
+	return results
+}
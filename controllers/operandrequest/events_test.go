@@ -0,0 +1,128 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Recording the events of the custom resources", func() {
+	const namespace = "ibm-operators"
+
+	var (
+		ctx      context.Context
+		r        *Reconciler
+		recorder *record.FakeRecorder
+	)
+
+	etcdCluster := func() unstructured.Unstructured {
+		cr := unstructured.Unstructured{}
+		cr.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+		cr.SetKind("EtcdCluster")
+		cr.SetName("example")
+		cr.Object["spec"] = map[string]interface{}{"size": int64(1)}
+		return cr
+	}
+
+	BeforeEach(func() {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+		recorder = record.NewFakeRecorder(10)
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   fake.NewClientBuilder().WithScheme(s).Build(),
+				Recorder: recorder,
+			},
+		}
+		request := testutil.OperandRequestObj("common-service", "ibm-common-services", "ibm-cloudpak-name", "ibm-cloudpak")
+		ctx = withEventObject(context.Background(), request)
+	})
+
+	It("Should record the custom resources created, updated and deleted", func() {
+		Expect(r.createCustomResource(ctx, etcdCluster(), namespace, "etcdCluster", []byte(`{"size": 1}`), []byte(`{"size": 1}`), operatorv1alpha1.MergeStrategyMerge)).Should(Succeed())
+		Expect(recorder.Events).Should(Receive(Equal("Normal CreatedCustomResource Created EtcdCluster ibm-operators/example")))
+
+		Expect(r.updateCustomResource(ctx, etcdCluster(), namespace, "etcdCluster", []byte(`{"size": 3}`), nil,
+			operatorv1alpha1.UpdateStrategyPatch, operatorv1alpha1.MergeStrategyMerge, nil, metav1.DeletePropagationBackground)).Should(Succeed())
+		Expect(recorder.Events).Should(Receive(Equal("Normal UpdatedCustomResource Updated EtcdCluster ibm-operators/example")))
+
+		Expect(r.deleteCustomResource(ctx, etcdCluster(), namespace, metav1.DeletePropagationBackground)).Should(Succeed())
+		Expect(recorder.Events).Should(Receive(Equal("Normal DeletedCustomResource Deleted EtcdCluster ibm-operators/example")))
+	})
+
+	It("Should record the custom resources failed to be updated", func() {
+		Expect(r.updateCustomResource(ctx, etcdCluster(), namespace, "etcdCluster", []byte(`{"size": 3}`), nil,
+			operatorv1alpha1.UpdateStrategyPatch, operatorv1alpha1.MergeStrategyMerge, nil, metav1.DeletePropagationBackground)).ShouldNot(Succeed())
+		Expect(recorder.Events).Should(Receive(HavePrefix("Warning UpdateCustomResourceFailed Failed to update EtcdCluster ibm-operators/example")))
+	})
+
+	It("Should not record the custom resources already existing", func() {
+		Expect(r.createCustomResource(ctx, etcdCluster(), namespace, "etcdCluster", nil, nil, operatorv1alpha1.MergeStrategyMerge)).Should(Succeed())
+		Expect(recorder.Events).Should(Receive())
+		Expect(r.createCustomResource(ctx, etcdCluster(), namespace, "etcdCluster", nil, nil, operatorv1alpha1.MergeStrategyMerge)).Should(Succeed())
+		Expect(recorder.Events).ShouldNot(Receive())
+	})
+
+	It("Should report the actions taken on the custom resources of the operands", func() {
+		ctx, results := withOperandResults(ctx)
+		etcdCtx := withOperandName(ctx, "etcd")
+		Expect(r.createCustomResource(etcdCtx, etcdCluster(), namespace, "etcdCluster", []byte(`{"size": 1}`), []byte(`{"size": 1}`), operatorv1alpha1.MergeStrategyMerge)).Should(Succeed())
+		Expect(r.updateCustomResource(etcdCtx, etcdCluster(), namespace, "etcdCluster", []byte(`{"size": 3}`), nil,
+			operatorv1alpha1.UpdateStrategyPatch, operatorv1alpha1.MergeStrategyMerge, nil, metav1.DeletePropagationBackground)).Should(Succeed())
+		backup := etcdCluster()
+		backup.SetName("backup")
+		Expect(r.updateCustomResource(etcdCtx, backup, namespace, "etcdCluster", []byte(`{"size": 3}`), nil,
+			operatorv1alpha1.UpdateStrategyPatch, operatorv1alpha1.MergeStrategyMerge, nil, metav1.DeletePropagationBackground)).ShouldNot(Succeed())
+		Expect(r.deleteCustomResource(withOperandName(ctx, "etcd-backup"), etcdCluster(), namespace, metav1.DeletePropagationBackground)).Should(Succeed())
+
+		request := testutil.OperandRequestObj("common-service", "ibm-common-services", "ibm-cloudpak-name", "ibm-cloudpak")
+		request.Status.Members = []operatorv1alpha1.MemberStatus{
+			{Name: "etcd", Phase: operatorv1alpha1.MemberPhase{OperandPhase: operatorv1alpha1.ServiceRunning}},
+			{Name: "jenkins", Phase: operatorv1alpha1.MemberPhase{OperandPhase: operatorv1alpha1.ServiceWaitingForDependencies}},
+			{Name: "mongodb", Phase: operatorv1alpha1.MemberPhase{OperatorPhase: operatorv1alpha1.OperatorInstalling}},
+		}
+		type result struct {
+			name, action, kind, resourceName, reason string
+		}
+		var actual []result
+		for _, res := range results.list(request) {
+			actual = append(actual, result{res.Name, string(res.Action), res.Kind, res.ResourceName, res.Reason})
+		}
+		Expect(actual).Should(Equal([]result{
+			{"etcd", "Created", "EtcdCluster", "example", "CreatedCustomResource"},
+			{"etcd", "Updated", "EtcdCluster", "example", "UpdatedCustomResource"},
+			{"etcd", "Failed", "EtcdCluster", "backup", "UpdateCustomResourceFailed"},
+			{"etcd-backup", "Deleted", "EtcdCluster", "example", "DeletedCustomResource"},
+			{"jenkins", "Skipped", "", "", "WaitingForDependencies"},
+			{"mongodb", "Skipped", "", "", "OperatorNotReady"},
+		}))
+	})
+})
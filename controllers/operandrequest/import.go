@@ -0,0 +1,156 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+)
+
+// ImportSubscriptions drafts the OperandRegistry, the OperandConfig and the OperandRequest <namespace>/<name> for the
+// Subscriptions not managed by ODLM, so a cluster with manually created Subscriptions can adopt ODLM.
+// The services of the OperandConfig are derived from the alm-examples of the installed ClusterServiceVersions.
+// It only reads from the cluster.
+func (r *Reconciler) ImportSubscriptions(ctx context.Context, key types.NamespacedName) (*Bundle, error) {
+	subList := &olmv1alpha1.SubscriptionList{}
+	if err := r.Reader.List(ctx, subList); err != nil {
+		return nil, errors.Wrap(err, "failed to list Subscriptions")
+	}
+	subs := subList.Items
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].Namespace != subs[j].Namespace {
+			return subs[i].Namespace < subs[j].Namespace
+		}
+		return subs[i].Name < subs[j].Name
+	})
+
+	registryInstance := operatorv1alpha1.OperandRegistry{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}
+	configInstance := operatorv1alpha1.OperandConfig{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}
+	request := operatorv1alpha1.Request{Registry: key.Name, RegistryNamespace: key.Namespace}
+	bundle := &Bundle{ClusterServiceVersions: make(map[string]string)}
+
+	names := make(map[string]bool)
+	for _, sub := range subs {
+		if _, ok := sub.Labels[constant.OpreqLabel]; ok {
+			klog.V(2).Infof("Skip importing the Subscription %s/%s managed by ODLM", sub.Namespace, sub.Name)
+			continue
+		}
+		if sub.Spec == nil {
+			continue
+		}
+		// The operand names are unique in the OperandRegistry
+		name := sub.Name
+		if names[name] {
+			name = sub.Name + "-" + sub.Namespace
+		}
+		names[name] = true
+
+		opt := operatorv1alpha1.Operator{
+			Name:                name,
+			Namespace:           sub.Namespace,
+			SourceName:          sub.Spec.CatalogSource,
+			SourceNamespace:     sub.Spec.CatalogSourceNamespace,
+			PackageName:         sub.Spec.Package,
+			Channel:             sub.Spec.Channel,
+			InstallPlanApproval: sub.Spec.InstallPlanApproval,
+			StartingCSV:         sub.Spec.StartingCSV,
+		}
+		if sub.Namespace == constant.ClusterOperatorNamespace {
+			opt.InstallMode = operatorv1alpha1.InstallModeCluster
+		}
+		registryInstance.Spec.Operators = append(registryInstance.Spec.Operators, opt)
+		request.Operands = append(request.Operands, operatorv1alpha1.Operand{Name: name})
+
+		csvName := sub.Status.InstalledCSV
+		if csvName == "" {
+			klog.Warningf("Subscription %s/%s has no installed ClusterServiceVersion, no service is drafted for it", sub.Namespace, sub.Name)
+			continue
+		}
+		bundle.ClusterServiceVersions[name] = csvName
+		csv := &olmv1alpha1.ClusterServiceVersion{}
+		if err := r.Reader.Get(ctx, types.NamespacedName{Name: csvName, Namespace: sub.Namespace}, csv); err != nil {
+			if apierrors.IsNotFound(err) {
+				klog.Warningf("ClusterServiceVersion %s/%s of the Subscription %s is not found, no service is drafted for it", sub.Namespace, csvName, sub.Name)
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to get the ClusterServiceVersion %s/%s", sub.Namespace, csvName)
+		}
+		service, err := draftConfigService(name, csv)
+		if err != nil {
+			return nil, err
+		}
+		configInstance.Spec.Services = append(configInstance.Spec.Services, *service)
+	}
+
+	bundle.Request = &operatorv1alpha1.OperandRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+		Spec:       operatorv1alpha1.OperandRequestSpec{Requests: []operatorv1alpha1.Request{request}},
+	}
+	bundle.Registries = []operatorv1alpha1.OperandRegistry{registryInstance}
+	bundle.Configs = []operatorv1alpha1.OperandConfig{configInstance}
+	return bundle, nil
+}
+
+// draftConfigService drafts the service of the operand with the spec of the custom resources in the alm-examples,
+// the first example of each kind is used
+func draftConfigService(name string, csv *olmv1alpha1.ClusterServiceVersion) (*operatorv1alpha1.ConfigService, error) {
+	service := &operatorv1alpha1.ConfigService{Name: name, Spec: make(map[string]runtime.RawExtension)}
+	almExamples := csv.GetAnnotations()["alm-examples"]
+	if almExamples == "" {
+		return service, nil
+	}
+	var almExampleList []interface{}
+	if err := json.Unmarshal([]byte(almExamples), &almExampleList); err != nil {
+		return nil, errors.Wrapf(err, "failed to convert alm-examples in the ClusterServiceVersion %s/%s to slice", csv.Namespace, csv.Name)
+	}
+	for _, almExample := range almExampleList {
+		example, ok := almExample.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		cr := unstructured.Unstructured{Object: example}
+		spec, ok := cr.Object["spec"].(map[string]interface{})
+		kind := cr.GetKind()
+		if !ok || kind == "" {
+			continue
+		}
+		key := strings.ToLower(kind[:1]) + kind[1:]
+		if _, ok := service.Spec[key]; ok {
+			continue
+		}
+		raw, err := json.Marshal(spec)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal the spec of the %s example in the ClusterServiceVersion %s/%s", kind, csv.Namespace, csv.Name)
+		}
+		service.Spec[key] = runtime.RawExtension{Raw: raw}
+	}
+	return service, nil
+}
@@ -0,0 +1,153 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Importing the Subscriptions", func() {
+	const (
+		importName        = "imported"
+		importNamespace   = "ibm-common-services"
+		operatorNamespace = "ibm-operators"
+	)
+
+	var (
+		ctx context.Context
+		r   *Reconciler
+		c   client.Client
+	)
+
+	manualSubscription := func(name, namespace string) *olmv1alpha1.Subscription {
+		sub := testutil.Subscription(name, namespace)
+		sub.Labels = nil
+		return sub
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		s := runtime.NewScheme()
+		Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+		Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+
+		etcdSub := manualSubscription("etcd", operatorNamespace)
+		etcdSub.Spec.InstallPlanApproval = olmv1alpha1.ApprovalManual
+		etcdSub.Status = testutil.SubscriptionStatus("etcd", operatorNamespace, "0.0.1")
+		etcdCSV := testutil.ClusterServiceVersion("etcd-csv.v0.0.1", operatorNamespace, testutil.EtcdExample)
+		// The jenkins operator is installed in AllNamespaces mode, and is still being installed
+		jenkinsSub := manualSubscription("jenkins-operator", constant.ClusterOperatorNamespace)
+		jenkinsSub.Status.CurrentCSV = "jenkins-csv.v0.0.2"
+		// The subscription created by ODLM is left out
+		managedSub := testutil.Subscription("mongodb", operatorNamespace)
+
+		objects := []client.Object{etcdSub, etcdCSV, jenkinsSub, managedSub}
+		c = fake.NewClientBuilder().WithScheme(s).WithObjects(objects...).Build()
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client: c,
+				Reader: c,
+			},
+		}
+	})
+
+	It("Should draft the ODLM objects of the manually created Subscriptions", func() {
+		bundle, err := r.ImportSubscriptions(ctx, types.NamespacedName{Name: importName, Namespace: importNamespace})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(bundle.Registries).Should(HaveLen(1))
+		Expect(bundle.Registries[0].Name).Should(Equal(importName))
+		Expect(bundle.Registries[0].Namespace).Should(Equal(importNamespace))
+		Expect(bundle.Registries[0].Spec.Operators).Should(Equal([]operatorv1alpha1.Operator{
+			{
+				Name:            "etcd",
+				Namespace:       operatorNamespace,
+				SourceName:      "community-operators",
+				SourceNamespace: "openshift-marketplace",
+				PackageName:     "etcd",
+				Channel:         "alpha",
+				// The manual approval of the Subscription is kept
+				InstallPlanApproval: olmv1alpha1.ApprovalManual,
+			},
+			{
+				Name:            "jenkins-operator",
+				Namespace:       constant.ClusterOperatorNamespace,
+				InstallMode:     operatorv1alpha1.InstallModeCluster,
+				SourceName:      "community-operators",
+				SourceNamespace: "openshift-marketplace",
+				PackageName:     "jenkins-operator",
+				Channel:         "alpha",
+			},
+		}))
+
+		By("Deriving the services from the alm-examples of the installed ClusterServiceVersions")
+		Expect(bundle.Configs).Should(HaveLen(1))
+		Expect(bundle.Configs[0].Spec.Services).Should(HaveLen(1))
+		service := bundle.Configs[0].Spec.Services[0]
+		Expect(service.Name).Should(Equal("etcd"))
+		Expect(service.Spec).Should(HaveKey("etcdCluster"))
+		Expect(service.Spec["etcdCluster"].Raw).Should(MatchJSON(`{"size": 3, "version": "3.2.13"}`))
+		Expect(bundle.ClusterServiceVersions).Should(Equal(map[string]string{"etcd": "etcd-csv.v0.0.1"}))
+
+		By("Requesting all the imported operands")
+		Expect(bundle.Request.Name).Should(Equal(importName))
+		Expect(bundle.Request.Spec.Requests).Should(Equal([]operatorv1alpha1.Request{
+			{
+				Registry:          importName,
+				RegistryNamespace: importNamespace,
+				Operands:          []operatorv1alpha1.Operand{{Name: "etcd"}, {Name: "jenkins-operator"}},
+			},
+		}))
+	})
+
+	It("Should render the drafts as a multi-document manifest without changing the cluster", func() {
+		bundle, err := r.ImportSubscriptions(ctx, types.NamespacedName{Name: importName, Namespace: importNamespace})
+		Expect(err).NotTo(HaveOccurred())
+		manifest, err := bundle.Manifest()
+		Expect(err).NotTo(HaveOccurred())
+
+		var kinds []string
+		for _, document := range bytes.Split(manifest, []byte("\n---\n"))[1:] {
+			obj := map[string]interface{}{}
+			Expect(yaml.Unmarshal(document, &obj)).Should(Succeed())
+			kinds = append(kinds, obj["kind"].(string))
+		}
+		Expect(kinds).Should(Equal([]string{"OperandRequest", "OperandRegistry", "OperandConfig"}))
+
+		registryList := &operatorv1alpha1.OperandRegistryList{}
+		Expect(c.List(ctx, registryList)).Should(Succeed())
+		Expect(registryList.Items).Should(BeEmpty())
+		sub := &olmv1alpha1.Subscription{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "etcd", Namespace: operatorNamespace}, sub)).Should(Succeed())
+		Expect(sub.Labels).ShouldNot(HaveKey(constant.OpreqLabel))
+	})
+})
@@ -30,8 +30,13 @@ import (
 	"github.com/pkg/errors"
 	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/discovery"
 	"k8s.io/klog"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -42,16 +47,76 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/audit"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/clusterversion"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/metrics"
 	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/ratelimit"
 )
 
 // Reconciler reconciles a OperandRequest object
 type Reconciler struct {
 	*deploy.ODLMOperator
 	StepSize int
-	Mutex    sync.Mutex
+	// SuspendUpgrades pins the installed operators to manual approval, so that their upgrades are withheld
+	SuspendUpgrades bool
+	// NamespaceLimiter limits the rate of the reconciles per namespace, nil means no limit
+	NamespaceLimiter *ratelimit.NamespaceLimiter
+	// DebounceWindow coalesces the updates of an OperandRequest received within the window into one reconcile,
+	// 0 means every update is reconciled
+	DebounceWindow time.Duration
+	// KeepFailedCRs keeps the custom resources failed to be created in ConfigMaps for inspection
+	KeepFailedCRs bool
+	// ApplyDefaults creates the custom resources of a service without spec straight from the alm-examples
+	ApplyDefaults bool
+	// RefreshEvents are the OperandRequests to be reconciled immediately
+	RefreshEvents <-chan event.GenericEvent
+	// ClusterVersionDetector detects the cluster version to select the OperandConfig overrides
+	ClusterVersionDetector clusterversion.Detector
+	// Discovery finds the apiVersions served for the custom resources to migrate them, nil means no migration
+	Discovery discovery.DiscoveryInterface
+	// AccessReviewer checks the permissions before creating the custom resources, it defaults to SelfSubjectAccessReviews
+	AccessReviewer AccessReviewer
+	// FinalizerPolicy decides whether the deletion is blocked until the clean up succeeds, it is strict by default
+	FinalizerPolicy string
+	// FinalizerTimeout is how long the best-effort finalizer retries the clean up before giving up
+	FinalizerTimeout time.Duration
+	// InstallTimeout is how long an OperandRequest may take to be Running before it is marked Failed,
+	// it can be overridden by the OperandRequest, 0 means no timeout
+	InstallTimeout time.Duration
+	// CSVWaitTimeout is how long an operand waits for the ClusterServiceVersion of its Subscription
+	// to be resolved before it is marked Failed, 0 means no timeout
+	CSVWaitTimeout time.Duration
+	// RetryBudget is the number of the failed reconciles in a row before an OperandRequest isn't retried
+	// until its spec is changed, it can be overridden by the OperandRequest, 0 means no budget
+	RetryBudget int32
+	// PhasePrecedence is the order the phases of the members take precedence in the phase of the OperandRequest,
+	// nil means the default precedence
+	PhasePrecedence []operatorv1alpha1.ClusterPhase
+	// Clock checks the install timeout, it defaults to the real clock
+	Clock clock.Clock
+	// DeletionPropagation is the propagation policy to delete the custom resources, it is Background by default,
+	// and it can be overridden by the OperandConfig service
+	DeletionPropagation metav1.DeletionPropagation
+	// PhaseMetrics exports the phases of the OperandRequests and their operators, nil means no metrics
+	PhaseMetrics *metrics.PhaseCollector
+	// Summary aggregates the phases of the OperandRequests, nil means no summary
+	Summary *RequestSummary
+	Mutex   sync.Mutex
 }
+
+const (
+	// FinalizerPolicyStrict blocks the deletion of the OperandRequest until the clean up succeeds
+	FinalizerPolicyStrict = "strict"
+	// FinalizerPolicyBestEffort removes the finalizer anyway once the clean up keeps failing for the FinalizerTimeout
+	FinalizerPolicyBestEffort = "best-effort"
+	// DefaultFinalizerTimeout is the default FinalizerTimeout
+	DefaultFinalizerTimeout = 5 * time.Minute
+	// DefaultCSVWaitTimeout is the default CSVWaitTimeout
+	DefaultCSVWaitTimeout = 10 * time.Minute
+)
+
 type clusterObjects struct {
 	namespace     *corev1.Namespace
 	operatorGroup *olmv1.OperatorGroup
@@ -64,17 +129,71 @@ type clusterObjects struct {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reconcileErr error) {
+	// Record the OperandRequest as the owner of the audited mutations
+	ctx = audit.WithRequest(ctx, "OperandRequest", req.NamespacedName)
+
+	// Throttle the namespaces flooding the queue, so that they don't monopolize the workers
+	if r.NamespaceLimiter != nil {
+		if delay := r.NamespaceLimiter.When(req.Namespace); delay > 0 {
+			klog.V(2).Infof("Rate limited the reconcile of OperandRequest %s, retry after %v", req.NamespacedName, delay)
+			return ctrl.Result{RequeueAfter: delay}, nil
+		}
+	}
+
 	// Fetch the OperandRequest instance
 	requestInstance := &operatorv1alpha1.OperandRequest{}
 	if err := r.Client.Get(ctx, req.NamespacedName, requestInstance); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.PhaseMetrics.DeleteRequest(req.NamespacedName)
+			r.Summary.DeleteRequest(req.NamespacedName)
+		}
 		// Error reading the object - requeue the request.
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
 	originalInstance := requestInstance.DeepCopy()
 
+	// Export the phases and summarize them once the status is patched, until the finalizer is removed
+	released := false
+	defer func() {
+		if released {
+			r.PhaseMetrics.DeleteRequest(req.NamespacedName)
+			r.Summary.DeleteRequest(req.NamespacedName)
+			return
+		}
+		r.PhaseMetrics.SetRequest(requestInstance)
+		r.Summary.SetRequest(requestInstance)
+	}()
+
+	// Record the events of the custom resources on the OperandRequest
+	ctx = withEventObject(ctx, requestInstance)
+
+	// The retry budget is only exhausted for the generation of the spec it is counted for
+	budget := r.retryBudget(requestInstance)
+	exhausted := budget > 0 && requestInstance.Status.ObservedGeneration == requestInstance.Generation &&
+		requestInstance.Status.RetryCount >= budget
+
 	// Always attempt to patch the status after each reconciliation.
 	defer func() {
+		// The status of a paused OperandRequest is left as it is
+		if isPaused(requestInstance) && requestInstance.DeletionTimestamp.IsZero() {
+			return
+		}
+		if requestInstance.DeletionTimestamp.IsZero() {
+			r.checkInstallTimeout(requestInstance)
+		}
+		// A change of the spec resets the retry budget
+		if requestInstance.Status.ObservedGeneration != requestInstance.Generation {
+			requestInstance.Status.ObservedGeneration = requestInstance.Generation
+			requestInstance.Status.RetryCount = 0
+		}
+		// Count the failed reconciles in a row to spot the OperandRequests stuck in retries
+		if reconcileErr != nil {
+			requestInstance.Status.RetryCount++
+		} else if !exhausted {
+			requestInstance.Status.RetryCount = 0
+		}
+		requestInstance.SetRetryBudgetExhaustedCondition(budget, budget > 0 && requestInstance.Status.RetryCount >= budget)
 		if reflect.DeepEqual(originalInstance.Status, requestInstance.Status) {
 			return
 		}
@@ -86,8 +205,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 	// Remove finalizer when DeletionTimestamp none zero
 	if !requestInstance.ObjectMeta.DeletionTimestamp.IsZero() {
 
+		// Surface the teardown in the status before cleaning up
+		if requestInstance.Status.Phase != operatorv1alpha1.ClusterPhaseDeleting {
+			requestInstance.SetClusterPhase(operatorv1alpha1.ClusterPhaseDeleting)
+			if err := r.Client.Status().Patch(ctx, requestInstance, client.MergeFrom(originalInstance)); err != nil {
+				klog.Errorf("failed to set the Deleting phase for OperandRequest %s: %v", req.NamespacedName.String(), err)
+				return ctrl.Result{}, client.IgnoreNotFound(err)
+			}
+			originalInstance = requestInstance.DeepCopy()
+		}
+
 		// Check and clean up the subscriptions
-		err := r.checkFinalizer(ctx, requestInstance)
+		err := r.cleanupOnDeletion(ctx, requestInstance)
 		if err != nil {
 			klog.Errorf("failed to clean up the subscriptions for OperandRequest %s: %v", req.NamespacedName.String(), err)
 			return ctrl.Result{}, err
@@ -102,6 +231,20 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 				return ctrl.Result{}, client.IgnoreNotFound(err)
 			}
 		}
+		released = true
+		return ctrl.Result{}, nil
+	}
+
+	// A paused OperandRequest doesn't touch its operators and operands,
+	// so the subscriptions shared with the other OperandRequests are left untouched as well
+	if isPaused(requestInstance) {
+		klog.V(1).Infof("OperandRequest %s is paused, skip reconciling it", req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	// Stop retrying the OperandRequest once it exhausted the retry budget, until its spec is changed
+	if exhausted {
+		klog.Warningf("OperandRequest %s failed %d reconciles in a row, skip reconciling it until its spec is changed", req.NamespacedName, requestInstance.Status.RetryCount)
 		return ctrl.Result{}, nil
 	}
 
@@ -134,14 +277,33 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 		return ctrl.Result{Requeue: true}, err
 	}
 
+	// Report the actions taken on the operands during the reconcile
+	ctx, results := withOperandResults(ctx)
+	defer func() {
+		requestInstance.SetOperandResults(results.list(requestInstance), metav1.NewTime(r.clock().Now()))
+	}()
+
+	// Delete the operators to reinstall, they are recreated by reconcileOperator
+	if err := r.reinstallOperands(ctx, requestInstance); err != nil {
+		klog.Errorf("failed to reinstall the operands for OperandRequest %s: %v", req.NamespacedName.String(), err)
+		return ctrl.Result{}, err
+	}
+
+	// The operands beyond the operand quota of the namespace are neither installed nor created
+	rejected, err := r.rejectedOperands(ctx, requestInstance)
+	if err != nil {
+		klog.Errorf("failed to check the operand quota for OperandRequest %s: %v", req.NamespacedName.String(), err)
+		return ctrl.Result{}, err
+	}
+
 	// Reconcile Operators
-	if err := r.reconcileOperator(ctx, requestInstance); err != nil {
+	if err := r.reconcileOperator(ctx, requestInstance, rejected); err != nil {
 		klog.Errorf("failed to reconcile Operators for OperandRequest %s: %v", req.NamespacedName.String(), err)
 		return ctrl.Result{}, err
 	}
 
 	// Reconcile Operands
-	if merr := r.reconcileOperand(ctx, requestInstance); len(merr.Errors) != 0 {
+	if merr := r.reconcileOperand(ctx, requestInstance, rejected); len(merr.Errors) != 0 {
 		klog.Errorf("failed to reconcile Operands for OperandRequest %s: %v", req.NamespacedName.String(), merr)
 		return ctrl.Result{}, merr
 	}
@@ -156,6 +318,50 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 	return ctrl.Result{RequeueAfter: constant.DefaultSyncPeriod}, nil
 }
 
+// isPaused returns true if the reconcile of the OperandRequest is paused
+func isPaused(requestInstance *operatorv1alpha1.OperandRequest) bool {
+	return requestInstance.GetAnnotations()[constant.PausedAnnotation] == "true"
+}
+
+// retryBudget returns the retry budget of the OperandRequest, 0 means no budget
+func (r *Reconciler) retryBudget(requestInstance *operatorv1alpha1.OperandRequest) int32 {
+	if requestInstance.Spec.RetryBudget != nil {
+		return *requestInstance.Spec.RetryBudget
+	}
+	return r.RetryBudget
+}
+
+// checkInstallTimeout marks the OperandRequest Failed when it isn't Running within its install timeout,
+// the time waiting for the manual approval of an InstallPlan isn't counted
+func (r *Reconciler) checkInstallTimeout(requestInstance *operatorv1alpha1.OperandRequest) {
+	if requestInstance.Status.Phase == operatorv1alpha1.ClusterPhaseRunning || requestInstance.Status.Phase == operatorv1alpha1.ClusterPhaseWaitingForApproval {
+		requestInstance.Status.InstallStartTime = nil
+		requestInstance.SetRequestInstallTimeoutCondition(0, false)
+		return
+	}
+	now := r.clock().Now()
+	if requestInstance.Status.InstallStartTime == nil {
+		requestInstance.Status.InstallStartTime = &metav1.Time{Time: now}
+	}
+	timeout := r.InstallTimeout
+	if requestInstance.Spec.InstallTimeout != nil {
+		timeout = requestInstance.Spec.InstallTimeout.Duration
+	}
+	timedOut := timeout > 0 && now.Sub(requestInstance.Status.InstallStartTime.Time) >= timeout
+	requestInstance.SetRequestInstallTimeoutCondition(timeout, timedOut)
+	if timedOut {
+		klog.Warningf("OperandRequest %s/%s isn't Running within the install timeout %v", requestInstance.Namespace, requestInstance.Name, timeout)
+		requestInstance.SetClusterPhase(operatorv1alpha1.ClusterPhaseFailed)
+	}
+}
+
+func (r *Reconciler) clock() clock.Clock {
+	if r.Clock != nil {
+		return r.Clock
+	}
+	return clock.RealClock{}
+}
+
 func (r *Reconciler) checkPermission(ctx context.Context, req ctrl.Request) bool {
 	// Check update permission
 	if !r.checkUpdateAuth(ctx, req.Namespace, "operator.ibm.com", "operandrequests") {
@@ -189,6 +395,66 @@ func (r *Reconciler) checkUpdateAuth(ctx context.Context, namespace, group, reso
 	return sar.Status.Allowed
 }
 
+// AccessReviewer reviews whether ODLM is allowed to access the resources
+type AccessReviewer interface {
+	Review(ctx context.Context, attributes *authorizationv1.ResourceAttributes) (bool, error)
+}
+
+// selfSubjectAccessReviewer reviews the access of ODLM with SelfSubjectAccessReviews
+type selfSubjectAccessReviewer struct {
+	client.Client
+}
+
+func (r selfSubjectAccessReviewer) Review(ctx context.Context, attributes *authorizationv1.ResourceAttributes) (bool, error) {
+	sar := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: attributes,
+		},
+	}
+	if err := r.Create(ctx, sar); err != nil {
+		return false, err
+	}
+	klog.V(3).Infof("Operator %s permission of %s in namespace %s, Allowed: %t, Denied: %t, Reason: %s", attributes.Verb, attributes.Resource, attributes.Namespace, sar.Status.Allowed, sar.Status.Denied, sar.Status.Reason)
+	return sar.Status.Allowed, nil
+}
+
+func (r *Reconciler) accessReviewer() AccessReviewer {
+	if r.AccessReviewer != nil {
+		return r.AccessReviewer
+	}
+	return selfSubjectAccessReviewer{Client: r.Client}
+}
+
+// checkCreatePermissions is the preflight checking if ODLM is allowed to create the kinds of custom resources.
+// The missing permissions are recorded in the OperandRequest instead of failing the creation repeatedly.
+func (r *Reconciler) checkCreatePermissions(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest, operandName string, gvks []schema.GroupVersionKind, namespace string) (bool, error) {
+	allowed := true
+	for _, gvk := range gvks {
+		mapping, err := r.Client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			// The CRD may not be established yet, the creation reports it
+			klog.V(2).Infof("Skip checking the permissions of %s: %v", gvk.String(), err)
+			continue
+		}
+		attributes := &authorizationv1.ResourceAttributes{
+			Namespace: namespace,
+			Verb:      "create",
+			Group:     gvk.Group,
+			Resource:  mapping.Resource.Resource,
+		}
+		ok, err := r.accessReviewer().Review(ctx, attributes)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to review the permission to create %s in the namespace %s", mapping.Resource.GroupResource().String(), namespace)
+		}
+		requestInstance.SetInsufficientPermissionsCondition(operandName, attributes.Verb, mapping.Resource.GroupResource().String(), namespace, !ok, &r.Mutex)
+		if !ok {
+			klog.Warningf("ODLM is not allowed to create %s in the namespace %s for the operand %s", mapping.Resource.GroupResource().String(), namespace, operandName)
+			allowed = false
+		}
+	}
+	return allowed, nil
+}
+
 func (r *Reconciler) addFinalizer(ctx context.Context, cr *operatorv1alpha1.OperandRequest) (bool, error) {
 	if cr.GetDeletionTimestamp() == nil {
 		originalReq := cr.DeepCopy()
@@ -205,6 +471,25 @@ func (r *Reconciler) addFinalizer(ctx context.Context, cr *operatorv1alpha1.Oper
 	return true, nil
 }
 
+// cleanupOnDeletion cleans up the resources of the OperandRequest being deleted,
+// the best-effort policy ignores the failure once the FinalizerTimeout expires
+func (r *Reconciler) cleanupOnDeletion(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest) error {
+	err := r.checkFinalizer(ctx, requestInstance)
+	if err == nil || r.FinalizerPolicy != FinalizerPolicyBestEffort {
+		return err
+	}
+	timeout := r.FinalizerTimeout
+	if timeout <= 0 {
+		timeout = DefaultFinalizerTimeout
+	}
+	if elapsed := time.Since(requestInstance.GetDeletionTimestamp().Time); elapsed < timeout {
+		return errors.Wrapf(err, "failed to clean up after %v, retry until %v", elapsed.Round(time.Second), timeout)
+	}
+	klog.Warningf("Giving up cleaning up OperandRequest %s in the namespace %s after %v: %v", requestInstance.Name, requestInstance.Namespace, timeout, err)
+	r.Recorder.Eventf(requestInstance, corev1.EventTypeWarning, "CleanupFailed", "Removed the finalizer without cleaning up after %v: %v", timeout, err)
+	return nil
+}
+
 func (r *Reconciler) checkFinalizer(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest) error {
 	klog.V(1).Infof("Deleting OperandRequest %s in the namespace %s", requestInstance.Name, requestInstance.Namespace)
 	existingSub := &olmv1alpha1.SubscriptionList{}
@@ -232,15 +517,31 @@ func (r *Reconciler) getRegistryToRequestMapper() handler.MapFunc {
 		requestList, _ := r.ListOperandRequestsByRegistry(ctx, types.NamespacedName{Namespace: object.GetNamespace(), Name: object.GetName()})
 
 		requests := []ctrl.Request{}
+		// The OperandRequests in the status of the OperandRegistry have created subscriptions
+		// from it, they are reconciled even if they don't reference it anymore
+		if registry, ok := object.(*operatorv1alpha1.OperandRegistry); ok {
+			requests = registry.GetAllReconcileRequest()
+		}
 		for _, request := range requestList {
 			namespaceName := types.NamespacedName{Name: request.Name, Namespace: request.Namespace}
 			req := ctrl.Request{NamespacedName: namespaceName}
-			requests = append(requests, req)
+			if !containsRequest(requests, req) {
+				requests = append(requests, req)
+			}
 		}
 		return requests
 	}
 }
 
+func containsRequest(requests []ctrl.Request, req ctrl.Request) bool {
+	for _, r := range requests {
+		if r == req {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Reconciler) getSubToRequestMapper() handler.MapFunc {
 	return func(object client.Object) []ctrl.Request {
 		reg, _ := regexp.Compile(`^(.*)\.(.*)\/request`)
@@ -282,8 +583,18 @@ func (r *Reconciler) getConfigToRequestMapper() handler.MapFunc {
 
 // SetupWithManager adds OperandRequest controller to the manager.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&operatorv1alpha1.OperandRequest{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+	// Reconcile the OperandRequest once its annotations change, e.g. the removal of its operands is confirmed,
+	// or a value rendered into the OperandConfig is updated
+	requestPredicate := predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{})
+	forPredicates := []predicate.Predicate{requestPredicate}
+	if r.DebounceWindow > 0 {
+		// The updates are enqueued by the debounced watch below
+		forPredicates = append(forPredicates, predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool { return false },
+		})
+	}
+	ctrlBuilder := ctrl.NewControllerManagedBy(mgr).
+		For(&operatorv1alpha1.OperandRequest{}, builder.WithPredicates(forPredicates...)).
 		Watches(&source.Kind{Type: &olmv1alpha1.Subscription{}}, handler.EnqueueRequestsFromMapFunc(r.getSubToRequestMapper()), builder.WithPredicates(predicate.Funcs{
 			UpdateFunc: func(e event.UpdateEvent) bool {
 				oldObject := e.ObjectOld.(*olmv1alpha1.Subscription)
@@ -296,9 +607,9 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 		})).
 		Watches(&source.Kind{Type: &operatorv1alpha1.OperandRegistry{}}, handler.EnqueueRequestsFromMapFunc(r.getRegistryToRequestMapper()), builder.WithPredicates(predicate.Funcs{
 			UpdateFunc: func(e event.UpdateEvent) bool {
-				oldObject := e.ObjectOld.(*operatorv1alpha1.OperandRegistry)
-				newObject := e.ObjectNew.(*operatorv1alpha1.OperandRegistry)
-				return !reflect.DeepEqual(oldObject.Spec, newObject.Spec)
+				// The generation is only increased by the changes of the spec,
+				// e.g. the channel, install mode or namespace of the operators
+				return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration()
 			},
 			DeleteFunc: func(e event.DeleteEvent) bool {
 				// Evaluates to false if the object has been confirmed deleted.
@@ -315,5 +626,16 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 				newObject := e.ObjectNew.(*operatorv1alpha1.OperandConfig)
 				return !reflect.DeepEqual(oldObject.Spec, newObject.Spec)
 			},
-		})).Complete(r)
+		}))
+	if r.DebounceWindow > 0 {
+		ctrlBuilder = ctrlBuilder.Watches(&source.Kind{Type: &operatorv1alpha1.OperandRequest{}}, ratelimit.NewDebounceHandler(r.DebounceWindow), builder.WithPredicates(requestPredicate, predicate.Funcs{
+			CreateFunc:  func(e event.CreateEvent) bool { return false },
+			DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+			GenericFunc: func(e event.GenericEvent) bool { return false },
+		}))
+	}
+	if r.RefreshEvents != nil {
+		ctrlBuilder = ctrlBuilder.Watches(&source.Channel{Source: r.RefreshEvents}, &handler.EnqueueRequestForObject{})
+	}
+	return ctrlBuilder.Complete(r)
 }
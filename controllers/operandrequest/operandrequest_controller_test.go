@@ -18,6 +18,9 @@ package operandrequest
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
 
 	"crypto/sha256"
 	"encoding/hex"
@@ -26,10 +29,22 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/ratelimit"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
 )
 
@@ -370,3 +385,376 @@ var _ = Describe("OperandRegistry controller", func() {
 		})
 	})
 })
+
+var _ = Describe("OperandRequest rate limiting", func() {
+	It("Should not block the reconciles of a namespace while another one floods the queue", func() {
+		ctx := context.Background()
+		r := &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client: k8sClient,
+				Reader: k8sClient,
+			},
+			NamespaceLimiter: ratelimit.NewNamespaceLimiter(1, 3),
+		}
+		floodingRequest := func(i int) ctrl.Request {
+			return ctrl.Request{NamespacedName: types.NamespacedName{Name: fmt.Sprintf("request-%d", i), Namespace: "ibm-flooding"}}
+		}
+
+		By("Flooding the reconciles from one namespace")
+		throttled := 0
+		for i := 0; i < 20; i++ {
+			result, err := r.Reconcile(ctx, floodingRequest(i))
+			Expect(err).NotTo(HaveOccurred())
+			if result.RequeueAfter > 0 {
+				throttled++
+			}
+		}
+		Expect(throttled).Should(BeNumerically(">=", 15))
+
+		By("Reconciling the OperandRequest from another namespace")
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "request", Namespace: "ibm-quiet"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).Should(BeZero())
+	})
+})
+
+var _ = Describe("OperandRequest finalizer", func() {
+	const (
+		name      = "finalizing-request"
+		namespace = "ibm-finalizing"
+	)
+
+	var (
+		ctx context.Context
+		key types.NamespacedName
+	)
+
+	// newReconciler builds a reconciler whose clean up always fails
+	newReconciler := func(policy string, deletedFor time.Duration) *Reconciler {
+		s := runtime.NewScheme()
+		Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+		Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+		request := &operatorv1alpha1.OperandRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-deletedFor)},
+			},
+		}
+		request.EnsureFinalizer()
+		c := &failingListClient{Client: fake.NewClientBuilder().WithScheme(s).WithObjects(request).Build()}
+		return &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Recorder: record.NewFakeRecorder(10),
+			},
+			FinalizerPolicy:  policy,
+			FinalizerTimeout: time.Minute,
+		}
+	}
+
+	hasFinalizer := func(r *Reconciler) bool {
+		request := &operatorv1alpha1.OperandRequest{}
+		Expect(r.Client.Get(ctx, key, request)).Should(Succeed())
+		return len(request.GetFinalizers()) > 0
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		key = types.NamespacedName{Name: name, Namespace: namespace}
+	})
+
+	It("Should block the deletion until the clean up succeeds with the strict policy", func() {
+		r := newReconciler(FinalizerPolicyStrict, time.Hour)
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).Should(HaveOccurred())
+		Expect(hasFinalizer(r)).Should(BeTrue())
+	})
+
+	It("Should retry the failed clean up before the timeout with the best-effort policy", func() {
+		r := newReconciler(FinalizerPolicyBestEffort, 0)
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).Should(HaveOccurred())
+		Expect(hasFinalizer(r)).Should(BeTrue())
+	})
+
+	It("Should remove the finalizer after the timeout with the best-effort policy", func() {
+		r := newReconciler(FinalizerPolicyBestEffort, time.Hour)
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hasFinalizer(r)).Should(BeFalse())
+		Expect(r.Recorder.(*record.FakeRecorder).Events).Should(Receive(ContainSubstring("CleanupFailed")))
+	})
+})
+
+var _ = Describe("OperandRequest retry count", func() {
+	It("Should count the failed reconciles and reset the count on success", func() {
+		ctx := context.Background()
+		key := types.NamespacedName{Name: "retrying-request", Namespace: "ibm-retrying"}
+		s := runtime.NewScheme()
+		Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+		Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+		request := &operatorv1alpha1.OperandRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              key.Name,
+				Namespace:         key.Namespace,
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+		}
+		request.EnsureFinalizer()
+		c := &failingListClient{Client: fake.NewClientBuilder().WithScheme(s).WithObjects(request).Build()}
+		r := &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client: c,
+				Reader: c,
+			},
+		}
+		retryCount := func() int32 {
+			request := &operatorv1alpha1.OperandRequest{}
+			Expect(c.Get(ctx, key, request)).Should(Succeed())
+			return request.Status.RetryCount
+		}
+
+		By("Failing to clean up the OperandRequest")
+		for i := 1; i <= 3; i++ {
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			Expect(err).Should(HaveOccurred())
+			Expect(retryCount()).Should(Equal(int32(i)))
+		}
+
+		By("Cleaning up the OperandRequest")
+		r.Client = c.Client
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(retryCount()).Should(BeZero())
+	})
+})
+
+var _ = Describe("OperandRequest retry budget", func() {
+	It("Should stop retrying once the budget is exhausted and reset the budget on a spec change", func() {
+		ctx := context.Background()
+		key := types.NamespacedName{Name: "ibm-cloudpak-name", Namespace: "ibm-cloudpak"}
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+		Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+
+		// The OperandRegistry is missing, so that every reconcile fails
+		request := testutil.OperandRequestObj("common-service", "ibm-common-services", key.Name, key.Namespace)
+		request.Generation = 1
+		budget := int32(2)
+		request.Spec.RetryBudget = &budget
+		request.UpdateLabels()
+		request.InitRequestStatus()
+		request.EnsureFinalizer()
+		c := &allowedAccessClient{Client: fake.NewClientBuilder().WithScheme(s).WithObjects(request, testutil.NamespaceObj(key.Namespace)).Build()}
+		r := &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Recorder: record.NewFakeRecorder(10),
+			},
+		}
+		getRequest := func() *operatorv1alpha1.OperandRequest {
+			request := &operatorv1alpha1.OperandRequest{}
+			Expect(c.Get(ctx, key, request)).Should(Succeed())
+			return request
+		}
+		exhausted := func() bool {
+			for _, c := range getRequest().Status.Conditions {
+				if c.Type == operatorv1alpha1.ConditionRetryBudgetExhausted {
+					return true
+				}
+			}
+			return false
+		}
+
+		By("Failing the reconciles within the budget")
+		for i := 1; i <= 2; i++ {
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			Expect(err).Should(HaveOccurred())
+			Expect(getRequest().Status.RetryCount).Should(Equal(int32(i)))
+		}
+		Expect(exhausted()).Should(BeTrue())
+
+		By("Skipping the reconcile once the budget is exhausted")
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).Should(Equal(ctrl.Result{}))
+		Expect(getRequest().Status.RetryCount).Should(Equal(int32(2)))
+		Expect(exhausted()).Should(BeTrue())
+
+		By("Resetting the budget once the spec is changed")
+		request = getRequest()
+		request.Generation = 2
+		Expect(c.Update(ctx, request)).Should(Succeed())
+		_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).Should(HaveOccurred())
+		Expect(getRequest().Status.RetryCount).Should(Equal(int32(1)))
+		Expect(getRequest().Status.ObservedGeneration).Should(Equal(int64(2)))
+		Expect(exhausted()).Should(BeFalse())
+	})
+})
+
+var _ = Describe("OperandRequest install timeout", func() {
+	It("Should mark the OperandRequest Failed once it isn't Running within the timeout", func() {
+		fakeClock := clock.NewFakeClock(time.Now())
+		r := &Reconciler{InstallTimeout: 10 * time.Minute, Clock: fakeClock}
+		request := testutil.OperandRequestObj("common-service", "ibm-common-services", "ibm-cloudpak-name", "ibm-cloudpak")
+		timedOut := func() bool {
+			for _, c := range request.Status.Conditions {
+				if c.Type == operatorv1alpha1.ConditionRequestInstallTimeout {
+					return true
+				}
+			}
+			return false
+		}
+
+		By("Starting to wait for the OperandRequest to be Running")
+		request.SetClusterPhase(operatorv1alpha1.ClusterPhaseInstalling)
+		r.checkInstallTimeout(request)
+		Expect(request.Status.InstallStartTime).ShouldNot(BeNil())
+		Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseInstalling))
+
+		By("Keeping the phase before the timeout")
+		fakeClock.Step(9 * time.Minute)
+		r.checkInstallTimeout(request)
+		Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseInstalling))
+		Expect(timedOut()).Should(BeFalse())
+
+		By("Marking the OperandRequest Failed after the timeout")
+		fakeClock.Step(2 * time.Minute)
+		r.checkInstallTimeout(request)
+		Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseFailed))
+		Expect(timedOut()).Should(BeTrue())
+
+		By("Overriding the timeout in the OperandRequest")
+		request.Spec.InstallTimeout = &metav1.Duration{Duration: 30 * time.Minute}
+		request.SetClusterPhase(operatorv1alpha1.ClusterPhaseInstalling)
+		r.checkInstallTimeout(request)
+		Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseInstalling))
+		Expect(timedOut()).Should(BeFalse())
+
+		By("Resetting the timeout once the OperandRequest is Running")
+		request.SetClusterPhase(operatorv1alpha1.ClusterPhaseRunning)
+		r.checkInstallTimeout(request)
+		Expect(request.Status.InstallStartTime).Should(BeNil())
+		fakeClock.Step(time.Hour)
+		request.SetClusterPhase(operatorv1alpha1.ClusterPhaseUpdating)
+		r.checkInstallTimeout(request)
+		Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseUpdating))
+		Expect(timedOut()).Should(BeFalse())
+
+		By("Not timing out while waiting for the approval of an InstallPlan")
+		request.SetClusterPhase(operatorv1alpha1.ClusterPhaseWaitingForApproval)
+		fakeClock.Step(time.Hour)
+		r.checkInstallTimeout(request)
+		Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseWaitingForApproval))
+		Expect(request.Status.InstallStartTime).Should(BeNil())
+		Expect(timedOut()).Should(BeFalse())
+	})
+})
+
+// failingListClient fails listing the resources to simulate a failed clean up
+type failingListClient struct {
+	client.Client
+}
+
+func (c *failingListClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return fmt.Errorf("failed to list %T", list)
+}
+
+// allowedAccessClient allows all the access reviews of ODLM
+type allowedAccessClient struct {
+	client.Client
+}
+
+func (c *allowedAccessClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if sar, ok := obj.(*authorizationv1.SelfSubjectAccessReview); ok {
+		sar.Status.Allowed = true
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+var _ = Describe("Pausing an OperandRequest", func() {
+	const (
+		registryName, registryNamespace = "common-service", "ibm-common-services"
+		operatorNamespace               = "ibm-operators"
+		requestName                     = "ibm-cloudpak-name"
+		pausedNamespace                 = "ibm-paused"
+		activeNamespace                 = "ibm-active"
+	)
+
+	It("Should leave the subscription shared with an active OperandRequest unaffected", func() {
+		ctx := context.Background()
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+		Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+
+		// Both OperandRequests share the subscription of etcd
+		sub := testutil.Subscription("etcd", operatorNamespace)
+		sub.Annotations = map[string]string{
+			registryNamespace + "." + registryName + "/registry": "true",
+			registryNamespace + "." + registryName + "/config":   "true",
+			pausedNamespace + "." + requestName + "/request":     "true",
+			activeNamespace + "." + requestName + "/request":     "true",
+			constant.OperandRequestsAnnotation:                   activeNamespace + "/" + requestName + "," + pausedNamespace + "/" + requestName,
+		}
+		sub.Status = testutil.SubscriptionStatus("etcd", operatorNamespace, "0.0.1")
+
+		// The paused OperandRequest drops etcd, which is only applied once it is resumed
+		paused := testutil.OperandRequestObj(registryName, registryNamespace, requestName, pausedNamespace)
+		paused.Annotations = map[string]string{constant.PausedAnnotation: "true"}
+		paused.Spec.Requests[0].Operands = paused.Spec.Requests[0].Operands[1:]
+		paused.EnsureFinalizer()
+		paused.InitRequestStatus()
+		paused.SetMemberStatus("etcd", operatorv1alpha1.OperatorRunning, operatorv1alpha1.ServiceRunning, &sync.Mutex{})
+		active := testutil.OperandRequestObj(registryName, registryNamespace, requestName, activeNamespace)
+		active.EnsureFinalizer()
+		active.InitRequestStatus()
+
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+			testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespace),
+			testutil.OperandConfigObj(registryName, registryNamespace),
+			testutil.NamespaceObj(pausedNamespace), testutil.NamespaceObj(activeNamespace),
+			sub, paused, active,
+		).Build()
+		r := &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Recorder: record.NewFakeRecorder(100),
+			},
+		}
+		getSub := func() *olmv1alpha1.Subscription {
+			sub := &olmv1alpha1.Subscription{}
+			Expect(c.Get(ctx, types.NamespacedName{Name: "etcd", Namespace: operatorNamespace}, sub)).Should(Succeed())
+			return sub
+		}
+		originalSub := getSub()
+
+		By("Reconciling the paused OperandRequest")
+		pausedKey := types.NamespacedName{Name: requestName, Namespace: pausedNamespace}
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: pausedKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).Should(Equal(ctrl.Result{}))
+
+		By("Checking the subscription and the status of the paused OperandRequest are unchanged")
+		Expect(getSub().ResourceVersion).Should(Equal(originalSub.ResourceVersion))
+		pausedRequest := &operatorv1alpha1.OperandRequest{}
+		Expect(c.Get(ctx, pausedKey, pausedRequest)).Should(Succeed())
+		Expect(pausedRequest.Status).Should(Equal(paused.Status))
+
+		By("Reconciling the operators of the active OperandRequest")
+		activeRequest := &operatorv1alpha1.OperandRequest{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: requestName, Namespace: activeNamespace}, activeRequest)).Should(Succeed())
+		Expect(r.reconcileOperator(ctx, activeRequest, nil)).Should(Succeed())
+
+		By("Checking the subscription still references the paused OperandRequest")
+		Expect(getSub().Annotations).Should(HaveKeyWithValue(pausedNamespace+"."+requestName+"/request", "true"))
+		Expect(getSub().Annotations).Should(HaveKeyWithValue(activeNamespace+"."+requestName+"/request", "true"))
+	})
+})
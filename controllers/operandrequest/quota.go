@@ -0,0 +1,93 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+)
+
+// operandQuota returns the number of operands the OperandRequests of a namespace may install,
+// it is negative when the namespace has no operand quota.
+func (r *Reconciler) operandQuota(ctx context.Context, namespace string) (int, error) {
+	ns := &corev1.Namespace{}
+	if err := r.Reader.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return 0, errors.Wrapf(err, "failed to get the namespace %s", namespace)
+	}
+	value, ok := ns.Annotations[constant.OperandQuotaAnnotation]
+	if !ok {
+		return -1, nil
+	}
+	quota, err := strconv.Atoi(value)
+	if err != nil || quota < 0 {
+		klog.Warningf("Ignore the invalid operand quota %q of the namespace %s", value, namespace)
+		return -1, nil
+	}
+	return quota, nil
+}
+
+// rejectedOperands returns the operands of the OperandRequest exceeding the operand quota of its namespace.
+// The operands already installed by the OperandRequests of the namespace are grandfathered,
+// even if the quota is lowered afterwards.
+func (r *Reconciler) rejectedOperands(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest) (map[string]bool, error) {
+	quota, err := r.operandQuota(ctx, requestInstance.Namespace)
+	if err != nil || quota < 0 {
+		return nil, err
+	}
+
+	requestList := &operatorv1alpha1.OperandRequestList{}
+	if err := r.Client.List(ctx, requestList, client.InNamespace(requestInstance.Namespace)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list the OperandRequests in the namespace %s", requestInstance.Namespace)
+	}
+	installed := make(map[string]bool)
+	for _, request := range requestList.Items {
+		// The status of the reconciled OperandRequest in memory is the latest
+		if request.Name == requestInstance.Name {
+			continue
+		}
+		for _, m := range request.Status.Members {
+			installed[m.Name] = true
+		}
+	}
+	for _, m := range requestInstance.Status.Members {
+		installed[m.Name] = true
+	}
+
+	rejected := make(map[string]bool)
+	for _, req := range requestInstance.Spec.Requests {
+		for _, operand := range req.Operands {
+			if installed[operand.Name] {
+				continue
+			}
+			if len(installed) < quota {
+				installed[operand.Name] = true
+				continue
+			}
+			rejected[operand.Name] = true
+		}
+	}
+	return rejected, nil
+}
@@ -0,0 +1,121 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+)
+
+// reapplyCustomResources reapplies the custom resources of the service when the schema of their CRD changes,
+// e.g. a later operator version adds a required field, so that the API server validates and defaults them
+// against the new schema. The spec is already merged with the OperandConfig by reconcileCRwithConfig.
+// The hash of the schema is recorded in the annotation of the custom resources.
+func (r *Reconciler) reapplyCustomResources(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest, operandName string, service *operatorv1alpha1.ConfigService, namespace string, csv *olmv1alpha1.ClusterServiceVersion) error {
+	var almExampleList []unstructured.Unstructured
+	if err := json.Unmarshal([]byte(csv.GetAnnotations()["alm-examples"]), &almExampleList); err != nil {
+		return errors.Wrapf(err, "failed to convert alm-examples in the ClusterServiceVersion %s/%s to slice", csv.GetNamespace(), csv.GetName())
+	}
+
+	for _, almExample := range almExampleList {
+		if almExample.Object["spec"] == nil {
+			continue
+		}
+		if _, _, found := service.GetCRSpec(almExample.GroupVersionKind()); !found {
+			continue
+		}
+		apiVersion, err := r.servedAPIVersion(almExample.GetAPIVersion(), almExample.GetKind())
+		if err != nil {
+			return err
+		}
+		cr := &unstructured.Unstructured{}
+		cr.SetAPIVersion(apiVersion)
+		cr.SetKind(almExample.GetKind())
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: almExample.GetName(), Namespace: namespace}, cr); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return errors.Wrapf(err, "failed to get the custom resource %s/%s", namespace, almExample.GetName())
+		}
+		if !checkLabel(*cr, map[string]string{constant.OpreqLabel: "true"}) {
+			continue
+		}
+
+		hash, err := r.crdSchemaHash(ctx, cr.GroupVersionKind())
+		if err != nil {
+			return err
+		}
+		previousHash := cr.GetAnnotations()[constant.CRDSchemaHashAnnotation]
+		if previousHash == hash {
+			continue
+		}
+		annotations := cr.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[constant.CRDSchemaHashAnnotation] = hash
+		cr.SetAnnotations(annotations)
+		if err := r.Client.Update(ctx, cr); err != nil {
+			return errors.Wrapf(err, "failed to reapply the custom resource %s %s/%s", cr.GetKind(), namespace, cr.GetName())
+		}
+		// The custom resources created before the hash was recorded are only annotated
+		if previousHash == "" {
+			continue
+		}
+		klog.Infof("Reapplied the custom resource %s %s/%s after the schema of its CRD changed", cr.GetKind(), namespace, cr.GetName())
+		requestInstance.SetReappliedCondition(operandName, cr.GetKind(), namespace, cr.GetName(), &r.Mutex)
+		r.Recorder.Eventf(requestInstance, corev1.EventTypeNormal, "Reapplied", "Reapplied the %s %s/%s after the schema of its CRD changed", cr.GetKind(), namespace, cr.GetName())
+	}
+	return nil
+}
+
+// crdSchemaHash returns the hash of the versions of the CRD defining the kind, including their schemas
+func (r *Reconciler) crdSchemaHash(ctx context.Context, gvk schema.GroupVersionKind) (string, error) {
+	mapping, err := r.Client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the resource of %s", gvk.String())
+	}
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"})
+	if err := r.Reader.Get(ctx, types.NamespacedName{Name: mapping.Resource.GroupResource().String()}, crd); err != nil {
+		return "", errors.Wrapf(err, "failed to get the CRD %s", mapping.Resource.GroupResource().String())
+	}
+	versions, _, err := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the versions of the CRD %s", crd.GetName())
+	}
+	raw, err := json.Marshal(versions)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal the versions of the CRD %s", crd.GetName())
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:8]), nil
+}
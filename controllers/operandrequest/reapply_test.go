@@ -0,0 +1,176 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/yaml"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+const widgetCRD = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.reapply.ibm.com
+spec:
+  group: reapply.ibm.com
+  names:
+    kind: Widget
+    listKind: WidgetList
+    plural: widgets
+    singular: widget
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              size:
+                type: integer
+`
+
+const widgetExample = `[{"apiVersion": "reapply.ibm.com/v1", "kind": "Widget", "metadata": {"name": "example"}, "spec": {"size": 1}}]`
+
+var _ = Describe("Reapplying the custom resources", func() {
+	var (
+		ctx       context.Context
+		r         *Reconciler
+		namespace string
+	)
+
+	getCRD := func() *unstructured.Unstructured {
+		crd := &unstructured.Unstructured{}
+		crd.SetAPIVersion("apiextensions.k8s.io/v1")
+		crd.SetKind("CustomResourceDefinition")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "widgets.reapply.ibm.com"}, crd)).Should(Succeed())
+		return crd
+	}
+
+	getWidget := func() *unstructured.Unstructured {
+		widget := &unstructured.Unstructured{}
+		widget.SetAPIVersion("reapply.ibm.com/v1")
+		widget.SetKind("Widget")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "example", Namespace: namespace}, widget)).Should(Succeed())
+		return widget
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   k8sClient,
+				Reader:   k8sClient,
+				Recorder: record.NewFakeRecorder(10),
+			},
+		}
+		namespace = testutil.CreateNSName("reapply")
+		Expect(k8sClient.Create(ctx, testutil.NamespaceObj(namespace))).Should(Succeed())
+
+		crd := &unstructured.Unstructured{}
+		Expect(yaml.Unmarshal([]byte(widgetCRD), &crd.Object)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, crd)).Should(Succeed())
+		Eventually(func() bool {
+			conditions, _, _ := unstructured.NestedSlice(getCRD().Object, "status", "conditions")
+			for _, c := range conditions {
+				condition := c.(map[string]interface{})
+				if condition["type"] == "Established" && condition["status"] == "True" {
+					return true
+				}
+			}
+			return false
+		}, testutil.Timeout, testutil.Interval).Should(BeTrue())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, getCRD())).Should(Succeed())
+		Eventually(func() bool {
+			crd := &unstructured.Unstructured{}
+			crd.SetAPIVersion("apiextensions.k8s.io/v1")
+			crd.SetKind("CustomResourceDefinition")
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "widgets.reapply.ibm.com"}, crd)
+			return err != nil
+		}, testutil.Timeout, testutil.Interval).Should(BeTrue())
+	})
+
+	It("Should reapply the custom resources once the CRD schema changes", func() {
+		service := &operatorv1alpha1.ConfigService{
+			Name: "widget",
+			Spec: map[string]runtime.RawExtension{
+				"widget": {Raw: []byte(`{"size": 3}`)},
+			},
+		}
+		csv := testutil.ClusterServiceVersion("widget-csv.v0.0.1", namespace, widgetExample)
+		request := &operatorv1alpha1.OperandRequest{}
+
+		By("Recording the CRD schema of the created custom resource")
+		Expect(r.reconcileCRwithConfig(ctx, service, namespace, csv)).Should(Succeed())
+		Expect(r.reapplyCustomResources(ctx, request, "widget", service, namespace, csv)).Should(Succeed())
+		widget := getWidget()
+		hash := widget.GetAnnotations()[constant.CRDSchemaHashAnnotation]
+		Expect(hash).ShouldNot(BeEmpty())
+		Expect(request.Status.Conditions).Should(BeEmpty())
+
+		By("Skipping the custom resource while the CRD schema is unchanged")
+		Expect(r.reapplyCustomResources(ctx, request, "widget", service, namespace, csv)).Should(Succeed())
+		Expect(getWidget().GetResourceVersion()).Should(Equal(widget.GetResourceVersion()))
+		Expect(request.Status.Conditions).Should(BeEmpty())
+
+		By("Bumping the CRD schema with a defaulted field")
+		crd := getCRD()
+		versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+		Expect(unstructured.SetNestedField(versions[0].(map[string]interface{}), map[string]interface{}{
+			"type":    "string",
+			"default": "standard",
+		}, "schema", "openAPIV3Schema", "properties", "spec", "properties", "tier")).Should(Succeed())
+		Expect(unstructured.SetNestedSlice(crd.Object, versions, "spec", "versions")).Should(Succeed())
+		Expect(k8sClient.Update(ctx, crd)).Should(Succeed())
+
+		By("Reapplying the custom resource")
+		Eventually(func() error {
+			return r.reapplyCustomResources(ctx, request, "widget", service, namespace, csv)
+		}, testutil.Timeout, testutil.Interval).Should(Succeed())
+		widget = getWidget()
+		Expect(widget.GetAnnotations()[constant.CRDSchemaHashAnnotation]).ShouldNot(Equal(hash))
+		tier, _, _ := unstructured.NestedString(widget.Object, "spec", "tier")
+		Expect(tier).Should(Equal("standard"))
+		size, _, _ := unstructured.NestedInt64(widget.Object, "spec", "size")
+		Expect(size).Should(Equal(int64(3)))
+		Expect(request.Status.Conditions).Should(HaveLen(1))
+		Expect(request.Status.Conditions[0].Type).Should(Equal(operatorv1alpha1.ConditionReapplied))
+		Expect(request.Status.Conditions[0].Status).Should(Equal(corev1.ConditionTrue))
+		Expect(request.Status.Conditions[0].Message).Should(ContainSubstring(namespace + "/example"))
+	})
+})
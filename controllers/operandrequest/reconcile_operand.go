@@ -17,6 +17,7 @@
 package operandrequest
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
@@ -28,30 +29,56 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/blang/semver/v4"
 	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/pkg/errors"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
 
 	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
 	constant "github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
 	util "github.com/IBM/operand-deployment-lifecycle-manager/controllers/util"
 )
 
-func (r *Reconciler) reconcileOperand(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest) *util.MultiErr {
+// reconcileOperand creates the custom resources of the OperandRequest, except the operands rejected by the operand quota
+func (r *Reconciler) reconcileOperand(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest, rejected map[string]bool) *util.MultiErr {
 	klog.V(1).Infof("Reconciling Operands for OperandRequest: %s/%s", requestInstance.GetNamespace(), requestInstance.GetName())
 	// Update request status
 	defer func() {
-		requestInstance.UpdateClusterPhase()
+		requestInstance.UpdateClusterPhaseWithPrecedence(r.PhasePrecedence)
 	}()
 
 	merr := &util.MultiErr{}
-	if err := r.checkCustomResource(ctx, requestInstance); err != nil {
-		merr.Add(err)
-		return merr
+	// The custom resources of the removed operands are kept in dry-run mode
+	dryRun := isDryRun(requestInstance)
+	if !dryRun {
+		if err := r.checkCustomResource(ctx, requestInstance); err != nil {
+			merr.Add(err)
+			return merr
+		}
+	}
+	// The operands in a dependency cycle can never be created, they are failed until the cycle is broken
+	cycle := requestInstance.DependencyCycle()
+	requestInstance.SetDependencyCycleCondition(cycle)
+	inCycle := make(map[string]bool)
+	if cycle != nil {
+		klog.Warningf("The dependencies of the operands in the OperandRequest %s/%s form a cycle %s", requestInstance.Namespace, requestInstance.Name, strings.Join(cycle, " -> "))
+		r.Recorder.Eventf(requestInstance, corev1.EventTypeWarning, "DependencyCycle", "The dependencies of the operands form a cycle %s", strings.Join(cycle, " -> "))
+		for _, name := range cycle {
+			inCycle[name] = true
+		}
 	}
 	for _, req := range requestInstance.Spec.Requests {
 		registryKey := requestInstance.GetRegistryKey(req)
@@ -64,6 +91,11 @@ func (r *Reconciler) reconcileOperand(ctx context.Context, requestInstance *oper
 		regNs := registryInstance.ObjectMeta.Namespace
 
 		for i, operand := range req.Operands {
+			// The operands exceeding the operand quota of the namespace are reported by reconcileOperator
+			if rejected[operand.Name] {
+				continue
+			}
+			ctx := withOperandName(ctx, operand.Name)
 
 			opdRegistry := registryInstance.GetOperator(operand.Name)
 			if opdRegistry == nil {
@@ -109,6 +141,8 @@ func (r *Reconciler) reconcileOperand(ctx context.Context, requestInstance *oper
 				continue
 			}
 
+			requestInstance.SetMemberInstallPlanRef(operand.Name, pendingInstallPlanRef(sub), &r.Mutex)
+
 			csv, err := r.GetClusterServiceVersion(ctx, sub)
 
 			// If can't get CSV, requeue the request
@@ -118,12 +152,42 @@ func (r *Reconciler) reconcileOperand(ctx context.Context, requestInstance *oper
 				continue
 			}
 
+			// The CatalogSource is only checked while the operator isn't installed
+			var catalogSourceHealth *operatorv1alpha1.CatalogSourceHealth
+			if csv == nil || csv.Status.Phase != olmv1alpha1.CSVPhaseSucceeded {
+				if catalogSourceHealth, err = r.unhealthyCatalogSource(ctx, sub); err != nil {
+					klog.Warningf("Failed to check the CatalogSource of the Subscription %s/%s: %v", sub.Namespace, sub.Name, err)
+				}
+			}
+			requestInstance.SetMemberCatalogSourceHealth(operand.Name, catalogSourceHealth, &r.Mutex)
+
+			// The operand waits for the ClusterServiceVersion until the CSV wait timeout, the request is requeued meanwhile
+			since := requestInstance.SetWaitingForCSVCondition(operand.Name, sub.Namespace+"/"+sub.Name, csv == nil, &r.Mutex)
 			if csv == nil {
+				if r.CSVWaitTimeout > 0 && r.clock().Since(since) >= r.CSVWaitTimeout {
+					klog.Errorf("The ClusterServiceVersion of the Subscription %s/%s isn't resolved within %v", sub.Namespace, sub.Name, r.CSVWaitTimeout)
+					r.Recorder.Eventf(requestInstance, corev1.EventTypeWarning, "ClusterServiceVersionTimeout", "The ClusterServiceVersion of the Subscription %s/%s of %s isn't resolved within %v", sub.Namespace, sub.Name, operand.Name, r.CSVWaitTimeout)
+					requestInstance.SetMemberStatus(operand.Name, operatorv1alpha1.OperatorFailed, "", &r.Mutex)
+					continue
+				}
 				klog.Warningf("ClusterServiceVersion for the Subscription %s in the namespace %s is not ready yet, retry", operatorName, namespace)
 				requestInstance.SetMemberStatus(operand.Name, operatorv1alpha1.OperatorInstalling, "", &r.Mutex)
 				continue
 			}
 
+			// The custom resources are only created by the ClusterServiceVersion pinned by the operand
+			if operand.StartingCSV != "" {
+				requestInstance.SetCSVMismatchCondition(operand.Name, csv.Name, operand.StartingCSV, &r.Mutex)
+				if csv.Name != operand.StartingCSV {
+					klog.Warningf("The ClusterServiceVersion %s of Subscription %s/%s isn't the pinned %s", csv.Name, namespace, operatorName, operand.StartingCSV)
+					requestInstance.SetMemberStatus(operand.Name, operatorv1alpha1.OperatorFailed, "", &r.Mutex)
+					continue
+				}
+			} else {
+				// Clear the mismatch left by a removed pin
+				requestInstance.SetCSVMismatchCondition(operand.Name, "", "", &r.Mutex)
+			}
+
 			if csv.Status.Phase == olmv1alpha1.CSVPhaseFailed {
 				merr.Add(fmt.Errorf("the ClusterServiceVersion of Subscription %s/%s is Failed", namespace, operatorName))
 				requestInstance.SetMemberStatus(operand.Name, operatorv1alpha1.OperatorFailed, "", &r.Mutex)
@@ -132,38 +196,193 @@ func (r *Reconciler) reconcileOperand(ctx context.Context, requestInstance *oper
 			if csv.Status.Phase != olmv1alpha1.CSVPhaseSucceeded {
 				klog.Errorf("the ClusterServiceVersion of Subscription %s/%s is not Ready", namespace, operatorName)
 				requestInstance.SetMemberStatus(operand.Name, operatorv1alpha1.OperatorInstalling, "", &r.Mutex)
+				// The custom resources aren't created until the controller and the webhooks of the operator are ready
+				if requestInstance.GetMemberOperandPhase(operand.Name, &r.Mutex) != operatorv1alpha1.ServiceRunning {
+					requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceWaitingForOperatorReady, &r.Mutex)
+				}
 				continue
 			}
 
 			klog.V(3).Info("Generating customresource base on ClusterServiceVersion: ", csv.GetName())
 			requestInstance.SetMemberStatus(operand.Name, operatorv1alpha1.OperatorRunning, "", &r.Mutex)
 
+			// The custom resources of the operand are crafted by the user
+			if !operand.IsInstallCR() {
+				klog.V(2).Infof("The custom resources of the operand %s in the OperandRequest %s/%s are managed by the user, Skip creating CR for it", operand.Name, requestInstance.Namespace, requestInstance.Name)
+				requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceUserManaged, &r.Mutex)
+				continue
+			}
+			if !req.IsManageCRs() {
+				klog.V(2).Infof("The request of the operand %s in the OperandRequest %s/%s disables the management of the custom resources, Skip creating CR for it", operand.Name, requestInstance.Namespace, requestInstance.Name)
+				requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceCRManagementDisabled, &r.Mutex)
+				continue
+			}
+
+			if inCycle[operand.Name] {
+				requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceFailed, &r.Mutex)
+				continue
+			}
+			// The operand depending on an operand not requested would wait forever
+			missing := requestInstance.MissingDependencies(operand)
+			requestInstance.SetMissingDependencyCondition(operand.Name, missing, &r.Mutex)
+			if len(missing) != 0 {
+				klog.Warningf("The dependencies %s of the operand %s aren't requested by the OperandRequest %s/%s", strings.Join(missing, ", "), operand.Name, requestInstance.Namespace, requestInstance.Name)
+				r.Recorder.Eventf(requestInstance, corev1.EventTypeWarning, "MissingDependency", "The dependencies %s of the operand %s aren't requested", strings.Join(missing, ", "), operand.Name)
+				requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceFailed, &r.Mutex)
+				continue
+			}
+			// The custom resources aren't created until the dependencies of the operand are Running
+			if requestInstance.GetMemberOperandPhase(operand.Name, &r.Mutex) != operatorv1alpha1.ServiceRunning {
+				if pending := pendingDependencies(requestInstance, operand, &r.Mutex); len(pending) != 0 {
+					klog.V(2).Infof("The operand %s in the OperandRequest %s/%s is waiting for the dependencies %s", operand.Name, requestInstance.Namespace, requestInstance.Name, strings.Join(pending, ", "))
+					requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceWaitingForDependencies, &r.Mutex)
+					continue
+				}
+			}
+
 			// Merge and Generate CR
+			var validationTemplate *batchv1beta1.JobTemplateSpec
+			var validationNamespace string
 			if operand.Kind == "" {
-				configInstance, err := r.GetOperandConfig(ctx, registryKey)
+				configKey := requestInstance.GetConfigKey(req)
+				configInstance, err := r.GetOperandConfig(ctx, configKey)
 				if err != nil {
-					merr.Add(errors.Wrapf(err, "failed to get the OperandConfig %s", registryKey.String()))
+					merr.Add(errors.Wrapf(err, "failed to get the OperandConfig %s", configKey.String()))
 					continue
 				}
 				// Check the requested Service Config if exist in specific OperandConfig
 				opdConfig := configInstance.GetService(operand.Name)
 				if opdConfig == nil {
-					klog.V(2).Infof("There is no service: %s from the OperandConfig instance: %s/%s, Skip creating CR for it", operand.Name, req.RegistryNamespace, req.Registry)
+					klog.V(2).Infof("There is no service: %s from the OperandConfig instance: %s/%s, Skip creating CR for it", operand.Name, configKey.Namespace, configKey.Name)
+					requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceConfigMissing, &r.Mutex)
+					continue
+				}
+				if !opdConfig.IsManageCRs() {
+					klog.V(2).Infof("The service %s in the OperandConfig %s disables the management of the custom resources, Skip creating CR for it", operand.Name, configKey.String())
+					requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceCRManagementDisabled, &r.Mutex)
+					continue
+				}
+				// The spec values are merged as objects, a scalar or an array can't be merged
+				if err := opdConfig.ValidateSpecValues(); err != nil {
+					merr.Add(errors.Wrapf(err, "invalid OperandConfig %s", registryKey.String()))
+					requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceFailed, &r.Mutex)
+					continue
+				}
+				// The invalid alm-examples only fail their own operand
+				if err := util.ValidateALMExamples(csv.GetAnnotations()["alm-examples"]); err != nil {
+					klog.Errorf("Invalid alm-examples in the ClusterServiceVersion %s/%s: %v", csv.Namespace, csv.Name, err)
+					r.Recorder.Eventf(requestInstance, corev1.EventTypeWarning, "InvalidALMExamples", "Invalid alm-examples in the ClusterServiceVersion %s/%s of %s: %v", csv.Namespace, csv.Name, operand.Name, err)
+					recordOperandFailure(ctx, "InvalidALMExamples", errors.Wrapf(err, "invalid alm-examples in the ClusterServiceVersion %s/%s", csv.Namespace, csv.Name))
+					requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceFailed, &r.Mutex)
+					continue
+				}
+				// A service without spec doesn't configure any custom resource
+				if len(opdConfig.Spec) == 0 {
+					if !r.ApplyDefaults {
+						klog.Warningf("The service %s in the OperandConfig %s doesn't configure any custom resource, Skip creating CR for it", operand.Name, registryKey.String())
+						requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceSpecEmpty, &r.Mutex)
+						continue
+					}
+					opdConfig, err = withALMExampleKinds(opdConfig, csv)
+					if err != nil {
+						merr.Add(err)
+						continue
+					}
+				}
+				opdConfig, err = applyDefaults(configInstance.Spec.Defaults, opdConfig)
+				if err != nil {
+					merr.Add(err)
 					continue
 				}
-				err = r.reconcileCRwithConfig(ctx, opdConfig, opdRegistry.Namespace, csv)
+				opdConfig, err = applyOperatorDefaults(opdRegistry, opdConfig)
 				if err != nil {
 					merr.Add(err)
+					continue
+				}
+				// Render the templates referring to the metadata of the OperandRequest, the parameters of the request and the namespace of the custom resources
+				crNamespace := opdConfig.GetCRNamespace(opdRegistry, r.DefaultTargetNamespace)
+				renderedConfig, missingAnnotations, err := renderRequestTemplates(requestInstance, req.Parameters, opdConfig, crNamespace)
+				if err != nil {
+					merr.Add(errors.Wrapf(err, "invalid OperandConfig %s", registryKey.String()))
+					requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceFailed, &r.Mutex)
+					continue
+				}
+				requestInstance.SetMissingRequestAnnotationCondition(operand.Name, missingAnnotations, &r.Mutex)
+				if len(missingAnnotations) != 0 {
+					klog.Warningf("The OperandRequest %s/%s misses the annotations %s required by the OperandConfig of %s", requestInstance.Namespace, requestInstance.Name, strings.Join(missingAnnotations, ", "), operand.Name)
+					r.Recorder.Eventf(requestInstance, corev1.EventTypeWarning, "MissingRequestAnnotation", "Missing the annotations %s required by the OperandConfig of %s", strings.Join(missingAnnotations, ", "), operand.Name)
 					requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceFailed, &r.Mutex)
+					continue
+				}
+				opdConfig = renderedConfig
+				allowed, err := r.checkCreatePermissions(ctx, requestInstance, operand.Name, configuredKinds(opdConfig, csv), crNamespace)
+				if err != nil {
+					merr.Add(err)
+					continue
 				}
+				if !allowed {
+					requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceFailed, &r.Mutex)
+					continue
+				}
+				if dryRun {
+					changes, err := r.dryRunCRwithConfig(ctx, opdConfig, crNamespace, csv)
+					if err != nil {
+						merr.Add(err)
+						continue
+					}
+					requestInstance.SetMemberDryRunChanges(operand.Name, changes, &r.Mutex)
+					continue
+				}
+				requestInstance.SetMemberDryRunChanges(operand.Name, nil, &r.Mutex)
+				err = r.reconcileCRwithConfig(ctx, opdConfig, crNamespace, csv)
+				// The referenced keys missing from the secrets or the configmaps are never written as empty values
+				requestInstance.SetMissingConfigReferenceCondition(operand.Name, missingKeyRef(err), &r.Mutex)
+				if err != nil {
+					merr.Add(err)
+					requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceFailed, &r.Mutex)
+					continue
+				}
+				if err := r.reapplyCustomResources(ctx, requestInstance, operand.Name, opdConfig, crNamespace, csv); err != nil {
+					merr.Add(err)
+				}
+				validationTemplate, validationNamespace = opdConfig.PostInstallValidation, crNamespace
 			} else {
+				allowed, err := r.checkCreatePermissions(ctx, requestInstance, operand.Name, []schema.GroupVersionKind{schema.FromAPIVersionAndKind(operand.APIVersion, operand.Kind)}, requestInstance.Namespace)
+				if err != nil {
+					merr.Add(err)
+					continue
+				}
+				if !allowed {
+					requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceFailed, &r.Mutex)
+					continue
+				}
+				if dryRun {
+					change, err := r.dryRunCRwithRequest(ctx, operand, types.NamespacedName{Name: requestInstance.Name, Namespace: requestInstance.Namespace}, i)
+					if err != nil {
+						merr.Add(err)
+						continue
+					}
+					var changes []operatorv1alpha1.DryRunChange
+					if change != nil {
+						changes = append(changes, *change)
+					}
+					requestInstance.SetMemberDryRunChanges(operand.Name, changes, &r.Mutex)
+					continue
+				}
+				requestInstance.SetMemberDryRunChanges(operand.Name, nil, &r.Mutex)
 				err = r.reconcileCRwithRequest(ctx, requestInstance, operand, types.NamespacedName{Name: requestInstance.Name, Namespace: requestInstance.Namespace}, i)
 				if err != nil {
 					merr.Add(err)
 					requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceFailed, &r.Mutex)
+					continue
 				}
 			}
 			requestInstance.SetMemberStatus(operand.Name, "", operatorv1alpha1.ServiceRunning, &r.Mutex)
+			if validationTemplate != nil {
+				if err := r.reconcileValidationJob(ctx, requestInstance, operand.Name, validationNamespace, validationTemplate); err != nil {
+					merr.Add(err)
+				}
+			}
 		}
 	}
 	if len(merr.Errors) != 0 {
@@ -173,6 +392,18 @@ func (r *Reconciler) reconcileOperand(ctx context.Context, requestInstance *oper
 	return &util.MultiErr{}
 }
 
+// pendingDependencies returns the dependencies of the operand which aren't Running yet
+func pendingDependencies(requestInstance *operatorv1alpha1.OperandRequest, operand operatorv1alpha1.Operand, mu sync.Locker) []string {
+	var pending []string
+	for _, dependency := range operand.DependsOn {
+		phase := requestInstance.GetMemberOperandPhase(dependency, mu)
+		if phase != operatorv1alpha1.ServiceRunning && phase != operatorv1alpha1.ServiceUserManaged && phase != operatorv1alpha1.ServiceCRManagementDisabled {
+			pending = append(pending, dependency)
+		}
+	}
+	return pending
+}
+
 // reconcileCRwithConfig merge and create custom resource base on OperandConfig and CSV alm-examples
 func (r *Reconciler) reconcileCRwithConfig(ctx context.Context, service *operatorv1alpha1.ConfigService, namespace string, csv *olmv1alpha1.ClusterServiceVersion) error {
 	almExamples := csv.GetAnnotations()["alm-examples"]
@@ -184,12 +415,27 @@ func (r *Reconciler) reconcileCRwithConfig(ctx context.Context, service *operato
 		return errors.Wrapf(err, "failed to convert alm-examples in the Subscription %s/%s to slice", namespace, service.Name)
 	}
 
+	// Apply the overrides matching the cluster version
+	service, err = r.applyOverrides(ctx, service)
+	if err != nil {
+		return err
+	}
+
+	// Resolve the secret and configmap references before merging, the values only live in memory
+	unresolvedService := service
+	service, err = r.resolveKeyRefs(ctx, service, namespace)
+	if err != nil {
+		return err
+	}
+
 	merr := &util.MultiErr{}
 
 	foundMap := make(map[string]bool)
 	for cr := range service.Spec {
 		foundMap[cr] = false
 	}
+	// The named instances are created from the first alm-example of their kind
+	instancesReconciled := make(map[schema.GroupVersionKind]bool)
 
 	// Merge OperandConfig and ClusterServiceVersion alm-examples
 	for _, almExample := range almExampleList {
@@ -202,31 +448,53 @@ func (r *Reconciler) reconcileCRwithConfig(ctx context.Context, service *operato
 		if spec == nil {
 			continue
 		}
-
-		err := r.Client.Get(ctx, types.NamespacedName{
-			Name:      name,
-			Namespace: namespace,
-		}, &crFromALM)
+		// The configuration is matched with the GroupVersionKind in the alm-examples
+		gvk := crFromALM.GroupVersionKind()
 
 		for cr := range service.Spec {
-			if strings.EqualFold(crFromALM.GetKind(), cr) {
+			if kindKey, _ := operatorv1alpha1.ParseCRSpecKey(cr); operatorv1alpha1.CRSpecKeyMatches(kindKey, gvk) {
 				foundMap[cr] = true
 			}
 		}
 
+		if !service.ManagesCR(gvk, name) {
+			klog.V(2).Infof("Skip the alm-example %s %s filtered out by the service %s", gvk.Kind, name, service.Name)
+			continue
+		}
+
+		// Migrate the deprecated apiVersion cached in the alm-examples, the API server converts the existing custom resource
+		apiVersion, err := r.servedAPIVersion(crFromALM.GetAPIVersion(), crFromALM.GetKind())
+		if err != nil {
+			merr.Add(err)
+			continue
+		}
+		crFromALM.SetAPIVersion(apiVersion)
+
+		if !instancesReconciled[gvk] {
+			instancesReconciled[gvk] = true
+			if err := r.reconcileCRInstances(ctx, *crFromALM.DeepCopy(), gvk, service, unresolvedService, namespace); err != nil {
+				merr.Add(err)
+			}
+		}
+
+		err = r.Client.Get(ctx, types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		}, &crFromALM)
+
 		if err != nil && !apierrors.IsNotFound(err) {
 			merr.Add(errors.Wrapf(err, "failed to get the custom resource %s/%s", namespace, name))
 			continue
 		} else if apierrors.IsNotFound(err) {
 			// Create Custom Resource
-			if err := r.compareConfigandExample(ctx, crFromALM, service, namespace); err != nil {
+			if err := r.compareConfigandExample(ctx, crFromALM, gvk, service, unresolvedService, namespace); err != nil {
 				merr.Add(err)
 				continue
 			}
 		} else {
 			if checkLabel(crFromALM, map[string]string{constant.OpreqLabel: "true"}) {
 				// Update or Delete Custom Resource
-				if err := r.existingCustomResource(ctx, crFromALM, spec.(map[string]interface{}), service, namespace); err != nil {
+				if err := r.existingCustomResource(ctx, crFromALM, gvk, spec.(map[string]interface{}), service, namespace); err != nil {
 					merr.Add(err)
 					continue
 				}
@@ -248,6 +516,78 @@ func (r *Reconciler) reconcileCRwithConfig(ctx context.Context, service *operato
 	return nil
 }
 
+// reconcileCRInstances creates or updates the named instances of the custom resource in the service from its alm-example,
+// and deletes the instances removed from the service. The unresolved service is the service before its references are resolved.
+func (r *Reconciler) reconcileCRInstances(ctx context.Context, crFromALM unstructured.Unstructured, gvk schema.GroupVersionKind, service, unresolvedService *operatorv1alpha1.ConfigService, namespace string) error {
+	specFromALM, _ := crFromALM.Object["spec"].(map[string]interface{})
+	instances := service.GetCRInstances(gvk)
+	mergeStrategy := service.GetMergeStrategy(gvk)
+	propagation := r.deletionPropagation(service)
+
+	merr := &util.MultiErr{}
+	for instance, key := range instances {
+		existingCR := unstructured.Unstructured{}
+		existingCR.SetAPIVersion(crFromALM.GetAPIVersion())
+		existingCR.SetKind(crFromALM.GetKind())
+		err := r.Client.Get(ctx, types.NamespacedName{
+			Name:      instance,
+			Namespace: namespace,
+		}, &existingCR)
+		if err != nil && !apierrors.IsNotFound(err) {
+			merr.Add(errors.Wrapf(err, "failed to get the custom resource %s/%s", namespace, instance))
+			continue
+		}
+		if apierrors.IsNotFound(err) {
+			crTemplate := crFromALM.DeepCopy()
+			crTemplate.SetName(instance)
+			ensureLabel(*crTemplate, map[string]string{constant.CRInstanceLabel: service.Name})
+			if err := r.createCustomResource(ctx, *crTemplate, namespace, key, service.Spec[key].Raw, unresolvedService.Spec[key].Raw, mergeStrategy); err != nil {
+				merr.Add(errors.Wrapf(err, "failed to create custom resource -- Kind: %s", crFromALM.GetKind()))
+			}
+			continue
+		}
+		if !checkLabel(existingCR, map[string]string{constant.OpreqLabel: "true"}) {
+			klog.V(2).Info("Skip the custom resource not created by ODLM")
+			continue
+		}
+		if err := r.updateCustomResource(ctx, existingCR, namespace, key, service.Spec[key].Raw, specFromALM, service.UpdateStrategy, mergeStrategy, service.IgnoredSpecPaths, propagation); err != nil {
+			merr.Add(errors.Wrap(err, "failed to update custom resource"))
+		}
+	}
+
+	// Delete the instances removed from the service
+	existingInstances, err := r.listCRInstances(ctx, crFromALM.GroupVersionKind(), service.Name, namespace)
+	if err != nil {
+		merr.Add(err)
+	}
+	for _, cr := range existingInstances {
+		if _, found := instances[cr.GetName()]; found {
+			continue
+		}
+		if err := r.deleteCustomResource(ctx, cr, namespace, propagation); err != nil {
+			merr.Add(err)
+		}
+	}
+
+	if len(merr.Errors) != 0 {
+		return merr
+	}
+	return nil
+}
+
+// listCRInstances lists the named instances of the kind created by ODLM for the service in the namespace
+func (r *Reconciler) listCRInstances(ctx context.Context, gvk schema.GroupVersionKind, serviceName, namespace string) ([]unstructured.Unstructured, error) {
+	crList := &unstructured.UnstructuredList{}
+	crList.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := r.Client.List(ctx, crList, client.InNamespace(namespace), client.MatchingLabels{
+		constant.OpreqLabel:      "true",
+		constant.CRInstanceLabel: serviceName,
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list the instances of %s in the namespace %s", gvk.Kind, namespace)
+	}
+	return crList.Items, nil
+}
+
 // reconcileCRwithRequest merge and create custom resource base on OperandRequest and CSV alm-examples
 func (r *Reconciler) reconcileCRwithRequest(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest, operand operatorv1alpha1.Operand, requestKey types.NamespacedName, index int) error {
 	merr := &util.MultiErr{}
@@ -263,20 +603,20 @@ func (r *Reconciler) reconcileCRwithRequest(ctx context.Context, requestInstance
 		return fmt.Errorf("The Kind of operand is empty for operator " + operand.Name)
 	}
 
-	var name string
-	if operand.InstanceName == "" {
-		crInfo := sha256.Sum256([]byte(operand.APIVersion + operand.Kind + strconv.Itoa(index)))
-		name = requestKey.Name + "-" + hex.EncodeToString(crInfo[:7])
-	} else {
-		name = operand.InstanceName
+	name := requestCRName(requestKey.Name, operand, index)
+
+	// The name is kept from the requested apiVersion, so that the migrated custom resource is still found
+	apiVersion, err := r.servedAPIVersion(operand.APIVersion, operand.Kind)
+	if err != nil {
+		return err
 	}
 
 	crFromRequest.SetName(name)
 	crFromRequest.SetNamespace(requestKey.Namespace)
-	crFromRequest.SetAPIVersion(operand.APIVersion)
+	crFromRequest.SetAPIVersion(apiVersion)
 	crFromRequest.SetKind(operand.Kind)
 
-	err := r.Client.Get(ctx, types.NamespacedName{
+	err = r.Client.Get(ctx, types.NamespacedName{
 		Name:      name,
 		Namespace: requestKey.Namespace,
 	}, &crFromRequest)
@@ -285,17 +625,18 @@ func (r *Reconciler) reconcileCRwithRequest(ctx context.Context, requestInstance
 		merr.Add(errors.Wrapf(err, "failed to get custom resource %s/%s", requestKey.Namespace, name))
 	} else if apierrors.IsNotFound(err) {
 		// Create Custom resource
-		if err := r.createCustomResource(ctx, crFromRequest, requestKey.Namespace, operand.Kind, operand.Spec.Raw); err != nil {
+		if err := r.createCustomResource(ctx, crFromRequest, requestKey.Namespace, operand.Kind, operand.Spec.Raw, operand.Spec.Raw, operatorv1alpha1.MergeStrategyMerge); err != nil {
 			merr.Add(err)
 		}
-		requestInstance.SetMemberCRStatus(operand.Name, name, operand.Kind, operand.APIVersion, &r.Mutex)
+		requestInstance.SetMemberCRStatus(operand.Name, name, operand.Kind, apiVersion, &r.Mutex)
 	} else {
 		if checkLabel(crFromRequest, map[string]string{constant.OpreqLabel: "true"}) {
 			// Update or Delete Custom resource
 			klog.V(3).Info("Found existing custom resource: " + operand.Kind)
-			if err := r.updateCustomResource(ctx, crFromRequest, requestKey.Namespace, operand.Kind, operand.Spec.Raw, map[string]interface{}{}); err != nil {
+			if err := r.updateCustomResource(ctx, crFromRequest, requestKey.Namespace, operand.Kind, operand.Spec.Raw, map[string]interface{}{}, operatorv1alpha1.UpdateStrategyPatch, operatorv1alpha1.MergeStrategyMerge, nil, r.deletionPropagation(nil)); err != nil {
 				return err
 			}
+			requestInstance.MigrateMemberCRStatus(operand.Name, name, operand.Kind, apiVersion, &r.Mutex)
 		} else {
 			klog.V(2).Info("Skip the custom resource not created by ODLM")
 		}
@@ -307,8 +648,46 @@ func (r *Reconciler) reconcileCRwithRequest(ctx context.Context, requestInstance
 	return nil
 }
 
+// requestCRName returns the name of the custom resource of the operand requested at the index of the OperandRequest
+func requestCRName(requestName string, operand operatorv1alpha1.Operand, index int) string {
+	if operand.InstanceName != "" {
+		return operand.InstanceName
+	}
+	crInfo := sha256.Sum256([]byte(operand.APIVersion + operand.Kind + strconv.Itoa(index)))
+	return requestName + "-" + hex.EncodeToString(crInfo[:7])
+}
+
+// configuredKinds returns the kinds of the custom resources in the alm-examples configured by the service
+func configuredKinds(service *operatorv1alpha1.ConfigService, csv *olmv1alpha1.ClusterServiceVersion) []schema.GroupVersionKind {
+	var almExampleList []unstructured.Unstructured
+	if err := json.Unmarshal([]byte(csv.GetAnnotations()["alm-examples"]), &almExampleList); err != nil {
+		// The alm-examples are reported invalid when merging them
+		return nil
+	}
+	var gvks []schema.GroupVersionKind
+	for _, crFromALM := range almExampleList {
+		gvk := crFromALM.GroupVersionKind()
+		for cr := range service.Spec {
+			if operatorv1alpha1.CRSpecKeyMatches(cr, gvk) && !containsGVK(gvks, gvk) {
+				gvks = append(gvks, gvk)
+			}
+		}
+	}
+	return gvks
+}
+
+func containsGVK(gvks []schema.GroupVersionKind, gvk schema.GroupVersionKind) bool {
+	for _, g := range gvks {
+		if g == gvk {
+			return true
+		}
+	}
+	return false
+}
+
 // deleteAllCustomResource remove custom resource base on OperandConfig and CSV alm-examples
 func (r *Reconciler) deleteAllCustomResource(ctx context.Context, csv *olmv1alpha1.ClusterServiceVersion, requestInstance *operatorv1alpha1.OperandRequest, csc *operatorv1alpha1.OperandConfig, operandName, namespace string) error {
+	ctx = withOperandName(ctx, operandName)
 
 	customeResourceMap := make(map[string]operatorv1alpha1.OperandCRMember)
 	for _, member := range requestInstance.Status.Members {
@@ -321,6 +700,9 @@ func (r *Reconciler) deleteAllCustomResource(ctx context.Context, csv *olmv1alph
 		}
 	}
 
+	service := csc.GetService(operandName)
+	propagation := r.deletionPropagation(service)
+
 	merr := &util.MultiErr{}
 	var (
 		wg sync.WaitGroup
@@ -344,7 +726,7 @@ func (r *Reconciler) deleteAllCustomResource(ctx context.Context, csv *olmv1alph
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			if err := r.deleteCustomResource(ctx, crShouldBeDeleted, requestInstance.Namespace); err != nil {
+			if err := r.deleteCustomResource(ctx, crShouldBeDeleted, requestInstance.Namespace, propagation); err != nil {
 				r.Mutex.Lock()
 				defer r.Mutex.Unlock()
 				merr.Add(err)
@@ -359,7 +741,6 @@ func (r *Reconciler) deleteAllCustomResource(ctx context.Context, csv *olmv1alph
 		return merr
 	}
 
-	service := csc.GetService(operandName)
 	if service == nil {
 		return nil
 	}
@@ -375,6 +756,8 @@ func (r *Reconciler) deleteAllCustomResource(ctx context.Context, csv *olmv1alph
 		return errors.Wrapf(err, "failed to convert alm-examples in the Subscription %s to slice", service.Name)
 	}
 
+	instancesDeleted := make(map[schema.GroupVersionKind]bool)
+
 	// Merge OperandConfig and ClusterServiceVersion alm-examples
 	for _, crFromALM := range almExamplesRaw {
 
@@ -385,37 +768,55 @@ func (r *Reconciler) deleteAllCustomResource(ctx context.Context, csv *olmv1alph
 		name := crTemplate.GetName()
 		// Get the kind of CR
 		kind := crTemplate.GetKind()
+		// Delete the named instances of the kind
+		if gvk := crTemplate.GroupVersionKind(); !instancesDeleted[gvk] {
+			instancesDeleted[gvk] = true
+			instances, err := r.listCRInstances(ctx, gvk, service.Name, namespace)
+			if err != nil {
+				r.Mutex.Lock()
+				merr.Add(err)
+				r.Mutex.Unlock()
+			}
+			for _, instance := range instances {
+				instance := instance
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := r.deleteCustomResource(ctx, instance, namespace, propagation); err != nil {
+						r.Mutex.Lock()
+						defer r.Mutex.Unlock()
+						merr.Add(err)
+					}
+				}()
+			}
+		}
+		// Compare the key of OperandConfig and the GroupVersionKind of the CR
+		if _, _, found := service.GetCRSpec(crTemplate.GroupVersionKind()); !found {
+			continue
+		}
 		// Delete the CR
-		for crdName := range service.Spec {
-
-			// Compare the name of OperandConfig and CRD name
-			if strings.EqualFold(kind, crdName) {
-				err := r.Client.Get(ctx, types.NamespacedName{
-					Name:      name,
-					Namespace: namespace,
-				}, &crTemplate)
-				if err != nil && !apierrors.IsNotFound(err) {
+		err := r.Client.Get(ctx, types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		}, &crTemplate)
+		if err != nil && !apierrors.IsNotFound(err) {
+			merr.Add(err)
+			continue
+		}
+		if apierrors.IsNotFound(err) {
+			klog.V(2).Info("Finish Deleting the CR: " + kind)
+			continue
+		}
+		if checkLabel(crTemplate, map[string]string{constant.OpreqLabel: "true"}) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := r.deleteCustomResource(ctx, crTemplate, namespace, propagation); err != nil {
+					r.Mutex.Lock()
+					defer r.Mutex.Unlock()
 					merr.Add(err)
-					continue
-				}
-				if apierrors.IsNotFound(err) {
-					klog.V(2).Info("Finish Deleting the CR: " + kind)
-					continue
 				}
-				if checkLabel(crTemplate, map[string]string{constant.OpreqLabel: "true"}) {
-					wg.Add(1)
-					go func() {
-						defer wg.Done()
-						if err := r.deleteCustomResource(ctx, crTemplate, namespace); err != nil {
-							r.Mutex.Lock()
-							defer r.Mutex.Unlock()
-							merr.Add(err)
-						}
-					}()
-				}
-
-			}
-
+			}()
 		}
 	}
 	wg.Wait()
@@ -426,29 +827,30 @@ func (r *Reconciler) deleteAllCustomResource(ctx context.Context, csv *olmv1alph
 	return nil
 }
 
-func (r *Reconciler) compareConfigandExample(ctx context.Context, crTemplate unstructured.Unstructured, service *operatorv1alpha1.ConfigService, namespace string) error {
+func (r *Reconciler) compareConfigandExample(ctx context.Context, crTemplate unstructured.Unstructured, gvk schema.GroupVersionKind, service, unresolvedService *operatorv1alpha1.ConfigService, namespace string) error {
 	kind := crTemplate.GetKind()
 
-	for crdName, crdConfig := range service.Spec {
-		// Compare the name of OperandConfig and CRD name
-		if strings.EqualFold(kind, crdName) {
-			klog.V(3).Info("Found OperandConfig spec for custom resource: " + kind)
-			err := r.createCustomResource(ctx, crTemplate, namespace, crdName, crdConfig.Raw)
-			if err != nil {
-				return errors.Wrapf(err, "failed to create custom resource -- Kind: %s", kind)
-			}
+	// Compare the key of OperandConfig and the GroupVersionKind of the CR
+	if crdName, crdConfig, found := service.GetCRSpec(gvk); found {
+		klog.V(3).Info("Found OperandConfig spec for custom resource: " + kind)
+		err := r.createCustomResource(ctx, crTemplate, namespace, crdName, crdConfig.Raw, unresolvedService.Spec[crdName].Raw, service.GetMergeStrategy(gvk))
+		if err != nil {
+			return errors.Wrapf(err, "failed to create custom resource -- Kind: %s", kind)
 		}
 	}
 	return nil
 }
 
-func (r *Reconciler) createCustomResource(ctx context.Context, crTemplate unstructured.Unstructured, namespace, crName string, crConfig []byte) error {
+// createCustomResource creates the custom resource from the template merged with the config,
+// the unresolved config is the config before its references are resolved, which is kept when the creation fails
+func (r *Reconciler) createCustomResource(ctx context.Context, crTemplate unstructured.Unstructured, namespace, crName string, crConfig, unresolvedConfig []byte, mergeStrategy operatorv1alpha1.MergeStrategy) error {
 
 	//Convert CR template spec to string
 	specJSONString, _ := json.Marshal(crTemplate.Object["spec"])
+	failedCR := crTemplate.DeepCopy()
 
 	// Merge CR template spec and OperandConfig spec
-	mergedCR := util.MergeCR(specJSONString, crConfig)
+	mergedCR := mergeCRSpec(specJSONString, crConfig, mergeStrategy)
 
 	crTemplate.Object["spec"] = mergedCR
 	crTemplate.SetNamespace(namespace)
@@ -458,44 +860,60 @@ func (r *Reconciler) createCustomResource(ctx context.Context, crTemplate unstru
 	// Creat the CR
 	crerr := r.Create(ctx, &crTemplate)
 	if crerr != nil && !apierrors.IsAlreadyExists(crerr) {
+		r.recordCREvent(ctx, crCreate, crTemplate.GetKind(), namespace, crTemplate.GetName(), crerr)
+		if r.KeepFailedCRs {
+			// The resolved secret values aren't kept in the ConfigMap
+			failedCR.Object["spec"] = mergeCRSpec(specJSONString, unresolvedConfig, mergeStrategy)
+			failedCR.SetNamespace(namespace)
+			ensureLabel(*failedCR, map[string]string{constant.OpreqLabel: "true"})
+			if err := r.keepFailedCustomResource(ctx, *failedCR, crerr); err != nil {
+				klog.Errorf("failed to keep the failed custom resource %s/%s: %v", namespace, crTemplate.GetName(), err)
+			}
+		}
 		return errors.Wrap(crerr, "failed to create custom resource")
 	}
 
+	if crerr == nil {
+		r.recordCREvent(ctx, crCreate, crTemplate.GetKind(), namespace, crTemplate.GetName(), nil)
+	}
+
+	if r.KeepFailedCRs {
+		if err := r.deleteFailedCustomResource(ctx, crTemplate); err != nil {
+			klog.Errorf("failed to delete the failed custom resource %s/%s: %v", namespace, crTemplate.GetName(), err)
+		}
+	}
+
 	klog.V(2).Info("Finish creating the Custom Resource: ", crName)
 
 	return nil
 }
 
-func (r *Reconciler) existingCustomResource(ctx context.Context, existingCR unstructured.Unstructured, specFromALM map[string]interface{}, service *operatorv1alpha1.ConfigService, namespace string) error {
+func (r *Reconciler) existingCustomResource(ctx context.Context, existingCR unstructured.Unstructured, gvk schema.GroupVersionKind, specFromALM map[string]interface{}, service *operatorv1alpha1.ConfigService, namespace string) error {
 	kind := existingCR.GetKind()
 
-	var found bool
-	for crName, crdConfig := range service.Spec {
-		// Compare the name of OperandConfig and CRD name
-		if strings.EqualFold(kind, crName) {
-			found = true
-			klog.V(3).Info("Found OperandConfig spec for custom resource: " + kind)
-			err := r.updateCustomResource(ctx, existingCR, namespace, crName, crdConfig.Raw, specFromALM)
-			if err != nil {
-				return errors.Wrap(err, "failed to update custom resource")
-			}
-		}
-	}
+	// Compare the key of OperandConfig and the GroupVersionKind of the CR
+	crName, crdConfig, found := service.GetCRSpec(gvk)
 	if !found {
-		err := r.deleteCustomResource(ctx, existingCR, namespace)
-		if err != nil {
-			return err
-		}
+		return r.deleteCustomResource(ctx, existingCR, namespace, r.deletionPropagation(service))
+	}
+	klog.V(3).Info("Found OperandConfig spec for custom resource: " + kind)
+	if err := r.updateCustomResource(ctx, existingCR, namespace, crName, crdConfig.Raw, specFromALM, service.UpdateStrategy, service.GetMergeStrategy(gvk), service.IgnoredSpecPaths, r.deletionPropagation(service)); err != nil {
+		return errors.Wrap(err, "failed to update custom resource")
 	}
 	return nil
 }
 
-func (r *Reconciler) updateCustomResource(ctx context.Context, existingCR unstructured.Unstructured, namespace, crName string, crConfig []byte, configFromALM map[string]interface{}) error {
+// maxChangesAnnotationLength bounds the size of the annotation listing the changed paths of a custom resource
+const maxChangesAnnotationLength = 1024
+
+func (r *Reconciler) updateCustomResource(ctx context.Context, existingCR unstructured.Unstructured, namespace, crName string, crConfig []byte, configFromALM map[string]interface{}, updateStrategy operatorv1alpha1.UpdateStrategy, mergeStrategy operatorv1alpha1.MergeStrategy, ignoredPaths []string, propagation metav1.DeletionPropagation) error {
 
 	kind := existingCR.GetKind()
 	apiversion := existingCR.GetAPIVersion()
 	name := existingCR.GetName()
 
+	var recreateCR *unstructured.Unstructured
+
 	// Update the CR
 	err := wait.PollImmediate(constant.DefaultCRFetchPeriod, constant.DefaultCRFetchTimeout, func() (bool, error) {
 
@@ -519,33 +937,35 @@ func (r *Reconciler) updateCustomResource(ctx context.Context, existingCR unstru
 			return true, nil
 		}
 
-		configFromALMRaw, err := json.Marshal(configFromALM)
-		if err != nil {
-			klog.Error(err)
-			return false, err
-		}
-
-		existingCRRaw, err := json.Marshal(existingCR.Object["spec"])
+		updatedCRSpec, err := desiredCRSpec(configFromALM, existingCR.Object["spec"], crConfig, mergeStrategy)
 		if err != nil {
 			klog.Error(err)
 			return false, err
 		}
+		keepIgnoredSpecPaths(updatedCRSpec, existingCR.Object["spec"], ignoredPaths)
 
-		// Merge spec from ALM example and existing CR
-		updatedExistingCR := util.MergeCR(configFromALMRaw, existingCRRaw)
+		CRgeneration := existingCR.GetGeneration()
 
-		updatedExistingCRRaw, err := json.Marshal(updatedExistingCR)
-		if err != nil {
-			klog.Error(err)
-			return false, err
+		if reflect.DeepEqual(existingCR.Object["spec"], updatedCRSpec) {
+			return true, nil
 		}
 
-		// Merge spec from update existing CR and OperandConfig spec
-		updatedCRSpec := util.MergeCR(updatedExistingCRRaw, crConfig)
+		existingCRRaw, _ := json.Marshal(existingCR.Object["spec"])
+		updatedCRRaw, _ := json.Marshal(updatedCRSpec)
+		changedPaths := util.DiffCR(existingCRRaw, updatedCRRaw)
+		klog.V(2).Infof("the fields %v of custom resource -- Kind: %s, NamespacedName: %s/%s are changed", changedPaths, kind, namespace, name)
 
-		CRgeneration := existingCR.GetGeneration()
+		// Record the paths changed by this update on the custom resource
+		annotations := existingCR.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[constant.LastAppliedChangesAnnotation] = util.SummarizeDiff(changedPaths, maxChangesAnnotationLength)
+		existingCR.SetAnnotations(annotations)
 
-		if reflect.DeepEqual(existingCR.Object["spec"], updatedCRSpec) {
+		if updateStrategy == operatorv1alpha1.UpdateStrategyRecreate {
+			existingCR.Object["spec"] = updatedCRSpec
+			recreateCR = &existingCR
 			return true, nil
 		}
 
@@ -557,6 +977,7 @@ func (r *Reconciler) updateCustomResource(ctx context.Context, existingCR unstru
 		if err != nil {
 			return false, errors.Wrapf(err, "failed to update custom resource -- Kind: %s, NamespacedName: %s/%s", kind, namespace, name)
 		}
+		r.recordCREvent(ctx, crUpdate, kind, namespace, name, nil)
 
 		UpdatedCR := unstructured.Unstructured{
 			Object: map[string]interface{}{
@@ -583,13 +1004,143 @@ func (r *Reconciler) updateCustomResource(ctx context.Context, existingCR unstru
 	})
 
 	if err != nil {
+		r.recordCREvent(ctx, crUpdate, kind, namespace, name, err)
 		return errors.Wrapf(err, "failed to update custom resource -- Kind: %s, NamespacedName: %s/%s", kind, namespace, name)
 	}
 
+	if recreateCR != nil {
+		return r.recreateCustomResource(ctx, *recreateCR, propagation)
+	}
+
 	return nil
 }
 
-func (r *Reconciler) deleteCustomResource(ctx context.Context, existingCR unstructured.Unstructured, namespace string) error {
+// desiredCRSpec merges the spec from the alm-examples, the spec of the existing custom resource
+// and the spec from the OperandConfig or the OperandRequest in order
+func desiredCRSpec(specFromALM map[string]interface{}, existingSpec interface{}, crConfig []byte, mergeStrategy operatorv1alpha1.MergeStrategy) (map[string]interface{}, error) {
+	specFromALMRaw, err := json.Marshal(specFromALM)
+	if err != nil {
+		return nil, err
+	}
+
+	existingSpecRaw, err := json.Marshal(existingSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge spec from ALM example and existing CR
+	updatedExistingCR := mergeCRSpec(specFromALMRaw, existingSpecRaw, mergeStrategy)
+
+	updatedExistingCRRaw, err := json.Marshal(updatedExistingCR)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge spec from update existing CR and OperandConfig spec
+	return mergeCRSpec(updatedExistingCRRaw, crConfig, mergeStrategy), nil
+}
+
+// mergeCRSpec merges the changed spec into the default spec with the merge strategy
+func mergeCRSpec(defaultSpec, changedSpec []byte, mergeStrategy operatorv1alpha1.MergeStrategy) map[string]interface{} {
+	if mergeStrategy == operatorv1alpha1.MergeStrategyStrategicMerge {
+		return util.StrategicMergeCR(defaultSpec, changedSpec)
+	}
+	return util.MergeCR(defaultSpec, changedSpec)
+}
+
+// keepIgnoredSpecPaths sets the fields of the desired spec at the ignored paths to their values in the existing spec,
+// the fields absent from the existing spec are removed
+func keepIgnoredSpecPaths(desiredSpec map[string]interface{}, existingSpec interface{}, ignoredPaths []string) {
+	if desiredSpec == nil {
+		return
+	}
+	existing, _ := existingSpec.(map[string]interface{})
+	for _, path := range ignoredPaths {
+		fields := strings.Split(path, ".")
+		value, found, err := unstructured.NestedFieldNoCopy(existing, fields...)
+		if err != nil || !found {
+			unstructured.RemoveNestedField(desiredSpec, fields...)
+			continue
+		}
+		if err := unstructured.SetNestedField(desiredSpec, runtime.DeepCopyJSONValue(value), fields...); err != nil {
+			klog.Warningf("Failed to keep the ignored spec path %s: %v", path, err)
+		}
+	}
+}
+
+// recreateCustomResource deletes the custom resource, waits until it is gone and creates it with the new spec
+func (r *Reconciler) recreateCustomResource(ctx context.Context, cr unstructured.Unstructured, propagation metav1.DeletionPropagation) error {
+	kind := cr.GetKind()
+	apiversion := cr.GetAPIVersion()
+	name := cr.GetName()
+	namespace := cr.GetNamespace()
+
+	klog.V(2).Infof("recreating custom resource with apiversion: %s, kind: %s, %s/%s", apiversion, kind, namespace, name)
+
+	if err := r.Delete(ctx, &cr, client.PropagationPolicy(propagation)); err != nil && !apierrors.IsNotFound(err) {
+		r.recordCREvent(ctx, crRecreate, kind, namespace, name, err)
+		return errors.Wrapf(err, "failed to delete custom resource -- Kind: %s, NamespacedName: %s/%s", kind, namespace, name)
+	}
+
+	err := wait.PollImmediate(constant.DefaultCRDeletePeriod, constant.DefaultCRDeleteTimeout, func() (bool, error) {
+		klog.V(3).Infof("Waiting for CR %s is removed ...", kind)
+		err := r.Client.Get(ctx, types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		}, &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": apiversion,
+				"kind":       kind,
+			},
+		})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to get custom resource -- Kind: %s, NamespacedName: %s/%s", kind, namespace, name)
+		}
+		return false, nil
+	})
+	if err != nil {
+		r.recordCREvent(ctx, crRecreate, kind, namespace, name, err)
+		return errors.Wrapf(err, "failed to delete custom resource -- Kind: %s, NamespacedName: %s/%s", kind, namespace, name)
+	}
+
+	newCR := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiversion,
+			"kind":       kind,
+			"spec":       cr.Object["spec"],
+		},
+	}
+	newCR.SetName(name)
+	newCR.SetNamespace(namespace)
+	newCR.SetLabels(cr.GetLabels())
+	newCR.SetAnnotations(cr.GetAnnotations())
+
+	if err := r.Create(ctx, &newCR); err != nil {
+		r.recordCREvent(ctx, crRecreate, kind, namespace, name, err)
+		return errors.Wrapf(err, "failed to create custom resource -- Kind: %s, NamespacedName: %s/%s", kind, namespace, name)
+	}
+	r.recordCREvent(ctx, crRecreate, kind, namespace, name, nil)
+
+	klog.V(2).Info("Finish recreating the Custom Resource: ", kind)
+	return nil
+}
+
+// deletionPropagation returns the propagation policy to delete the custom resources of the service,
+// the policy of the service overrides the default policy of ODLM
+func (r *Reconciler) deletionPropagation(service *operatorv1alpha1.ConfigService) metav1.DeletionPropagation {
+	if service != nil && service.DeletionPropagation != "" {
+		return service.DeletionPropagation
+	}
+	if r.DeletionPropagation != "" {
+		return r.DeletionPropagation
+	}
+	return metav1.DeletePropagationBackground
+}
+
+func (r *Reconciler) deleteCustomResource(ctx context.Context, existingCR unstructured.Unstructured, namespace string, propagation metav1.DeletionPropagation) error {
 
 	kind := existingCR.GetKind()
 	apiversion := existingCR.GetAPIVersion()
@@ -613,8 +1164,9 @@ func (r *Reconciler) deleteCustomResource(ctx context.Context, existingCR unstru
 	} else {
 		if checkLabel(crShouldBeDeleted, map[string]string{constant.OpreqLabel: "true"}) && !checkLabel(crShouldBeDeleted, map[string]string{constant.NotUninstallLabel: "true"}) {
 			klog.V(3).Infof("Deleting custom resource: %s from custom resource definition: %s", name, kind)
-			err := r.Delete(ctx, &crShouldBeDeleted)
+			err := r.Delete(ctx, &crShouldBeDeleted, client.PropagationPolicy(propagation))
 			if err != nil && !apierrors.IsNotFound(err) {
+				r.recordCREvent(ctx, crDelete, kind, namespace, name, err)
 				return errors.Wrapf(err, "failed to delete custom resource -- Kind: %s, NamespacedName: %s/%s", kind, namespace, name)
 			}
 			err = wait.PollImmediate(constant.DefaultCRDeletePeriod, constant.DefaultCRDeleteTimeout, func() (bool, error) {
@@ -635,8 +1187,10 @@ func (r *Reconciler) deleteCustomResource(ctx context.Context, existingCR unstru
 				return false, nil
 			})
 			if err != nil {
+				r.recordCREvent(ctx, crDelete, kind, namespace, name, err)
 				return errors.Wrapf(err, "failed to delete custom resource -- Kind: %s, NamespacedName: %s/%s", kind, namespace, name)
 			}
+			r.recordCREvent(ctx, crDelete, kind, namespace, name, nil)
 			klog.V(1).Infof("Finish deleting custom resource -- Kind: %s, NamespacedName: %s/%s", kind, namespace, name)
 		}
 	}
@@ -650,6 +1204,10 @@ func (r *Reconciler) checkCustomResource(ctx context.Context, requestInstance *o
 
 	customeResourceMap := make(map[string]operatorv1alpha1.OperandCRMember)
 	for _, member := range members {
+		// The custom resources of the members pending deletion are kept until the removal is confirmed
+		if member.Phase.OperandPhase == operatorv1alpha1.ServicePendingDeletion {
+			continue
+		}
 		if len(member.OperandCRList) != 0 {
 			for _, cr := range member.OperandCRList {
 				customeResourceMap[member.Name+"/"+cr.Kind+"/"+cr.Name] = cr
@@ -689,11 +1247,12 @@ func (r *Reconciler) checkCustomResource(ctx context.Context, requestInstance *o
 		var (
 			operatorName = strings.Split(index, "/")[0]
 			opdMember    = opdMember
+			ctx          = withOperandName(ctx, operatorName)
 		)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			if err := r.deleteCustomResource(ctx, crShouldBeDeleted, requestInstance.Namespace); err != nil {
+			if err := r.deleteCustomResource(ctx, crShouldBeDeleted, requestInstance.Namespace, r.deletionPropagation(nil)); err != nil {
 				r.Mutex.Lock()
 				defer r.Mutex.Unlock()
 				merr.Add(err)
@@ -711,6 +1270,174 @@ func (r *Reconciler) checkCustomResource(ctx context.Context, requestInstance *o
 	return nil
 }
 
+// resolveKeyRefs returns a copy of the service whose spec has every `valueFrom.secretKeyRef`
+// and `valueFrom.configMapKeyRef` replaced by the value of the referenced key
+func (r *Reconciler) resolveKeyRefs(ctx context.Context, service *operatorv1alpha1.ConfigService, namespace string) (*operatorv1alpha1.ConfigService, error) {
+	resolvedService := service.DeepCopy()
+	for cr, spec := range resolvedService.Spec {
+		if !bytes.Contains(spec.Raw, []byte("secretKeyRef")) && !bytes.Contains(spec.Raw, []byte("configMapKeyRef")) {
+			continue
+		}
+		var specMap interface{}
+		if err := json.Unmarshal(spec.Raw, &specMap); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal the spec of %s in the service %s", cr, service.Name)
+		}
+		resolvedSpec, err := r.resolveValueFrom(ctx, specMap, namespace)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve the spec of %s in the service %s", cr, service.Name)
+		}
+		resolvedRaw, err := json.Marshal(resolvedSpec)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal the spec of %s in the service %s", cr, service.Name)
+		}
+		resolvedService.Spec[cr] = runtime.RawExtension{Raw: resolvedRaw}
+	}
+	return resolvedService, nil
+}
+
+func (r *Reconciler) resolveValueFrom(ctx context.Context, value interface{}, namespace string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := getSecretKeyRef(v); ok {
+			return r.getSecretKeyValue(ctx, ref, namespace)
+		}
+		if ref, ok := getConfigMapKeyRef(v); ok {
+			return r.getConfigMapKeyValue(ctx, ref, namespace)
+		}
+		for key, item := range v {
+			resolved, err := r.resolveValueFrom(ctx, item, namespace)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = resolved
+		}
+	case []interface{}:
+		for i, item := range v {
+			resolved, err := r.resolveValueFrom(ctx, item, namespace)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+	}
+	return value, nil
+}
+
+// missingKeyRefError is the error of a reference to a missing key, or to the missing secret or configmap of the key
+type missingKeyRefError struct {
+	kind, namespace, name, key string
+}
+
+func (e *missingKeyRefError) Error() string {
+	return fmt.Sprintf("key %s not found in the %s %s/%s", e.key, e.kind, e.namespace, e.name)
+}
+
+// missingKeyRef returns the description of the missing key referenced in the error, empty means the error isn't about a missing key
+func missingKeyRef(err error) string {
+	var refErr *missingKeyRefError
+	if !errors.As(err, &refErr) {
+		return ""
+	}
+	return fmt.Sprintf("the key %s of the %s %s", refErr.key, refErr.kind, refErr.name)
+}
+
+func (r *Reconciler) getSecretKeyValue(ctx context.Context, ref *corev1.SecretKeySelector, namespace string) (interface{}, error) {
+	optional := ref.Optional != nil && *ref.Optional
+	missing := &missingKeyRefError{kind: "secret", namespace: namespace, name: ref.Name, key: ref.Key}
+	secret := &corev1.Secret{}
+	// Use the API reader, the cache only contains the secrets labeled by OperandBindInfo
+	if err := r.Reader.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, secret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "failed to get the secret %s/%s", namespace, ref.Name)
+		}
+		if optional {
+			return nil, nil
+		}
+		return nil, missing
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		if optional {
+			return nil, nil
+		}
+		return nil, missing
+	}
+	return string(value), nil
+}
+
+func (r *Reconciler) getConfigMapKeyValue(ctx context.Context, ref *corev1.ConfigMapKeySelector, namespace string) (interface{}, error) {
+	optional := ref.Optional != nil && *ref.Optional
+	missing := &missingKeyRefError{kind: "configmap", namespace: namespace, name: ref.Name, key: ref.Key}
+	cm := &corev1.ConfigMap{}
+	// Use the API reader, the cache only contains the configmaps labeled by OperandBindInfo
+	if err := r.Reader.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, cm); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "failed to get the configmap %s/%s", namespace, ref.Name)
+		}
+		if optional {
+			return nil, nil
+		}
+		return nil, missing
+	}
+	if value, ok := cm.Data[ref.Key]; ok {
+		return value, nil
+	}
+	if value, ok := cm.BinaryData[ref.Key]; ok {
+		return string(value), nil
+	}
+	if optional {
+		return nil, nil
+	}
+	return nil, missing
+}
+
+// getValueFromRef returns the reference of the value in the form of `valueFrom: {<refKind>: {...}}`
+func getValueFromRef(value map[string]interface{}, refKind string) (map[string]interface{}, bool) {
+	if len(value) != 1 {
+		return nil, false
+	}
+	valueFrom, ok := value["valueFrom"].(map[string]interface{})
+	if !ok || len(valueFrom) != 1 {
+		return nil, false
+	}
+	refMap, ok := valueFrom[refKind].(map[string]interface{})
+	return refMap, ok
+}
+
+// getSecretKeyRef checks if the value is in the form of `valueFrom: {secretKeyRef: {name: <name>, key: <key>}}`
+func getSecretKeyRef(value map[string]interface{}) (*corev1.SecretKeySelector, bool) {
+	refMap, ok := getValueFromRef(value, "secretKeyRef")
+	if !ok {
+		return nil, false
+	}
+	ref := &corev1.SecretKeySelector{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(refMap, ref); err != nil {
+		klog.Warningf("Invalid secretKeyRef %v: %v", refMap, err)
+		return nil, false
+	}
+	if ref.Name == "" || ref.Key == "" {
+		return nil, false
+	}
+	return ref, true
+}
+
+// getConfigMapKeyRef checks if the value is in the form of `valueFrom: {configMapKeyRef: {name: <name>, key: <key>}}`
+func getConfigMapKeyRef(value map[string]interface{}) (*corev1.ConfigMapKeySelector, bool) {
+	refMap, ok := getValueFromRef(value, "configMapKeyRef")
+	if !ok {
+		return nil, false
+	}
+	ref := &corev1.ConfigMapKeySelector{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(refMap, ref); err != nil {
+		klog.Warningf("Invalid configMapKeyRef %v: %v", refMap, err)
+		return nil, false
+	}
+	if ref.Name == "" || ref.Key == "" {
+		return nil, false
+	}
+	return ref, true
+}
+
 func checkLabel(unstruct unstructured.Unstructured, labels map[string]string) bool {
 	for k, v := range labels {
 		if !hasLabel(unstruct, k) {
@@ -744,3 +1471,266 @@ func ensureLabel(cr unstructured.Unstructured, labels map[string]string) bool {
 	cr.SetLabels(existingLabels)
 	return true
 }
+
+// withALMExampleKinds configures every kind of the alm-examples with an empty spec in a copy of the service,
+// so that the custom resources are created straight from the alm-examples
+func withALMExampleKinds(service *operatorv1alpha1.ConfigService, csv *olmv1alpha1.ClusterServiceVersion) (*operatorv1alpha1.ConfigService, error) {
+	var almExampleList []interface{}
+	if err := json.Unmarshal([]byte(csv.GetAnnotations()["alm-examples"]), &almExampleList); err != nil {
+		return nil, errors.Wrapf(err, "failed to convert alm-examples in the ClusterServiceVersion %s/%s to slice", csv.Namespace, csv.Name)
+	}
+	defaultedService := service.DeepCopy()
+	defaultedService.Spec = make(map[string]runtime.RawExtension)
+	for _, almExample := range almExampleList {
+		example, ok := almExample.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		cr := unstructured.Unstructured{Object: example}
+		if _, ok := cr.Object["spec"].(map[string]interface{}); !ok || cr.GetKind() == "" {
+			continue
+		}
+		defaultedService.Spec[cr.GetKind()] = runtime.RawExtension{Raw: []byte(`{}`)}
+	}
+	return defaultedService, nil
+}
+
+// applyDefaults merges the spec of every custom resource of the service over the defaults of the OperandConfig
+func applyDefaults(defaults *runtime.RawExtension, service *operatorv1alpha1.ConfigService) (*operatorv1alpha1.ConfigService, error) {
+	if defaults == nil || len(defaults.Raw) == 0 {
+		return service, nil
+	}
+	if err := operatorv1alpha1.ValidateObjectValue(defaults.Raw); err != nil {
+		return nil, errors.Wrapf(err, "invalid defaults of the OperandConfig for the service %s", service.Name)
+	}
+	defaultedService := service.DeepCopy()
+	for cr, spec := range service.Spec {
+		mergedSpec, err := json.Marshal(util.MergeCR(defaults.Raw, spec.Raw))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal the spec of %s with the defaults in the service %s", cr, service.Name)
+		}
+		defaultedService.Spec[cr] = runtime.RawExtension{Raw: mergedSpec}
+	}
+	return defaultedService, nil
+}
+
+// applyOperatorDefaults merges the spec of every custom resource of the service over the defaults
+// of the operator in the OperandRegistry, which have the lowest precedence
+func applyOperatorDefaults(operator *operatorv1alpha1.Operator, service *operatorv1alpha1.ConfigService) (*operatorv1alpha1.ConfigService, error) {
+	if operator == nil || len(operator.Defaults) == 0 {
+		return service, nil
+	}
+	defaultedService := service.DeepCopy()
+	for cr, spec := range service.Spec {
+		// The named instances inherit the defaults of their kind
+		kindKey, _ := operatorv1alpha1.ParseCRSpecKey(cr)
+		defaults, ok := operator.Defaults[kindKey]
+		if !ok || len(defaults.Raw) == 0 {
+			continue
+		}
+		if err := operatorv1alpha1.ValidateObjectValue(defaults.Raw); err != nil {
+			return nil, errors.Wrapf(err, "invalid defaults of %s in the OperandRegistry for the operator %s", cr, operator.Name)
+		}
+		mergedSpec, err := json.Marshal(util.MergeCR(defaults.Raw, spec.Raw))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal the spec of %s with the defaults of the operator %s", cr, operator.Name)
+		}
+		defaultedService.Spec[cr] = runtime.RawExtension{Raw: mergedSpec}
+	}
+	return defaultedService, nil
+}
+
+// applyOverrides returns a copy of the service whose spec is merged with
+// the overrides matching the cluster version
+func (r *Reconciler) applyOverrides(ctx context.Context, service *operatorv1alpha1.ConfigService) (*operatorv1alpha1.ConfigService, error) {
+	if len(service.Overrides) == 0 {
+		return service, nil
+	}
+	if r.ClusterVersionDetector == nil {
+		klog.Warningf("Cluster version detection is disabled, skip the overrides of the service %s", service.Name)
+		return service, nil
+	}
+	version, err := r.ClusterVersionDetector.Detect(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to detect the cluster version for the overrides of the service %s", service.Name)
+	}
+
+	overriddenService := service.DeepCopy()
+	if overriddenService.Spec == nil {
+		overriddenService.Spec = make(map[string]runtime.RawExtension)
+	}
+	for _, override := range service.Overrides {
+		versionRange, err := semver.ParseRange(override.ClusterVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cluster version %s in the overrides of the service %s", override.ClusterVersion, service.Name)
+		}
+		if !versionRange(version) {
+			continue
+		}
+		klog.V(2).Infof("Apply the override for the cluster version %s to the service %s", override.ClusterVersion, service.Name)
+		for cr, spec := range override.Spec {
+			mergedSpec, err := json.Marshal(util.MergeCR(overriddenService.Spec[cr].Raw, spec.Raw))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to marshal the spec of %s in the service %s", cr, service.Name)
+			}
+			overriddenService.Spec[cr] = runtime.RawExtension{Raw: mergedSpec}
+		}
+	}
+	return overriddenService, nil
+}
+
+// failedCustomResourceNamespace returns the namespace of the ConfigMap keeping the failed custom resource,
+// it is the namespace of the OperandRequest of the context, so that the OperandRequest can own the ConfigMap
+func failedCustomResourceNamespace(ctx context.Context, cr unstructured.Unstructured) string {
+	if requestInstance, _ := ctx.Value(eventObjectKey{}).(*operatorv1alpha1.OperandRequest); requestInstance != nil {
+		return requestInstance.Namespace
+	}
+	return cr.GetNamespace()
+}
+
+// failedCustomResourceName returns the name of the ConfigMap keeping the failed custom resource
+func failedCustomResourceName(cr unstructured.Unstructured) string {
+	name := "failed-" + strings.ToLower(cr.GetKind()) + "-" + cr.GetName()
+	if len(name) > validation.DNS1123SubdomainMaxLength {
+		name = name[:validation.DNS1123SubdomainMaxLength]
+	}
+	return strings.TrimRight(name, "-.")
+}
+
+// keepFailedCustomResource saves the custom resource failed to be created into a ConfigMap, so that users can inspect
+// what was attempted. The ConfigMap is owned by the OperandRequest of the context and lives in its namespace.
+func (r *Reconciler) keepFailedCustomResource(ctx context.Context, cr unstructured.Unstructured, crErr error) error {
+	crYAML, err := yaml.Marshal(cr.Object)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the custom resource %s/%s", cr.GetNamespace(), cr.GetName())
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      failedCustomResourceName(cr),
+			Namespace: failedCustomResourceNamespace(ctx, cr),
+			Labels: map[string]string{
+				constant.FailedCRLabel: "true",
+			},
+		},
+		Data: map[string]string{
+			"customResource.yaml": string(crYAML),
+			"error":               crErr.Error(),
+		},
+	}
+	if requestInstance, _ := ctx.Value(eventObjectKey{}).(*operatorv1alpha1.OperandRequest); requestInstance != nil {
+		if err := controllerutil.SetOwnerReference(requestInstance, cm, r.Scheme); err != nil {
+			return errors.Wrapf(err, "failed to set the owner of ConfigMap %s/%s", cm.Namespace, cm.Name)
+		}
+	}
+	if err := r.Create(ctx, cm); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create ConfigMap %s/%s", cm.Namespace, cm.Name)
+		}
+		if err := r.Update(ctx, cm); err != nil {
+			return errors.Wrapf(err, "failed to update ConfigMap %s/%s", cm.Namespace, cm.Name)
+		}
+	}
+	klog.V(1).Infof("Keep the failed custom resource %s/%s in the ConfigMap %s", cr.GetNamespace(), cr.GetName(), cm.Name)
+	return nil
+}
+
+// deleteFailedCustomResource deletes the ConfigMap keeping the custom resource once it is created
+func (r *Reconciler) deleteFailedCustomResource(ctx context.Context, cr unstructured.Unstructured) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      failedCustomResourceName(cr),
+			Namespace: failedCustomResourceNamespace(ctx, cr),
+		},
+	}
+	if err := r.Delete(ctx, cm); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete ConfigMap %s/%s", cm.Namespace, cm.Name)
+	}
+	return nil
+}
+
+// pendingInstallPlanRef returns the InstallPlan of the subscription when it is waiting for a manual approval
+func pendingInstallPlanRef(sub *olmv1alpha1.Subscription) *operatorv1alpha1.InstallPlanReference {
+	if sub.Status.InstallPlanRef == nil {
+		return nil
+	}
+	cond := sub.Status.GetCondition(olmv1alpha1.SubscriptionInstallPlanPending)
+	if cond.Status != corev1.ConditionTrue || cond.Reason != string(olmv1alpha1.InstallPlanPhaseRequiresApproval) {
+		return nil
+	}
+	return &operatorv1alpha1.InstallPlanReference{
+		Name:      sub.Status.InstallPlanRef.Name,
+		Namespace: sub.Status.InstallPlanRef.Namespace,
+	}
+}
+
+// unhealthyCatalogSource returns the health of the CatalogSource of the subscription when it is unhealthy,
+// i.e. OLM can't connect to its registry, or it doesn't exist
+func (r *Reconciler) unhealthyCatalogSource(ctx context.Context, sub *olmv1alpha1.Subscription) (*operatorv1alpha1.CatalogSourceHealth, error) {
+	if sub.Spec == nil || sub.Spec.CatalogSource == "" {
+		return nil, nil
+	}
+	health := &operatorv1alpha1.CatalogSourceHealth{
+		Name:      sub.Spec.CatalogSource,
+		Namespace: sub.Spec.CatalogSourceNamespace,
+		Reason:    operatorv1alpha1.CatalogSourceUnhealthy,
+	}
+	catalogSource := &olmv1alpha1.CatalogSource{}
+	if err := r.Reader.Get(ctx, types.NamespacedName{Name: health.Name, Namespace: health.Namespace}, catalogSource); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "failed to get the CatalogSource %s/%s", health.Namespace, health.Name)
+		}
+		health.Reason = operatorv1alpha1.CatalogSourceNotFound
+		health.Message = "The CatalogSource doesn't exist"
+		return health, nil
+	}
+	if state := catalogSource.Status.GRPCConnectionState; state != nil {
+		if state.LastObservedState == "READY" {
+			return nil, nil
+		}
+		health.Message = "The connection state of the registry is " + state.LastObservedState
+		return health, nil
+	}
+	if catalogSource.Status.Reason != "" {
+		health.Message = string(catalogSource.Status.Reason) + ": " + catalogSource.Status.Message
+		return health, nil
+	}
+	return nil, nil
+}
+
+// servedAPIVersion returns the preferred apiVersion served for the kind, it differs from the given apiVersion
+// once the operator deprecates it. The given apiVersion is kept when it can't be migrated.
+func (r *Reconciler) servedAPIVersion(apiVersion, kind string) (string, error) {
+	if r.Discovery == nil {
+		return apiVersion, nil
+	}
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse the apiVersion %s of %s", apiVersion, kind)
+	}
+	groups, err := r.Discovery.ServerGroups()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to discover the apiVersions served for %s", kind)
+	}
+	for _, group := range groups.Groups {
+		if group.Name != gv.Group {
+			continue
+		}
+		preferred := group.PreferredVersion.GroupVersion
+		if preferred == "" || preferred == apiVersion {
+			return apiVersion, nil
+		}
+		resources, err := r.Discovery.ServerResourcesForGroupVersion(preferred)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to discover the resources served by %s", preferred)
+		}
+		for _, resource := range resources.APIResources {
+			if resource.Kind == kind {
+				klog.V(2).Infof("Migrating %s from the apiVersion %s to %s", kind, apiVersion, preferred)
+				return preferred, nil
+			}
+		}
+		return apiVersion, nil
+	}
+	// The group isn't served yet, e.g. the operator is still being installed
+	return apiVersion, nil
+}
@@ -0,0 +1,1665 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/blang/semver/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	olmv1 "github.com/operator-framework/api/pkg/operators/v1"
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/clusterversion"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Reconcile operand", func() {
+	const (
+		operatorNamespace = "ibm-operators-operand"
+	)
+
+	var (
+		ctx                   context.Context
+		r                     *Reconciler
+		operatorNamespaceName string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client: k8sClient,
+				Reader: k8sClient,
+			},
+		}
+		operatorNamespaceName = testutil.CreateNSName(operatorNamespace)
+		Expect(k8sClient.Create(ctx, testutil.NamespaceObj(operatorNamespaceName))).Should(Succeed())
+	})
+
+	Context("Resolving secret references in the OperandConfig", func() {
+		It("Should inject the secret value into the custom resource only", func() {
+			By("Creating the Secret")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "etcd-version",
+					Namespace: operatorNamespaceName,
+				},
+				StringData: map[string]string{
+					"version": "3.4.13",
+				},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(`{"version": {"valueFrom": {"secretKeyRef": {"name": "etcd-version", "key": "version"}}}}`)},
+				},
+			}
+			csv := testutil.ClusterServiceVersion("etcd-csv.v0.0.1", operatorNamespaceName, testutil.EtcdExample)
+
+			By("Reconciling the custom resource with the OperandConfig")
+			Expect(r.reconcileCRwithConfig(ctx, service, operatorNamespaceName, csv)).Should(Succeed())
+
+			By("Checking the secret value is injected into the custom resource")
+			etcdCluster := &unstructured.Unstructured{}
+			etcdCluster.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+			etcdCluster.SetKind("EtcdCluster")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}, etcdCluster)).Should(Succeed())
+			version, _, _ := unstructured.NestedString(etcdCluster.Object, "spec", "version")
+			Expect(version).Should(Equal("3.4.13"))
+
+			By("Checking the secret value is not in the OperandConfig service")
+			Expect(string(service.Spec["etcdCluster"].Raw)).ShouldNot(ContainSubstring("3.4.13"))
+			Expect(string(service.Spec["etcdCluster"].Raw)).Should(ContainSubstring("secretKeyRef"))
+			for _, v := range etcdCluster.GetAnnotations() {
+				Expect(v).ShouldNot(ContainSubstring("3.4.13"))
+			}
+		})
+
+		It("Should fail when the referenced secret key doesn't exist", func() {
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(`{"version": {"valueFrom": {"secretKeyRef": {"name": "not-exist", "key": "version"}}}}`)},
+				},
+			}
+			_, err := r.resolveKeyRefs(ctx, service, operatorNamespaceName)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+	Context("Resolving configmap references in the OperandConfig", func() {
+		const registryName, registryNamespace = "common-service", "ibm-common-services"
+		var (
+			c    client.Client
+			objs []client.Object
+		)
+
+		BeforeEach(func() {
+			objs = []client.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "etcd-credentials", Namespace: operatorNamespaceName},
+					Data:       map[string][]byte{"password": []byte("passw0rd")},
+				},
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "etcd-settings", Namespace: operatorNamespaceName},
+					Data:       map[string]string{"version": "3.4.13"},
+				},
+			}
+		})
+
+		It("Should replace the secret and configmap references with their values", func() {
+			r, c = newEtcdOperandReconciler(objs...)
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(`{"version": {"valueFrom": {"configMapKeyRef": {"name": "etcd-settings", "key": "version"}}},` +
+						`"auth": [{"password": {"valueFrom": {"secretKeyRef": {"name": "etcd-credentials", "key": "password"}}}}]}`)},
+				},
+			}
+			resolved, err := r.resolveKeyRefs(ctx, service, operatorNamespaceName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved.Spec["etcdCluster"].Raw).Should(MatchJSON(`{"version": "3.4.13", "auth": [{"password": "passw0rd"}]}`))
+			Expect(string(service.Spec["etcdCluster"].Raw)).Should(ContainSubstring("configMapKeyRef"))
+		})
+
+		It("Should fail the operand on a missing configmap key until it is added", func() {
+			// The version of etcd is read from a key missing from the configmap
+			config := testutil.OperandConfigObj(registryName, registryNamespace)
+			config.Spec.Services[0].Spec["etcdCluster"] = runtime.RawExtension{Raw: []byte(`{"version": {"valueFrom": {"configMapKeyRef": {"name": "etcd-settings", "key": "etcd-version"}}}}`)}
+			sub, csv := runningEtcdOperator(operatorNamespaceName)
+			r, c = newEtcdOperandReconciler(append(objs,
+				testutil.NamespaceObj("ibm-cloudpak"), testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespaceName), config, sub, csv,
+			)...)
+
+			request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+			request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+			missingReferences := func() []string {
+				var messages []string
+				for _, condition := range request.Status.Conditions {
+					if condition.Type == operatorv1alpha1.ConditionMissingConfigReference {
+						messages = append(messages, condition.Message)
+					}
+				}
+				return messages
+			}
+			getEtcdCluster := func() (*unstructured.Unstructured, error) {
+				etcdCluster := &unstructured.Unstructured{}
+				etcdCluster.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+				etcdCluster.SetKind("EtcdCluster")
+				err := c.Get(ctx, types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}, etcdCluster)
+				return etcdCluster, err
+			}
+
+			By("Failing the operand without writing an empty value")
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).ShouldNot(BeEmpty())
+			Expect(request.GetMemberOperandPhase("etcd", &sync.Mutex{})).Should(Equal(operatorv1alpha1.ServiceFailed))
+			Expect(missingReferences()).Should(ConsistOf("Missing the key etcd-version of the configmap etcd-settings referenced by the OperandConfig for etcd"))
+			_, err := getEtcdCluster()
+			Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+
+			By("Creating the custom resource once the key is added")
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, types.NamespacedName{Name: "etcd-settings", Namespace: operatorNamespaceName}, cm)).Should(Succeed())
+			cm.Data["etcd-version"] = "3.4.13"
+			Expect(c.Update(ctx, cm)).Should(Succeed())
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.GetMemberOperandPhase("etcd", &sync.Mutex{})).Should(Equal(operatorv1alpha1.ServiceRunning))
+			Expect(missingReferences()).Should(BeEmpty())
+			etcdCluster, err := getEtcdCluster()
+			Expect(err).NotTo(HaveOccurred())
+			version, _, _ := unstructured.NestedString(etcdCluster.Object, "spec", "version")
+			Expect(version).Should(Equal("3.4.13"))
+		})
+	})
+
+	Context("Updating the custom resource with the update strategy", func() {
+		getEtcdCluster := func() *unstructured.Unstructured {
+			etcdCluster := &unstructured.Unstructured{}
+			etcdCluster.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+			etcdCluster.SetKind("EtcdCluster")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}, etcdCluster)).Should(Succeed())
+			return etcdCluster
+		}
+
+		DescribeTable("Should apply the changed spec",
+			func(updateStrategy operatorv1alpha1.UpdateStrategy, recreated bool) {
+				service := &operatorv1alpha1.ConfigService{
+					Name: "etcd",
+					Spec: map[string]runtime.RawExtension{
+						"etcdCluster": {Raw: []byte(`{"size": 1}`)},
+					},
+					UpdateStrategy: updateStrategy,
+				}
+				csv := testutil.ClusterServiceVersion("etcd-csv.v0.0.1", operatorNamespaceName, testutil.EtcdExample)
+
+				By("Creating the custom resource")
+				Expect(r.reconcileCRwithConfig(ctx, service, operatorNamespaceName, csv)).Should(Succeed())
+				originalUID := getEtcdCluster().GetUID()
+
+				By("Changing the spec of the custom resource")
+				service.Spec["etcdCluster"] = runtime.RawExtension{Raw: []byte(`{"size": 5}`)}
+				Expect(r.reconcileCRwithConfig(ctx, service, operatorNamespaceName, csv)).Should(Succeed())
+
+				By("Checking the custom resource is updated")
+				etcdCluster := getEtcdCluster()
+				size, _, _ := unstructured.NestedInt64(etcdCluster.Object, "spec", "size")
+				Expect(size).Should(Equal(int64(5)))
+				Expect(etcdCluster.GetLabels()).Should(HaveKeyWithValue(constant.OpreqLabel, "true"))
+				Expect(etcdCluster.GetAnnotations()).Should(HaveKeyWithValue(constant.LastAppliedChangesAnnotation, "size"))
+				if recreated {
+					Expect(etcdCluster.GetUID()).ShouldNot(Equal(originalUID))
+				} else {
+					Expect(etcdCluster.GetUID()).Should(Equal(originalUID))
+				}
+			},
+			Entry("Patch by default", operatorv1alpha1.UpdateStrategy(""), false),
+			Entry("Patch", operatorv1alpha1.UpdateStrategyPatch, false),
+			Entry("Recreate", operatorv1alpha1.UpdateStrategyRecreate, true),
+		)
+
+		It("Should ignore the changes of the spec paths written by the operator", func() {
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(`{"size": 1, "version": "3.2.13"}`)},
+				},
+				IgnoredSpecPaths: []string{"version"},
+			}
+			csv := testutil.ClusterServiceVersion("etcd-csv.v0.0.1", operatorNamespaceName, testutil.EtcdExample)
+
+			By("Creating the custom resource")
+			Expect(r.reconcileCRwithConfig(ctx, service, operatorNamespaceName, csv)).Should(Succeed())
+
+			By("Changing the version of the custom resource by the operator")
+			etcdCluster := getEtcdCluster()
+			Expect(unstructured.SetNestedField(etcdCluster.Object, "3.4.13", "spec", "version")).Should(Succeed())
+			Expect(k8sClient.Update(ctx, etcdCluster)).Should(Succeed())
+			generation := getEtcdCluster().GetGeneration()
+
+			By("Checking the custom resource isn't updated")
+			Expect(r.reconcileCRwithConfig(ctx, service, operatorNamespaceName, csv)).Should(Succeed())
+			etcdCluster = getEtcdCluster()
+			Expect(etcdCluster.GetGeneration()).Should(Equal(generation))
+			version, _, _ := unstructured.NestedString(etcdCluster.Object, "spec", "version")
+			Expect(version).Should(Equal("3.4.13"))
+
+			By("Checking the other changes keep the version written by the operator")
+			service.Spec["etcdCluster"] = runtime.RawExtension{Raw: []byte(`{"size": 5, "version": "3.2.13"}`)}
+			Expect(r.reconcileCRwithConfig(ctx, service, operatorNamespaceName, csv)).Should(Succeed())
+			etcdCluster = getEtcdCluster()
+			size, _, _ := unstructured.NestedInt64(etcdCluster.Object, "spec", "size")
+			Expect(size).Should(Equal(int64(5)))
+			version, _, _ = unstructured.NestedString(etcdCluster.Object, "spec", "version")
+			Expect(version).Should(Equal("3.4.13"))
+		})
+	})
+	Context("Matching the custom resources by GroupVersionKind", func() {
+		It("Should configure the same kind of different groups separately", func() {
+			// The same kind from different groups isn't served by the test API server
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+			addUnstructuredKinds(s, schema.GroupVersionKind{Group: "a.example.com", Version: "v1", Kind: "Cluster"},
+				schema.GroupVersionKind{Group: "b.example.com", Version: "v1", Kind: "Cluster"})
+			c := fake.NewClientBuilder().WithScheme(s).Build()
+			r.Client, r.Reader = c, c
+			csv := testutil.ClusterServiceVersion("cluster-csv.v0.0.1", operatorNamespaceName, `[
+				{"apiVersion": "a.example.com/v1", "kind": "Cluster", "metadata": {"name": "example-a"}, "spec": {"size": 1}},
+				{"apiVersion": "b.example.com/v1", "kind": "Cluster", "metadata": {"name": "example-b"}, "spec": {"size": 1}}
+			]`)
+			getSize := func(apiVersion, name string) int64 {
+				cluster := &unstructured.Unstructured{}
+				cluster.SetAPIVersion(apiVersion)
+				cluster.SetKind("Cluster")
+				Expect(c.Get(ctx, types.NamespacedName{Name: name, Namespace: operatorNamespaceName}, cluster)).Should(Succeed())
+				size, _, _ := unstructured.NestedInt64(cluster.Object, "spec", "size")
+				return size
+			}
+
+			By("Creating the custom resources with the group/version/kind keys")
+			service := &operatorv1alpha1.ConfigService{
+				Name: "cluster",
+				Spec: map[string]runtime.RawExtension{
+					"a.example.com/v1/Cluster": {Raw: []byte(`{"size": 3}`)},
+					"b.example.com/v1/Cluster": {Raw: []byte(`{"size": 5}`)},
+				},
+			}
+			Expect(r.reconcileCRwithConfig(ctx, service, operatorNamespaceName, csv)).Should(Succeed())
+			Expect(getSize("a.example.com/v1", "example-a")).Should(Equal(int64(3)))
+			Expect(getSize("b.example.com/v1", "example-b")).Should(Equal(int64(5)))
+
+			By("Updating the custom resources with the kind key matching any group")
+			service.Spec = map[string]runtime.RawExtension{
+				"cluster":                  {Raw: []byte(`{"size": 2}`)},
+				"b.example.com/v1/Cluster": {Raw: []byte(`{"size": 4}`)},
+			}
+			Expect(r.reconcileCRwithConfig(ctx, service, operatorNamespaceName, csv)).Should(Succeed())
+			Expect(getSize("a.example.com/v1", "example-a")).Should(Equal(int64(2)))
+			Expect(getSize("b.example.com/v1", "example-b")).Should(Equal(int64(4)))
+		})
+	})
+
+	Context("Filtering the alm-examples managed by the service", func() {
+		DescribeTable("Should only create the custom resources included and not excluded",
+			func(includeCRs, excludeCRs []string, expected []string) {
+				s := runtime.NewScheme()
+				Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+				addUnstructuredKinds(s, schema.GroupVersionKind{Group: "a.example.com", Version: "v1", Kind: "Cluster"},
+					schema.GroupVersionKind{Group: "b.example.com", Version: "v1", Kind: "Cluster"},
+					schema.GroupVersionKind{Group: "a.example.com", Version: "v1", Kind: "Backup"})
+				c := fake.NewClientBuilder().WithScheme(s).Build()
+				r.Client, r.Reader = c, c
+				csv := testutil.ClusterServiceVersion("cluster-csv.v0.0.1", operatorNamespaceName, `[
+					{"apiVersion": "a.example.com/v1", "kind": "Cluster", "metadata": {"name": "example-a"}, "spec": {"size": 1}},
+					{"apiVersion": "b.example.com/v1", "kind": "Cluster", "metadata": {"name": "example-b"}, "spec": {"size": 1}},
+					{"apiVersion": "a.example.com/v1", "kind": "Backup", "metadata": {"name": "example-backup"}, "spec": {"schedule": "daily"}}
+				]`)
+				service := &operatorv1alpha1.ConfigService{
+					Name: "cluster",
+					Spec: map[string]runtime.RawExtension{
+						"cluster": {Raw: []byte(`{"size": 3}`)},
+						"backup":  {Raw: []byte(`{}`)},
+					},
+					IncludeCRs: includeCRs,
+					ExcludeCRs: excludeCRs,
+				}
+				Expect(r.reconcileCRwithConfig(ctx, service, operatorNamespaceName, csv)).Should(Succeed())
+
+				var created []string
+				for _, apiVersionKind := range [][2]string{{"a.example.com/v1", "ClusterList"}, {"b.example.com/v1", "ClusterList"}, {"a.example.com/v1", "BackupList"}} {
+					list := &unstructured.UnstructuredList{}
+					list.SetAPIVersion(apiVersionKind[0])
+					list.SetKind(apiVersionKind[1])
+					Expect(c.List(ctx, list)).Should(Succeed())
+					for _, cr := range list.Items {
+						created = append(created, cr.GetName())
+					}
+				}
+				Expect(created).Should(ConsistOf(expected))
+			},
+			Entry("Managing all the alm-examples by default", nil, nil, []string{"example-a", "example-b", "example-backup"}),
+			Entry("Including the group/version/kind", []string{"a.example.com/v1/Cluster"}, nil, []string{"example-a"}),
+			Entry("Including the kind and excluding the name", []string{"cluster"}, []string{"example-b"}, []string{"example-a"}),
+			Entry("Excluding the kind", nil, []string{"backup"}, []string{"example-a", "example-b"}),
+			Entry("Excluding the kind included", []string{"backup"}, []string{"Backup"}, []string{}),
+		)
+	})
+
+	Context("Reporting the changes of the custom resources in dry-run mode", func() {
+		etcdScheme := func(kinds ...string) *runtime.Scheme {
+			s := runtime.NewScheme()
+			for _, kind := range kinds {
+				addUnstructuredKinds(s, schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: kind})
+			}
+			return s
+		}
+
+		It("Should list the created, updated and deleted custom resources without writing them", func() {
+			existing := func(kind, name string, size int64) *unstructured.Unstructured {
+				cr := &unstructured.Unstructured{}
+				cr.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+				cr.SetKind(kind)
+				cr.SetName(name)
+				cr.SetNamespace(operatorNamespaceName)
+				cr.SetLabels(map[string]string{constant.OpreqLabel: "true"})
+				cr.Object["spec"] = map[string]interface{}{"size": size}
+				return cr
+			}
+			c := fake.NewClientBuilder().WithScheme(etcdScheme("EtcdCluster", "EtcdBackup", "EtcdRestore")).WithObjects(existing("EtcdCluster", "example", 1), existing("EtcdRestore", "restore", 1)).Build()
+			r.Client, r.Reader = c, c
+			csv := testutil.ClusterServiceVersion("etcd-csv.v0.0.1", operatorNamespaceName, `[
+				{"apiVersion": "etcd.database.coreos.com/v1beta2", "kind": "EtcdCluster", "metadata": {"name": "example"}, "spec": {"size": 1}},
+				{"apiVersion": "etcd.database.coreos.com/v1beta2", "kind": "EtcdBackup", "metadata": {"name": "backup"}, "spec": {"size": 1}},
+				{"apiVersion": "etcd.database.coreos.com/v1beta2", "kind": "EtcdRestore", "metadata": {"name": "restore"}, "spec": {"size": 1}}
+			]`)
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(`{"size": 3}`)},
+					"etcdBackup":  {Raw: []byte(`{"storageType": "S3"}`)},
+				},
+			}
+
+			changes, err := r.dryRunCRwithConfig(ctx, service, operatorNamespaceName, csv)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).Should(ConsistOf(
+				operatorv1alpha1.DryRunChange{APIVersion: "etcd.database.coreos.com/v1beta2", Kind: "EtcdCluster", Name: "example", Namespace: operatorNamespaceName, Action: operatorv1alpha1.DryRunUpdate, Fields: []string{"size"}},
+				operatorv1alpha1.DryRunChange{APIVersion: "etcd.database.coreos.com/v1beta2", Kind: "EtcdBackup", Name: "backup", Namespace: operatorNamespaceName, Action: operatorv1alpha1.DryRunCreate, Fields: []string{"storageType"}},
+				operatorv1alpha1.DryRunChange{APIVersion: "etcd.database.coreos.com/v1beta2", Kind: "EtcdRestore", Name: "restore", Namespace: operatorNamespaceName, Action: operatorv1alpha1.DryRunDelete},
+			))
+
+			By("Keeping the custom resources unchanged")
+			cluster := existing("EtcdCluster", "example", 0)
+			Expect(c.Get(ctx, types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}, cluster)).Should(Succeed())
+			Expect(cluster.Object["spec"]).Should(HaveKeyWithValue("size", int64(1)))
+			Expect(c.Get(ctx, types.NamespacedName{Name: "restore", Namespace: operatorNamespaceName}, existing("EtcdRestore", "restore", 0))).Should(Succeed())
+			err = c.Get(ctx, types.NamespacedName{Name: "backup", Namespace: operatorNamespaceName}, existing("EtcdBackup", "backup", 0))
+			Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+		})
+
+		It("Should skip the excluded custom resources and report the named instances", func() {
+			instance := &unstructured.Unstructured{}
+			instance.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+			instance.SetKind("EtcdCluster")
+			instance.SetName("stale")
+			instance.SetNamespace(operatorNamespaceName)
+			instance.SetLabels(map[string]string{constant.OpreqLabel: "true", constant.CRInstanceLabel: "etcd"})
+			instance.Object["spec"] = map[string]interface{}{"size": int64(1)}
+			c := fake.NewClientBuilder().WithScheme(etcdScheme("EtcdCluster", "EtcdBackup")).WithObjects(instance).Build()
+			r.Client, r.Reader = c, c
+			csv := testutil.ClusterServiceVersion("etcd-csv.v0.0.1", operatorNamespaceName, `[
+				{"apiVersion": "etcd.database.coreos.com/v1beta2", "kind": "EtcdCluster", "metadata": {"name": "example"}, "spec": {"size": 1}},
+				{"apiVersion": "etcd.database.coreos.com/v1beta2", "kind": "EtcdBackup", "metadata": {"name": "backup"}, "spec": {"size": 1}}
+			]`)
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster":        {Raw: []byte(`{"size": 3}`)},
+					"etcdCluster:second": {Raw: []byte(`{"version": "3.4"}`)},
+					"etcdBackup":         {Raw: []byte(`{"storageType": "S3"}`)},
+				},
+				ExcludeCRs: []string{"backup"},
+			}
+
+			changes, err := r.dryRunCRwithConfig(ctx, service, operatorNamespaceName, csv)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).Should(ConsistOf(
+				operatorv1alpha1.DryRunChange{APIVersion: "etcd.database.coreos.com/v1beta2", Kind: "EtcdCluster", Name: "example", Namespace: operatorNamespaceName, Action: operatorv1alpha1.DryRunCreate, Fields: []string{"size"}},
+				operatorv1alpha1.DryRunChange{APIVersion: "etcd.database.coreos.com/v1beta2", Kind: "EtcdCluster", Name: "second", Namespace: operatorNamespaceName, Action: operatorv1alpha1.DryRunCreate, Fields: []string{"version"}},
+				operatorv1alpha1.DryRunChange{APIVersion: "etcd.database.coreos.com/v1beta2", Kind: "EtcdCluster", Name: "stale", Namespace: operatorNamespaceName, Action: operatorv1alpha1.DryRunDelete},
+			))
+		})
+
+		It("Should report nothing for the custom resource requested up to date", func() {
+			operand := operatorv1alpha1.Operand{
+				Name:       "etcd",
+				APIVersion: "etcd.database.coreos.com/v1beta2",
+				Kind:       "EtcdCluster",
+				Spec:       &runtime.RawExtension{Raw: []byte(`{"size": 1}`)},
+			}
+			key := types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}
+			c := fake.NewClientBuilder().Build()
+			r.Client, r.Reader = c, c
+
+			change, err := r.dryRunCRwithRequest(ctx, operand, key, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(change.Action).Should(Equal(operatorv1alpha1.DryRunCreate))
+
+			Expect(r.reconcileCRwithRequest(ctx, &operatorv1alpha1.OperandRequest{}, operand, key, 0)).Should(Succeed())
+			change, err = r.dryRunCRwithRequest(ctx, operand, key, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(change).Should(BeNil())
+		})
+	})
+
+	Context("Requesting an operand missing from the OperandConfig", func() {
+		It("Should record the missing config service in the member status", func() {
+			const registryName, registryNamespace = "common-service", "ibm-common-services"
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+			Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+
+			// The OperandConfig only has the service of jenkins
+			config := testutil.OperandConfigObj(registryName, registryNamespace)
+			config.Spec.Services = config.Spec.Services[1:]
+			sub := testutil.Subscription("etcd", operatorNamespaceName)
+			sub.Status = testutil.SubscriptionStatus("etcd", operatorNamespaceName, "0.0.1")
+			csv := testutil.ClusterServiceVersion(sub.Status.CurrentCSV, operatorNamespaceName, testutil.EtcdExample)
+			csv.Status = testutil.ClusterServiceVersionStatus()
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+				testutil.NamespaceObj("ibm-cloudpak"), testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespaceName), config, sub, csv,
+			).Build()
+			r.Client, r.Reader = c, c
+
+			request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+			request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+			merr := r.reconcileOperand(ctx, request, nil)
+			Expect(merr.Errors).Should(BeEmpty())
+			Expect(request.Status.Members).Should(HaveLen(1))
+			Expect(request.Status.Members[0].Name).Should(Equal("etcd"))
+			Expect(request.Status.Members[0].Phase.OperatorPhase).Should(Equal(operatorv1alpha1.OperatorRunning))
+			Expect(request.Status.Members[0].Phase.OperandPhase).Should(Equal(operatorv1alpha1.ServiceConfigMissing))
+		})
+	})
+
+	Context("Requesting an operand configured in another namespace", func() {
+		const registryName, registryNamespace, configNamespace = "common-service", "ibm-common-services", "ibm-common-configs"
+		var c client.Client
+
+		BeforeEach(func() {
+			sub, csv := runningEtcdOperator(operatorNamespaceName)
+			r, c = newEtcdOperandReconciler(
+				testutil.NamespaceObj("ibm-cloudpak"), testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespaceName),
+				testutil.OperandConfigObj(registryName, configNamespace), sub, csv,
+			)
+		})
+
+		It("Should create the custom resources from the OperandConfig in the config namespace", func() {
+			request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+			request.Spec.Requests[0].ConfigNamespace = configNamespace
+			request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+			merr := r.reconcileOperand(ctx, request, nil)
+			Expect(merr.Errors).Should(BeEmpty())
+			Expect(request.Status.Members).Should(HaveLen(1))
+			Expect(request.Status.Members[0].Phase.OperandPhase).Should(Equal(operatorv1alpha1.ServiceRunning))
+
+			etcdCluster := &unstructured.Unstructured{}
+			etcdCluster.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+			etcdCluster.SetKind("EtcdCluster")
+			Expect(c.Get(ctx, types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}, etcdCluster)).Should(Succeed())
+		})
+
+		It("Should look for the OperandConfig in the registry namespace by default", func() {
+			request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+			request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+			merr := r.reconcileOperand(ctx, request, nil)
+			Expect(merr.Errors).Should(HaveLen(1))
+			Expect(merr.Errors[0]).Should(ContainSubstring("failed to get the OperandConfig " + registryNamespace + "/" + registryName))
+		})
+	})
+
+	Context("Requesting an operand whose service has no spec", func() {
+		DescribeTable("Should either create the custom resources from the alm-examples or record the empty service spec",
+			func(applyDefaults bool, operandPhase operatorv1alpha1.ServicePhase) {
+				const registryName, registryNamespace = "common-service", "ibm-common-services"
+				config := testutil.OperandConfigObj(registryName, registryNamespace)
+				config.Spec.Services[0].Spec = nil
+				sub, csv := runningEtcdOperator(operatorNamespaceName)
+				var c client.Client
+				r, c = newEtcdOperandReconciler(
+					testutil.NamespaceObj("ibm-cloudpak"), testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespaceName), config, sub, csv,
+				)
+				r.ApplyDefaults = applyDefaults
+
+				request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+				request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+				merr := r.reconcileOperand(ctx, request, nil)
+				Expect(merr.Errors).Should(BeEmpty())
+				Expect(request.Status.Members).Should(HaveLen(1))
+				Expect(request.Status.Members[0].Phase.OperandPhase).Should(Equal(operandPhase))
+
+				etcdCluster := &unstructured.Unstructured{}
+				etcdCluster.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+				etcdCluster.SetKind("EtcdCluster")
+				err := c.Get(ctx, types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}, etcdCluster)
+				if applyDefaults {
+					Expect(err).NotTo(HaveOccurred())
+					size, _, _ := unstructured.NestedInt64(etcdCluster.Object, "spec", "size")
+					Expect(size).Should(Equal(int64(3)))
+				} else {
+					Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+				}
+			},
+			Entry("Apply the defaults", true, operatorv1alpha1.ServiceRunning),
+			Entry("Skip the custom resources", false, operatorv1alpha1.ServiceSpecEmpty),
+		)
+	})
+
+	Context("Requesting an operand with the custom resources managed by the user", func() {
+		DescribeTable("Should create the custom resources only when installCR isn't false",
+			func(installCR *bool, operandPhase operatorv1alpha1.ServicePhase) {
+				const registryName, registryNamespace = "common-service", "ibm-common-services"
+				sub, csv := runningEtcdOperator(operatorNamespaceName)
+				var c client.Client
+				r, c = newEtcdOperandReconciler(
+					testutil.NamespaceObj("ibm-cloudpak"), testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespaceName),
+					testutil.OperandConfigObj(registryName, registryNamespace), sub, csv,
+				)
+
+				request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+				request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+				request.Spec.Requests[0].Operands[0].InstallCR = installCR
+				merr := r.reconcileOperand(ctx, request, nil)
+				Expect(merr.Errors).Should(BeEmpty())
+				Expect(request.Status.Members).Should(HaveLen(1))
+				Expect(request.Status.Members[0].Phase.OperatorPhase).Should(Equal(operatorv1alpha1.OperatorRunning))
+				Expect(request.Status.Members[0].Phase.OperandPhase).Should(Equal(operandPhase))
+
+				etcdCluster := &unstructured.Unstructured{}
+				etcdCluster.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+				etcdCluster.SetKind("EtcdCluster")
+				err := c.Get(ctx, types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}, etcdCluster)
+				if installCR == nil || *installCR {
+					Expect(err).NotTo(HaveOccurred())
+				} else {
+					Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+				}
+			},
+			Entry("Install the custom resources by default", nil, operatorv1alpha1.ServiceRunning),
+			Entry("Install the custom resources", boolPtr(true), operatorv1alpha1.ServiceRunning),
+			Entry("Leave the custom resources to the user", boolPtr(false), operatorv1alpha1.ServiceUserManaged),
+		)
+	})
+
+	Context("Disabling the management of the custom resources", func() {
+		DescribeTable("Should install the operator without applying the custom resources",
+			func(requestManageCRs, serviceManageCRs *bool, operandPhase operatorv1alpha1.ServicePhase) {
+				const registryName, registryNamespace = "common-service", "ibm-common-services"
+				registry := testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespaceName)
+				for i := range registry.Spec.Operators {
+					registry.Spec.Operators[i].InstallPlanApproval = olmv1alpha1.ApprovalAutomatic
+				}
+				config := testutil.OperandConfigObj(registryName, registryNamespace)
+				for i := range config.Spec.Services {
+					config.Spec.Services[i].ManageCRs = serviceManageCRs
+				}
+				request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+				request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+				request.Spec.Requests[0].ManageCRs = requestManageCRs
+				var c client.Client
+				r, c = newEtcdOperandReconciler(testutil.NamespaceObj("ibm-cloudpak"), testutil.NamespaceObj(operatorNamespaceName), registry, config, request)
+
+				By("Creating the Subscription of the operator")
+				Expect(r.reconcileOperator(ctx, request, nil)).Should(Succeed())
+				sub := &olmv1alpha1.Subscription{}
+				Expect(c.Get(ctx, types.NamespacedName{Name: "etcd", Namespace: operatorNamespaceName}, sub)).Should(Succeed())
+				sub.Status = testutil.SubscriptionStatus("etcd", operatorNamespaceName, "0.0.1")
+				Expect(c.Status().Update(ctx, sub)).Should(Succeed())
+				csv := testutil.ClusterServiceVersion(sub.Status.CurrentCSV, operatorNamespaceName, testutil.EtcdExample)
+				csv.Status = testutil.ClusterServiceVersionStatus()
+				Expect(c.Create(ctx, csv)).Should(Succeed())
+
+				By("Reconciling the custom resources of the operand")
+				Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+				Expect(request.GetMemberOperandPhase("etcd", &sync.Mutex{})).Should(Equal(operandPhase))
+				request.UpdateClusterPhase()
+				Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseRunning))
+
+				etcdCluster := &unstructured.Unstructured{}
+				etcdCluster.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+				etcdCluster.SetKind("EtcdCluster")
+				err := c.Get(ctx, types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}, etcdCluster)
+				if operandPhase == operatorv1alpha1.ServiceRunning {
+					Expect(err).NotTo(HaveOccurred())
+				} else {
+					Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+				}
+			},
+			Entry("Manage the custom resources by default", nil, nil, operatorv1alpha1.ServiceRunning),
+			Entry("Disable the management in the request", boolPtr(false), nil, operatorv1alpha1.ServiceCRManagementDisabled),
+			Entry("Disable the management in the service", boolPtr(true), boolPtr(false), operatorv1alpha1.ServiceCRManagementDisabled),
+		)
+	})
+
+	Context("Requesting an operand whose alm-examples are invalid", func() {
+		It("Should fail the operand without blocking the other operands", func() {
+			const registryName, registryNamespace = "common-service", "ibm-common-services"
+			registry := testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespaceName)
+			objs := []client.Object{
+				testutil.NamespaceObj("ibm-cloudpak"), registry, testutil.OperandConfigObj(registryName, registryNamespace),
+			}
+			for name, almExamples := range map[string]string{"etcd": testutil.EtcdExample, "jenkins": `[{"apiVersion": "jenkins.io/v1alpha2",`} {
+				sub := testutil.Subscription(name, operatorNamespaceName)
+				sub.Spec.Package = registry.GetOperator(name).PackageName
+				sub.Status = testutil.SubscriptionStatus(name, operatorNamespaceName, "0.0.1")
+				csv := testutil.ClusterServiceVersion(sub.Status.CurrentCSV, operatorNamespaceName, almExamples)
+				csv.Status = testutil.ClusterServiceVersionStatus()
+				objs = append(objs, sub, csv)
+			}
+			var c client.Client
+			r, c = newEtcdOperandReconciler(objs...)
+			recorder := record.NewFakeRecorder(10)
+			r.Recorder = recorder
+
+			request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+			ctx, results := withOperandResults(ctx)
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.Status.Members).Should(HaveLen(2))
+			for _, member := range request.Status.Members {
+				switch member.Name {
+				case "etcd":
+					Expect(member.Phase.OperandPhase).Should(Equal(operatorv1alpha1.ServiceRunning))
+				case "jenkins":
+					Expect(member.Phase.OperandPhase).Should(Equal(operatorv1alpha1.ServiceFailed))
+				}
+			}
+			Expect(recorder.Events).Should(Receive(And(ContainSubstring("InvalidALMExamples"), ContainSubstring("the alm-examples are not a JSON array"))))
+
+			By("Checking the custom resource of the valid operand is created")
+			etcdCluster := &unstructured.Unstructured{}
+			etcdCluster.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+			etcdCluster.SetKind("EtcdCluster")
+			Expect(c.Get(ctx, types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}, etcdCluster)).Should(Succeed())
+
+			By("Checking the parse error is reported in the operand results")
+			var failed []operatorv1alpha1.OperandResult
+			for _, result := range results.list(request) {
+				if result.Action == operatorv1alpha1.OperandFailed {
+					failed = append(failed, result)
+				}
+			}
+			Expect(failed).Should(HaveLen(1))
+			Expect(failed[0].Name).Should(Equal("jenkins"))
+			Expect(failed[0].Reason).Should(Equal("InvalidALMExamples"))
+			Expect(failed[0].Message).Should(ContainSubstring("the alm-examples are not a JSON array"))
+		})
+	})
+
+	Context("Requesting an operand whose operator is not ready", func() {
+		It("Should defer creating the custom resources until the ClusterServiceVersion is Succeeded", func() {
+			const registryName, registryNamespace = "common-service", "ibm-common-services"
+			registry := testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespaceName)
+			objs := []client.Object{
+				testutil.NamespaceObj("ibm-cloudpak"), registry, testutil.OperandConfigObj(registryName, registryNamespace),
+			}
+			for _, name := range []string{"etcd", "jenkins"} {
+				sub := testutil.Subscription(name, operatorNamespaceName)
+				sub.Spec.Package = registry.GetOperator(name).PackageName
+				sub.Status = testutil.SubscriptionStatus(name, operatorNamespaceName, "0.0.1")
+				csv := testutil.ClusterServiceVersion(sub.Status.CurrentCSV, operatorNamespaceName, testutil.EtcdExample)
+				csv.Status = testutil.ClusterServiceVersionStatus()
+				csv.Status.Phase = olmv1alpha1.CSVPhaseInstalling
+				objs = append(objs, sub, csv)
+			}
+			var c client.Client
+			r, c = newEtcdOperandReconciler(objs...)
+
+			request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.GetMemberOperandPhase("etcd", &sync.Mutex{})).Should(Equal(operatorv1alpha1.ServiceWaitingForOperatorReady))
+			request.UpdateClusterPhase()
+			Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseInstalling))
+
+			etcdCluster := &unstructured.Unstructured{}
+			etcdCluster.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+			etcdCluster.SetKind("EtcdCluster")
+			Expect(apierrors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}, etcdCluster))).Should(BeTrue())
+
+			By("Creating the custom resource once the ClusterServiceVersion is Succeeded")
+			csv := &olmv1alpha1.ClusterServiceVersion{}
+			Expect(c.Get(ctx, types.NamespacedName{Name: testutil.SubscriptionStatus("etcd", operatorNamespaceName, "0.0.1").CurrentCSV, Namespace: operatorNamespaceName}, csv)).Should(Succeed())
+			csv.Status = testutil.ClusterServiceVersionStatus()
+			Expect(c.Update(ctx, csv)).Should(Succeed())
+
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.GetMemberOperandPhase("etcd", &sync.Mutex{})).Should(Equal(operatorv1alpha1.ServiceRunning))
+			Expect(request.GetMemberOperandPhase("jenkins", &sync.Mutex{})).Should(Equal(operatorv1alpha1.ServiceWaitingForOperatorReady))
+			Expect(c.Get(ctx, types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}, etcdCluster)).Should(Succeed())
+		})
+	})
+
+	Context("Waiting for the ClusterServiceVersion of the Subscription", func() {
+		It("Should wait for the ClusterServiceVersion within the timeout, and go on once it is resolved", func() {
+			const registryName, registryNamespace = "common-service", "ibm-common-services"
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+			Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+
+			sub := testutil.Subscription("etcd", operatorNamespaceName)
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+				testutil.NamespaceObj("ibm-cloudpak"), testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespaceName),
+				testutil.OperandConfigObj(registryName, registryNamespace), sub,
+			).Build()
+			r.Client, r.Reader = c, c
+			r.AccessReviewer = &fakeAccessReviewer{}
+			recorder := record.NewFakeRecorder(10)
+			r.Recorder = recorder
+			r.CSVWaitTimeout = 10 * time.Minute
+			fakeClock := clock.NewFakeClock(time.Now())
+			r.Clock = fakeClock
+
+			request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+			request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+			waitingConditions := func() []operatorv1alpha1.Condition {
+				var conditions []operatorv1alpha1.Condition
+				for _, cond := range request.Status.Conditions {
+					if cond.Type == operatorv1alpha1.ConditionWaitingForCSV {
+						conditions = append(conditions, cond)
+					}
+				}
+				return conditions
+			}
+
+			By("Waiting for the ClusterServiceVersion while the Subscription isn't resolved")
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.Status.Members[0].Phase.OperatorPhase).Should(Equal(operatorv1alpha1.OperatorInstalling))
+			Expect(waitingConditions()).Should(HaveLen(1))
+			Expect(waitingConditions()[0].Message).Should(ContainSubstring("for etcd"))
+
+			By("Failing the operand once the timeout expires")
+			fakeClock.Step(11 * time.Minute)
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.Status.Members[0].Phase.OperatorPhase).Should(Equal(operatorv1alpha1.OperatorFailed))
+			Expect(recorder.Events).Should(Receive(ContainSubstring("ClusterServiceVersionTimeout")))
+
+			By("Going on once the ClusterServiceVersion is resolved")
+			sub.Status = testutil.SubscriptionStatus("etcd", operatorNamespaceName, "0.0.1")
+			Expect(c.Status().Update(ctx, sub)).Should(Succeed())
+			csv := testutil.ClusterServiceVersion(sub.Status.CurrentCSV, operatorNamespaceName, testutil.EtcdExample)
+			csv.Status = testutil.ClusterServiceVersionStatus()
+			csv.Status.Phase = olmv1alpha1.CSVPhaseInstalling
+			Expect(c.Create(ctx, csv)).Should(Succeed())
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.Status.Members[0].Phase.OperatorPhase).Should(Equal(operatorv1alpha1.OperatorInstalling))
+			Expect(waitingConditions()).Should(BeEmpty())
+		})
+	})
+
+	Context("Requesting an operand depending on the other operands", func() {
+		const registryName, registryNamespace = "common-service", "ibm-common-services"
+		var (
+			c       client.Client
+			request *operatorv1alpha1.OperandRequest
+		)
+
+		BeforeEach(func() {
+			// Only the operator of etcd is installed
+			sub, csv := runningEtcdOperator(operatorNamespaceName)
+			r, c = newEtcdOperandReconciler(
+				testutil.NamespaceObj("ibm-cloudpak"), testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespaceName),
+				testutil.OperandConfigObj(registryName, registryNamespace), sub, csv,
+			)
+			r.Recorder = record.NewFakeRecorder(10)
+
+			request = testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+		})
+
+		getEtcdCluster := func() error {
+			etcdCluster := &unstructured.Unstructured{}
+			etcdCluster.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+			etcdCluster.SetKind("EtcdCluster")
+			return c.Get(ctx, types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}, etcdCluster)
+		}
+
+		It("Should create the custom resources once the dependencies are Running", func() {
+			request.Spec.Requests[0].Operands[0].DependsOn = []string{"jenkins"}
+
+			By("Waiting for jenkins to be Running")
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.GetMemberOperandPhase("etcd", &sync.Mutex{})).Should(Equal(operatorv1alpha1.ServiceWaitingForDependencies))
+			Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseInstalling))
+			Expect(apierrors.IsNotFound(getEtcdCluster())).Should(BeTrue())
+
+			By("Creating the custom resources of etcd once jenkins is Running")
+			request.SetMemberStatus("jenkins", operatorv1alpha1.OperatorRunning, operatorv1alpha1.ServiceRunning, &sync.Mutex{})
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.GetMemberOperandPhase("etcd", &sync.Mutex{})).Should(Equal(operatorv1alpha1.ServiceRunning))
+			Expect(getEtcdCluster()).Should(Succeed())
+		})
+
+		It("Should fail the operands in a dependency cycle", func() {
+			request.Spec.Requests[0].Operands[0].DependsOn = []string{"jenkins"}
+			request.Spec.Requests[0].Operands[1].DependsOn = []string{"etcd"}
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.GetMemberOperandPhase("etcd", &sync.Mutex{})).Should(Equal(operatorv1alpha1.ServiceFailed))
+			Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseFailed))
+			Expect(apierrors.IsNotFound(getEtcdCluster())).Should(BeTrue())
+			var messages []string
+			for _, condition := range request.Status.Conditions {
+				if condition.Type == operatorv1alpha1.ConditionDependencyCycle {
+					messages = append(messages, condition.Message)
+				}
+			}
+			Expect(messages).Should(ConsistOf(ContainSubstring("etcd -> jenkins -> etcd")))
+
+			By("Removing the condition once the cycle is broken")
+			request.Spec.Requests[0].Operands[1].DependsOn = nil
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			for _, condition := range request.Status.Conditions {
+				Expect(condition.Type).ShouldNot(Equal(operatorv1alpha1.ConditionDependencyCycle))
+			}
+		})
+
+		It("Should fail the operand depending on an operand not requested", func() {
+			request.Spec.Requests[0].Operands[0].DependsOn = []string{"mongodb"}
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.GetMemberOperandPhase("etcd", &sync.Mutex{})).Should(Equal(operatorv1alpha1.ServiceFailed))
+			Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseFailed))
+			Expect(apierrors.IsNotFound(getEtcdCluster())).Should(BeTrue())
+			var messages []string
+			for _, condition := range request.Status.Conditions {
+				if condition.Type == operatorv1alpha1.ConditionMissingDependency {
+					messages = append(messages, condition.Message)
+				}
+			}
+			Expect(messages).Should(ConsistOf(ContainSubstring("mongodb aren't requested for etcd")))
+
+			By("Removing the condition once the dependency is dropped")
+			request.Spec.Requests[0].Operands[0].DependsOn = nil
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.GetMemberOperandPhase("etcd", &sync.Mutex{})).Should(Equal(operatorv1alpha1.ServiceRunning))
+			for _, condition := range request.Status.Conditions {
+				Expect(condition.Type).ShouldNot(Equal(operatorv1alpha1.ConditionMissingDependency))
+			}
+		})
+	})
+
+	Context("Requesting an operand whose spec value is not an object", func() {
+		It("Should fail the operand naming the service and the key", func() {
+			const registryName, registryNamespace = "common-service", "ibm-common-services"
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+			Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+
+			config := testutil.OperandConfigObj(registryName, registryNamespace)
+			config.Spec.Services[0].Spec["etcdCluster"] = runtime.RawExtension{Raw: []byte(`[{"size": 3}]`)}
+			sub := testutil.Subscription("etcd", operatorNamespaceName)
+			sub.Status = testutil.SubscriptionStatus("etcd", operatorNamespaceName, "0.0.1")
+			csv := testutil.ClusterServiceVersion(sub.Status.CurrentCSV, operatorNamespaceName, testutil.EtcdExample)
+			csv.Status = testutil.ClusterServiceVersionStatus()
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+				testutil.NamespaceObj("ibm-cloudpak"), testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespaceName), config, sub, csv,
+			).Build()
+			r.Client, r.Reader = c, c
+
+			request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+			request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+			merr := r.reconcileOperand(ctx, request, nil)
+			Expect(merr.Errors).Should(HaveLen(1))
+			Expect(merr.Errors[0]).Should(ContainSubstring("etcd"))
+			Expect(merr.Errors[0]).Should(ContainSubstring("etcdCluster"))
+			Expect(request.Status.Members).Should(HaveLen(1))
+			Expect(request.Status.Members[0].Phase.OperandPhase).Should(Equal(operatorv1alpha1.ServiceFailed))
+		})
+	})
+
+	Context("Reporting the health of the CatalogSource", func() {
+		It("Should report the unhealthy CatalogSource of the operator being installed", func() {
+			const registryName, registryNamespace = "common-service", "ibm-common-services"
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+			Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+
+			// The CSV of the subscription isn't resolved yet
+			sub := testutil.Subscription("etcd", operatorNamespaceName)
+			catalogSource := &olmv1alpha1.CatalogSource{
+				ObjectMeta: metav1.ObjectMeta{Name: sub.Spec.CatalogSource, Namespace: sub.Spec.CatalogSourceNamespace},
+				Status: olmv1alpha1.CatalogSourceStatus{
+					GRPCConnectionState: &olmv1alpha1.GRPCConnectionState{LastObservedState: "TRANSIENT_FAILURE"},
+				},
+			}
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+				testutil.NamespaceObj("ibm-cloudpak"), testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespaceName),
+				testutil.OperandConfigObj(registryName, registryNamespace), sub, catalogSource,
+			).Build()
+			r.Client, r.Reader = c, c
+
+			request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+			request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+			request.SetMemberStatus("etcd", operatorv1alpha1.OperatorInstalling, "", &sync.Mutex{})
+
+			By("Reporting the connection failure of the CatalogSource")
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.Status.Members[0].Phase.OperatorPhase).Should(Equal(operatorv1alpha1.OperatorInstalling))
+			Expect(request.Status.Members[0].CatalogSourceHealth).ShouldNot(BeNil())
+			Expect(*request.Status.Members[0].CatalogSourceHealth).Should(Equal(operatorv1alpha1.CatalogSourceHealth{
+				Name:      "community-operators",
+				Namespace: "openshift-marketplace",
+				Reason:    operatorv1alpha1.CatalogSourceUnhealthy,
+				Message:   "The connection state of the registry is TRANSIENT_FAILURE",
+			}))
+
+			By("Removing the health once the CatalogSource is ready")
+			catalogSource.Status.GRPCConnectionState.LastObservedState = "READY"
+			Expect(c.Update(ctx, catalogSource)).Should(Succeed())
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.Status.Members[0].CatalogSourceHealth).Should(BeNil())
+
+			By("Reporting the missing CatalogSource")
+			Expect(c.Delete(ctx, catalogSource)).Should(Succeed())
+			Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+			Expect(request.Status.Members[0].CatalogSourceHealth).ShouldNot(BeNil())
+			Expect(request.Status.Members[0].CatalogSourceHealth.Reason).Should(Equal(operatorv1alpha1.CatalogSourceNotFound))
+		})
+	})
+
+	Context("Creating the custom resources in the default target namespace", func() {
+		DescribeTable("Should create the custom resources in the target namespace",
+			func(targetNamespace, expectedNamespace string) {
+				const registryName, registryNamespace = "common-service", "ibm-common-services"
+				// The etcd operator is installed in AllNamespaces mode
+				registry := testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespaceName)
+				registry.Spec.Operators[0].InstallMode = operatorv1alpha1.InstallModeCluster
+				config := testutil.OperandConfigObj(registryName, registryNamespace)
+				config.Spec.Services[0].TargetNamespace = targetNamespace
+				sub, csv := runningEtcdOperator(constant.ClusterOperatorNamespace)
+				var c client.Client
+				r, c = newEtcdOperandReconciler(
+					testutil.NamespaceObj("ibm-cloudpak"), testutil.NamespaceObj("ibm-apps"), testutil.NamespaceObj("ibm-workloads"),
+					registry, config, sub, csv,
+				)
+				r.DefaultTargetNamespace = "ibm-apps"
+
+				request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+				request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+				merr := r.reconcileOperand(ctx, request, nil)
+				Expect(merr.Errors).Should(BeEmpty())
+				Expect(request.Status.Members[0].Phase.OperandPhase).Should(Equal(operatorv1alpha1.ServiceRunning))
+
+				etcdCluster := &unstructured.Unstructured{}
+				etcdCluster.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+				etcdCluster.SetKind("EtcdCluster")
+				Expect(c.Get(ctx, types.NamespacedName{Name: "example", Namespace: expectedNamespace}, etcdCluster)).Should(Succeed())
+				Expect(apierrors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: "example", Namespace: constant.ClusterOperatorNamespace}, etcdCluster))).Should(BeTrue())
+			},
+			Entry("Using the default target namespace", "", "ibm-apps"),
+			Entry("Using the target namespace of the service", "ibm-workloads", "ibm-workloads"),
+		)
+	})
+
+	Context("Pinning the operator to a ClusterServiceVersion", func() {
+		DescribeTable("Should only create the custom resources with the pinned ClusterServiceVersion",
+			func(pinnedCSV string, pinned bool) {
+				const registryName, registryNamespace = "common-service", "ibm-common-services"
+				sub, csv := runningEtcdOperator(operatorNamespaceName)
+				var c client.Client
+				r, c = newEtcdOperandReconciler(
+					testutil.NamespaceObj("ibm-cloudpak"), testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespaceName),
+					testutil.OperandConfigObj(registryName, registryNamespace), sub, csv,
+				)
+
+				request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", "ibm-cloudpak")
+				request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+				request.Spec.Requests[0].Operands[0].StartingCSV = pinnedCSV
+				// The mismatch of a previous pin
+				request.SetCSVMismatchCondition("etcd", "etcd-csv.v0.0.1", "etcd-csv.v0.0.0", &r.Mutex)
+				Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+
+				etcdCluster := &unstructured.Unstructured{}
+				etcdCluster.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+				etcdCluster.SetKind("EtcdCluster")
+				err := c.Get(ctx, types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}, etcdCluster)
+				var conditions []operatorv1alpha1.ConditionType
+				for _, cond := range request.Status.Conditions {
+					conditions = append(conditions, cond.Type)
+				}
+				if pinned {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(request.Status.Members[0].Phase.OperandPhase).Should(Equal(operatorv1alpha1.ServiceRunning))
+					Expect(conditions).ShouldNot(ContainElement(operatorv1alpha1.ConditionCSVMismatch))
+				} else {
+					Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+					Expect(request.Status.Members[0].Phase.OperatorPhase).Should(Equal(operatorv1alpha1.OperatorFailed))
+					Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseFailed))
+					Expect(conditions).Should(ContainElement(operatorv1alpha1.ConditionCSVMismatch))
+				}
+			},
+			Entry("Installing the pinned ClusterServiceVersion", "etcd-csv.v0.0.1", true),
+			Entry("Installing another ClusterServiceVersion", "etcd-csv.v0.0.2", false),
+			Entry("Removing the pin", "", true),
+		)
+	})
+
+	Context("Deleting the custom resources with the propagation policy", func() {
+		var c *deleteRecordingClient
+
+		newCR := func() unstructured.Unstructured {
+			cr := unstructured.Unstructured{}
+			cr.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+			cr.SetKind("EtcdCluster")
+			cr.SetName("example")
+			cr.SetNamespace(operatorNamespaceName)
+			cr.SetLabels(map[string]string{constant.OpreqLabel: "true"})
+			cr.Object["spec"] = map[string]interface{}{"size": int64(1)}
+			return cr
+		}
+
+		BeforeEach(func() {
+			cr := newCR()
+			c = &deleteRecordingClient{Client: fake.NewClientBuilder().WithObjects(&cr).Build()}
+			r.Client, r.Reader = c, c
+		})
+
+		DescribeTable("Should delete the custom resource with the propagation policy of the service",
+			func(defaultPropagation, servicePropagation, expected metav1.DeletionPropagation) {
+				r.DeletionPropagation = defaultPropagation
+				service := &operatorv1alpha1.ConfigService{Name: "etcd", DeletionPropagation: servicePropagation}
+				Expect(r.deleteCustomResource(ctx, newCR(), operatorNamespaceName, r.deletionPropagation(service))).Should(Succeed())
+				Expect(c.propagations).Should(Equal([]metav1.DeletionPropagation{expected}))
+			},
+			Entry("Deleting in background by default", metav1.DeletionPropagation(""), metav1.DeletionPropagation(""), metav1.DeletePropagationBackground),
+			Entry("Deleting with the default policy of ODLM", metav1.DeletePropagationOrphan, metav1.DeletionPropagation(""), metav1.DeletePropagationOrphan),
+			Entry("Deleting with the policy of the service", metav1.DeletePropagationBackground, metav1.DeletePropagationForeground, metav1.DeletePropagationForeground),
+		)
+
+		It("Should recreate the custom resource with the propagation policy", func() {
+			Expect(r.recreateCustomResource(ctx, newCR(), metav1.DeletePropagationForeground)).Should(Succeed())
+			Expect(c.propagations).Should(Equal([]metav1.DeletionPropagation{metav1.DeletePropagationForeground}))
+		})
+	})
+
+	Context("Keeping the failed custom resources", func() {
+		BeforeEach(func() {
+			r.KeepFailedCRs = true
+		})
+
+		It("Should persist the attempted custom resource when the creation fails", func() {
+			cr := unstructured.Unstructured{}
+			cr.SetAPIVersion("example.ibm.com/v1")
+			cr.SetKind("NotInstalled")
+			cr.SetName("example")
+			cr.Object["spec"] = map[string]interface{}{"size": int64(1)}
+
+			Expect(r.createCustomResource(ctx, cr, operatorNamespaceName, "notInstalled", []byte(`{"size": 3}`), []byte(`{"size": 3}`), operatorv1alpha1.MergeStrategyMerge)).ShouldNot(Succeed())
+
+			cm := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "failed-notinstalled-example", Namespace: operatorNamespaceName}, cm)).Should(Succeed())
+			Expect(cm.Labels).Should(HaveKeyWithValue(constant.FailedCRLabel, "true"))
+			Expect(cm.Data["error"]).ShouldNot(BeEmpty())
+			Expect(cm.Data["customResource.yaml"]).Should(MatchYAML(`
+apiVersion: example.ibm.com/v1
+kind: NotInstalled
+metadata:
+  name: example
+  namespace: ` + operatorNamespaceName + `
+  labels:
+    ` + constant.OpreqLabel + `: "true"
+spec:
+  size: 3
+`))
+		})
+
+		It("Should clean up the failed custom resource once it is created", func() {
+			Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "failed-etcdcluster-example",
+					Namespace: operatorNamespaceName,
+					Labels:    map[string]string{constant.FailedCRLabel: "true"},
+				},
+			})).Should(Succeed())
+
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(`{"size": 1}`)},
+				},
+			}
+			csv := testutil.ClusterServiceVersion("etcd-csv.v0.0.1", operatorNamespaceName, testutil.EtcdExample)
+			Expect(r.reconcileCRwithConfig(ctx, service, operatorNamespaceName, csv)).Should(Succeed())
+
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "failed-etcdcluster-example", Namespace: operatorNamespaceName}, &corev1.ConfigMap{})
+			Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+		})
+	})
+	Context("Applying the overrides for the cluster version", func() {
+		DescribeTable("Should merge the overrides only when the cluster version matches",
+			func(clusterVersion string, expectedSpec string) {
+				r.ClusterVersionDetector = fakeDetector{version: clusterVersion}
+				service := &operatorv1alpha1.ConfigService{
+					Name: "etcd",
+					Spec: map[string]runtime.RawExtension{
+						"etcdCluster": {Raw: []byte(`{"size": 1, "version": "3.2.13"}`)},
+					},
+					Overrides: []operatorv1alpha1.ConfigOverride{
+						{
+							ClusterVersion: ">=4.6.0 <4.8.0",
+							Spec: map[string]runtime.RawExtension{
+								"etcdCluster": {Raw: []byte(`{"version": "3.1.0"}`)},
+							},
+						},
+					},
+				}
+				overriddenService, err := r.applyOverrides(ctx, service)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(overriddenService.Spec["etcdCluster"].Raw).Should(MatchJSON(expectedSpec))
+				Expect(service.Spec["etcdCluster"].Raw).Should(MatchJSON(`{"size": 1, "version": "3.2.13"}`))
+			},
+			Entry("Inside the range", "4.6.8", `{"size": 1, "version": "3.1.0"}`),
+			Entry("At the lower bound of the range", "4.6.0", `{"size": 1, "version": "3.1.0"}`),
+			Entry("Below the range", "4.5.16", `{"size": 1, "version": "3.2.13"}`),
+			Entry("Above the range", "4.8.2", `{"size": 1, "version": "3.2.13"}`),
+		)
+
+		It("Should fail when the cluster version can't be detected", func() {
+			r.ClusterVersionDetector = fakeDetector{version: "unknown"}
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Overrides: []operatorv1alpha1.ConfigOverride{
+					{ClusterVersion: ">=4.6.0"},
+				},
+			}
+			_, err := r.applyOverrides(ctx, service)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("Migrating the apiVersion of the custom resources", func() {
+		newDiscovery := func(resources ...*metav1.APIResourceList) *fakediscovery.FakeDiscovery {
+			return &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: resources}}
+		}
+		etcdResources := func(groupVersion string) *metav1.APIResourceList {
+			return &metav1.APIResourceList{
+				GroupVersion: groupVersion,
+				APIResources: []metav1.APIResource{{Name: "etcdclusters", Kind: "EtcdCluster", Namespaced: true}},
+			}
+		}
+
+		DescribeTable("Should use the preferred apiVersion served for the kind",
+			func(kind, expected string, resources ...*metav1.APIResourceList) {
+				r.Discovery = newDiscovery(resources...)
+				apiVersion, err := r.servedAPIVersion("etcd.database.coreos.com/v1beta2", kind)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(apiVersion).Should(Equal(expected))
+			},
+			Entry("apiVersion unchanged", "EtcdCluster", "etcd.database.coreos.com/v1beta2",
+				etcdResources("etcd.database.coreos.com/v1beta2")),
+			Entry("apiVersion migrated", "EtcdCluster", "etcd.database.coreos.com/v1",
+				etcdResources("etcd.database.coreos.com/v1"), etcdResources("etcd.database.coreos.com/v1beta2")),
+			Entry("kind not served by the preferred apiVersion", "EtcdBackup", "etcd.database.coreos.com/v1beta2",
+				etcdResources("etcd.database.coreos.com/v1"), etcdResources("etcd.database.coreos.com/v1beta2")),
+			Entry("group not served", "EtcdCluster", "etcd.database.coreos.com/v1beta2"),
+		)
+
+		It("Should record the migration of the managed custom resource", func() {
+			var mu sync.Mutex
+			request := &operatorv1alpha1.OperandRequest{}
+			request.SetMemberStatus("etcd", operatorv1alpha1.OperatorRunning, "", &mu)
+			request.SetMemberCRStatus("etcd", "example", "EtcdCluster", "etcd.database.coreos.com/v1beta2", &mu)
+
+			r.Discovery = newDiscovery(etcdResources("etcd.database.coreos.com/v1"), etcdResources("etcd.database.coreos.com/v1beta2"))
+			apiVersion, err := r.servedAPIVersion("etcd.database.coreos.com/v1beta2", "EtcdCluster")
+			Expect(err).NotTo(HaveOccurred())
+			request.MigrateMemberCRStatus("etcd", "example", "EtcdCluster", apiVersion, &mu)
+
+			Expect(request.Status.Members[0].OperandCRList[0].APIVersion).Should(Equal("etcd.database.coreos.com/v1"))
+			var messages []string
+			for _, c := range request.Status.Conditions {
+				if c.Type == operatorv1alpha1.ConditionMigrated {
+					messages = append(messages, c.Message)
+				}
+			}
+			Expect(messages).Should(ConsistOf("Migrated EtcdCluster example from etcd.database.coreos.com/v1beta2 to etcd.database.coreos.com/v1"))
+		})
+	})
+
+	Context("Applying the defaults of the OperandConfig", func() {
+		It("Should merge the defaults into the custom resource unless the service overrides them", func() {
+			defaults := &runtime.RawExtension{Raw: []byte(`{"storageClass": "standard", "imagePullSecrets": [{"name": "pull-secret"}]}`)}
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(`{"storageClass": "fast"}`)},
+				},
+			}
+			defaultedService, err := applyDefaults(defaults, service)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(service.Spec["etcdCluster"].Raw)).Should(Equal(`{"storageClass": "fast"}`))
+
+			csv := testutil.ClusterServiceVersion("etcd-csv.v0.0.1", operatorNamespaceName, testutil.EtcdExample)
+			Expect(r.reconcileCRwithConfig(ctx, defaultedService, operatorNamespaceName, csv)).Should(Succeed())
+
+			etcdCluster := &unstructured.Unstructured{}
+			etcdCluster.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+			etcdCluster.SetKind("EtcdCluster")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "example", Namespace: operatorNamespaceName}, etcdCluster)).Should(Succeed())
+			storageClass, _, _ := unstructured.NestedString(etcdCluster.Object, "spec", "storageClass")
+			Expect(storageClass).Should(Equal("fast"))
+			pullSecrets, _, _ := unstructured.NestedSlice(etcdCluster.Object, "spec", "imagePullSecrets")
+			Expect(pullSecrets).Should(ConsistOf(map[string]interface{}{"name": "pull-secret"}))
+		})
+
+		It("Should keep the service without the defaults", func() {
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(`{"size": 3}`)},
+				},
+			}
+			defaultedService, err := applyDefaults(nil, service)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(defaultedService).Should(Equal(service))
+		})
+	})
+
+	Context("Applying the defaults of the OperandRegistry", func() {
+		It("Should resolve the registry defaults, then the config, then the overrides", func() {
+			r.ClusterVersionDetector = fakeDetector{version: "4.6.8"}
+			operator := &operatorv1alpha1.Operator{
+				Name: "etcd",
+				Defaults: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(`{"size": 1, "version": "3.2.13", "storageClass": "standard", "pod": {"resources": {"limits": {"cpu": "100m"}}}}`)},
+					"etcdBackup":  {Raw: []byte(`{"backupPolicy": {"maxBackups": 3}}`)},
+				},
+			}
+			defaults := &runtime.RawExtension{Raw: []byte(`{"storageClass": "fast"}`)}
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(`{"size": 3}`)},
+				},
+				Overrides: []operatorv1alpha1.ConfigOverride{
+					{
+						ClusterVersion: ">=4.6.0",
+						Spec: map[string]runtime.RawExtension{
+							"etcdCluster": {Raw: []byte(`{"version": "3.1.0"}`)},
+						},
+					},
+				},
+			}
+
+			resolvedService, err := applyDefaults(defaults, service)
+			Expect(err).NotTo(HaveOccurred())
+			resolvedService, err = applyOperatorDefaults(operator, resolvedService)
+			Expect(err).NotTo(HaveOccurred())
+			resolvedService, err = r.applyOverrides(ctx, resolvedService)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resolvedService.Spec["etcdCluster"].Raw).Should(MatchJSON(`{"size": 3, "version": "3.1.0", "storageClass": "fast", "pod": {"resources": {"limits": {"cpu": "100m"}}}}`))
+			Expect(resolvedService.Spec).ShouldNot(HaveKey("etcdBackup"))
+			Expect(service.Spec["etcdCluster"].Raw).Should(MatchJSON(`{"size": 3}`))
+		})
+
+		It("Should fail on the defaults which are not objects", func() {
+			operator := &operatorv1alpha1.Operator{
+				Name: "etcd",
+				Defaults: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(`["size"]`)},
+				},
+			}
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(`{"size": 3}`)},
+				},
+			}
+			_, err := applyOperatorDefaults(operator, service)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("Checking the permissions before creating the custom resources", func() {
+		It("Should record the missing permission instead of creating the custom resource", func() {
+			reviewer := &fakeAccessReviewer{deniedVerbs: map[string]bool{"create": true}}
+			r.AccessReviewer = reviewer
+			request := &operatorv1alpha1.OperandRequest{}
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(`{"size": 1}`)},
+				},
+			}
+			csv := testutil.ClusterServiceVersion("etcd-csv.v0.0.1", operatorNamespaceName, testutil.EtcdExample)
+			gvks := configuredKinds(service, csv)
+			Expect(gvks).Should(ConsistOf(etcdClusterGVK))
+
+			By("Denying the create verb")
+			allowed, err := r.checkCreatePermissions(ctx, request, "etcd", gvks, operatorNamespaceName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allowed).Should(BeFalse())
+			Expect(reviewer.reviewed).Should(ConsistOf(authorizationv1.ResourceAttributes{
+				Namespace: operatorNamespaceName,
+				Verb:      "create",
+				Group:     "etcd.database.coreos.com",
+				Resource:  "etcdclusters",
+			}))
+			Expect(request.Status.Conditions).Should(HaveLen(1))
+			Expect(request.Status.Conditions[0].Type).Should(Equal(operatorv1alpha1.ConditionInsufficientPermissions))
+			Expect(request.Status.Conditions[0].Message).Should(Equal("Missing the permission to create etcdclusters.etcd.database.coreos.com in the namespace " + operatorNamespaceName + " for etcd"))
+
+			By("Granting the create verb")
+			reviewer.deniedVerbs = nil
+			allowed, err = r.checkCreatePermissions(ctx, request, "etcd", gvks, operatorNamespaceName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allowed).Should(BeTrue())
+			Expect(request.Status.Conditions).Should(BeEmpty())
+		})
+
+		It("Should skip the kinds not served yet", func() {
+			reviewer := &fakeAccessReviewer{deniedVerbs: map[string]bool{"create": true}}
+			r.AccessReviewer = reviewer
+			request := &operatorv1alpha1.OperandRequest{}
+			allowed, err := r.checkCreatePermissions(ctx, request, "etcd", []schema.GroupVersionKind{{Group: "unknown.ibm.com", Version: "v1", Kind: "Unknown"}}, operatorNamespaceName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allowed).Should(BeTrue())
+			Expect(reviewer.reviewed).Should(BeEmpty())
+		})
+	})
+
+	Context("Showing the InstallPlan pending approval", func() {
+		It("Should surface the InstallPlan reference in the member status when present", func() {
+			var mu sync.Mutex
+			sub := &olmv1alpha1.Subscription{}
+			sub.Status.InstallPlanRef = &corev1.ObjectReference{Name: "install-abcde", Namespace: operatorNamespace}
+			request := &operatorv1alpha1.OperandRequest{}
+			request.SetMemberStatus("etcd", operatorv1alpha1.OperatorInstalling, "", &mu)
+
+			By("Ignoring the InstallPlan not waiting for approval")
+			request.SetMemberInstallPlanRef("etcd", pendingInstallPlanRef(sub), &mu)
+			Expect(request.Status.Members[0].InstallPlanRef).Should(BeNil())
+
+			By("Recording the InstallPlan waiting for approval")
+			sub.Status.SetCondition(olmv1alpha1.SubscriptionCondition{
+				Type:   olmv1alpha1.SubscriptionInstallPlanPending,
+				Status: corev1.ConditionTrue,
+				Reason: string(olmv1alpha1.InstallPlanPhaseRequiresApproval),
+			})
+			request.SetMemberInstallPlanRef("etcd", pendingInstallPlanRef(sub), &mu)
+			Expect(request.Status.Members[0].InstallPlanRef).Should(Equal(&operatorv1alpha1.InstallPlanReference{Name: "install-abcde", Namespace: operatorNamespace}))
+
+			By("Clearing the InstallPlan once it is approved")
+			sub.Status.RemoveConditions(olmv1alpha1.SubscriptionInstallPlanPending)
+			request.SetMemberInstallPlanRef("etcd", pendingInstallPlanRef(sub), &mu)
+			Expect(request.Status.Members[0].InstallPlanRef).Should(BeNil())
+		})
+	})
+})
+
+var _ = Describe("Reconciling the named instances of the custom resources", func() {
+	const namespace = "ibm-operators"
+
+	var (
+		ctx     context.Context
+		r       *Reconciler
+		c       client.Client
+		csv     *olmv1alpha1.ClusterServiceVersion
+		service *operatorv1alpha1.ConfigService
+	)
+
+	instanceSize := func(name string) (int64, error) {
+		cr := &unstructured.Unstructured{}
+		cr.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+		cr.SetKind("EtcdCluster")
+		if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, cr); err != nil {
+			return 0, err
+		}
+		size, _, _ := unstructured.NestedInt64(cr.Object, "spec", "size")
+		return size, nil
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		r, c = newEtcdOperandReconciler()
+		csv = testutil.ClusterServiceVersion("etcd-csv.v0.0.1", namespace, testutil.EtcdExample)
+		service = &operatorv1alpha1.ConfigService{
+			Name: "etcd",
+			Spec: map[string]runtime.RawExtension{
+				"etcdCluster:backup": {Raw: []byte(`{"size": 1}`)},
+				"etcdCluster:audit":  {Raw: []byte(`{"size": 5}`)},
+			},
+		}
+	})
+
+	It("Should reconcile each instance of the kind independently", func() {
+		Expect(r.reconcileCRwithConfig(ctx, service, namespace, csv)).Should(Succeed())
+		Expect(instanceSize("backup")).Should(Equal(int64(1)))
+		Expect(instanceSize("audit")).Should(Equal(int64(5)))
+		_, err := instanceSize("example")
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+
+		By("Updating one instance")
+		service.Spec["etcdCluster:backup"] = runtime.RawExtension{Raw: []byte(`{"size": 3}`)}
+		Expect(r.reconcileCRwithConfig(ctx, service, namespace, csv)).Should(Succeed())
+		Expect(instanceSize("backup")).Should(Equal(int64(3)))
+		Expect(instanceSize("audit")).Should(Equal(int64(5)))
+
+		By("Removing one instance")
+		delete(service.Spec, "etcdCluster:audit")
+		Expect(r.reconcileCRwithConfig(ctx, service, namespace, csv)).Should(Succeed())
+		Expect(instanceSize("backup")).Should(Equal(int64(3)))
+		_, err = instanceSize("audit")
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+	})
+
+	It("Should keep the custom resource of the alm-examples apart from the instances", func() {
+		service.Spec["etcdCluster"] = runtime.RawExtension{Raw: []byte(`{"size": 7}`)}
+		Expect(r.reconcileCRwithConfig(ctx, service, namespace, csv)).Should(Succeed())
+		Expect(instanceSize("example")).Should(Equal(int64(7)))
+		Expect(instanceSize("backup")).Should(Equal(int64(1)))
+
+		By("Deleting the instances with the operand")
+		config := testutil.OperandConfigObj("common-service", "ibm-common-services")
+		config.Spec.Services = []operatorv1alpha1.ConfigService{*service}
+		request := testutil.OperandRequestObj("common-service", "ibm-common-services", "ibm-cloudpak-name", "ibm-cloudpak")
+		Expect(r.deleteAllCustomResource(ctx, csv, request, config, "etcd", namespace)).Should(Succeed())
+		for _, name := range []string{"example", "backup", "audit"} {
+			_, err := instanceSize(name)
+			Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+		}
+	})
+})
+
+type fakeDetector struct {
+	version string
+}
+
+func (d fakeDetector) Detect(ctx context.Context) (semver.Version, error) {
+	return clusterversion.Parse(d.version)
+}
+
+var _ = Describe("Keeping the custom resources failed to be created", func() {
+	const namespace = "ibm-operators"
+
+	var (
+		ctx     context.Context
+		r       *Reconciler
+		c       client.Client
+		request *operatorv1alpha1.OperandRequest
+	)
+
+	BeforeEach(func() {
+		request = testutil.OperandRequestObj("common-service", "ibm-common-services", "ibm-cloudpak-name", "ibm-cloudpak")
+		request.UID = types.UID("request-uid")
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "etcd-secret", Namespace: namespace},
+			Data:       map[string][]byte{"password": []byte("s3cret")},
+		}
+		var fakeClient client.Client
+		r, fakeClient = newEtcdOperandReconciler(secret)
+		c = &failingCreateClient{Client: fakeClient}
+		r.Client, r.Reader = c, c
+		r.KeepFailedCRs = true
+		ctx = withEventObject(context.Background(), request)
+	})
+
+	It("Should keep the references instead of the secret values in the ConfigMap owned by the OperandRequest", func() {
+		service := &operatorv1alpha1.ConfigService{
+			Name: "etcd",
+			Spec: map[string]runtime.RawExtension{
+				"etcdCluster": {Raw: []byte(`{"size": 1, "password": {"valueFrom": {"secretKeyRef": {"name": "etcd-secret", "key": "password"}}}}`)},
+			},
+		}
+		csv := testutil.ClusterServiceVersion("etcd-csv.v0.0.1", namespace, testutil.EtcdExample)
+		Expect(r.reconcileCRwithConfig(ctx, service, namespace, csv)).ShouldNot(Succeed())
+
+		cm := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "failed-etcdcluster-example", Namespace: request.Namespace}, cm)).Should(Succeed())
+		Expect(cm.Data["customResource.yaml"]).Should(ContainSubstring("secretKeyRef"))
+		Expect(cm.Data["customResource.yaml"]).ShouldNot(ContainSubstring("s3cret"))
+		Expect(cm.OwnerReferences).Should(HaveLen(1))
+		Expect(cm.OwnerReferences[0].Kind).Should(Equal("OperandRequest"))
+		Expect(cm.OwnerReferences[0].Name).Should(Equal(request.Name))
+	})
+})
+
+// failingCreateClient fails creating the custom resources
+type failingCreateClient struct {
+	client.Client
+}
+
+func (c *failingCreateClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if _, ok := obj.(*unstructured.Unstructured); ok {
+		return apierrors.NewBadRequest("the custom resource is invalid")
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// deleteRecordingClient records the propagation policies the objects are deleted with
+type deleteRecordingClient struct {
+	client.Client
+	propagations []metav1.DeletionPropagation
+}
+
+func (c *deleteRecordingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	deleteOpts := &client.DeleteOptions{}
+	deleteOpts.ApplyOptions(opts)
+	var propagation metav1.DeletionPropagation
+	if deleteOpts.PropagationPolicy != nil {
+		propagation = *deleteOpts.PropagationPolicy
+	}
+	c.propagations = append(c.propagations, propagation)
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// addUnstructuredKinds registers the kinds of the custom resources in the scheme, as the fake client only lists the known kinds
+func addUnstructuredKinds(s *runtime.Scheme, gvks ...schema.GroupVersionKind) {
+	for _, gvk := range gvks {
+		s.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		s.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+	}
+}
+
+// etcdClusterGVK is the kind of the custom resource in the alm-examples of the etcd operator
+var etcdClusterGVK = schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"}
+
+// newEtcdOperandReconciler returns a Reconciler on a fake client holding the objects and the CRD of EtcdCluster,
+// and the fake client itself
+func newEtcdOperandReconciler(objs ...client.Object) (*Reconciler, client.Client) {
+	s := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+	Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+	Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+	Expect(olmv1.AddToScheme(s)).Should(Succeed())
+	addUnstructuredKinds(s, etcdClusterGVK)
+	crd := &unstructured.Unstructured{}
+	crd.SetAPIVersion("apiextensions.k8s.io/v1")
+	crd.SetKind("CustomResourceDefinition")
+	crd.SetName("etcdclusters.etcd.database.coreos.com")
+	Expect(unstructured.SetNestedSlice(crd.Object, []interface{}{map[string]interface{}{"name": "v1beta2"}}, "spec", "versions")).Should(Succeed())
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(append(objs, crd)...).Build()
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(etcdClusterGVK, meta.RESTScopeNamespace)
+	return &Reconciler{
+		ODLMOperator: &deploy.ODLMOperator{
+			Client: restMappedClient{Client: c, mapper: mapper},
+			Reader: c,
+			Scheme: s,
+		},
+		AccessReviewer: &fakeAccessReviewer{},
+	}, c
+}
+
+// runningEtcdOperator returns the Subscription of the etcd operator with its ClusterServiceVersion Succeeded
+func runningEtcdOperator(namespace string) (*olmv1alpha1.Subscription, *olmv1alpha1.ClusterServiceVersion) {
+	sub := testutil.Subscription("etcd", namespace)
+	sub.Status = testutil.SubscriptionStatus("etcd", namespace, "0.0.1")
+	csv := testutil.ClusterServiceVersion(sub.Status.CurrentCSV, namespace, testutil.EtcdExample)
+	csv.Status = testutil.ClusterServiceVersionStatus()
+	return sub, csv
+}
+
+// restMappedClient serves the RESTMapper missing in the fake client
+type restMappedClient struct {
+	client.Client
+	mapper meta.RESTMapper
+}
+
+func (c restMappedClient) RESTMapper() meta.RESTMapper {
+	return c.mapper
+}
+
+// fakeAccessReviewer denies the verbs without asking the API server
+type fakeAccessReviewer struct {
+	deniedVerbs map[string]bool
+	reviewed    []authorizationv1.ResourceAttributes
+}
+
+func (r *fakeAccessReviewer) Review(ctx context.Context, attributes *authorizationv1.ResourceAttributes) (bool, error) {
+	r.reviewed = append(r.reviewed, *attributes)
+	return !r.deniedVerbs[attributes.Verb], nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
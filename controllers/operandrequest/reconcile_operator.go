@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -43,13 +44,14 @@ import (
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/util"
 )
 
-func (r *Reconciler) reconcileOperator(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest) error {
+// reconcileOperator installs the operators of the OperandRequest, except the operands rejected by the operand quota
+func (r *Reconciler) reconcileOperator(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest, rejected map[string]bool) error {
 	klog.V(1).Infof("Reconciling Operators for OperandRequest: %s/%s", requestInstance.GetNamespace(), requestInstance.GetName())
 
 	// Update request status
 	defer func() {
 		requestInstance.FreshMemberStatus()
-		requestInstance.UpdateClusterPhase()
+		requestInstance.UpdateClusterPhaseWithPrecedence(r.PhasePrecedence)
 	}()
 
 	for _, req := range requestInstance.Spec.Requests {
@@ -99,6 +101,11 @@ func (r *Reconciler) reconcileOperator(ctx context.Context, requestInstance *ope
 				wg sync.WaitGroup
 			)
 			for _, operand := range req.Operands[i:j] {
+				requestInstance.SetNamespaceQuotaExceededCondition(operand.Name, rejected[operand.Name], &r.Mutex)
+				if rejected[operand.Name] {
+					klog.Warningf("Operand %s of the OperandRequest %s/%s exceeds the operand quota of the namespace", operand.Name, requestInstance.Namespace, requestInstance.Name)
+					continue
+				}
 				wg.Add(1)
 				go func(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest, registryInstance *operatorv1alpha1.OperandRegistry, operand operatorv1alpha1.Operand, registryKey types.NamespacedName, mu *sync.Mutex) {
 					defer wg.Done()
@@ -147,14 +154,37 @@ func (r *Reconciler) reconcileSubscription(ctx context.Context, requestInstance
 		return nil
 	}
 
+	// The Subscription of an operator without namespace would be created in an undefined namespace
+	if err := opt.ValidateNamespace(); err != nil {
+		klog.Errorf("Invalid OperandRegistry %s: %v", registryKey.String(), err)
+		requestInstance.SetMemberStatus(operand.Name, operatorv1alpha1.OperatorFailed, "", mu)
+		return err
+	}
+
 	// Check subscription if exist
 	namespace := r.GetOperatorNamespace(opt.InstallMode, opt.Namespace)
+
+	// Install the operator from the CatalogSource selected for its namespace and install mode
+	opt, err := r.applyCatalogOverride(ctx, opt, namespace)
+	if err != nil {
+		klog.Errorf("failed to select the CatalogSource of the operator %s: %v", operand.Name, err)
+		requestInstance.SetMemberStatus(operand.Name, operatorv1alpha1.OperatorFailed, "", mu)
+		return err
+	}
+
 	sub, err := r.GetSubscription(ctx, opt.Name, namespace, opt.PackageName)
 
 	if err != nil {
 		if apierrors.IsNotFound(err) {
-			// Subscription does not exist, create a new one
-			if err = r.createSubscription(ctx, requestInstance, opt, registryKey); err != nil {
+			// Subscription does not exist, create a new one, starting from the ClusterServiceVersion pinned by the operand.
+			// The pinned operator is approved manually, otherwise OLM upgrades it past the pinned ClusterServiceVersion.
+			template := opt
+			if operand.StartingCSV != "" {
+				template = opt.DeepCopy()
+				template.StartingCSV = operand.StartingCSV
+				template.InstallPlanApproval = olmv1alpha1.ApprovalManual
+			}
+			if err = r.createSubscription(ctx, requestInstance, template, registryKey); err != nil {
 				requestInstance.SetMemberStatus(opt.Name, operatorv1alpha1.OperatorFailed, "", mu)
 				return err
 			}
@@ -166,14 +196,26 @@ func (r *Reconciler) reconcileSubscription(ctx context.Context, requestInstance
 
 	// Subscription existing and managed by OperandRequest controller
 	if _, ok := sub.Labels[constant.OpreqLabel]; ok {
+		// Pin the installed operator to manual approval when the upgrades are suspended,
+		// and the operator pinned to a ClusterServiceVersion by the operand
+		template := opt
+		suspend := r.SuspendUpgrades && sub.Status.InstalledCSV != ""
+		if suspend || operand.StartingCSV != "" {
+			template = opt.DeepCopy()
+			template.InstallPlanApproval = olmv1alpha1.ApprovalManual
+		}
+		_, suspended := sub.Annotations[constant.UpgradesSuspendedAnnotation]
+		// The InstallPlan withheld by the pin is approved once the pin is removed
+		unpinned := operand.StartingCSV == "" && sub.Spec.StartingCSV != "" && installPlanApproval(sub.Spec.InstallPlanApproval) == olmv1alpha1.ApprovalManual
 		// Subscription channel changed, update it.
-		if compareSub(sub, opt, registryKey, types.NamespacedName{Namespace: requestInstance.Namespace, Name: requestInstance.Name}) {
-			sub.Spec.CatalogSource = opt.SourceName
-			sub.Spec.Channel = opt.Channel
-			sub.Spec.CatalogSourceNamespace = opt.SourceNamespace
-			sub.Spec.Package = opt.PackageName
-			if opt.InstallPlanApproval != "" && sub.Spec.InstallPlanApproval != opt.InstallPlanApproval {
-				sub.Spec.InstallPlanApproval = opt.InstallPlanApproval
+		if compareSub(sub, template, registryKey, types.NamespacedName{Namespace: requestInstance.Namespace, Name: requestInstance.Name}) || suspend != suspended || !checkRequestsAnnotation(sub.Annotations) {
+			sub.Spec.CatalogSource = template.SourceName
+			sub.Spec.Channel = template.Channel
+			sub.Spec.CatalogSourceNamespace = template.SourceNamespace
+			sub.Spec.Package = template.PackageName
+			// The approval is restored once the upgrades are resumed or the pin is removed
+			if installPlanApproval(sub.Spec.InstallPlanApproval) != installPlanApproval(template.InstallPlanApproval) {
+				sub.Spec.InstallPlanApproval = installPlanApproval(template.InstallPlanApproval)
 			}
 			// add annotations to existing Subscriptions for upgrade case
 			if sub.Annotations == nil {
@@ -182,12 +224,32 @@ func (r *Reconciler) reconcileSubscription(ctx context.Context, requestInstance
 			sub.Annotations[registryKey.Namespace+"."+registryKey.Name+"/registry"] = "true"
 			sub.Annotations[registryKey.Namespace+"."+registryKey.Name+"/config"] = "true"
 			sub.Annotations[requestInstance.Namespace+"."+requestInstance.Name+"/request"] = "true"
+			setRequestsAnnotation(sub.Annotations)
+			if suspend {
+				sub.Annotations[constant.UpgradesSuspendedAnnotation] = "true"
+			} else {
+				delete(sub.Annotations, constant.UpgradesSuspendedAnnotation)
+			}
 			if err = r.updateSubscription(ctx, requestInstance, sub); err != nil {
 				requestInstance.SetMemberStatus(opt.Name, operatorv1alpha1.OperatorFailed, "", mu)
 				return err
 			}
+			// Approve the install plan withheld while the upgrades were suspended or the operator was pinned
+			if (suspended || unpinned) && !suspend && installPlanApproval(opt.InstallPlanApproval) == olmv1alpha1.ApprovalAutomatic {
+				if err = r.approveInstallPlan(ctx, sub); err != nil {
+					requestInstance.SetMemberStatus(opt.Name, operatorv1alpha1.OperatorFailed, "", mu)
+					return err
+				}
+			}
 			requestInstance.SetMemberStatus(opt.Name, operatorv1alpha1.OperatorUpdating, "", mu)
 		}
+		// Only the InstallPlan of the pinned ClusterServiceVersion is approved
+		if operand.StartingCSV != "" && sub.Status.InstalledCSV == "" {
+			if err = r.approveInstallPlanOf(ctx, sub, operand.StartingCSV); err != nil {
+				requestInstance.SetMemberStatus(opt.Name, operatorv1alpha1.OperatorFailed, "", mu)
+				return err
+			}
+		}
 	} else {
 		// Subscription existing and not managed by OperandRequest controller
 		klog.V(1).Infof("Subscription %s in namespace %s isn't created by ODLM. Ignore update/delete it.", sub.Name, sub.Namespace)
@@ -195,6 +257,30 @@ func (r *Reconciler) reconcileSubscription(ctx context.Context, requestInstance
 	return nil
 }
 
+// applyCatalogOverride returns the operator with the CatalogSource of the catalog override selecting it,
+// or the operator itself when none selects it
+func (r *Reconciler) applyCatalogOverride(ctx context.Context, opt *operatorv1alpha1.Operator, namespace string) (*operatorv1alpha1.Operator, error) {
+	if len(opt.CatalogOverrides) == 0 {
+		return opt, nil
+	}
+	// The namespace is created with the Subscription, it has no labels yet
+	ns := &corev1.Namespace{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "failed to get the namespace %s", namespace)
+	}
+	override, err := opt.GetCatalogOverride(ns.Labels)
+	if err != nil {
+		return nil, err
+	}
+	if override == nil {
+		return opt, nil
+	}
+	klog.V(2).Infof("Install the operator %s in the namespace %s from the CatalogSource %s/%s", opt.Name, namespace, override.SourceNamespace, override.SourceName)
+	overridden := opt.DeepCopy()
+	overridden.SourceName, overridden.SourceNamespace = override.SourceName, override.SourceNamespace
+	return overridden, nil
+}
+
 func (r *Reconciler) createSubscription(ctx context.Context, cr *operatorv1alpha1.OperandRequest, opt *operatorv1alpha1.Operator, key types.NamespacedName) error {
 	namespace := r.GetOperatorNamespace(opt.InstallMode, opt.Namespace)
 	klog.V(3).Info("Subscription Namespace: ", namespace)
@@ -213,19 +299,26 @@ func (r *Reconciler) createSubscription(ctx context.Context, cr *operatorv1alpha
 		}
 	}
 
-	if namespace != constant.ClusterOperatorNamespace {
-		// Create required operatorgroup
-		existOG := &olmv1.OperatorGroupList{}
-		if err := r.Client.List(ctx, existOG, &client.ListOptions{Namespace: co.operatorGroup.Namespace}); err != nil {
-			return err
-		}
-		if len(existOG.Items) == 0 {
-			og := co.operatorGroup
-			klog.V(3).Info("Creating the OperatorGroup for Subscription: " + opt.Name)
-			if err := r.Create(ctx, og); err != nil && !apierrors.IsAlreadyExists(err) {
-				return err
-			}
-		}
+	// The target namespaces incompatible with the install mode are ignored
+	if err := opt.ValidateTargetNamespaces(); err != nil {
+		klog.Warningf("Invalid OperandRegistry %s: %v", key.String(), err)
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, "InvalidTargetNamespaces", "Invalid OperandRegistry %s: %v", key.String(), err)
+	}
+
+	// The OperatorGroup isn't created until its target namespaces exist
+	missing, err := r.missingTargetNamespaces(ctx, co.operatorGroup)
+	if err != nil {
+		return err
+	}
+	cr.SetTargetNamespaceMissingCondition(opt.Name, missing, &r.Mutex)
+	if len(missing) != 0 {
+		r.Recorder.Eventf(cr, corev1.EventTypeWarning, "TargetNamespaceMissing", "The target namespaces %s of the operator %s don't exist", strings.Join(missing, ", "), opt.Name)
+		return fmt.Errorf("the target namespaces %s of the operator %s don't exist", strings.Join(missing, ", "), opt.Name)
+	}
+
+	// Create required operatorgroup
+	if err := r.ensureOperatorGroup(ctx, co.operatorGroup); err != nil {
+		return err
 	}
 
 	// Create subscription
@@ -244,6 +337,38 @@ func (r *Reconciler) createSubscription(ctx context.Context, cr *operatorv1alpha
 	return nil
 }
 
+// missingTargetNamespaces returns the target namespaces of the OperatorGroup which don't exist
+func (r *Reconciler) missingTargetNamespaces(ctx context.Context, og *olmv1.OperatorGroup) ([]string, error) {
+	var missing []string
+	for _, name := range og.Spec.TargetNamespaces {
+		if err := r.Reader.Get(ctx, types.NamespacedName{Name: name}, &corev1.Namespace{}); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "failed to get the target namespace %s", name)
+			}
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// ensureOperatorGroup creates the OperatorGroup when there is none in its namespace.
+// The existing OperatorGroups are left untouched.
+func (r *Reconciler) ensureOperatorGroup(ctx context.Context, og *olmv1.OperatorGroup) error {
+	existOG := &olmv1.OperatorGroupList{}
+	if err := r.Client.List(ctx, existOG, &client.ListOptions{Namespace: og.Namespace}); err != nil {
+		return errors.Wrapf(err, "failed to list OperatorGroups in the namespace %s", og.Namespace)
+	}
+	if len(existOG.Items) != 0 {
+		klog.V(2).Infof("OperatorGroup %s already exists in the namespace %s, leave it untouched", existOG.Items[0].Name, og.Namespace)
+		return nil
+	}
+	klog.V(3).Infof("Creating the OperatorGroup %s in the namespace %s", og.Name, og.Namespace)
+	if err := r.Create(ctx, og); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create OperatorGroup %s/%s", og.Namespace, og.Name)
+	}
+	return nil
+}
+
 func (r *Reconciler) updateSubscription(ctx context.Context, cr *operatorv1alpha1.OperandRequest, sub *olmv1alpha1.Subscription) error {
 
 	klog.V(2).Infof("Updating Subscription %s/%s ...", sub.Namespace, sub.Name)
@@ -256,6 +381,43 @@ func (r *Reconciler) updateSubscription(ctx context.Context, cr *operatorv1alpha
 	return nil
 }
 
+// approveInstallPlan approves the pending install plan of the subscription
+func (r *Reconciler) approveInstallPlan(ctx context.Context, sub *olmv1alpha1.Subscription) error {
+	return r.approveInstallPlanOf(ctx, sub, "")
+}
+
+// approveInstallPlanOf approves the InstallPlan of the subscription pending for a manual approval,
+// only when it installs the ClusterServiceVersion unless it is empty
+func (r *Reconciler) approveInstallPlanOf(ctx context.Context, sub *olmv1alpha1.Subscription, csvName string) error {
+	if sub.Status.InstallPlanRef == nil {
+		return nil
+	}
+	ip := &olmv1alpha1.InstallPlan{}
+	ipKey := types.NamespacedName{Name: sub.Status.InstallPlanRef.Name, Namespace: sub.Status.InstallPlanRef.Namespace}
+	if err := r.Client.Get(ctx, ipKey, ip); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if ip.Spec.Approved || ip.Spec.Approval != olmv1alpha1.ApprovalManual {
+		return nil
+	}
+	installsCSV := csvName == ""
+	for _, name := range ip.Spec.ClusterServiceVersionNames {
+		if name == csvName {
+			installsCSV = true
+		}
+	}
+	if !installsCSV {
+		klog.V(2).Infof("Withholding the InstallPlan %s for Subscription %s/%s, which doesn't install %s", ipKey.String(), sub.Namespace, sub.Name, csvName)
+		return nil
+	}
+	klog.V(2).Infof("Approving the InstallPlan %s for Subscription %s/%s", ipKey.String(), sub.Namespace, sub.Name)
+	ip.Spec.Approved = true
+	if err := r.Update(ctx, ip); err != nil {
+		return errors.Wrapf(err, "failed to approve the InstallPlan %s", ipKey.String())
+	}
+	return nil
+}
+
 func (r *Reconciler) deleteSubscription(ctx context.Context, operandName string, requestInstance *operatorv1alpha1.OperandRequest, registryInstance *operatorv1alpha1.OperandRegistry, configInstance *operatorv1alpha1.OperandConfig) error {
 	op := registryInstance.GetOperator(operandName)
 	if op == nil {
@@ -291,6 +453,8 @@ func (r *Reconciler) deleteSubscription(ctx context.Context, operandName string,
 	regNs := registryInstance.ObjectMeta.Namespace
 	delete(sub.Annotations, regNs+"."+regName+"/registry")
 	delete(sub.Annotations, regNs+"."+regName+"/config")
+	delete(sub.Annotations, requestInstance.Namespace+"."+requestInstance.Name+"/request")
+	setRequestsAnnotation(sub.Annotations)
 	reg, _ := regexp.Compile(`^(.*)\.(.*)\/registry`)
 	annoSlice := make([]string, 0)
 	for anno := range sub.Annotations {
@@ -316,7 +480,7 @@ func (r *Reconciler) deleteSubscription(ctx context.Context, operandName string,
 
 	if csv != nil {
 		klog.V(2).Infof("Deleting all the Custom Resources for CSV, Namespace: %s, Name: %s", csv.Namespace, csv.Name)
-		if err := r.deleteAllCustomResource(ctx, csv, requestInstance, configInstance, operandName, op.Namespace); err != nil {
+		if err := r.deleteAllCustomResource(ctx, csv, requestInstance, configInstance, operandName, configInstance.GetService(operandName).GetCRNamespace(op, r.DefaultTargetNamespace)); err != nil {
 			return err
 		}
 		if r.checkUninstallLabel(ctx, op.Name, namespace) {
@@ -355,6 +519,8 @@ func (r *Reconciler) absentOperatorsAndOperands(ctx context.Context, requestInst
 	if err != nil {
 		return err
 	}
+	droppedOperands := getDroppedOperands(requestInstance)
+	confirmedOperands := r.deferUnconfirmedRemovals(requestInstance, needDeletedOperands)
 
 	var (
 		wg sync.WaitGroup
@@ -366,11 +532,30 @@ func (r *Reconciler) absentOperatorsAndOperands(ctx context.Context, requestInst
 		if err != nil {
 			return err
 		}
-		configInstance, err := r.GetOperandConfig(ctx, registryKey)
+		configInstance, err := r.GetOperandConfig(ctx, requestInstance.GetConfigKey(req))
 		if err != nil {
 			return err
 		}
 		merr := &util.MultiErr{}
+		// Remove the OperandRequest from the subscriptions still used by other OperandRequests
+		for o := range droppedOperands.Difference(needDeletedOperands).Iter() {
+			if err := r.removeRequestAnnotation(ctx, fmt.Sprintf("%v", o), requestInstance, registryInstance); err != nil {
+				merr.Add(err)
+			}
+		}
+		// Tear down one operand at a time on deletion, so the dependent operands are removed first
+		if !requestInstance.DeletionTimestamp.IsZero() {
+			for _, o := range teardownOrder(requestInstance, needDeletedOperands) {
+				if err := r.deleteSubscription(ctx, o, requestInstance, registryInstance, configInstance); err != nil {
+					merr.Add(err)
+					return merr
+				}
+			}
+			if len(merr.Errors) != 0 {
+				return merr
+			}
+			continue
+		}
 		remainingOp := needDeletedOperands.Clone()
 		for o := range needDeletedOperands.Iter() {
 			var (
@@ -383,6 +568,10 @@ func (r *Reconciler) absentOperatorsAndOperands(ctx context.Context, requestInst
 					r.Mutex.Lock()
 					defer r.Mutex.Unlock()
 					merr.Add(err)
+					return
+				}
+				if confirmedOperands.Contains(o) {
+					requestInstance.RemoveMemberStatus(fmt.Sprintf("%v", o), &r.Mutex)
 				}
 				remainingOp.Remove(o)
 			}()
@@ -396,9 +585,64 @@ func (r *Reconciler) absentOperatorsAndOperands(ctx context.Context, requestInst
 		}
 
 	}
+	return r.consumeConfirmedRemovals(ctx, requestInstance, confirmedOperands)
+}
+
+// consumeConfirmedRemovals removes the deleted operands from the confirmed-removals annotation,
+// so that dropping them again requires a new confirmation.
+func (r *Reconciler) consumeConfirmedRemovals(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest, deletedOperands gset.Set) error {
+	if deletedOperands.Cardinality() == 0 {
+		return nil
+	}
+	var remaining []string
+	for _, name := range strings.Split(requestInstance.GetAnnotations()[constant.ConfirmedRemovalsAnnotation], ",") {
+		if name = strings.TrimSpace(name); name != "" && !deletedOperands.Contains(name) {
+			remaining = append(remaining, name)
+		}
+	}
+	var value interface{}
+	if len(remaining) != 0 {
+		value = strings.Join(remaining, ",")
+	}
+	mergePatch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				constant.ConfirmedRemovalsAnnotation: value,
+			},
+		},
+	})
+	// Patch a copy to keep the in-memory status changes of the OperandRequest
+	if err := r.Patch(ctx, requestInstance.DeepCopy(), client.RawPatch(types.MergePatchType, mergePatch)); err != nil {
+		return errors.Wrapf(err, "failed to update the confirmed removals of the OperandRequest %s/%s", requestInstance.Namespace, requestInstance.Name)
+	}
 	return nil
 }
 
+// deferUnconfirmedRemovals removes the operands whose removal isn't confirmed from the operands to be deleted,
+// and moves them to the PendingDeletion phase. It returns the operands pending deletion whose removal is confirmed.
+func (r *Reconciler) deferUnconfirmedRemovals(requestInstance *operatorv1alpha1.OperandRequest, needDeletedOperands gset.Set) gset.Set {
+	confirmedOperands := gset.NewSet()
+	if !requestInstance.Spec.ConfirmRemoval || !requestInstance.DeletionTimestamp.IsZero() {
+		return confirmedOperands
+	}
+	confirmed := gset.NewSet()
+	for _, name := range strings.Split(requestInstance.GetAnnotations()[constant.ConfirmedRemovalsAnnotation], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			confirmed.Add(name)
+		}
+	}
+	for o := range needDeletedOperands.Clone().Iter() {
+		if confirmed.Contains(o) {
+			confirmedOperands.Add(o)
+			continue
+		}
+		klog.V(1).Infof("Removal of the operand %v from the OperandRequest %s/%s isn't confirmed, defer its deletion", o, requestInstance.Namespace, requestInstance.Name)
+		requestInstance.SetMemberStatus(fmt.Sprintf("%v", o), "", operatorv1alpha1.ServicePendingDeletion, &r.Mutex)
+		needDeletedOperands.Remove(o)
+	}
+	return confirmedOperands
+}
+
 func (r *Reconciler) getNeedDeletedOperands(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest) (gset.Set, error) {
 	klog.V(3).Info("Getting the operater need to be delete")
 	deployedOperands := gset.NewSet()
@@ -415,6 +659,135 @@ func (r *Reconciler) getNeedDeletedOperands(ctx context.Context, requestInstance
 	return needDeleteOperands, nil
 }
 
+// teardownOrder returns the operands in the reverse topological order of their dependencies, so an operand is removed
+// before the operands it depends on. The operands requested later may depend on the earlier ones and are removed first
+// among the operands independent of each other. The operands no longer in the spec are removed at last, in the order of their names.
+func teardownOrder(requestInstance *operatorv1alpha1.OperandRequest, operands gset.Set) []string {
+	var requested []operatorv1alpha1.Operand
+	remaining := operands.Clone()
+	for _, req := range requestInstance.Spec.Requests {
+		for _, operand := range req.Operands {
+			if remaining.Contains(operand.Name) {
+				requested = append(requested, operand)
+				remaining.Remove(operand.Name)
+			}
+		}
+	}
+
+	var order []string
+	for len(requested) != 0 {
+		// The last operand no remaining operand depends on, or the last one when the dependencies form a cycle
+		next := len(requested) - 1
+		for i := len(requested) - 1; i >= 0; i-- {
+			if !hasDependent(requested, requested[i].Name) {
+				next = i
+				break
+			}
+		}
+		order = append(order, requested[next].Name)
+		requested = append(requested[:next], requested[next+1:]...)
+	}
+
+	var rest []string
+	for o := range remaining.Iter() {
+		rest = append(rest, fmt.Sprintf("%v", o))
+	}
+	sort.Strings(rest)
+	return append(order, rest...)
+}
+
+// hasDependent returns if any of the operands depends on the named one
+func hasDependent(operands []operatorv1alpha1.Operand, name string) bool {
+	for _, operand := range operands {
+		if operand.Name == name {
+			continue
+		}
+		for _, dependency := range operand.DependsOn {
+			if dependency == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getDroppedOperands returns the operands deployed but no longer requested by the OperandRequest
+func getDroppedOperands(requestInstance *operatorv1alpha1.OperandRequest) gset.Set {
+	deployedOperands := gset.NewSet()
+	for _, member := range requestInstance.Status.Members {
+		deployedOperands.Add(member.Name)
+	}
+	if !requestInstance.DeletionTimestamp.IsZero() {
+		return deployedOperands
+	}
+	requestedOperands := gset.NewSet()
+	for _, req := range requestInstance.Spec.Requests {
+		for _, operand := range req.Operands {
+			requestedOperands.Add(operand.Name)
+		}
+	}
+	return deployedOperands.Difference(requestedOperands)
+}
+
+// removeRequestAnnotation removes the OperandRequest from the annotations of the subscription
+func (r *Reconciler) removeRequestAnnotation(ctx context.Context, operandName string, requestInstance *operatorv1alpha1.OperandRequest, registryInstance *operatorv1alpha1.OperandRegistry) error {
+	op := registryInstance.GetOperator(operandName)
+	if op == nil {
+		return nil
+	}
+	namespace := r.GetOperatorNamespace(op.InstallMode, op.Namespace)
+	sub, err := r.GetSubscription(ctx, operandName, namespace, op.PackageName)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if sub == nil {
+		return nil
+	}
+	if _, ok := sub.Labels[constant.OpreqLabel]; !ok {
+		return nil
+	}
+	requestAnno := requestInstance.Namespace + "." + requestInstance.Name + "/request"
+	if _, ok := sub.Annotations[requestAnno]; !ok {
+		return nil
+	}
+	originalSub := sub.DeepCopy()
+	delete(sub.Annotations, requestAnno)
+	setRequestsAnnotation(sub.Annotations)
+	klog.V(2).Infof("Removing OperandRequest %s/%s from the annotations of Subscription %s/%s", requestInstance.Namespace, requestInstance.Name, sub.Namespace, sub.Name)
+	if err := r.Patch(ctx, sub, client.MergeFrom(originalSub)); err != nil {
+		return errors.Wrapf(err, "failed to update the annotations of Subscription %s/%s", sub.Namespace, sub.Name)
+	}
+	return nil
+}
+
+// getRequestsFromAnnotations returns the sorted OperandRequests referencing the subscription
+func getRequestsFromAnnotations(annotations map[string]string) string {
+	reg, _ := regexp.Compile(`^([^.]+)\.(.+)\/request$`)
+	var requests []string
+	for anno := range annotations {
+		if match := reg.FindStringSubmatch(anno); match != nil {
+			requests = append(requests, match[1]+"/"+match[2])
+		}
+	}
+	sort.Strings(requests)
+	return strings.Join(requests, ",")
+}
+
+// setRequestsAnnotation lists the OperandRequests referencing the subscription in its annotations
+func setRequestsAnnotation(annotations map[string]string) {
+	requests := getRequestsFromAnnotations(annotations)
+	if requests == "" {
+		delete(annotations, constant.OperandRequestsAnnotation)
+		return
+	}
+	annotations[constant.OperandRequestsAnnotation] = requests
+}
+
+// checkRequestsAnnotation checks if the list of OperandRequests in the annotations is up to date
+func checkRequestsAnnotation(annotations map[string]string) bool {
+	return annotations[constant.OperandRequestsAnnotation] == getRequestsFromAnnotations(annotations)
+}
+
 func (r *Reconciler) getCurrentOperands(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest) (gset.Set, error) {
 	klog.V(3).Info("Getting the operaters have been deployed")
 	deployedOperands := gset.NewSet()
@@ -453,6 +826,7 @@ func (r *Reconciler) generateClusterObjects(o *operatorv1alpha1.Operator, regist
 		registryKey.Namespace + "." + registryKey.Name + "/config":   "true",
 		requestKey.Namespace + "." + requestKey.Name + "/request":    "true",
 	}
+	setRequestsAnnotation(annotations)
 
 	klog.V(3).Info("Generating Namespace: ", o.Namespace)
 	// Namespace Object
@@ -467,14 +841,21 @@ func (r *Reconciler) generateClusterObjects(o *operatorv1alpha1.Operator, regist
 		},
 	}
 
-	// Operator Group Object
-	klog.V(3).Info("Generating Operator Group in the Namespace: ", o.Namespace, " with target namespace: ", o.TargetNamespaces)
-	og := generateOperatorGroup(o.Namespace, o.TargetNamespaces)
-	co.operatorGroup = og
-
 	// The namespace is 'openshift-operators' when installMode is cluster
 	namespace := r.GetOperatorNamespace(o.InstallMode, o.Namespace)
 
+	// Operator Group Object
+	var targetNamespaces []string
+	if o.InstallMode == operatorv1alpha1.InstallModeCluster {
+		// An empty target namespace list makes the OperatorGroup watch all the namespaces
+		targetNamespaces = []string{}
+	} else if len(o.TargetNamespaces) != 0 {
+		// The operator watches its own namespace, a single namespace or multiple namespaces
+		targetNamespaces = o.TargetNamespaces
+	}
+	klog.V(3).Info("Generating Operator Group in the Namespace: ", namespace, " with target namespace: ", targetNamespaces)
+	co.operatorGroup = generateOperatorGroup(namespace, targetNamespaces)
+
 	// Subscription Object
 	sub := &olmv1alpha1.Subscription{
 		ObjectMeta: metav1.ObjectMeta{
@@ -538,5 +919,13 @@ func compareSub(sub *olmv1alpha1.Subscription, template *operatorv1alpha1.Operat
 	_, conExists := anno[registryKey.Namespace+"."+registryKey.Name+"/config"]
 	_, reqExists := anno[requestKey.Namespace+"."+requestKey.Name+"/request"]
 	spec := sub.Spec
-	return !conExists || !regExists || !reqExists || spec.CatalogSource != template.SourceName || spec.Channel != template.Channel || spec.CatalogSourceNamespace != template.SourceNamespace || spec.Package != template.PackageName || spec.InstallPlanApproval != template.InstallPlanApproval
+	return !conExists || !regExists || !reqExists || spec.CatalogSource != template.SourceName || spec.Channel != template.Channel || spec.CatalogSourceNamespace != template.SourceNamespace || spec.Package != template.PackageName || installPlanApproval(spec.InstallPlanApproval) != installPlanApproval(template.InstallPlanApproval)
+}
+
+// installPlanApproval returns the approval of the InstallPlans, OLM approves them automatically when it is empty
+func installPlanApproval(approval olmv1alpha1.Approval) olmv1alpha1.Approval {
+	if approval == "" {
+		return olmv1alpha1.ApprovalAutomatic
+	}
+	return approval
 }
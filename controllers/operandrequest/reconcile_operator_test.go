@@ -0,0 +1,790 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+
+	gset "github.com/deckarep/golang-set"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	olmv1 "github.com/operator-framework/api/pkg/operators/v1"
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Reconcile operator", func() {
+	const (
+		requestName       = "ibm-cloudpak-name"
+		requestNamespace  = "ibm-cloudpak-operator"
+		registryName      = "common-service"
+		registryNamespace = "ibm-common-services-operator"
+		operatorNamespace = "ibm-operators-operator"
+	)
+
+	var (
+		ctx                   context.Context
+		r                     *Reconciler
+		registry              *operatorv1alpha1.OperandRegistry
+		request               *operatorv1alpha1.OperandRequest
+		registryKey           types.NamespacedName
+		operatorNamespaceName string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client: k8sClient,
+				Reader: k8sClient,
+			},
+		}
+		registryNamespaceName := testutil.CreateNSName(registryNamespace)
+		requestNamespaceName := testutil.CreateNSName(requestNamespace)
+		operatorNamespaceName = testutil.CreateNSName(operatorNamespace)
+		Expect(k8sClient.Create(ctx, testutil.NamespaceObj(registryNamespaceName))).Should(Succeed())
+		Expect(k8sClient.Create(ctx, testutil.NamespaceObj(requestNamespaceName))).Should(Succeed())
+		Expect(k8sClient.Create(ctx, testutil.NamespaceObj(operatorNamespaceName))).Should(Succeed())
+
+		registry = testutil.OperandRegistryObj(registryName, registryNamespaceName, operatorNamespaceName)
+		request = testutil.OperandRequestObj(registryName, registryNamespaceName, requestName, requestNamespaceName)
+		registryKey = types.NamespacedName{Name: registryName, Namespace: registryNamespaceName}
+	})
+
+	Context("Suspending the operator upgrades", func() {
+		It("Should withhold the upgrades but allow the new installs", func() {
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+			Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1.AddToScheme(s)).Should(Succeed())
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(testutil.NamespaceObj(operatorNamespaceName)).Build()
+			r.Client, r.Reader, r.Recorder = c, c, record.NewFakeRecorder(10)
+			etcdOperand := request.Spec.Requests[0].Operands[0]
+			subKey := types.NamespacedName{Name: "etcd", Namespace: operatorNamespaceName}
+			r.SuspendUpgrades = true
+
+			By("Installing the operator while the upgrades are suspended")
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			sub := &olmv1alpha1.Subscription{}
+			Expect(c.Get(ctx, subKey, sub)).Should(Succeed())
+			Expect(installPlanApproval(sub.Spec.InstallPlanApproval)).Should(Equal(olmv1alpha1.ApprovalAutomatic))
+
+			By("Setting the installed CSV of the Subscription")
+			sub.Status = testutil.SubscriptionStatus("etcd", operatorNamespaceName, "0.0.1")
+			Expect(c.Update(ctx, sub)).Should(Succeed())
+
+			By("Checking the Subscription is pinned to manual approval")
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			Expect(c.Get(ctx, subKey, sub)).Should(Succeed())
+			Expect(sub.Spec.InstallPlanApproval).Should(Equal(olmv1alpha1.ApprovalManual))
+			Expect(sub.Annotations).Should(HaveKeyWithValue(constant.UpgradesSuspendedAnnotation, "true"))
+
+			By("Creating the InstallPlan of the upgrade pending for approval")
+			ip := testutil.InstallPlan("etcd-install-plan", operatorNamespaceName)
+			ip.Spec.Approval = olmv1alpha1.ApprovalManual
+			ip.Spec.Approved = false
+			Expect(c.Create(ctx, ip)).Should(Succeed())
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			Expect(c.Get(ctx, types.NamespacedName{Name: "etcd-install-plan", Namespace: operatorNamespaceName}, ip)).Should(Succeed())
+			Expect(ip.Spec.Approved).Should(BeFalse())
+
+			By("Lifting the suspension")
+			r.SuspendUpgrades = false
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			sub = &olmv1alpha1.Subscription{}
+			Expect(c.Get(ctx, subKey, sub)).Should(Succeed())
+			Expect(sub.Spec.InstallPlanApproval).Should(Equal(olmv1alpha1.ApprovalAutomatic))
+			Expect(sub.Annotations).ShouldNot(HaveKey(constant.UpgradesSuspendedAnnotation))
+
+			By("Checking the withheld InstallPlan is approved")
+			Expect(c.Get(ctx, types.NamespacedName{Name: "etcd-install-plan", Namespace: operatorNamespaceName}, ip)).Should(Succeed())
+			Expect(ip.Spec.Approved).Should(BeTrue())
+
+			By("Leaving the Subscription unchanged on the next reconcile")
+			request.SetMemberStatus("etcd", operatorv1alpha1.OperatorRunning, "", &r.Mutex)
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			updated := &olmv1alpha1.Subscription{}
+			Expect(c.Get(ctx, subKey, updated)).Should(Succeed())
+			Expect(updated.ResourceVersion).Should(Equal(sub.ResourceVersion))
+			Expect(request.Status.Members[0].Phase.OperatorPhase).Should(Equal(operatorv1alpha1.OperatorRunning))
+		})
+	})
+	Context("Pinning the operator to a ClusterServiceVersion", func() {
+		It("Should only approve the InstallPlan of the pinned ClusterServiceVersion", func() {
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+			Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1.AddToScheme(s)).Should(Succeed())
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(testutil.NamespaceObj(operatorNamespaceName)).Build()
+			r.Client, r.Reader, r.Recorder = c, c, record.NewFakeRecorder(10)
+			etcdOperand := request.Spec.Requests[0].Operands[0]
+			etcdOperand.StartingCSV = "etcd-csv.v0.0.1"
+			subKey := types.NamespacedName{Name: "etcd", Namespace: operatorNamespaceName}
+			installPlan := func(name, csvName string) *olmv1alpha1.InstallPlan {
+				ip := testutil.InstallPlan(name, operatorNamespaceName)
+				ip.Spec.Approval = olmv1alpha1.ApprovalManual
+				ip.Spec.ClusterServiceVersionNames = []string{csvName}
+				Expect(c.Create(ctx, ip)).Should(Succeed())
+				return ip
+			}
+			approved := func(ip *olmv1alpha1.InstallPlan) bool {
+				Expect(c.Get(ctx, types.NamespacedName{Name: ip.Name, Namespace: ip.Namespace}, ip)).Should(Succeed())
+				return ip.Spec.Approved
+			}
+
+			By("Creating the Subscription with the manual approval")
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			sub := &olmv1alpha1.Subscription{}
+			Expect(c.Get(ctx, subKey, sub)).Should(Succeed())
+			Expect(sub.Spec.StartingCSV).Should(Equal("etcd-csv.v0.0.1"))
+			Expect(sub.Spec.InstallPlanApproval).Should(Equal(olmv1alpha1.ApprovalManual))
+
+			By("Approving the InstallPlan of the pinned ClusterServiceVersion")
+			pinned := installPlan("etcd-install-plan", "etcd-csv.v0.0.1")
+			sub.Status = testutil.SubscriptionStatus("etcd", operatorNamespaceName, "0.0.1")
+			sub.Status.InstalledCSV = ""
+			Expect(c.Update(ctx, sub)).Should(Succeed())
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			Expect(approved(pinned)).Should(BeTrue())
+
+			By("Withholding the InstallPlan of the upgrade")
+			upgrade := installPlan("etcd-upgrade", "etcd-csv.v0.0.2")
+			Expect(c.Get(ctx, subKey, sub)).Should(Succeed())
+			sub.Status.InstalledCSV = "etcd-csv.v0.0.1"
+			sub.Status.InstallPlanRef.Name = upgrade.Name
+			Expect(c.Update(ctx, sub)).Should(Succeed())
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			Expect(approved(upgrade)).Should(BeFalse())
+			Expect(c.Get(ctx, subKey, sub)).Should(Succeed())
+			Expect(sub.Spec.InstallPlanApproval).Should(Equal(olmv1alpha1.ApprovalManual))
+
+			By("Approving the withheld upgrade once the pin is removed")
+			etcdOperand.StartingCSV = ""
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			Expect(c.Get(ctx, subKey, sub)).Should(Succeed())
+			Expect(sub.Spec.InstallPlanApproval).Should(Equal(olmv1alpha1.ApprovalAutomatic))
+			Expect(approved(upgrade)).Should(BeTrue())
+
+			By("Leaving the unpinned Subscription unchanged on the next reconcile")
+			request.SetMemberStatus("etcd", operatorv1alpha1.OperatorRunning, "", &r.Mutex)
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			updated := &olmv1alpha1.Subscription{}
+			Expect(c.Get(ctx, subKey, updated)).Should(Succeed())
+			Expect(updated.ResourceVersion).Should(Equal(sub.ResourceVersion))
+			Expect(request.Status.Members[0].Phase.OperatorPhase).Should(Equal(operatorv1alpha1.OperatorRunning))
+		})
+	})
+
+	Context("Ensuring the OperatorGroup", func() {
+		It("Should create the OperatorGroup when it is absent", func() {
+			etcdOperand := request.Spec.Requests[0].Operands[0]
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+
+			ogList := &olmv1.OperatorGroupList{}
+			Expect(k8sClient.List(ctx, ogList, client.InNamespace(operatorNamespaceName))).Should(Succeed())
+			Expect(ogList.Items).Should(HaveLen(1))
+			Expect(ogList.Items[0].Spec.TargetNamespaces).Should(Equal([]string{operatorNamespaceName}))
+
+			By("Generating the OperatorGroup for all the namespaces in cluster install mode")
+			opt := registry.GetOperator("etcd").DeepCopy()
+			opt.InstallMode = operatorv1alpha1.InstallModeCluster
+			co := r.generateClusterObjects(opt, registryKey, types.NamespacedName{Namespace: request.Namespace, Name: request.Name})
+			Expect(co.operatorGroup.Namespace).Should(Equal(constant.ClusterOperatorNamespace))
+			Expect(co.operatorGroup.Spec.TargetNamespaces).Should(BeEmpty())
+		})
+
+		It("Should target the namespaces of the operator", func() {
+			By("Generating the OperatorGroup for multiple namespaces")
+			opt := registry.GetOperator("etcd").DeepCopy()
+			opt.TargetNamespaces = []string{operatorNamespaceName, request.Namespace}
+			co := r.generateClusterObjects(opt, registryKey, types.NamespacedName{Namespace: request.Namespace, Name: request.Name})
+			Expect(co.operatorGroup.Namespace).Should(Equal(operatorNamespaceName))
+			Expect(co.operatorGroup.Spec.TargetNamespaces).Should(Equal([]string{operatorNamespaceName, request.Namespace}))
+
+			By("Generating the OperatorGroup for the own namespace without the target namespaces")
+			opt.TargetNamespaces = []string{}
+			co = r.generateClusterObjects(opt, registryKey, types.NamespacedName{Namespace: request.Namespace, Name: request.Name})
+			Expect(co.operatorGroup.Spec.TargetNamespaces).Should(Equal([]string{operatorNamespaceName}))
+
+			By("Ignoring the target namespaces in cluster install mode")
+			opt.InstallMode = operatorv1alpha1.InstallModeCluster
+			opt.TargetNamespaces = []string{request.Namespace}
+			recorder := record.NewFakeRecorder(10)
+			c := fake.NewClientBuilder().WithScheme(k8sClient.Scheme()).Build()
+			r.Client, r.Recorder = c, recorder
+			Expect(r.createSubscription(ctx, request, opt, registryKey)).Should(Succeed())
+			Expect(recorder.Events).Should(Receive(HavePrefix("Warning InvalidTargetNamespaces")))
+			ogList := &olmv1.OperatorGroupList{}
+			Expect(c.List(ctx, ogList, client.InNamespace(constant.ClusterOperatorNamespace))).Should(Succeed())
+			Expect(ogList.Items).Should(HaveLen(1))
+			Expect(ogList.Items[0].Spec.TargetNamespaces).Should(BeEmpty())
+		})
+
+		It("Should wait for the target namespaces before creating the OperatorGroup", func() {
+			opt := registry.GetOperator("etcd").DeepCopy()
+			opt.TargetNamespaces = []string{operatorNamespaceName, request.Namespace}
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+			Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1.AddToScheme(s)).Should(Succeed())
+			recorder := record.NewFakeRecorder(10)
+			c := fake.NewClientBuilder().WithScheme(s).Build()
+			r.Client, r.Reader, r.Recorder = c, c, recorder
+			targetNamespaceMissing := func() []operatorv1alpha1.Condition {
+				var conditions []operatorv1alpha1.Condition
+				for _, cond := range request.Status.Conditions {
+					if cond.Type == operatorv1alpha1.ConditionTargetNamespaceMissing {
+						conditions = append(conditions, cond)
+					}
+				}
+				return conditions
+			}
+
+			By("Flagging the missing target namespace")
+			err := r.createSubscription(ctx, request, opt, registryKey)
+			Expect(err).Should(MatchError(ContainSubstring("the target namespaces " + request.Namespace + " of the operator etcd don't exist")))
+			Expect(recorder.Events).Should(Receive(HavePrefix("Warning TargetNamespaceMissing")))
+			Expect(targetNamespaceMissing()).Should(HaveLen(1))
+			Expect(targetNamespaceMissing()[0].Message).Should(Equal("Missing the target namespaces " + request.Namespace + " of the OperatorGroup for etcd"))
+			ogList := &olmv1.OperatorGroupList{}
+			Expect(c.List(ctx, ogList, client.InNamespace(operatorNamespaceName))).Should(Succeed())
+			Expect(ogList.Items).Should(BeEmpty())
+
+			By("Creating the OperatorGroup once the target namespaces exist")
+			Expect(c.Create(ctx, testutil.NamespaceObj(request.Namespace))).Should(Succeed())
+			Expect(r.createSubscription(ctx, request, opt, registryKey)).Should(Succeed())
+			Expect(targetNamespaceMissing()).Should(BeEmpty())
+			Expect(c.List(ctx, ogList, client.InNamespace(operatorNamespaceName))).Should(Succeed())
+			Expect(ogList.Items).Should(HaveLen(1))
+			Expect(ogList.Items[0].Spec.TargetNamespaces).Should(Equal([]string{operatorNamespaceName, request.Namespace}))
+		})
+
+		It("Should leave the existing OperatorGroup untouched", func() {
+			existing := &olmv1.OperatorGroup{
+				ObjectMeta: metav1.ObjectMeta{Name: "existing-operatorgroup", Namespace: operatorNamespaceName},
+				Spec:       olmv1.OperatorGroupSpec{TargetNamespaces: []string{operatorNamespaceName, request.Namespace}},
+			}
+			Expect(k8sClient.Create(ctx, existing)).Should(Succeed())
+
+			etcdOperand := request.Spec.Requests[0].Operands[0]
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+
+			ogList := &olmv1.OperatorGroupList{}
+			Expect(k8sClient.List(ctx, ogList, client.InNamespace(operatorNamespaceName))).Should(Succeed())
+			Expect(ogList.Items).Should(HaveLen(1))
+			Expect(ogList.Items[0].Name).Should(Equal("existing-operatorgroup"))
+			Expect(ogList.Items[0].Spec.TargetNamespaces).Should(Equal([]string{operatorNamespaceName, request.Namespace}))
+			Expect(ogList.Items[0].Labels).ShouldNot(HaveKey(constant.OpreqLabel))
+		})
+	})
+
+	Context("Selecting the CatalogSource of the operator", func() {
+		var c client.Client
+
+		BeforeEach(func() {
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+			Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1.AddToScheme(s)).Should(Succeed())
+			ns := testutil.NamespaceObj(operatorNamespaceName)
+			ns.Labels = map[string]string{"network": "airgapped"}
+			c = fake.NewClientBuilder().WithScheme(s).WithObjects(ns).Build()
+			r.Client, r.Reader = c, c
+		})
+
+		getSubscription := func() *olmv1alpha1.Subscription {
+			sub := &olmv1alpha1.Subscription{}
+			Expect(c.Get(ctx, types.NamespacedName{Name: "etcd", Namespace: operatorNamespaceName}, sub)).Should(Succeed())
+			return sub
+		}
+
+		It("Should install the operator from the CatalogSource of the matching override", func() {
+			registry.Spec.Operators[0].CatalogOverrides = []operatorv1alpha1.CatalogOverride{
+				{
+					InstallMode:     operatorv1alpha1.InstallModeCluster,
+					SourceName:      "cluster-operators",
+					SourceNamespace: "openshift-marketplace",
+				},
+				{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"network": "airgapped"}},
+					SourceName:        "mirrored-operators",
+					SourceNamespace:   "mirror-marketplace",
+				},
+			}
+			etcdOperand := request.Spec.Requests[0].Operands[0]
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			sub := getSubscription()
+			Expect(sub.Spec.CatalogSource).Should(Equal("mirrored-operators"))
+			Expect(sub.Spec.CatalogSourceNamespace).Should(Equal("mirror-marketplace"))
+
+			By("Keeping the Subscription on the CatalogSource of the override")
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			Expect(getSubscription().Spec.CatalogSource).Should(Equal("mirrored-operators"))
+		})
+
+		It("Should fall back to the CatalogSource of the operator when no override matches", func() {
+			registry.Spec.Operators[0].CatalogOverrides = []operatorv1alpha1.CatalogOverride{
+				{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"network": "connected"}},
+					SourceName:        "mirrored-operators",
+					SourceNamespace:   "mirror-marketplace",
+				},
+			}
+			etcdOperand := request.Spec.Requests[0].Operands[0]
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			sub := getSubscription()
+			Expect(sub.Spec.CatalogSource).Should(Equal("community-operators"))
+			Expect(sub.Spec.CatalogSourceNamespace).Should(Equal("openshift-marketplace"))
+		})
+
+		It("Should fail the operator selected by more than one override", func() {
+			registry.Spec.Operators[0].CatalogOverrides = []operatorv1alpha1.CatalogOverride{
+				{SourceName: "mirrored-operators", SourceNamespace: "mirror-marketplace"},
+				{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"network": "airgapped"}},
+					SourceName:        "airgapped-operators",
+					SourceNamespace:   "mirror-marketplace",
+				},
+			}
+			etcdOperand := request.Spec.Requests[0].Operands[0]
+			err := r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)
+			Expect(err).Should(MatchError(ContainSubstring("the catalog overrides 0, 1 of the operator etcd select it at the same time")))
+			Expect(request.Status.Members).Should(HaveLen(1))
+			Expect(request.Status.Members[0].Phase.OperatorPhase).Should(Equal(operatorv1alpha1.OperatorFailed))
+			Expect(errors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: "etcd", Namespace: operatorNamespaceName}, &olmv1alpha1.Subscription{}))).Should(BeTrue())
+		})
+
+		It("Should fail the operator without namespace in the namespace install mode", func() {
+			registry.Spec.Operators[0].Namespace = ""
+			etcdOperand := request.Spec.Requests[0].Operands[0]
+			err := r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)
+			Expect(err).Should(MatchError(ContainSubstring("the namespace of the operator etcd is required in the namespace install mode")))
+			Expect(request.Status.Members).Should(HaveLen(1))
+			Expect(request.Status.Members[0].Phase.OperatorPhase).Should(Equal(operatorv1alpha1.OperatorFailed))
+			subList := &olmv1alpha1.SubscriptionList{}
+			Expect(c.List(ctx, subList)).Should(Succeed())
+			Expect(subList.Items).Should(BeEmpty())
+
+			By("Installing the operator without namespace in the cluster install mode")
+			registry.Spec.Operators[0].InstallMode = operatorv1alpha1.InstallModeCluster
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			Expect(c.Get(ctx, types.NamespacedName{Name: "etcd", Namespace: constant.ClusterOperatorNamespace}, &olmv1alpha1.Subscription{})).Should(Succeed())
+		})
+	})
+
+	Context("Looking up the Subscription of the operator", func() {
+		It("Should match the Subscription by its name, package and namespace", func() {
+			s := runtime.NewScheme()
+			Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+			subscription := func(name, namespace, packageName, currentCSV string) *olmv1alpha1.Subscription {
+				sub := testutil.Subscription(name, namespace)
+				sub.Spec.Package = packageName
+				sub.Status.CurrentCSV = currentCSV
+				return sub
+			}
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+				subscription("etcd", operatorNamespaceName, "etcd", "etcd-csv.v0.0.1"),
+				// The same-named Subscription of another catalog in another namespace
+				subscription("etcd", request.Namespace, "etcd", "etcd-csv.v0.0.2"),
+				// The same-named Subscription of another package
+				subscription("etcd", registryKey.Namespace, "etcd-enterprise", "etcd-enterprise-csv.v1.0.0"),
+			).Build()
+			r.Client, r.Reader = c, c
+
+			sub, err := r.GetSubscription(ctx, "etcd", operatorNamespaceName, "etcd")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sub.Status.CurrentCSV).Should(Equal("etcd-csv.v0.0.1"))
+			sub, err = r.GetSubscription(ctx, "etcd", request.Namespace, "etcd")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sub.Status.CurrentCSV).Should(Equal("etcd-csv.v0.0.2"))
+
+			By("Skipping the same-named Subscription of another package")
+			_, err = r.GetSubscription(ctx, "etcd", registryKey.Namespace, "etcd")
+			Expect(err).Should(MatchError(ContainSubstring("subscribes to the package etcd-enterprise instead of etcd")))
+			Expect(c.Create(ctx, subscription("etcd-operator", registryKey.Namespace, "etcd", "etcd-csv.v0.0.3"))).Should(Succeed())
+			sub, err = r.GetSubscription(ctx, "etcd", registryKey.Namespace, "etcd")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sub.Name).Should(Equal("etcd-operator"))
+			Expect(sub.Status.CurrentCSV).Should(Equal("etcd-csv.v0.0.3"))
+		})
+	})
+
+	Context("Confirming the removal of the operands", func() {
+		It("Should defer the deletion until the removal is confirmed", func() {
+			Expect(k8sClient.Create(ctx, registry)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, testutil.OperandConfigObj(registryName, registryKey.Namespace))).Should(Succeed())
+			etcdOperand := request.Spec.Requests[0].Operands[0]
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+
+			By("Dropping the etcd operand from the OperandRequest")
+			request.Spec.ConfirmRemoval = true
+			request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[1:]
+			request.UpdateLabels()
+			Expect(k8sClient.Create(ctx, request)).Should(Succeed())
+			request.Status.Members = []operatorv1alpha1.MemberStatus{
+				{Name: "etcd", Phase: operatorv1alpha1.MemberPhase{OperatorPhase: operatorv1alpha1.OperatorRunning, OperandPhase: operatorv1alpha1.ServiceRunning}},
+				{Name: "jenkins", Phase: operatorv1alpha1.MemberPhase{OperatorPhase: operatorv1alpha1.OperatorRunning, OperandPhase: operatorv1alpha1.ServiceRunning}},
+			}
+
+			By("Checking the deletion is deferred")
+			Expect(r.absentOperatorsAndOperands(ctx, request)).Should(Succeed())
+			subKey := types.NamespacedName{Name: "etcd", Namespace: operatorNamespaceName}
+			Expect(k8sClient.Get(ctx, subKey, &olmv1alpha1.Subscription{})).Should(Succeed())
+			request.FreshMemberStatus()
+			Expect(request.Status.Members).Should(HaveLen(2))
+			Expect(request.Status.Members[0].Phase.OperandPhase).Should(Equal(operatorv1alpha1.ServicePendingDeletion))
+
+			By("Confirming the removal of the etcd operand")
+			confirmed := &operatorv1alpha1.OperandRequest{}
+			requestKey := types.NamespacedName{Name: request.Name, Namespace: request.Namespace}
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, requestKey, confirmed); err != nil {
+					return err
+				}
+				if confirmed.Annotations == nil {
+					confirmed.Annotations = map[string]string{}
+				}
+				confirmed.Annotations[constant.ConfirmedRemovalsAnnotation] = "etcd"
+				return k8sClient.Update(ctx, confirmed)
+			}, testutil.Timeout, testutil.Interval).Should(Succeed())
+			request.Annotations = confirmed.Annotations
+			Expect(r.absentOperatorsAndOperands(ctx, request)).Should(Succeed())
+
+			By("Checking the etcd operand is deleted and the confirmation is consumed")
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, subKey, &olmv1alpha1.Subscription{}))
+			}, testutil.Timeout, testutil.Interval).Should(BeTrue())
+			request.FreshMemberStatus()
+			Expect(request.Status.Members).Should(HaveLen(1))
+			Expect(request.Status.Members[0].Name).Should(Equal("jenkins"))
+			consumed := &operatorv1alpha1.OperandRequest{}
+			Expect(k8sClient.Get(ctx, requestKey, consumed)).Should(Succeed())
+			Expect(consumed.GetAnnotations()).ShouldNot(HaveKey(constant.ConfirmedRemovalsAnnotation))
+		})
+	})
+
+	Context("Removing an operand from the OperandRequest", func() {
+		var c client.Client
+
+		BeforeEach(func() {
+			s := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+			Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+			Expect(olmv1.AddToScheme(s)).Should(Succeed())
+			c = fake.NewClientBuilder().WithScheme(s).WithObjects(
+				testutil.NamespaceObj(operatorNamespaceName), registry, testutil.OperandConfigObj(registryName, registryKey.Namespace),
+			).Build()
+			r.Client, r.Reader = c, c
+		})
+
+		// requestEtcd requests the etcd operator with an EtcdCluster owned by the OperandRequest, and drops it
+		requestEtcd := func(request *operatorv1alpha1.OperandRequest) {
+			etcdOperand := operatorv1alpha1.Operand{Name: "etcd", Kind: "EtcdCluster", APIVersion: "etcd.database.coreos.com/v1beta2", InstanceName: request.Name}
+			request.Spec.Requests[0].Operands[0] = etcdOperand
+			request.UpdateLabels()
+			Expect(c.Create(ctx, request)).Should(Succeed())
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+
+			cr := &unstructured.Unstructured{}
+			cr.SetAPIVersion(etcdOperand.APIVersion)
+			cr.SetKind(etcdOperand.Kind)
+			cr.SetName(request.Name)
+			cr.SetNamespace(request.Namespace)
+			cr.SetLabels(map[string]string{constant.OpreqLabel: "true"})
+			Expect(c.Create(ctx, cr)).Should(Succeed())
+			request.Status.Members = []operatorv1alpha1.MemberStatus{
+				{
+					Name:          "etcd",
+					Phase:         operatorv1alpha1.MemberPhase{OperatorPhase: operatorv1alpha1.OperatorRunning, OperandPhase: operatorv1alpha1.ServiceRunning},
+					OperandCRList: []operatorv1alpha1.OperandCRMember{{Name: request.Name, Kind: etcdOperand.Kind, APIVersion: etcdOperand.APIVersion}},
+				},
+				{Name: "jenkins", Phase: operatorv1alpha1.MemberPhase{OperatorPhase: operatorv1alpha1.OperatorRunning, OperandPhase: operatorv1alpha1.ServiceRunning}},
+			}
+		}
+
+		dropEtcd := func(request *operatorv1alpha1.OperandRequest) {
+			request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[1:]
+			Expect(c.Update(ctx, request)).Should(Succeed())
+			Expect(r.absentOperatorsAndOperands(ctx, request)).Should(Succeed())
+			Expect(r.checkCustomResource(ctx, request)).Should(Succeed())
+		}
+
+		crExists := func(request *operatorv1alpha1.OperandRequest) bool {
+			cr := &unstructured.Unstructured{}
+			cr.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+			cr.SetKind("EtcdCluster")
+			err := c.Get(ctx, types.NamespacedName{Name: request.Name, Namespace: request.Namespace}, cr)
+			Expect(client.IgnoreNotFound(err)).Should(Succeed())
+			return err == nil
+		}
+
+		subKey := func() types.NamespacedName {
+			return types.NamespacedName{Name: "etcd", Namespace: operatorNamespaceName}
+		}
+
+		It("Should keep the operator shared with another OperandRequest", func() {
+			request2 := testutil.OperandRequestObj(registryName, registryKey.Namespace, requestName+"-2", request.Namespace)
+			requestEtcd(request)
+			requestEtcd(request2)
+
+			dropEtcd(request)
+
+			By("Checking only the custom resource of the OperandRequest is deleted")
+			Expect(crExists(request)).Should(BeFalse())
+			Expect(crExists(request2)).Should(BeTrue())
+
+			By("Checking the Subscription is kept for the other OperandRequest")
+			sub := &olmv1alpha1.Subscription{}
+			Expect(c.Get(ctx, subKey(), sub)).Should(Succeed())
+			Expect(sub.Annotations).Should(HaveKeyWithValue(constant.OperandRequestsAnnotation, request2.Namespace+"/"+request2.Name))
+
+			By("Deleting the Subscription once the last OperandRequest drops the operator")
+			dropEtcd(request2)
+			Expect(crExists(request2)).Should(BeFalse())
+			Expect(errors.IsNotFound(c.Get(ctx, subKey(), &olmv1alpha1.Subscription{}))).Should(BeTrue())
+		})
+
+		It("Should delete the operator owned by the OperandRequest only", func() {
+			requestEtcd(request)
+
+			dropEtcd(request)
+
+			Expect(crExists(request)).Should(BeFalse())
+			Expect(errors.IsNotFound(c.Get(ctx, subKey(), &olmv1alpha1.Subscription{}))).Should(BeTrue())
+		})
+	})
+
+	Context("Enforcing the operand quota of the namespace", func() {
+		var c client.Client
+
+		setQuota := func(quota string) {
+			ns := &corev1.Namespace{}
+			Expect(c.Get(ctx, types.NamespacedName{Name: request.Namespace}, ns)).Should(Succeed())
+			ns.Annotations = map[string]string{constant.OperandQuotaAnnotation: quota}
+			Expect(c.Update(ctx, ns)).Should(Succeed())
+		}
+
+		BeforeEach(func() {
+			c = fake.NewClientBuilder().WithObjects(testutil.NamespaceObj(request.Namespace), registry, testutil.OperandConfigObj(registryName, registryKey.Namespace), request).Build()
+			r.Client, r.Reader = c, c
+		})
+
+		It("Should reject the operands beyond the quota and grandfather the installed ones", func() {
+			By("Admitting all the operands without a quota")
+			rejected, err := r.rejectedOperands(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rejected).Should(BeEmpty())
+
+			By("Rejecting jenkins once etcd is installed by another OperandRequest")
+			other := testutil.OperandRequestObj(registryName, registryKey.Namespace, "other-request", request.Namespace)
+			other.Status.Members = []operatorv1alpha1.MemberStatus{{Name: "etcd"}}
+			Expect(c.Create(ctx, other)).Should(Succeed())
+			setQuota("1")
+			rejected, err = r.rejectedOperands(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rejected).Should(Equal(map[string]bool{"jenkins": true}))
+
+			By("Admitting jenkins once the quota is raised")
+			setQuota("2")
+			rejected, err = r.rejectedOperands(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rejected).Should(BeEmpty())
+
+			By("Keeping the installed operands when the quota is lowered")
+			request.Status.Members = []operatorv1alpha1.MemberStatus{{Name: "etcd"}, {Name: "jenkins"}}
+			setQuota("0")
+			rejected, err = r.rejectedOperands(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rejected).Should(BeEmpty())
+		})
+
+		It("Should report the rejected operands without installing them", func() {
+			setQuota("1")
+			rejected, err := r.rejectedOperands(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(r.reconcileOperator(ctx, request, rejected)).Should(Succeed())
+			Expect(c.Get(ctx, types.NamespacedName{Name: "etcd", Namespace: operatorNamespaceName}, &olmv1alpha1.Subscription{})).Should(Succeed())
+			Expect(errors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: "jenkins", Namespace: operatorNamespaceName}, &olmv1alpha1.Subscription{}))).Should(BeTrue())
+			var conditions []operatorv1alpha1.ConditionType
+			for _, cond := range request.Status.Conditions {
+				conditions = append(conditions, cond.Type)
+			}
+			Expect(conditions).Should(ContainElement(operatorv1alpha1.ConditionNamespaceQuotaExceeded))
+			Expect(request.Status.Members).Should(HaveLen(1))
+			Expect(request.Status.Members[0].Name).Should(Equal("etcd"))
+
+			By("Removing the condition once the quota is raised")
+			setQuota("2")
+			rejected, err = r.rejectedOperands(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(r.reconcileOperator(ctx, request, rejected)).Should(Succeed())
+			Expect(c.Get(ctx, types.NamespacedName{Name: "jenkins", Namespace: operatorNamespaceName}, &olmv1alpha1.Subscription{})).Should(Succeed())
+			for _, cond := range request.Status.Conditions {
+				Expect(cond.Type).ShouldNot(Equal(operatorv1alpha1.ConditionNamespaceQuotaExceeded))
+			}
+		})
+	})
+
+	Context("Annotating the Subscription with the OperandRequests", func() {
+		It("Should list the OperandRequests referencing the Subscription", func() {
+			etcdOperand := request.Spec.Requests[0].Operands[0]
+			request2 := testutil.OperandRequestObj(registryName, registryKey.Namespace, requestName+"-2", request.Namespace)
+
+			By("Reconciling the Subscription for both OperandRequests")
+			Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+			Expect(r.reconcileSubscription(ctx, request2, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+
+			sub := &olmv1alpha1.Subscription{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "etcd", Namespace: operatorNamespaceName}, sub)).Should(Succeed())
+			Expect(sub.Annotations).Should(HaveKeyWithValue(constant.OperandRequestsAnnotation,
+				request.Namespace+"/"+request.Name+","+request2.Namespace+"/"+request2.Name))
+
+			By("Removing the etcd operand from the second OperandRequest")
+			request2.Status.Members = []operatorv1alpha1.MemberStatus{{Name: "etcd"}, {Name: "jenkins"}}
+			request2.Spec.Requests[0].Operands = request2.Spec.Requests[0].Operands[1:]
+			droppedOperands := getDroppedOperands(request2)
+			Expect(droppedOperands.ToSlice()).Should(ConsistOf("etcd"))
+			Expect(r.removeRequestAnnotation(ctx, "etcd", request2, registry)).Should(Succeed())
+
+			By("Checking the annotation only lists the first OperandRequest")
+			sub = &olmv1alpha1.Subscription{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "etcd", Namespace: operatorNamespaceName}, sub)).Should(Succeed())
+			Expect(sub.Annotations).Should(HaveKeyWithValue(constant.OperandRequestsAnnotation, request.Namespace+"/"+request.Name))
+			Expect(sub.Annotations).ShouldNot(HaveKey(request2.Namespace + "." + request2.Name + "/request"))
+		})
+	})
+	Context("Tearing down the operands on deletion", func() {
+		It("Should remove the operands requested later first", func() {
+			request := &operatorv1alpha1.OperandRequest{
+				Spec: operatorv1alpha1.OperandRequestSpec{
+					Requests: []operatorv1alpha1.Request{
+						{Operands: []operatorv1alpha1.Operand{{Name: "etcd"}, {Name: "jenkins"}}},
+						{Operands: []operatorv1alpha1.Operand{{Name: "mongodb"}}},
+					},
+				},
+			}
+			operands := gset.NewSet("etcd", "jenkins", "mongodb", "redis", "kafka")
+			Expect(teardownOrder(request, operands)).Should(Equal([]string{"mongodb", "jenkins", "etcd", "kafka", "redis"}))
+			Expect(operands.Cardinality()).Should(Equal(5))
+		})
+
+		It("Should remove the operands before their dependencies", func() {
+			request := &operatorv1alpha1.OperandRequest{
+				Spec: operatorv1alpha1.OperandRequestSpec{
+					Requests: []operatorv1alpha1.Request{
+						{Operands: []operatorv1alpha1.Operand{{Name: "etcd"}, {Name: "jenkins", DependsOn: []string{"mongodb"}}}},
+						{Operands: []operatorv1alpha1.Operand{{Name: "mongodb", DependsOn: []string{"etcd"}}}},
+					},
+				},
+			}
+			operands := gset.NewSet("etcd", "jenkins", "mongodb")
+			Expect(teardownOrder(request, operands)).Should(Equal([]string{"jenkins", "mongodb", "etcd"}))
+		})
+	})
+	Context("Watching the OperandRegistry", func() {
+		It("Should map the OperandRegistry to the OperandRequests referencing it", func() {
+			Expect(k8sClient.Create(ctx, request)).Should(Succeed())
+			staleRequest := types.NamespacedName{Name: "stale-request", Namespace: request.Namespace}
+			registry.Status.OperatorsStatus = map[string]operatorv1alpha1.OperatorStatus{
+				"etcd": {
+					ReconcileRequests: []operatorv1alpha1.ReconcileRequest{
+						{Name: request.Name, Namespace: request.Namespace},
+						{Name: staleRequest.Name, Namespace: staleRequest.Namespace},
+					},
+				},
+			}
+
+			requests := r.getRegistryToRequestMapper()(registry)
+			Expect(requests).Should(ConsistOf(
+				ctrl.Request{NamespacedName: types.NamespacedName{Name: request.Name, Namespace: request.Namespace}},
+				ctrl.Request{NamespacedName: staleRequest},
+			))
+
+			Expect(k8sClient.Delete(ctx, request)).Should(Succeed())
+		})
+
+		It("Should reconcile the referencing OperandRequest when the OperandRegistry is edited", func() {
+			config := testutil.OperandConfigObj(registryName, registryKey.Namespace)
+			subKey := types.NamespacedName{Name: "etcd", Namespace: operatorNamespaceName}
+
+			By("Creating the OperandRegistry, OperandConfig and OperandRequest")
+			Expect(k8sClient.Create(ctx, registry)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, config)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, request)).Should(Succeed())
+
+			By("Checking the Subscription is created with the channel of the OperandRegistry")
+			Eventually(func() string {
+				sub := &olmv1alpha1.Subscription{}
+				if err := k8sClient.Get(ctx, subKey, sub); err != nil {
+					return ""
+				}
+				return sub.Spec.Channel
+			}, testutil.Timeout, testutil.Interval).Should(Equal(registry.GetOperator("etcd").Channel))
+
+			By("Changing the channel in the OperandRegistry")
+			Eventually(func() error {
+				registryInstance := &operatorv1alpha1.OperandRegistry{}
+				if err := k8sClient.Get(ctx, registryKey, registryInstance); err != nil {
+					return err
+				}
+				for i := range registryInstance.Spec.Operators {
+					if registryInstance.Spec.Operators[i].Name == "etcd" {
+						registryInstance.Spec.Operators[i].Channel = "clusterwide-alpha"
+					}
+				}
+				return k8sClient.Update(ctx, registryInstance)
+			}, testutil.Timeout, testutil.Interval).Should(Succeed())
+
+			By("Checking the Subscription is updated without changing the OperandRequest")
+			Eventually(func() string {
+				sub := &olmv1alpha1.Subscription{}
+				if err := k8sClient.Get(ctx, subKey, sub); err != nil {
+					return ""
+				}
+				return sub.Spec.Channel
+			}, testutil.Timeout, testutil.Interval).Should(Equal("clusterwide-alpha"))
+
+			By("Cleaning up the resources")
+			Expect(k8sClient.Delete(ctx, request)).Should(Succeed())
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, types.NamespacedName{Name: request.Name, Namespace: request.Namespace}, &operatorv1alpha1.OperandRequest{}))
+			}, testutil.Timeout, testutil.Interval).Should(BeTrue())
+			Expect(k8sClient.Delete(ctx, config)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, registry)).Should(Succeed())
+		})
+	})
+})
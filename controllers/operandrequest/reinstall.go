@@ -0,0 +1,155 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	gset "github.com/deckarep/golang-set"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/util"
+)
+
+// reinstallOperands deletes the Subscriptions and ClusterServiceVersions of the operands listed in the reinstall annotation,
+// they are recreated from the OperandRegistry by reconcileOperator. The reinstalled operands are removed from the annotation,
+// so each of them is reinstalled once. The custom resources are kept unless the reinstall-delete-crs annotation is true.
+func (r *Reconciler) reinstallOperands(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest) error {
+	operands := gset.NewSet()
+	for _, name := range strings.Split(requestInstance.GetAnnotations()[constant.ReinstallAnnotation], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			operands.Add(name)
+		}
+	}
+	if operands.Cardinality() == 0 {
+		return nil
+	}
+	deleteCRs := requestInstance.GetAnnotations()[constant.ReinstallDeleteCRsAnnotation] == "true"
+
+	merr := &util.MultiErr{}
+	failed := gset.NewSet()
+	requested := gset.NewSet()
+	for _, req := range requestInstance.Spec.Requests {
+		registryKey := requestInstance.GetRegistryKey(req)
+		for _, operand := range req.Operands {
+			if !operands.Contains(operand.Name) {
+				continue
+			}
+			requested.Add(operand.Name)
+			if err := r.reinstallOperand(ctx, requestInstance, registryKey, requestInstance.GetConfigKey(req), operand.Name, deleteCRs); err != nil {
+				merr.Add(err)
+				failed.Add(operand.Name)
+			}
+		}
+	}
+	for o := range operands.Difference(requested).Iter() {
+		klog.Warningf("The operand %v to reinstall isn't requested by the OperandRequest %s/%s", o, requestInstance.Namespace, requestInstance.Name)
+	}
+
+	// Keep the failed operands in the annotation to retry them
+	var remaining []string
+	for o := range failed.Iter() {
+		remaining = append(remaining, o.(string))
+	}
+	annotations := map[string]interface{}{
+		constant.ReinstallAnnotation:          nil,
+		constant.ReinstallDeleteCRsAnnotation: nil,
+	}
+	if len(remaining) != 0 {
+		annotations[constant.ReinstallAnnotation] = strings.Join(remaining, ",")
+		if deleteCRs {
+			annotations[constant.ReinstallDeleteCRsAnnotation] = "true"
+		}
+	}
+	mergePatch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	// Patch a copy to keep the in-memory status changes of the OperandRequest
+	if err := r.Patch(ctx, requestInstance.DeepCopy(), client.RawPatch(types.MergePatchType, mergePatch)); err != nil {
+		merr.Add(errors.Wrapf(err, "failed to update the operands to reinstall of the OperandRequest %s/%s", requestInstance.Namespace, requestInstance.Name))
+	}
+	if len(merr.Errors) != 0 {
+		return merr
+	}
+	return nil
+}
+
+// reinstallOperand deletes the Subscription and the ClusterServiceVersion of the operand,
+// the Subscriptions not created by ODLM are left untouched
+func (r *Reconciler) reinstallOperand(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest, registryKey, configKey types.NamespacedName, operandName string, deleteCRs bool) error {
+	registryInstance, err := r.GetOperandRegistry(ctx, registryKey)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the OperandRegistry %s", registryKey.String())
+	}
+	op := registryInstance.GetOperator(operandName)
+	if op == nil {
+		klog.Warningf("Operator %s not found in the OperandRegistry %s, skip reinstalling it", operandName, registryKey.String())
+		return nil
+	}
+
+	namespace := r.GetOperatorNamespace(op.InstallMode, op.Namespace)
+	sub, err := r.GetSubscription(ctx, op.Name, namespace, op.PackageName)
+	if apierrors.IsNotFound(err) {
+		klog.V(1).Infof("There is no Subscription %s or %s in the namespace %s to reinstall", op.Name, op.PackageName, namespace)
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to get the Subscription %s in the namespace %s", op.Name, namespace)
+	}
+	if _, ok := sub.Labels[constant.OpreqLabel]; !ok {
+		klog.Warningf("Subscription %s in the namespace %s isn't created by ODLM, skip reinstalling it", sub.Name, sub.Namespace)
+		r.Recorder.Eventf(requestInstance, corev1.EventTypeWarning, "ReinstallSkipped", "Subscription %s/%s of the operand %s isn't created by ODLM", sub.Namespace, sub.Name, operandName)
+		return nil
+	}
+
+	csv, err := r.GetClusterServiceVersion(ctx, sub)
+	if err != nil {
+		return err
+	}
+	if csv != nil {
+		if deleteCRs {
+			configInstance, err := r.GetOperandConfig(ctx, configKey)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get the OperandConfig %s", configKey.String())
+			}
+			klog.V(1).Infof("Deleting the custom resources of the operand %s to reinstall", operandName)
+			if err := r.deleteAllCustomResource(ctx, csv, requestInstance, configInstance, operandName, configInstance.GetService(operandName).GetCRNamespace(op, r.DefaultTargetNamespace)); err != nil {
+				return err
+			}
+		}
+		klog.V(1).Infof("Deleting the ClusterServiceVersion %s/%s to reinstall the operand %s", csv.Namespace, csv.Name, operandName)
+		if err := r.Delete(ctx, csv); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete the ClusterServiceVersion %s/%s", csv.Namespace, csv.Name)
+		}
+	}
+	klog.V(1).Infof("Deleting the Subscription %s/%s to reinstall the operand %s", sub.Namespace, sub.Name, operandName)
+	if err := r.Delete(ctx, sub); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete the Subscription %s/%s", sub.Namespace, sub.Name)
+	}
+	r.Recorder.Eventf(requestInstance, corev1.EventTypeNormal, "Reinstalling", "Deleted the Subscription %s/%s to reinstall the operand %s", sub.Namespace, sub.Name, operandName)
+	return nil
+}
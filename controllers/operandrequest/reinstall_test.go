@@ -0,0 +1,135 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	olmv1 "github.com/operator-framework/api/pkg/operators/v1"
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+// subscriptionCounter counts the Subscriptions created
+type subscriptionCounter struct {
+	client.Client
+	created int32
+}
+
+func (c *subscriptionCounter) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if _, ok := obj.(*olmv1alpha1.Subscription); ok {
+		atomic.AddInt32(&c.created, 1)
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+var _ = Describe("Reinstalling the operands", func() {
+	const (
+		registryName      = "common-service"
+		registryNamespace = "ibm-common-services"
+		operatorNamespace = "ibm-operators"
+	)
+
+	var (
+		ctx         context.Context
+		r           *Reconciler
+		c           *subscriptionCounter
+		request     *operatorv1alpha1.OperandRequest
+		registry    *operatorv1alpha1.OperandRegistry
+		registryKey = types.NamespacedName{Name: registryName, Namespace: registryNamespace}
+		subKey      = types.NamespacedName{Name: "etcd", Namespace: operatorNamespace}
+		requestKey  = types.NamespacedName{Name: "ibm-cloudpak-name", Namespace: "ibm-cloudpak"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+		Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+		Expect(olmv1.AddToScheme(s)).Should(Succeed())
+
+		registry = testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespace)
+		request = testutil.OperandRequestObj(registryName, registryNamespace, requestKey.Name, requestKey.Namespace)
+		request.Annotations = map[string]string{constant.ReinstallAnnotation: "etcd"}
+		sub := testutil.Subscription("etcd", operatorNamespace)
+		sub.Status = testutil.SubscriptionStatus("etcd", operatorNamespace, "0.0.1")
+		csv := testutil.ClusterServiceVersion(sub.Status.CurrentCSV, operatorNamespace, testutil.EtcdExample)
+		c = &subscriptionCounter{Client: fake.NewClientBuilder().WithScheme(s).WithObjects(
+			registry, testutil.OperandConfigObj(registryName, registryNamespace), request, sub, csv,
+		).Build()}
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Scheme:   s,
+				Recorder: record.NewFakeRecorder(10),
+			},
+		}
+	})
+
+	It("Should recreate the Subscription exactly once", func() {
+		etcdOperand := request.Spec.Requests[0].Operands[0]
+
+		By("Reinstalling the etcd operand")
+		Expect(r.reinstallOperands(ctx, request)).Should(Succeed())
+		Expect(apierrors.IsNotFound(c.Get(ctx, subKey, &olmv1alpha1.Subscription{}))).Should(BeTrue())
+		err := c.Get(ctx, types.NamespacedName{Name: "etcd-csv.v0.0.1", Namespace: operatorNamespace}, &olmv1alpha1.ClusterServiceVersion{})
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+		Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+		Expect(c.Get(ctx, subKey, &olmv1alpha1.Subscription{})).Should(Succeed())
+
+		By("Checking the trigger is cleared")
+		request = &operatorv1alpha1.OperandRequest{}
+		Expect(c.Get(ctx, requestKey, request)).Should(Succeed())
+		Expect(request.Annotations).ShouldNot(HaveKey(constant.ReinstallAnnotation))
+
+		By("Reconciling the OperandRequest again")
+		Expect(r.reinstallOperands(ctx, request)).Should(Succeed())
+		Expect(r.reconcileSubscription(ctx, request, registry, etcdOperand, registryKey, &r.Mutex)).Should(Succeed())
+		Expect(c.Get(ctx, subKey, &olmv1alpha1.Subscription{})).Should(Succeed())
+		Expect(atomic.LoadInt32(&c.created)).Should(Equal(int32(1)))
+	})
+
+	It("Should leave the Subscription not created by ODLM untouched", func() {
+		sub := &olmv1alpha1.Subscription{}
+		Expect(c.Get(ctx, subKey, sub)).Should(Succeed())
+		sub.Labels = nil
+		Expect(c.Update(ctx, sub)).Should(Succeed())
+
+		Expect(r.reinstallOperands(ctx, request)).Should(Succeed())
+		Expect(c.Get(ctx, subKey, &olmv1alpha1.Subscription{})).Should(Succeed())
+		Expect(r.Recorder.(*record.FakeRecorder).Events).Should(Receive(ContainSubstring("ReinstallSkipped")))
+		consumed := &operatorv1alpha1.OperandRequest{}
+		Expect(c.Get(ctx, requestKey, consumed)).Should(Succeed())
+		Expect(consumed.Annotations).ShouldNot(HaveKey(constant.ReinstallAnnotation))
+	})
+})
@@ -0,0 +1,94 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/pkg/errors"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+)
+
+// SubscriptionStatesPath is the path of the endpoint serving the states of the Subscriptions managed by ODLM
+const SubscriptionStatesPath = "/subscription-states"
+
+// subscriptionStateUnknown is the state of the Subscriptions not resolved by OLM yet
+const subscriptionStateUnknown = "Unknown"
+
+// SubscriptionStateReport counts the Subscriptions managed by ODLM by their state
+type SubscriptionStateReport struct {
+	Total int `json:"total"`
+	// States maps the states of the Subscriptions to their counts, the known states are always present
+	States map[string]int `json:"states"`
+}
+
+// ReportSubscriptionStates counts the Subscriptions managed by ODLM across the cluster by their state.
+// It only reads from the cluster.
+func (r *Reconciler) ReportSubscriptionStates(ctx context.Context) (*SubscriptionStateReport, error) {
+	subList := &olmv1alpha1.SubscriptionList{}
+	if err := r.Reader.List(ctx, subList, client.HasLabels{constant.OpreqLabel}); err != nil {
+		return nil, errors.Wrap(err, "failed to list Subscriptions")
+	}
+
+	report := &SubscriptionStateReport{
+		Total: len(subList.Items),
+		States: map[string]int{
+			olmv1alpha1.SubscriptionStateAtLatest:         0,
+			olmv1alpha1.SubscriptionStateUpgradeAvailable: 0,
+			olmv1alpha1.SubscriptionStateUpgradePending:   0,
+			olmv1alpha1.SubscriptionStateFailed:           0,
+			subscriptionStateUnknown:                      0,
+		},
+	}
+	for _, sub := range subList.Items {
+		state := string(sub.Status.State)
+		if state == olmv1alpha1.SubscriptionStateNone {
+			state = subscriptionStateUnknown
+		}
+		report.States[state]++
+	}
+	return report, nil
+}
+
+// SubscriptionStatesHandler serves the counts of the Subscriptions managed by ODLM by their state in JSON
+func (r *Reconciler) SubscriptionStatesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		report, err := r.ReportSubscriptionStates(req.Context())
+		if err != nil {
+			klog.Errorf("failed to report the states of the Subscriptions: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			klog.Errorf("failed to write the states of the Subscriptions: %v", err)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Subscription states", func() {
+	var r *Reconciler
+
+	subscription := func(name, namespace string, state olmv1alpha1.SubscriptionState) *olmv1alpha1.Subscription {
+		sub := testutil.Subscription(name, namespace)
+		sub.Status.State = state
+		return sub
+	}
+
+	BeforeEach(func() {
+		s := runtime.NewScheme()
+		Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+
+		unmanaged := subscription("unmanaged", "ibm-operators", olmv1alpha1.SubscriptionStateFailed)
+		unmanaged.Labels = nil
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects([]client.Object{
+			subscription("etcd", "ibm-operators", olmv1alpha1.SubscriptionStateAtLatest),
+			subscription("jenkins", "ibm-operators", olmv1alpha1.SubscriptionStateAtLatest),
+			subscription("etcd", "ibm-cloudpak", olmv1alpha1.SubscriptionStateUpgradePending),
+			subscription("jenkins", "ibm-cloudpak", olmv1alpha1.SubscriptionStateFailed),
+			subscription("mongodb", "ibm-cloudpak", olmv1alpha1.SubscriptionStateNone),
+			unmanaged,
+		}...).Build()
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client: c,
+				Reader: c,
+			},
+		}
+	})
+
+	It("Should serve the counts of the managed Subscriptions by state in JSON", func() {
+		recorder := httptest.NewRecorder()
+		r.SubscriptionStatesHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, SubscriptionStatesPath, nil))
+		Expect(recorder.Code).Should(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Type")).Should(Equal("application/json"))
+
+		report := &SubscriptionStateReport{}
+		Expect(json.Unmarshal(recorder.Body.Bytes(), report)).Should(Succeed())
+		Expect(report.Total).Should(Equal(5))
+		Expect(report.States).Should(Equal(map[string]int{
+			"AtLatestKnown":    2,
+			"UpgradeAvailable": 0,
+			"UpgradePending":   1,
+			"UpgradeFailed":    1,
+			"Unknown":          1,
+		}))
+
+		By("Rejecting the other methods")
+		recorder = httptest.NewRecorder()
+		r.SubscriptionStatesHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, SubscriptionStatesPath, nil))
+		Expect(recorder.Code).Should(Equal(http.StatusMethodNotAllowed))
+	})
+})
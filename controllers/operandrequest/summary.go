@@ -0,0 +1,141 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+)
+
+// RequestSummaryPath is the path of the endpoint serving the summary of the OperandRequests
+const RequestSummaryPath = "/operandrequests"
+
+// RequestSummary aggregates the phases of the OperandRequests across the namespaces,
+// it is refreshed on every reconcile of an OperandRequest
+type RequestSummary struct {
+	mu       sync.RWMutex
+	requests map[types.NamespacedName]requestSummaryEntry
+}
+
+type requestSummaryEntry struct {
+	phase   operatorv1alpha1.ClusterPhase
+	members []operatorv1alpha1.MemberStatus
+}
+
+// RequestSummaryReport counts the OperandRequests by phase and lists their failing operands
+type RequestSummaryReport struct {
+	Total int `json:"total"`
+	// Phases maps the phases of the OperandRequests to their counts
+	Phases map[string]int `json:"phases"`
+	// FailingOperands are the members whose operator or operand is Failed, sorted by OperandRequest and name
+	FailingOperands []FailingOperand `json:"failingOperands"`
+}
+
+// FailingOperand is a member of an OperandRequest whose operator or operand is Failed
+type FailingOperand struct {
+	// OperandRequest is the namespaced name of the OperandRequest
+	OperandRequest string                         `json:"operandRequest"`
+	Name           string                         `json:"name"`
+	OperatorPhase  operatorv1alpha1.OperatorPhase `json:"operatorPhase,omitempty"`
+	OperandPhase   operatorv1alpha1.ServicePhase  `json:"operandPhase,omitempty"`
+}
+
+// NewRequestSummary returns an empty RequestSummary
+func NewRequestSummary() *RequestSummary {
+	return &RequestSummary{requests: make(map[types.NamespacedName]requestSummaryEntry)}
+}
+
+// SetRequest records the phase and the members of the OperandRequest, it replaces the ones recorded before
+func (s *RequestSummary) SetRequest(request *operatorv1alpha1.OperandRequest) {
+	if s == nil {
+		return
+	}
+	entry := requestSummaryEntry{
+		phase:   request.Status.Phase,
+		members: append([]operatorv1alpha1.MemberStatus{}, request.Status.Members...),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[types.NamespacedName{Namespace: request.Namespace, Name: request.Name}] = entry
+}
+
+// DeleteRequest removes the OperandRequest from the summary once it is deleted
+func (s *RequestSummary) DeleteRequest(key types.NamespacedName) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.requests, key)
+}
+
+// Report aggregates the recorded OperandRequests, an OperandRequest without phase is Pending
+func (s *RequestSummary) Report() *RequestSummaryReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	report := &RequestSummaryReport{
+		Total:           len(s.requests),
+		Phases:          make(map[string]int),
+		FailingOperands: []FailingOperand{},
+	}
+	for key, entry := range s.requests {
+		phase := entry.phase
+		if phase == "" {
+			phase = operatorv1alpha1.ClusterPhaseNone
+		}
+		report.Phases[string(phase)]++
+		for _, member := range entry.members {
+			if member.Phase.OperatorPhase != operatorv1alpha1.OperatorFailed && member.Phase.OperandPhase != operatorv1alpha1.ServiceFailed {
+				continue
+			}
+			report.FailingOperands = append(report.FailingOperands, FailingOperand{
+				OperandRequest: key.String(),
+				Name:           member.Name,
+				OperatorPhase:  member.Phase.OperatorPhase,
+				OperandPhase:   member.Phase.OperandPhase,
+			})
+		}
+	}
+	sort.Slice(report.FailingOperands, func(i, j int) bool {
+		if report.FailingOperands[i].OperandRequest != report.FailingOperands[j].OperandRequest {
+			return report.FailingOperands[i].OperandRequest < report.FailingOperands[j].OperandRequest
+		}
+		return report.FailingOperands[i].Name < report.FailingOperands[j].Name
+	})
+	return report
+}
+
+// ServeHTTP serves the summary of the OperandRequests in JSON
+func (s *RequestSummary) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.Report()); err != nil {
+		klog.Errorf("failed to write the summary of the OperandRequests: %v", err)
+	}
+}
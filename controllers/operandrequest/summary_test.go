@@ -0,0 +1,105 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("OperandRequest summary", func() {
+	const registryName, registryNamespace = "common-service", "ibm-common-services"
+
+	var summary *RequestSummary
+
+	request := func(name, namespace string, phase operatorv1alpha1.ClusterPhase, members ...operatorv1alpha1.MemberStatus) *operatorv1alpha1.OperandRequest {
+		request := testutil.OperandRequestObj(registryName, registryNamespace, name, namespace)
+		request.Status.Phase = phase
+		request.Status.Members = members
+		return request
+	}
+
+	member := func(name string, operatorPhase operatorv1alpha1.OperatorPhase, operandPhase operatorv1alpha1.ServicePhase) operatorv1alpha1.MemberStatus {
+		return operatorv1alpha1.MemberStatus{Name: name, Phase: operatorv1alpha1.MemberPhase{OperatorPhase: operatorPhase, OperandPhase: operandPhase}}
+	}
+
+	BeforeEach(func() {
+		summary = NewRequestSummary()
+		summary.SetRequest(request("ibm-cloudpak-name", "ibm-cloudpak-a", operatorv1alpha1.ClusterPhaseRunning,
+			member("etcd", operatorv1alpha1.OperatorRunning, operatorv1alpha1.ServiceRunning)))
+		summary.SetRequest(request("ibm-cloudpak-name", "ibm-cloudpak-b", operatorv1alpha1.ClusterPhaseFailed,
+			member("jenkins", operatorv1alpha1.OperatorRunning, operatorv1alpha1.ServiceFailed),
+			member("etcd", operatorv1alpha1.OperatorFailed, ""),
+			member("mongodb", operatorv1alpha1.OperatorInstalling, "")))
+		summary.SetRequest(request("ibm-cloudpak-name", "ibm-cloudpak-c", ""))
+	})
+
+	It("Should count the OperandRequests by phase and list the failing operands", func() {
+		report := summary.Report()
+		Expect(report.Total).Should(Equal(3))
+		Expect(report.Phases).Should(Equal(map[string]int{"Running": 1, "Failed": 1, "Pending": 1}))
+		Expect(report.FailingOperands).Should(Equal([]FailingOperand{
+			{OperandRequest: "ibm-cloudpak-b/ibm-cloudpak-name", Name: "etcd", OperatorPhase: operatorv1alpha1.OperatorFailed},
+			{OperandRequest: "ibm-cloudpak-b/ibm-cloudpak-name", Name: "jenkins", OperatorPhase: operatorv1alpha1.OperatorRunning, OperandPhase: operatorv1alpha1.ServiceFailed},
+		}))
+	})
+
+	It("Should update the summary as the OperandRequests change", func() {
+		By("Recovering the failed OperandRequest")
+		summary.SetRequest(request("ibm-cloudpak-name", "ibm-cloudpak-b", operatorv1alpha1.ClusterPhaseRunning,
+			member("jenkins", operatorv1alpha1.OperatorRunning, operatorv1alpha1.ServiceRunning)))
+		report := summary.Report()
+		Expect(report.Phases).Should(Equal(map[string]int{"Running": 2, "Pending": 1}))
+		Expect(report.FailingOperands).Should(BeEmpty())
+
+		By("Deleting an OperandRequest")
+		summary.DeleteRequest(types.NamespacedName{Name: "ibm-cloudpak-name", Namespace: "ibm-cloudpak-c"})
+		report = summary.Report()
+		Expect(report.Total).Should(Equal(2))
+		Expect(report.Phases).Should(Equal(map[string]int{"Running": 2}))
+	})
+
+	It("Should serve the summary in JSON", func() {
+		recorder := httptest.NewRecorder()
+		summary.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, RequestSummaryPath, nil))
+		Expect(recorder.Code).Should(Equal(http.StatusOK))
+
+		report := &RequestSummaryReport{}
+		Expect(json.Unmarshal(recorder.Body.Bytes(), report)).Should(Succeed())
+		Expect(report.Total).Should(Equal(3))
+		Expect(report.FailingOperands).Should(HaveLen(2))
+
+		By("Rejecting the other methods")
+		recorder = httptest.NewRecorder()
+		summary.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, RequestSummaryPath, nil))
+		Expect(recorder.Code).Should(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("Should ignore the OperandRequests without a summary", func() {
+		var summary *RequestSummary
+		summary.SetRequest(request("ibm-cloudpak-name", "ibm-cloudpak-a", operatorv1alpha1.ClusterPhaseRunning))
+		summary.DeleteRequest(types.NamespacedName{Name: "ibm-cloudpak-name", Namespace: "ibm-cloudpak-a"})
+	})
+})
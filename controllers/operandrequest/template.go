@@ -0,0 +1,302 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+)
+
+// requestTemplateData is the data the templates in the OperandConfig are rendered with
+type requestTemplateData struct {
+	Request requestTemplateMetadata
+	// TargetNamespace is the namespace the custom resources of the service are created in
+	TargetNamespace string
+	// RequestName and RequestNamespace are the shorthands of .Request.Name and .Request.Namespace
+	RequestName      string
+	RequestNamespace string
+	// Parameters are the parameters of the request in the OperandRequest
+	Parameters map[string]string
+}
+
+// templateFields are the fields the templates can refer to, the fields of .Request are in templateRequestFields
+var (
+	templateFields        = map[string]bool{"Request": true, "TargetNamespace": true, "RequestName": true, "RequestNamespace": true, "Parameters": true}
+	templateRequestFields = map[string]bool{"Name": true, "Namespace": true, "Labels": true, "Annotations": true}
+)
+
+type requestTemplateMetadata struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// renderRequestTemplates returns a copy of the service whose string values are rendered as templates with the metadata
+// of the OperandRequest, e.g. `{{ .Request.Annotations.size }}`, the parameters of the request, e.g. `{{ .Parameters.size }}`,
+// and the namespace of its custom resources, e.g. `{{ .TargetNamespace }}`,
+// together with the annotations referred by the templates but missing from the OperandRequest.
+// The service is not rendered when any annotation is missing, and it fails when any parameter is missing.
+// An annotation or a parameter referred only by the condition of `if` or `with` is optional.
+func renderRequestTemplates(requestInstance *operatorv1alpha1.OperandRequest, parameters map[string]string, service *operatorv1alpha1.ConfigService, targetNamespace string) (*operatorv1alpha1.ConfigService, []string, error) {
+	data := requestTemplateData{
+		Request: requestTemplateMetadata{
+			Name:        requestInstance.Name,
+			Namespace:   requestInstance.Namespace,
+			Labels:      requestInstance.Labels,
+			Annotations: requestInstance.Annotations,
+		},
+		TargetNamespace:  targetNamespace,
+		RequestName:      requestInstance.Name,
+		RequestNamespace: requestInstance.Namespace,
+		Parameters:       parameters,
+	}
+	renderedService := service.DeepCopy()
+	missing := make(map[string]bool)
+	missingParameters := make(map[string]bool)
+	specs := []map[string]runtime.RawExtension{renderedService.Spec}
+	for _, override := range renderedService.Overrides {
+		specs = append(specs, override.Spec)
+	}
+	for _, spec := range specs {
+		for cr, value := range spec {
+			if !bytes.Contains(value.Raw, []byte("{{")) {
+				continue
+			}
+			var specMap interface{}
+			if err := json.Unmarshal(value.Raw, &specMap); err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to unmarshal the spec of %s in the service %s", cr, service.Name)
+			}
+			renderedSpec, err := renderTemplateValue(specMap, data, missing, missingParameters)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to render the spec of %s in the service %s", cr, service.Name)
+			}
+			renderedRaw, err := json.Marshal(renderedSpec)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to marshal the spec of %s in the service %s", cr, service.Name)
+			}
+			spec[cr] = runtime.RawExtension{Raw: renderedRaw}
+		}
+	}
+	if len(missingParameters) != 0 {
+		var names []string
+		for name := range missingParameters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, nil, errors.Errorf("missing the parameters %s of the request referred by the service %s", strings.Join(names, ", "), service.Name)
+	}
+	if len(missing) != 0 {
+		var annotations []string
+		for annotation := range missing {
+			annotations = append(annotations, annotation)
+		}
+		sort.Strings(annotations)
+		return nil, annotations, nil
+	}
+	return renderedService, nil, nil
+}
+
+// renderTemplateValue renders the string values in the value. A string which is a single action is converted to
+// a number or a boolean when it is rendered as one, so `{{ .Request.Annotations.size }}` can set an integer field.
+func renderTemplateValue(value interface{}, data requestTemplateData, missing, missingParameters map[string]bool) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			rendered, err := renderTemplateValue(item, data, missing, missingParameters)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = rendered
+		}
+	case []interface{}:
+		for i, item := range v {
+			rendered, err := renderTemplateValue(item, data, missing, missingParameters)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = rendered
+		}
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+		tmpl, err := template.New("value").Option("missingkey=zero").Parse(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the template %q", v)
+		}
+		// An unknown field would be rendered as is by the operator, or fail only once the annotations are added
+		if field := unknownTemplateField(tmpl.Tree.Root); field != "" {
+			return nil, errors.Errorf("unknown field %s in the template %q", field, v)
+		}
+		required, requiredParameters := make(map[string]bool), make(map[string]bool)
+		requiredKeys(tmpl.Tree.Root, required, requiredParameters)
+		var absent bool
+		for annotation := range required {
+			if _, ok := data.Request.Annotations[annotation]; !ok {
+				missing[annotation] = true
+				absent = true
+			}
+		}
+		for parameter := range requiredParameters {
+			if _, ok := data.Parameters[parameter]; !ok {
+				missingParameters[parameter] = true
+				absent = true
+			}
+		}
+		if absent {
+			return v, nil
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, errors.Wrapf(err, "failed to render the template %q", v)
+		}
+		rendered := buf.String()
+		if nodes := tmpl.Tree.Root.Nodes; len(nodes) == 1 && nodes[0].Type() == parse.NodeAction {
+			var scalar interface{}
+			if err := json.Unmarshal([]byte(rendered), &scalar); err == nil {
+				switch scalar.(type) {
+				case float64, bool:
+					return scalar, nil
+				}
+			}
+		}
+		return rendered, nil
+	}
+	return value, nil
+}
+
+// requiredKeys collects the annotations of the OperandRequest and the parameters of the request printed by the template,
+// the conditions of `if`, `with` and `range` are skipped
+func requiredKeys(node parse.Node, annotations, parameters map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			requiredKeys(child, annotations, parameters)
+		}
+	case *parse.ActionNode:
+		requiredKeys(n.Pipe, annotations, parameters)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			requiredKeys(cmd, annotations, parameters)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			requiredKeys(arg, annotations, parameters)
+		}
+	case *parse.IfNode:
+		requiredKeys(n.List, annotations, parameters)
+		requiredKeys(n.ElseList, annotations, parameters)
+	case *parse.WithNode:
+		requiredKeys(n.List, annotations, parameters)
+		requiredKeys(n.ElseList, annotations, parameters)
+	case *parse.RangeNode:
+		requiredKeys(n.List, annotations, parameters)
+		requiredKeys(n.ElseList, annotations, parameters)
+	case *parse.FieldNode:
+		if len(n.Ident) >= 3 && n.Ident[0] == "Request" && n.Ident[1] == "Annotations" {
+			annotations[n.Ident[2]] = true
+		}
+		if len(n.Ident) >= 2 && n.Ident[0] == "Parameters" {
+			parameters[n.Ident[1]] = true
+		}
+	}
+}
+
+// unknownTemplateField returns the first field printed or tested by the template which isn't in the template data.
+// The fields in the body of `with` and `range` are relative to their own value, and they are not checked.
+func unknownTemplateField(node parse.Node) string {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return ""
+		}
+		for _, child := range n.Nodes {
+			if field := unknownTemplateField(child); field != "" {
+				return field
+			}
+		}
+	case *parse.ActionNode:
+		return unknownTemplateField(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return ""
+		}
+		for _, cmd := range n.Cmds {
+			if field := unknownTemplateField(cmd); field != "" {
+				return field
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if field := unknownTemplateField(arg); field != "" {
+				return field
+			}
+		}
+	case *parse.IfNode:
+		for _, child := range []parse.Node{n.Pipe, n.List, n.ElseList} {
+			if field := unknownTemplateField(child); field != "" {
+				return field
+			}
+		}
+	case *parse.WithNode:
+		for _, child := range []parse.Node{n.Pipe, n.ElseList} {
+			if field := unknownTemplateField(child); field != "" {
+				return field
+			}
+		}
+	case *parse.RangeNode:
+		for _, child := range []parse.Node{n.Pipe, n.ElseList} {
+			if field := unknownTemplateField(child); field != "" {
+				return field
+			}
+		}
+	case *parse.FieldNode:
+		return checkTemplateField(n.Ident)
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			return checkTemplateField(n.Ident[1:])
+		}
+	}
+	return ""
+}
+
+// checkTemplateField returns the field if it isn't in the template data
+func checkTemplateField(ident []string) string {
+	if !templateFields[ident[0]] {
+		return "." + ident[0]
+	}
+	if ident[0] == "Request" && len(ident) > 1 && !templateRequestFields[ident[1]] {
+		return ".Request." + ident[1]
+	}
+	return ""
+}
@@ -0,0 +1,237 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Rendering the OperandConfig with the OperandRequest", func() {
+	const (
+		registryName      = "common-service"
+		registryNamespace = "ibm-common-services"
+		operatorNamespace = "ibm-operators"
+		requestNamespace  = "ibm-cloudpak"
+	)
+
+	newRequest := func(annotations map[string]string) *operatorv1alpha1.OperandRequest {
+		request := testutil.OperandRequestObj(registryName, registryNamespace, "ibm-cloudpak-name", requestNamespace)
+		request.Annotations = annotations
+		request.Spec.Requests[0].Operands = request.Spec.Requests[0].Operands[:1]
+		return request
+	}
+
+	DescribeTable("Should render the spec with the metadata of the OperandRequest",
+		func(spec string, annotations map[string]string, expectedSpec string, expectedMissing []string) {
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(spec)},
+				},
+			}
+			rendered, missing, err := renderRequestTemplates(newRequest(annotations), nil, service, operatorNamespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missing).Should(Equal(expectedMissing))
+			if expectedMissing != nil {
+				Expect(rendered).Should(BeNil())
+				return
+			}
+			Expect(rendered.Spec["etcdCluster"].Raw).Should(MatchJSON(expectedSpec))
+			// The service itself isn't changed
+			Expect(service.Spec["etcdCluster"].Raw).Should(MatchJSON(spec))
+		},
+		Entry("Rendering a number",
+			`{"size": "{{ .Request.Annotations.size }}"}`, map[string]string{"size": "5"},
+			`{"size": 5}`, nil),
+		Entry("Rendering a boolean",
+			`{"tls": {"enabled": "{{ .Request.Annotations.tls }}"}}`, map[string]string{"tls": "true"},
+			`{"tls": {"enabled": true}}`, nil),
+		Entry("Rendering a string in a list",
+			`{"labels": ["tier-{{ .Request.Annotations.tier }}", "{{ .Request.Namespace }}"]}`, map[string]string{"tier": "1"},
+			`{"labels": ["tier-1", "ibm-cloudpak"]}`, nil),
+		Entry("Rendering an optional annotation",
+			`{"version": "{{ with .Request.Annotations.version }}{{ . }}{{ else }}3.2.13{{ end }}"}`, nil,
+			`{"version": "3.2.13"}`, nil),
+		Entry("Rendering the target namespace and the shorthands of the OperandRequest",
+			`{"namespace": "{{ .TargetNamespace }}", "owner": "{{ .RequestNamespace }}/{{ .RequestName }}"}`, nil,
+			`{"namespace": "ibm-operators", "owner": "ibm-cloudpak/ibm-cloudpak-name"}`, nil),
+		Entry("Keeping the values without template",
+			`{"size": 3, "version": "3.2.13"}`, nil,
+			`{"size": 3, "version": "3.2.13"}`, nil),
+		Entry("Reporting the missing annotations",
+			`{"size": "{{ .Request.Annotations.size }}", "storage": {"class": "{{ .Request.Annotations.storageClass }}"}}`, map[string]string{"tier": "1"},
+			``, []string{"size", "storageClass"}),
+	)
+
+	DescribeTable("Should render the spec with the parameters of the request",
+		func(spec string, parameters map[string]string, expectedSpec string) {
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(spec)},
+				},
+			}
+			rendered, missing, err := renderRequestTemplates(newRequest(nil), parameters, service, operatorNamespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missing).Should(BeEmpty())
+			Expect(rendered.Spec["etcdCluster"].Raw).Should(MatchJSON(expectedSpec))
+		},
+		Entry("Substituting the parameters",
+			`{"size": "{{ .Parameters.size }}", "storage": {"class": "{{ .Parameters.storageClass }}"}}`, map[string]string{"size": "5", "storageClass": "fast"},
+			`{"size": 5, "storage": {"class": "fast"}}`),
+		Entry("Ignoring the unreferenced parameters",
+			`{"size": "{{ .Parameters.size }}"}`, map[string]string{"size": "5", "tier": "1"},
+			`{"size": 5}`),
+		Entry("Defaulting a missing parameter",
+			`{"size": "{{ with .Parameters.size }}{{ . }}{{ else }}3{{ end }}"}`, nil,
+			`{"size": "3"}`),
+		Entry("Overriding the default with a parameter",
+			`{"size": "{{ with .Parameters.size }}{{ . }}{{ else }}3{{ end }}"}`, map[string]string{"size": "5"},
+			`{"size": "5"}`),
+	)
+
+	It("Should fail to render the missing parameters", func() {
+		service := &operatorv1alpha1.ConfigService{
+			Name: "etcd",
+			Spec: map[string]runtime.RawExtension{
+				"etcdCluster": {Raw: []byte(`{"size": "{{ .Parameters.size }}", "version": "{{ .Parameters.version }}"}`)},
+			},
+		}
+		_, _, err := renderRequestTemplates(newRequest(nil), map[string]string{"tier": "1"}, service, operatorNamespace)
+		Expect(err).Should(MatchError(ContainSubstring("missing the parameters size, version")))
+	})
+
+	It("Should fail to render an invalid template", func() {
+		service := &operatorv1alpha1.ConfigService{
+			Name: "etcd",
+			Spec: map[string]runtime.RawExtension{
+				"etcdCluster": {Raw: []byte(`{"size": "{{ .Request.Annotations.size "}`)},
+			},
+		}
+		_, _, err := renderRequestTemplates(newRequest(map[string]string{"size": "5"}), nil, service, operatorNamespace)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring("etcdCluster"))
+	})
+
+	DescribeTable("Should fail to render the unknown fields",
+		func(spec string, field string) {
+			service := &operatorv1alpha1.ConfigService{
+				Name: "etcd",
+				Spec: map[string]runtime.RawExtension{
+					"etcdCluster": {Raw: []byte(spec)},
+				},
+			}
+			_, _, err := renderRequestTemplates(newRequest(nil), nil, service, operatorNamespace)
+			Expect(err).Should(MatchError(ContainSubstring("unknown field " + field)))
+		},
+		Entry("Misspelling the target namespace", `{"namespace": "{{ .TargetNamespaces }}"}`, ".TargetNamespaces"),
+		Entry("Misspelling a field of the OperandRequest", `{"name": "{{ .Request.Names }}"}`, ".Request.Names"),
+		Entry("Referring to an unknown field with a missing annotation", `{"size": "{{ .Request.Annotations.size }}-{{ .Cluster }}"}`, ".Cluster"),
+		Entry("Referring to an unknown field in a condition", `{"size": "{{ if .Size }}5{{ end }}"}`, ".Size"),
+	)
+
+	newReconciler := func(config *operatorv1alpha1.OperandConfig) (*Reconciler, client.Client, *record.FakeRecorder) {
+		sub, csv := runningEtcdOperator(operatorNamespace)
+		r, c := newEtcdOperandReconciler(
+			testutil.NamespaceObj(requestNamespace), testutil.OperandRegistryObj(registryName, registryNamespace, operatorNamespace), config, sub, csv,
+		)
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+		return r, c, recorder
+	}
+
+	getEtcdCluster := func(c client.Client) *unstructured.Unstructured {
+		etcdCluster := &unstructured.Unstructured{}
+		etcdCluster.SetAPIVersion("etcd.database.coreos.com/v1beta2")
+		etcdCluster.SetKind("EtcdCluster")
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "example", Namespace: operatorNamespace}, etcdCluster)).Should(Succeed())
+		return etcdCluster
+	}
+
+	It("Should create the custom resource from the annotations of the OperandRequest", func() {
+		ctx := context.Background()
+		config := testutil.OperandConfigObj(registryName, registryNamespace)
+		config.Spec.Services[0].Spec["etcdCluster"] = runtime.RawExtension{Raw: []byte(`{"size": "{{ .Request.Annotations.size }}"}`)}
+		r, c, recorder := newReconciler(config)
+
+		By("Recording the missing annotation")
+		request := newRequest(nil)
+		Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+		Expect(request.Status.Members[0].Phase.OperandPhase).Should(Equal(operatorv1alpha1.ServiceFailed))
+		Expect(recorder.Events).Should(Receive(ContainSubstring("MissingRequestAnnotation")))
+		var missing []operatorv1alpha1.Condition
+		for _, c := range request.Status.Conditions {
+			if c.Type == operatorv1alpha1.ConditionMissingRequestAnnotation {
+				missing = append(missing, c)
+			}
+		}
+		Expect(missing).Should(HaveLen(1))
+		Expect(missing[0].Status).Should(Equal(corev1.ConditionTrue))
+		Expect(missing[0].Message).Should(Equal("Missing the annotations size of the OperandRequest for etcd"))
+
+		By("Creating the custom resource once the annotation is added")
+		request.Annotations = map[string]string{"size": "5"}
+		request.SetMemberStatus("etcd", "", operatorv1alpha1.ServiceRunning, &sync.Mutex{})
+		Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+		Expect(request.Status.Members[0].Phase.OperandPhase).Should(Equal(operatorv1alpha1.ServiceRunning))
+		for _, c := range request.Status.Conditions {
+			Expect(c.Type).ShouldNot(Equal(operatorv1alpha1.ConditionMissingRequestAnnotation))
+		}
+
+		etcdCluster := getEtcdCluster(c)
+		size, found, err := unstructured.NestedInt64(etcdCluster.Object, "spec", "size")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).Should(BeTrue())
+		Expect(size).Should(Equal(int64(5)))
+	})
+
+	It("Should inject the target namespace into the merged custom resource", func() {
+		ctx := context.Background()
+		config := testutil.OperandConfigObj(registryName, registryNamespace)
+		config.Spec.Services[0].Spec["etcdCluster"] = runtime.RawExtension{Raw: []byte(`{"size": 3, "backup": {"namespace": "{{ .TargetNamespace }}", "owner": "{{ .RequestNamespace }}/{{ .RequestName }}"}}`)}
+		r, c, _ := newReconciler(config)
+
+		request := newRequest(nil)
+		Expect(r.reconcileOperand(ctx, request, nil).Errors).Should(BeEmpty())
+		Expect(request.Status.Members[0].Phase.OperandPhase).Should(Equal(operatorv1alpha1.ServiceRunning))
+
+		etcdCluster := getEtcdCluster(c)
+		namespace, _, _ := unstructured.NestedString(etcdCluster.Object, "spec", "backup", "namespace")
+		Expect(namespace).Should(Equal(operatorNamespace))
+		owner, _, _ := unstructured.NestedString(etcdCluster.Object, "spec", "backup", "owner")
+		Expect(owner).Should(Equal(requestNamespace + "/ibm-cloudpak-name"))
+		// The values of the alm-examples are merged under the config
+		size, _, _ := unstructured.NestedInt64(etcdCluster.Object, "spec", "size")
+		Expect(size).Should(Equal(int64(3)))
+		version, _, _ := unstructured.NestedString(etcdCluster.Object, "spec", "version")
+		Expect(version).ShouldNot(BeEmpty())
+	})
+})
@@ -0,0 +1,121 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+)
+
+// reconcileValidationJob runs the post-install validation Job of a Running operand and records its result
+// in the member status. The finished Job is deleted, and the operand isn't validated again until it is
+// Running again.
+func (r *Reconciler) reconcileValidationJob(ctx context.Context, requestInstance *operatorv1alpha1.OperandRequest, operandName, namespace string, template *batchv1beta1.JobTemplateSpec) error {
+	phase := requestInstance.GetMemberValidationPhase(operandName, &r.Mutex)
+	if phase == operatorv1alpha1.ValidationSucceeded || phase == operatorv1alpha1.ValidationFailed {
+		return nil
+	}
+
+	key := types.NamespacedName{Name: validationJobName(requestInstance, operandName), Namespace: namespace}
+	job := &batchv1.Job{}
+	if err := r.Client.Get(ctx, key, job); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get the validation Job %s", key.String())
+		}
+		klog.V(2).Infof("Creating the validation Job %s of the operand %s", key.String(), operandName)
+		if err := r.Client.Create(ctx, newValidationJob(key, template)); err != nil {
+			return errors.Wrapf(err, "failed to create the validation Job %s", key.String())
+		}
+		requestInstance.SetMemberValidationPhase(operandName, operatorv1alpha1.ValidationRunning, &r.Mutex)
+		return nil
+	}
+
+	phase, message := validationJobResult(job)
+	if phase == operatorv1alpha1.ValidationRunning {
+		requestInstance.SetMemberValidationPhase(operandName, phase, &r.Mutex)
+		return nil
+	}
+	if err := r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete the validation Job %s", key.String())
+	}
+	if phase == operatorv1alpha1.ValidationFailed {
+		klog.Errorf("The validation Job %s of the operand %s failed: %s", key.String(), operandName, message)
+		r.Recorder.Eventf(requestInstance, corev1.EventTypeWarning, "ValidationFailed", "The validation Job %s of the operand %s failed: %s", key.String(), operandName, message)
+	}
+	requestInstance.SetMemberValidationPhase(operandName, phase, &r.Mutex)
+	return nil
+}
+
+// newValidationJob returns the Job created from the template of the post-install validation
+func newValidationJob(key types.NamespacedName, template *batchv1beta1.JobTemplateSpec) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: *template.ObjectMeta.DeepCopy(),
+		Spec:       *template.Spec.DeepCopy(),
+	}
+	job.Name, job.Namespace = key.Name, key.Namespace
+	if job.Labels == nil {
+		job.Labels = make(map[string]string)
+	}
+	job.Labels[constant.OpreqLabel] = "true"
+	// The Jobs don't accept the default restart policy Always of the pods
+	if job.Spec.Template.Spec.RestartPolicy == "" {
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+	return job
+}
+
+// validationJobResult returns the validation phase of the Job and the message of its finished condition
+func validationJobResult(job *batchv1.Job) (operatorv1alpha1.ValidationPhase, string) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return operatorv1alpha1.ValidationSucceeded, c.Message
+		case batchv1.JobFailed:
+			return operatorv1alpha1.ValidationFailed, c.Message
+		}
+	}
+	return operatorv1alpha1.ValidationRunning, ""
+}
+
+// validationJobName returns the name of the validation Job of the operand requested by the OperandRequest,
+// it is short enough for the job-name label of the pods.
+func validationJobName(requestInstance *operatorv1alpha1.OperandRequest, operandName string) string {
+	hash := sha256.Sum256([]byte(requestInstance.Namespace + "/" + requestInstance.Name + "/" + operandName))
+	prefix := operandName
+	if len(prefix) > 30 {
+		prefix = strings.TrimSuffix(prefix[:30], "-")
+	}
+	return prefix + "-validation-" + hex.EncodeToString(hash[:5])
+}
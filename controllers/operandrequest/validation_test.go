@@ -0,0 +1,144 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+)
+
+var _ = Describe("Post-install validation", func() {
+	const operandNamespace = "ibm-operators"
+
+	var (
+		ctx      context.Context
+		c        client.Client
+		r        *Reconciler
+		recorder *record.FakeRecorder
+		request  *operatorv1alpha1.OperandRequest
+		template *batchv1beta1.JobTemplateSpec
+		jobKey   types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).Should(Succeed())
+		Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+		c = fake.NewClientBuilder().WithScheme(s).Build()
+		recorder = record.NewFakeRecorder(10)
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client:   c,
+				Reader:   c,
+				Recorder: recorder,
+			},
+		}
+
+		request = &operatorv1alpha1.OperandRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "ibm-cloudpak-name", Namespace: "ibm-cloudpak"},
+		}
+		request.SetMemberStatus("etcd", operatorv1alpha1.OperatorRunning, operatorv1alpha1.ServiceRunning, &sync.Mutex{})
+		template = &batchv1beta1.JobTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "etcd-validation"}},
+			Spec: batchv1.JobSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "validate", Image: "quay.io/opencloudio/etcd-check"}},
+					},
+				},
+			},
+		}
+		jobKey = types.NamespacedName{Name: validationJobName(request, "etcd"), Namespace: operandNamespace}
+	})
+
+	finishJob := func(conditionType batchv1.JobConditionType) {
+		job := &batchv1.Job{}
+		Expect(c.Get(ctx, jobKey, job)).Should(Succeed())
+		job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{Type: conditionType, Status: corev1.ConditionTrue, Message: "finished"})
+		Expect(c.Status().Update(ctx, job)).Should(Succeed())
+	}
+
+	It("Should validate the operand once the validation Job succeeds", func() {
+		By("Creating the validation Job")
+		Expect(r.reconcileValidationJob(ctx, request, "etcd", operandNamespace, template)).Should(Succeed())
+		job := &batchv1.Job{}
+		Expect(c.Get(ctx, jobKey, job)).Should(Succeed())
+		Expect(job.Labels).Should(HaveKeyWithValue("app", "etcd-validation"))
+		Expect(job.Labels).Should(HaveKeyWithValue(constant.OpreqLabel, "true"))
+		Expect(job.Spec.Template.Spec.RestartPolicy).Should(Equal(corev1.RestartPolicyNever))
+		Expect(request.Status.Members[0].Phase.ValidationPhase).Should(Equal(operatorv1alpha1.ValidationRunning))
+		request.UpdateClusterPhase()
+		Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseInstalling))
+
+		By("Waiting for the validation Job")
+		Expect(r.reconcileValidationJob(ctx, request, "etcd", operandNamespace, template)).Should(Succeed())
+		Expect(request.Status.Members[0].Phase.ValidationPhase).Should(Equal(operatorv1alpha1.ValidationRunning))
+
+		By("Recording the success and deleting the validation Job")
+		finishJob(batchv1.JobComplete)
+		Expect(r.reconcileValidationJob(ctx, request, "etcd", operandNamespace, template)).Should(Succeed())
+		Expect(request.Status.Members[0].Phase.ValidationPhase).Should(Equal(operatorv1alpha1.ValidationSucceeded))
+		Expect(apierrors.IsNotFound(c.Get(ctx, jobKey, &batchv1.Job{}))).Should(BeTrue())
+		request.UpdateClusterPhase()
+		Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseRunning))
+
+		By("Not running the validation Job again")
+		Expect(r.reconcileValidationJob(ctx, request, "etcd", operandNamespace, template)).Should(Succeed())
+		Expect(apierrors.IsNotFound(c.Get(ctx, jobKey, &batchv1.Job{}))).Should(BeTrue())
+	})
+
+	It("Should fail the validation once the validation Job fails", func() {
+		Expect(r.reconcileValidationJob(ctx, request, "etcd", operandNamespace, template)).Should(Succeed())
+		finishJob(batchv1.JobFailed)
+
+		Expect(r.reconcileValidationJob(ctx, request, "etcd", operandNamespace, template)).Should(Succeed())
+		Expect(request.Status.Members[0].Phase.ValidationPhase).Should(Equal(operatorv1alpha1.ValidationFailed))
+		Expect(apierrors.IsNotFound(c.Get(ctx, jobKey, &batchv1.Job{}))).Should(BeTrue())
+		Expect(recorder.Events).Should(Receive(ContainSubstring("ValidationFailed")))
+		request.UpdateClusterPhase()
+		Expect(request.Status.Phase).Should(Equal(operatorv1alpha1.ClusterPhaseFailed))
+
+		By("Validating the operand again once it is Running again")
+		request.SetMemberStatus("etcd", "", operatorv1alpha1.ServicePendingDeletion, &sync.Mutex{})
+		Expect(request.Status.Members[0].Phase.ValidationPhase).Should(Equal(operatorv1alpha1.ValidationNone))
+	})
+
+	It("Should keep the names of the validation Jobs short", func() {
+		name := validationJobName(request, "ibm-a-very-long-operand-name-exceeding-the-limit")
+		Expect(len(name)).Should(BeNumerically("<=", 63))
+		Expect(name).ShouldNot(Equal(validationJobName(request, "etcd")))
+	})
+})
@@ -0,0 +1,82 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+	"sort"
+
+	"github.com/blang/semver/v4"
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/pkg/errors"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
+)
+
+// VersionReport maps the packages of the operators installed by ODLM to their installed versions,
+// and the versions to the sorted namespaces they are installed in
+type VersionReport map[string]map[string][]string
+
+// ReportOperatorVersions reports the installed versions of the operators managed by ODLM across the cluster.
+// The version of an operator is the version of the ClusterServiceVersion of its Subscription, or the name
+// of the ClusterServiceVersion when it has no version. The operators still being installed are left out.
+// It only reads from the cluster.
+func (r *Reconciler) ReportOperatorVersions(ctx context.Context) (VersionReport, error) {
+	subList := &olmv1alpha1.SubscriptionList{}
+	if err := r.Reader.List(ctx, subList, client.HasLabels{constant.OpreqLabel}); err != nil {
+		return nil, errors.Wrap(err, "failed to list Subscriptions")
+	}
+
+	report := make(VersionReport)
+	for i := range subList.Items {
+		sub := &subList.Items[i]
+		if sub.Spec == nil {
+			continue
+		}
+		csv, err := r.GetClusterServiceVersion(ctx, sub)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the ClusterServiceVersion of the Subscription %s/%s", sub.Namespace, sub.Name)
+		}
+		if csv == nil {
+			klog.V(2).Infof("Subscription %s/%s has no installed ClusterServiceVersion, it is left out of the report", sub.Namespace, sub.Name)
+			continue
+		}
+		version := csv.Name
+		if !csv.Spec.Version.Equals(semver.Version{}) {
+			version = csv.Spec.Version.String()
+		}
+		if report[sub.Spec.Package] == nil {
+			report[sub.Spec.Package] = make(map[string][]string)
+		}
+		report[sub.Spec.Package][version] = append(report[sub.Spec.Package][version], sub.Namespace)
+	}
+
+	for _, versions := range report {
+		for _, namespaces := range versions {
+			sort.Strings(namespaces)
+		}
+	}
+	return report, nil
+}
+
+// Manifest renders the report as YAML, sorted by package and version
+func (v VersionReport) Manifest() ([]byte, error) {
+	return yaml.Marshal(v)
+}
@@ -0,0 +1,101 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operandrequest
+
+import (
+	"context"
+
+	"github.com/blang/semver/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/operator-framework/api/pkg/lib/version"
+	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Reporting the operator versions", func() {
+	var (
+		ctx context.Context
+		r   *Reconciler
+	)
+
+	// installed returns the Subscription of the package in the namespace, with its ClusterServiceVersion
+	installed := func(name, namespace, csvVersion string) []client.Object {
+		sub := testutil.Subscription(name, namespace)
+		sub.Status = testutil.SubscriptionStatus(name, namespace, csvVersion)
+		csv := testutil.ClusterServiceVersion(sub.Status.CurrentCSV, namespace, "[]")
+		csv.Spec.Version = version.OperatorVersion{Version: semver.MustParse(csvVersion)}
+		return []client.Object{sub, csv}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		s := runtime.NewScheme()
+		Expect(operatorv1alpha1.AddToScheme(s)).Should(Succeed())
+		Expect(olmv1alpha1.AddToScheme(s)).Should(Succeed())
+
+		var objects []client.Object
+		objects = append(objects, installed("etcd", "tenant-a", "0.0.1")...)
+		objects = append(objects, installed("etcd", "tenant-b", "0.0.2")...)
+		objects = append(objects, installed("etcd", "tenant-c", "0.0.1")...)
+		// The ClusterServiceVersion of jenkins has no version
+		jenkins := installed("jenkins", "tenant-a", "0.0.2")
+		jenkins[1].(*olmv1alpha1.ClusterServiceVersion).Spec.Version = version.OperatorVersion{}
+		objects = append(objects, jenkins...)
+		// The mongodb operator is still being installed
+		objects = append(objects, testutil.Subscription("mongodb", "tenant-a"))
+		// The Subscription not managed by ODLM is left out
+		unmanaged := installed("etcd", "tenant-d", "0.0.3")
+		unmanaged[0].SetLabels(nil)
+		objects = append(objects, unmanaged...)
+
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(objects...).Build()
+		r = &Reconciler{
+			ODLMOperator: &deploy.ODLMOperator{
+				Client: c,
+				Reader: c,
+			},
+		}
+	})
+
+	It("Should report the namespaces of each installed version of the packages", func() {
+		report, err := r.ReportOperatorVersions(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report).Should(Equal(VersionReport{
+			"etcd": {
+				"0.0.1": {"tenant-a", "tenant-c"},
+				"0.0.2": {"tenant-b"},
+			},
+			"jenkins": {
+				"jenkins-csv.v0.0.2": {"tenant-a"},
+			},
+		}))
+
+		manifest, err := report.Manifest()
+		Expect(err).NotTo(HaveOccurred())
+		parsed := VersionReport{}
+		Expect(yaml.Unmarshal(manifest, &parsed)).Should(Succeed())
+		Expect(parsed).Should(Equal(report))
+	})
+})
@@ -44,6 +44,10 @@ type ODLMOperator struct {
 	*rest.Config
 	Recorder record.EventRecorder
 	Scheme   *runtime.Scheme
+	// DefaultTargetNamespace is the namespace the custom resources of the operators installed in
+	// AllNamespaces mode are created in, unless the service of the OperandConfig sets its targetNamespace.
+	// The namespace of the operator is used when it is empty.
+	DefaultTargetNamespace string
 }
 
 // NewODLMOperator is the method to initialize an Operator struct
@@ -273,13 +277,9 @@ func (m *ODLMOperator) ListOperandRequestsByConfig(ctx context.Context, key type
 	if err = m.Client.List(ctx, requestCandidates); err != nil {
 		return
 	}
-	// Set default value for all the OperandRequest
 	for _, item := range requestCandidates.Items {
 		for _, r := range item.Spec.Requests {
-			if r.RegistryNamespace == "" {
-				r.RegistryNamespace = item.GetNamespace()
-			}
-			if r.Registry == key.Name && r.RegistryNamespace == key.Namespace {
+			if item.GetConfigKey(r) == key {
 				requestList = append(requestList, item)
 			}
 		}
@@ -287,7 +287,8 @@ func (m *ODLMOperator) ListOperandRequestsByConfig(ctx context.Context, key type
 	return
 }
 
-// GetSubscription gets Subscription by name and package name
+// GetSubscription gets Subscription by name and package name in the namespace of the operator.
+// A Subscription with the name but another package isn't the Subscription of the operator.
 func (m *ODLMOperator) GetSubscription(ctx context.Context, name, namespace, packageName string) (*olmv1alpha1.Subscription, error) {
 	klog.V(3).Infof("Fetch Subscription: %s/%s", namespace, name)
 	sub := &olmv1alpha1.Subscription{}
@@ -297,7 +298,10 @@ func (m *ODLMOperator) GetSubscription(ctx context.Context, name, namespace, pac
 	}
 	err := m.Client.Get(ctx, subKey, sub)
 	if err == nil {
-		return sub, nil
+		if subscriptionPackage(sub) == packageName {
+			return sub, nil
+		}
+		klog.Warningf("Subscription %s/%s subscribes to the package %s instead of %s", namespace, name, subscriptionPackage(sub), packageName)
 	} else if !apierrors.IsNotFound(err) {
 		return nil, err
 	}
@@ -310,13 +314,16 @@ func (m *ODLMOperator) GetSubscription(ctx context.Context, name, namespace, pac
 	}
 
 	var subCandidates []olmv1alpha1.Subscription
-	for _, sub := range subList.Items {
-		if sub.Spec.Package == packageName {
-			subCandidates = append(subCandidates, sub)
+	for i := range subList.Items {
+		if subscriptionPackage(&subList.Items[i]) == packageName {
+			subCandidates = append(subCandidates, subList.Items[i])
 		}
 	}
 
 	if len(subCandidates) == 0 {
+		if err == nil {
+			return nil, fmt.Errorf("the Subscription %s/%s subscribes to the package %s instead of %s", namespace, name, subscriptionPackage(sub), packageName)
+		}
 		return nil, err
 	}
 
@@ -327,6 +334,14 @@ func (m *ODLMOperator) GetSubscription(ctx context.Context, name, namespace, pac
 	return &subCandidates[0], nil
 }
 
+// subscriptionPackage returns the package the Subscription subscribes to
+func subscriptionPackage(sub *olmv1alpha1.Subscription) string {
+	if sub.Spec == nil {
+		return ""
+	}
+	return sub.Spec.Package
+}
+
 // GetClusterServiceVersion gets the ClusterServiceVersion from the subscription
 func (m *ODLMOperator) GetClusterServiceVersion(ctx context.Context, sub *olmv1alpha1.Subscription) (*olmv1alpha1.ClusterServiceVersion, error) {
 	// Check the ClusterServiceVersion status in the subscription
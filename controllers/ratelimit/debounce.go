@@ -0,0 +1,80 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ratelimit
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// DebounceHandler enqueues the object of the update events after a window, so that
+// the updates received within the window are coalesced into a single reconcile.
+// The other events are enqueued immediately.
+type DebounceHandler struct {
+	// Window is how long the updates of an object are coalesced
+	Window time.Duration
+}
+
+var _ handler.EventHandler = &DebounceHandler{}
+
+// NewDebounceHandler returns a DebounceHandler coalescing the updates received within the window
+func NewDebounceHandler(window time.Duration) *DebounceHandler {
+	return &DebounceHandler{Window: window}
+}
+
+// Create implements handler.EventHandler
+func (h *DebounceHandler) Create(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+	if e.Object == nil {
+		return
+	}
+	q.Add(requestFor(e.Object.GetNamespace(), e.Object.GetName()))
+}
+
+// Update implements handler.EventHandler
+func (h *DebounceHandler) Update(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	if e.ObjectNew == nil {
+		return
+	}
+	// The delaying queue keeps the earliest time of an object waiting to be added,
+	// the updates within the window don't delay the reconcile any further
+	q.AddAfter(requestFor(e.ObjectNew.GetNamespace(), e.ObjectNew.GetName()), h.Window)
+}
+
+// Delete implements handler.EventHandler
+func (h *DebounceHandler) Delete(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	if e.Object == nil {
+		return
+	}
+	q.Add(requestFor(e.Object.GetNamespace(), e.Object.GetName()))
+}
+
+// Generic implements handler.EventHandler
+func (h *DebounceHandler) Generic(e event.GenericEvent, q workqueue.RateLimitingInterface) {
+	if e.Object == nil {
+		return
+	}
+	q.Add(requestFor(e.Object.GetNamespace(), e.Object.GetName()))
+}
+
+func requestFor(namespace, name string) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+}
@@ -0,0 +1,90 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ratelimit
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+)
+
+var _ = Describe("Debounce handler", func() {
+	const window = 200 * time.Millisecond
+
+	var (
+		handler *DebounceHandler
+		queue   workqueue.RateLimitingInterface
+	)
+
+	newRequest := func(name string) *operatorv1alpha1.OperandRequest {
+		return &operatorv1alpha1.OperandRequest{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ibm-cloudpak"}}
+	}
+
+	// reconciles drains the queue like the controller workers, and returns the number of reconciles
+	reconciles := func() int {
+		count := 0
+		for queue.Len() > 0 {
+			item, _ := queue.Get()
+			queue.Done(item)
+			queue.Forget(item)
+			count++
+		}
+		return count
+	}
+
+	BeforeEach(func() {
+		handler = NewDebounceHandler(window)
+		queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	})
+
+	AfterEach(func() {
+		queue.ShutDown()
+	})
+
+	It("Should coalesce the rapid updates of an object into one reconcile", func() {
+		const updates = 10
+		request := newRequest("ibm-cloudpak-name")
+		for i := 0; i < updates; i++ {
+			handler.Update(event.UpdateEvent{ObjectOld: request, ObjectNew: request}, queue)
+			time.Sleep(window / (2 * updates))
+		}
+		Expect(queue.Len()).Should(BeZero())
+
+		Eventually(queue.Len, 2*window, 10*time.Millisecond).Should(Equal(1))
+		Consistently(queue.Len, window, 10*time.Millisecond).Should(Equal(1))
+		Expect(reconciles()).Should(BeNumerically("<", updates))
+	})
+
+	It("Should not coalesce the updates of different objects", func() {
+		handler.Update(event.UpdateEvent{ObjectOld: newRequest("first"), ObjectNew: newRequest("first")}, queue)
+		handler.Update(event.UpdateEvent{ObjectOld: newRequest("second"), ObjectNew: newRequest("second")}, queue)
+		Eventually(queue.Len, 2*window, 10*time.Millisecond).Should(Equal(2))
+	})
+
+	It("Should enqueue the other events immediately", func() {
+		handler.Create(event.CreateEvent{Object: newRequest("created")}, queue)
+		handler.Delete(event.DeleteEvent{Object: newRequest("deleted")}, queue)
+		handler.Generic(event.GenericEvent{Object: newRequest("refreshed")}, queue)
+		Expect(queue.Len()).Should(Equal(3))
+	})
+})
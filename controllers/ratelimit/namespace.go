@@ -0,0 +1,100 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// NamespaceLimiter limits the rate of the reconciles per namespace, so that
+// a namespace flooding the reconcile queue doesn't starve the others
+type NamespaceLimiter struct {
+	qps      rate.Limit
+	burst    int
+	mu       sync.Mutex
+	limiters map[string]*namespaceLimiter
+	// idle is how long a limiter takes to refill, an idle limiter is evicted since it behaves as a new one
+	idle      time.Duration
+	lastSweep time.Time
+}
+
+type namespaceLimiter struct {
+	*rate.Limiter
+	lastSeen time.Time
+}
+
+// NewNamespaceLimiter returns a NamespaceLimiter allowing qps reconciles per second
+// with bursts of at most burst reconciles in every namespace
+func NewNamespaceLimiter(qps float64, burst int) *NamespaceLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &NamespaceLimiter{
+		qps:       rate.Limit(qps),
+		burst:     burst,
+		limiters:  make(map[string]*namespaceLimiter),
+		idle:      time.Duration(float64(burst) / qps * float64(time.Second)),
+		lastSweep: time.Now(),
+	}
+}
+
+// When returns how long to wait before reconciling a resource in the namespace,
+// zero means the reconcile can start now and it is counted against the limit
+func (l *NamespaceLimiter) When(namespace string) time.Duration {
+	now := time.Now()
+	limiter := l.limiter(namespace, now)
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return time.Second
+	}
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		// Give the token back, the reconcile will ask again after the delay
+		reservation.CancelAt(now)
+	}
+	return delay
+}
+
+func (l *NamespaceLimiter) limiter(namespace string, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.evictIdle(now)
+	limiter, ok := l.limiters[namespace]
+	if !ok {
+		limiter = &namespaceLimiter{Limiter: rate.NewLimiter(l.qps, l.burst)}
+		l.limiters[namespace] = limiter
+	}
+	limiter.lastSeen = now
+	return limiter.Limiter
+}
+
+// evictIdle drops the limiters of the namespaces idle long enough to refill, including the deleted namespaces,
+// so that the limiters don't pile up. It sweeps at most once per idle period.
+func (l *NamespaceLimiter) evictIdle(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idle {
+		return
+	}
+	l.lastSweep = now
+	for namespace, limiter := range l.limiters {
+		if now.Sub(limiter.lastSeen) >= l.idle {
+			delete(l.limiters, namespace)
+		}
+	}
+}
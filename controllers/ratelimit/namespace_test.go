@@ -0,0 +1,64 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ratelimit
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Namespace limiter", func() {
+	It("Should throttle a flooding namespace without blocking the others", func() {
+		limiter := NewNamespaceLimiter(1, 5)
+
+		By("Flooding the first namespace")
+		for i := 0; i < 5; i++ {
+			Expect(limiter.When("flooding")).Should(BeZero())
+		}
+		for i := 0; i < 20; i++ {
+			Expect(limiter.When("flooding")).Should(BeNumerically(">", 0))
+		}
+
+		By("Reconciling the other namespace immediately")
+		Expect(limiter.When("quiet")).Should(BeZero())
+	})
+
+	It("Should allow the throttled namespace again after the delay", func() {
+		limiter := NewNamespaceLimiter(20, 1)
+		Expect(limiter.When("flooding")).Should(BeZero())
+		delay := limiter.When("flooding")
+		Expect(delay).Should(BeNumerically(">", 0))
+		Expect(delay).Should(BeNumerically("<=", 50*time.Millisecond))
+
+		time.Sleep(delay)
+		Expect(limiter.When("flooding")).Should(BeZero())
+	})
+
+	It("Should evict the limiters of the idle namespaces", func() {
+		limiter := NewNamespaceLimiter(20, 1)
+		Expect(limiter.When("deleted")).Should(BeZero())
+		Expect(limiter.limiters).Should(HaveKey("deleted"))
+
+		By("Sweeping once the namespace is idle long enough to refill")
+		time.Sleep(60 * time.Millisecond)
+		Expect(limiter.When("active")).Should(BeZero())
+		Expect(limiter.limiters).ShouldNot(HaveKey("deleted"))
+		Expect(limiter.limiters).Should(HaveKey("active"))
+	})
+})
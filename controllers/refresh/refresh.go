@@ -0,0 +1,174 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package refresh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+)
+
+// Path is the path of the refresh endpoint
+const Path = "/refresh"
+
+// DefaultInterval is the minimum interval between two refreshes
+const DefaultInterval = time.Minute
+
+// ErrRateLimited is returned when a refresh is requested before the interval passes
+var ErrRateLimited = errors.New("refresh is rate limited")
+
+// Refresher enqueues all the OperandRequests, OperandConfigs and OperandRegistries for an immediate reconcile
+type Refresher struct {
+	client.Reader
+	// Interval is the minimum interval between two refreshes
+	Interval time.Duration
+
+	requestEvents  chan event.GenericEvent
+	configEvents   chan event.GenericEvent
+	registryEvents chan event.GenericEvent
+
+	mu          sync.Mutex
+	lastRefresh time.Time
+}
+
+// Result is the number of the resources enqueued by a refresh
+type Result struct {
+	OperandRequests   int `json:"operandRequests"`
+	OperandConfigs    int `json:"operandConfigs"`
+	OperandRegistries int `json:"operandRegistries"`
+}
+
+// NewRefresher is the method to initialize a Refresher
+func NewRefresher(reader client.Reader, interval time.Duration) *Refresher {
+	return &Refresher{
+		Reader:         reader,
+		Interval:       interval,
+		requestEvents:  make(chan event.GenericEvent, 1024),
+		configEvents:   make(chan event.GenericEvent, 1024),
+		registryEvents: make(chan event.GenericEvent, 1024),
+	}
+}
+
+// OperandRequestEvents returns the events of the OperandRequests to be reconciled
+func (r *Refresher) OperandRequestEvents() <-chan event.GenericEvent {
+	return r.requestEvents
+}
+
+// OperandConfigEvents returns the events of the OperandConfigs to be reconciled
+func (r *Refresher) OperandConfigEvents() <-chan event.GenericEvent {
+	return r.configEvents
+}
+
+// OperandRegistryEvents returns the events of the OperandRegistries to be reconciled
+func (r *Refresher) OperandRegistryEvents() <-chan event.GenericEvent {
+	return r.registryEvents
+}
+
+// Refresh enqueues all the OperandRequests, OperandConfigs and OperandRegistries
+func (r *Refresher) Refresh(ctx context.Context) (*Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.lastRefresh.IsZero() && time.Since(r.lastRefresh) < r.Interval {
+		return nil, ErrRateLimited
+	}
+	r.lastRefresh = time.Now()
+
+	result := &Result{}
+
+	requestList := &operatorv1alpha1.OperandRequestList{}
+	if err := r.Reader.List(ctx, requestList); err != nil {
+		return nil, errors.Wrap(err, "failed to list OperandRequests")
+	}
+	for i := range requestList.Items {
+		if err := send(ctx, r.requestEvents, &requestList.Items[i]); err != nil {
+			return nil, err
+		}
+		result.OperandRequests++
+	}
+
+	configList := &operatorv1alpha1.OperandConfigList{}
+	if err := r.Reader.List(ctx, configList); err != nil {
+		return nil, errors.Wrap(err, "failed to list OperandConfigs")
+	}
+	for i := range configList.Items {
+		if err := send(ctx, r.configEvents, &configList.Items[i]); err != nil {
+			return nil, err
+		}
+		result.OperandConfigs++
+	}
+
+	registryList := &operatorv1alpha1.OperandRegistryList{}
+	if err := r.Reader.List(ctx, registryList); err != nil {
+		return nil, errors.Wrap(err, "failed to list OperandRegistries")
+	}
+	for i := range registryList.Items {
+		if err := send(ctx, r.registryEvents, &registryList.Items[i]); err != nil {
+			return nil, err
+		}
+		result.OperandRegistries++
+	}
+
+	klog.Infof("Enqueued %d OperandRequests, %d OperandConfigs and %d OperandRegistries for refresh", result.OperandRequests, result.OperandConfigs, result.OperandRegistries)
+	return result, nil
+}
+
+func send(ctx context.Context, events chan<- event.GenericEvent, object client.Object) error {
+	select {
+	case events <- event.GenericEvent{Object: object}:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "failed to enqueue %s/%s", object.GetNamespace(), object.GetName())
+	}
+}
+
+// ServeHTTP triggers a refresh on POST requests
+func (r *Refresher) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	result, err := r.Refresh(req.Context())
+	if err == ErrRateLimited {
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", int(r.Interval.Seconds())))
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if err != nil {
+		klog.Errorf("failed to refresh: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		klog.Errorf("failed to write the refresh result: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package refresh
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Refresher", func() {
+	var (
+		ctx       context.Context
+		refresher *Refresher
+	)
+
+	receive := func(events <-chan event.GenericEvent) []string {
+		var names []string
+		for {
+			select {
+			case e := <-events:
+				names = append(names, e.Object.GetNamespace()+"/"+e.Object.GetName())
+			default:
+				return names
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(operatorv1alpha1.AddToScheme(scheme)).Should(Succeed())
+
+		objects := []client.Object{
+			testutil.OperandRegistryObj("common-service", "ibm-common-services", "ibm-operators"),
+			testutil.OperandConfigObj("common-service", "ibm-common-services"),
+			testutil.OperandRequestObj("common-service", "ibm-common-services", "request-1", "ibm-cloudpak"),
+			testutil.OperandRequestObj("common-service", "ibm-common-services", "request-2", "ibm-cloudpak"),
+		}
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+		refresher = NewRefresher(reader, time.Hour)
+	})
+
+	It("Should enqueue all the ODLM resources", func() {
+		result, err := refresher.Refresh(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*result).Should(Equal(Result{OperandRequests: 2, OperandConfigs: 1, OperandRegistries: 1}))
+
+		Expect(receive(refresher.OperandRequestEvents())).Should(ConsistOf("ibm-cloudpak/request-1", "ibm-cloudpak/request-2"))
+		Expect(receive(refresher.OperandConfigEvents())).Should(ConsistOf("ibm-common-services/common-service"))
+		Expect(receive(refresher.OperandRegistryEvents())).Should(ConsistOf("ibm-common-services/common-service"))
+	})
+
+	It("Should rate limit the refreshes", func() {
+		_, err := refresher.Refresh(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = refresher.Refresh(ctx)
+		Expect(err).Should(Equal(ErrRateLimited))
+		Expect(receive(refresher.OperandRequestEvents())).Should(HaveLen(2))
+	})
+
+	It("Should serve the refresh endpoint", func() {
+		recorder := httptest.NewRecorder()
+		refresher.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, Path, nil))
+		Expect(recorder.Code).Should(Equal(http.StatusMethodNotAllowed))
+
+		recorder = httptest.NewRecorder()
+		refresher.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, Path, nil))
+		Expect(recorder.Code).Should(Equal(http.StatusAccepted))
+		Expect(recorder.Body.String()).Should(ContainSubstring(`"operandRequests":2`))
+
+		recorder = httptest.NewRecorder()
+		refresher.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, Path, nil))
+		Expect(recorder.Code).Should(Equal(http.StatusTooManyRequests))
+	})
+})
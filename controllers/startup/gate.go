@@ -0,0 +1,162 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package startup
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+)
+
+// DefaultTimeout is how long the OperandConfigs wait for the OperandRegistries at startup by default
+const DefaultTimeout = time.Minute
+
+// Gate holds the reconcile of the OperandConfigs until the OperandRegistries existing at startup are reconciled once,
+// so the status of the OperandConfigs isn't computed from the OperandRegistries without status.
+// The gate opens anyway after the timeout, an OperandRegistry failing to reconcile doesn't block the OperandConfigs.
+// A nil Gate is always open.
+type Gate struct {
+	reader  client.Reader
+	timeout time.Duration
+
+	mu         sync.Mutex
+	pending    map[types.NamespacedName]bool
+	reconciled map[types.NamespacedName]bool
+	done       chan struct{}
+	once       sync.Once
+}
+
+var _ manager.Runnable = &Gate{}
+
+// NewGate returns a closed Gate listing the OperandRegistries with the reader when it starts
+func NewGate(reader client.Reader, timeout time.Duration) *Gate {
+	return &Gate{
+		reader:     reader,
+		timeout:    timeout,
+		reconciled: make(map[types.NamespacedName]bool),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start implements manager.Runnable, it opens the gate once the OperandRegistries existing at startup are reconciled
+func (g *Gate) Start(ctx context.Context) error {
+	registryList := &operatorv1alpha1.OperandRegistryList{}
+	if err := g.reader.List(ctx, registryList); err != nil {
+		klog.Warningf("failed to list the OperandRegistries, open the startup gate: %v", err)
+		g.open()
+		return nil
+	}
+	keys := make([]types.NamespacedName, 0, len(registryList.Items))
+	for _, registry := range registryList.Items {
+		keys = append(keys, types.NamespacedName{Namespace: registry.Namespace, Name: registry.Name})
+	}
+	g.prime(keys)
+
+	timer := time.NewTimer(g.timeout)
+	defer timer.Stop()
+	select {
+	case <-g.done:
+		klog.V(1).Info("All the OperandRegistries are reconciled, open the startup gate")
+	case <-timer.C:
+		klog.Warningf("The OperandRegistries %v aren't reconciled in %s, open the startup gate", g.pendingKeys(), g.timeout)
+		g.open()
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// MarkReconciled records the OperandRegistry is reconciled, the gate opens when it is the last one pending
+func (g *Gate) MarkReconciled(key types.NamespacedName) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.pending == nil {
+		// The gate isn't started yet
+		g.reconciled[key] = true
+		return
+	}
+	delete(g.pending, key)
+	if len(g.pending) == 0 {
+		g.open()
+	}
+}
+
+// Wait blocks until the gate opens or the context is done
+func (g *Gate) Wait(ctx context.Context) error {
+	if g == nil {
+		return nil
+	}
+	select {
+	case <-g.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsOpen returns if the gate is open
+func (g *Gate) IsOpen() bool {
+	if g == nil {
+		return true
+	}
+	select {
+	case <-g.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// prime sets the OperandRegistries to wait for, except the ones already reconciled
+func (g *Gate) prime(keys []types.NamespacedName) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pending = make(map[types.NamespacedName]bool)
+	for _, key := range keys {
+		if !g.reconciled[key] {
+			g.pending[key] = true
+		}
+	}
+	if len(g.pending) == 0 {
+		g.open()
+	}
+}
+
+func (g *Gate) pendingKeys() []types.NamespacedName {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var keys []types.NamespacedName
+	for key := range g.pending {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (g *Gate) open() {
+	g.once.Do(func() {
+		close(g.done)
+	})
+}
@@ -0,0 +1,101 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package startup
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/testutil"
+)
+
+var _ = Describe("Startup gate", func() {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+		first  = types.NamespacedName{Namespace: "ibm-common-services", Name: "common-service"}
+		second = types.NamespacedName{Namespace: "ibm-cloudpak", Name: "cloudpak"}
+	)
+
+	newGate := func(timeout time.Duration) *Gate {
+		scheme := runtime.NewScheme()
+		Expect(operatorv1alpha1.AddToScheme(scheme)).Should(Succeed())
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			testutil.OperandRegistryObj(first.Name, first.Namespace, "ibm-operators"),
+			testutil.OperandRegistryObj(second.Name, second.Namespace, "ibm-operators"),
+		).Build()
+		return NewGate(c, timeout)
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("Should open once all the OperandRegistries are reconciled", func() {
+		gate := newGate(time.Minute)
+		// A registry reconciled before the gate starts counts
+		gate.MarkReconciled(first)
+		go func() {
+			defer GinkgoRecover()
+			Expect(gate.Start(ctx)).Should(Succeed())
+		}()
+		Consistently(gate.IsOpen, 100*time.Millisecond).Should(BeFalse())
+
+		waited := make(chan error)
+		go func() {
+			waited <- gate.Wait(ctx)
+		}()
+		gate.MarkReconciled(second)
+		Eventually(waited).Should(Receive(BeNil()))
+		Expect(gate.IsOpen()).Should(BeTrue())
+	})
+
+	It("Should open after the timeout when an OperandRegistry isn't reconciled", func() {
+		gate := newGate(100 * time.Millisecond)
+		go func() {
+			defer GinkgoRecover()
+			Expect(gate.Start(ctx)).Should(Succeed())
+		}()
+		gate.MarkReconciled(first)
+		Eventually(gate.IsOpen).Should(BeTrue())
+	})
+
+	It("Should stop waiting when the context is done", func() {
+		gate := newGate(time.Minute)
+		cancel()
+		Expect(gate.Wait(ctx)).Should(MatchError(context.Canceled))
+		Expect(gate.IsOpen()).Should(BeFalse())
+	})
+
+	It("Should always be open when it is nil", func() {
+		var gate *Gate
+		gate.MarkReconciled(first)
+		Expect(gate.Wait(ctx)).Should(Succeed())
+		Expect(gate.IsOpen()).Should(BeTrue())
+	})
+})
@@ -0,0 +1,47 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ValidateALMExamples checks if the alm-examples annotation of a ClusterServiceVersion is a JSON array
+// of custom resources, each of them with an apiVersion and a kind
+func ValidateALMExamples(almExamples string) error {
+	if strings.TrimSpace(almExamples) == "" {
+		return fmt.Errorf("the alm-examples are empty")
+	}
+	var examples []interface{}
+	if err := json.Unmarshal([]byte(almExamples), &examples); err != nil {
+		return fmt.Errorf("the alm-examples are not a JSON array: %v", err)
+	}
+	for i, example := range examples {
+		cr, ok := example.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("the alm-example %d is not a JSON object", i)
+		}
+		for _, field := range []string{"apiVersion", "kind"} {
+			if value, ok := cr[field].(string); !ok || value == "" {
+				return fmt.Errorf("the alm-example %d has no %s", i, field)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+//
+// Copyright 2021 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package util
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validating the alm-examples", func() {
+	It("Should accept an array of custom resources", func() {
+		Expect(ValidateALMExamples(`[{"apiVersion": "etcd.database.coreos.com/v1beta2", "kind": "EtcdCluster", "metadata": {"name": "example"}}]`)).Should(Succeed())
+		Expect(ValidateALMExamples(`[]`)).Should(Succeed())
+	})
+
+	DescribeTable("Should reject the invalid alm-examples",
+		func(almExamples, message string) {
+			Expect(ValidateALMExamples(almExamples)).Should(MatchError(ContainSubstring(message)))
+		},
+		Entry("empty", " ", "the alm-examples are empty"),
+		Entry("malformed", `[{"apiVersion": "etcd.database.coreos.com/v1beta2",`, "the alm-examples are not a JSON array"),
+		Entry("object", `{"kind": "EtcdCluster"}`, "the alm-examples are not a JSON array"),
+		Entry("not an object", `["EtcdCluster"]`, "the alm-example 0 is not a JSON object"),
+		Entry("without kind", `[{"apiVersion": "v1", "kind": "ConfigMap"}, {"apiVersion": "v1"}]`, "the alm-example 1 has no kind"),
+	)
+})
@@ -18,13 +18,27 @@ package util
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 
 	"k8s.io/klog"
 )
 
 // MergeCR deep merge two custom resource spec
 func MergeCR(defaultCR, changedCR []byte) map[string]interface{} {
+	return mergeCR(defaultCR, changedCR, false)
+}
+
+// StrategicMergeCR deep merge two custom resource spec like MergeCR, and also merge the lists of objects
+// with a name, e.g. the containers or the env, by the name of their items.
+// The other lists in the changed spec replace the lists in the default spec.
+func StrategicMergeCR(defaultCR, changedCR []byte) map[string]interface{} {
+	return mergeCR(defaultCR, changedCR, true)
+}
+
+func mergeCR(defaultCR, changedCR []byte, mergeLists bool) map[string]interface{} {
 	if len(defaultCR) == 0 && len(changedCR) == 0 {
 		return make(map[string]interface{})
 	}
@@ -53,12 +67,12 @@ func MergeCR(defaultCR, changedCR []byte) map[string]interface{} {
 		klog.Errorf("failed to unmarshal service spec: %v", changedCRUnmarshalErr)
 	}
 	for key := range defaultCRDecoded {
-		checkKeyBeforeMerging(key, defaultCRDecoded[key], changedCRDecoded[key], changedCRDecoded)
+		checkKeyBeforeMerging(key, defaultCRDecoded[key], changedCRDecoded[key], changedCRDecoded, mergeLists)
 	}
 	return changedCRDecoded
 }
 
-func checkKeyBeforeMerging(key string, defaultMap interface{}, changedMap interface{}, finalMap map[string]interface{}) {
+func checkKeyBeforeMerging(key string, defaultMap interface{}, changedMap interface{}, finalMap map[string]interface{}, mergeLists bool) {
 	if !reflect.DeepEqual(defaultMap, changedMap) {
 		switch defaultMap := defaultMap.(type) {
 		case map[string]interface{}:
@@ -69,7 +83,15 @@ func checkKeyBeforeMerging(key string, defaultMap interface{}, changedMap interf
 				defaultMapRef := defaultMap
 				changedMapRef := changedMap.(map[string]interface{})
 				for newKey := range defaultMapRef {
-					checkKeyBeforeMerging(newKey, defaultMapRef[newKey], changedMapRef[newKey], finalMap[key].(map[string]interface{}))
+					checkKeyBeforeMerging(newKey, defaultMapRef[newKey], changedMapRef[newKey], finalMap[key].(map[string]interface{}), mergeLists)
+				}
+			}
+		case []interface{}:
+			if changedMap == nil {
+				finalMap[key] = defaultMap
+			} else if changedList, ok := changedMap.([]interface{}); ok && mergeLists {
+				if mergedList, merged := mergeNamedLists(defaultMap, changedList); merged {
+					finalMap[key] = mergedList
 				}
 			}
 		default:
@@ -80,3 +102,119 @@ func checkKeyBeforeMerging(key string, defaultMap interface{}, changedMap interf
 		}
 	}
 }
+
+// mergeNamedLists merges the items of the changed list into the items of the default list with the same name,
+// the items only in the changed list are appended. It returns false if any item isn't an object with a name.
+func mergeNamedLists(defaultList, changedList []interface{}) ([]interface{}, bool) {
+	changedItems := make(map[string]map[string]interface{})
+	for _, item := range changedList {
+		name, ok := itemName(item)
+		if !ok {
+			return nil, false
+		}
+		changedItems[name] = item.(map[string]interface{})
+	}
+	defaultNames := make(map[string]bool)
+	for _, item := range defaultList {
+		name, ok := itemName(item)
+		if !ok {
+			return nil, false
+		}
+		defaultNames[name] = true
+	}
+
+	var mergedList []interface{}
+	for _, item := range defaultList {
+		name, _ := itemName(item)
+		changedItem, found := changedItems[name]
+		if !found {
+			mergedList = append(mergedList, item)
+			continue
+		}
+		defaultItem := item.(map[string]interface{})
+		for key := range defaultItem {
+			checkKeyBeforeMerging(key, defaultItem[key], changedItem[key], changedItem, true)
+		}
+		mergedList = append(mergedList, changedItem)
+	}
+	for _, item := range changedList {
+		if name, _ := itemName(item); !defaultNames[name] {
+			mergedList = append(mergedList, item)
+		}
+	}
+	return mergedList, true
+}
+
+// itemName returns the name of the list item, if it is an object with a name
+func itemName(item interface{}) (string, bool) {
+	object, ok := item.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	name, ok := object["name"].(string)
+	return name, ok
+}
+
+// DiffCR returns the sorted paths of the fields differing between two custom resource specs.
+// The lists are compared as a whole.
+func DiffCR(actualCR, desiredCR []byte) []string {
+	actualCRDecoded := make(map[string]interface{})
+	desiredCRDecoded := make(map[string]interface{})
+	if len(actualCR) != 0 {
+		if err := json.Unmarshal(actualCR, &actualCRDecoded); err != nil {
+			klog.Errorf("failed to unmarshal the actual CR spec: %v", err)
+		}
+	}
+	if len(desiredCR) != 0 {
+		if err := json.Unmarshal(desiredCR, &desiredCRDecoded); err != nil {
+			klog.Errorf("failed to unmarshal the desired CR spec: %v", err)
+		}
+	}
+	var paths []string
+	diffKeys("", actualCRDecoded, desiredCRDecoded, &paths)
+	sort.Strings(paths)
+	return paths
+}
+
+// SummarizeDiff joins the changed paths with commas and keeps the summary within maxLen bytes,
+// the paths left out are counted at the end of the summary.
+func SummarizeDiff(paths []string, maxLen int) string {
+	summary := strings.Join(paths, ",")
+	if len(summary) <= maxLen {
+		return summary
+	}
+	for kept := len(paths) - 1; kept >= 0; kept-- {
+		summary = strings.Join(paths[:kept], ",")
+		suffix := fmt.Sprintf("... and %d more", len(paths)-kept)
+		if kept > 0 {
+			suffix = "," + suffix
+		}
+		if len(summary)+len(suffix) <= maxLen {
+			return summary + suffix
+		}
+	}
+	return ""
+}
+
+func diffKeys(prefix string, actualMap, desiredMap map[string]interface{}, paths *[]string) {
+	keys := make(map[string]bool)
+	for key := range actualMap {
+		keys[key] = true
+	}
+	for key := range desiredMap {
+		keys[key] = true
+	}
+	for key := range keys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		actualValue, actualIsMap := actualMap[key].(map[string]interface{})
+		desiredValue, desiredIsMap := desiredMap[key].(map[string]interface{})
+		if actualIsMap && desiredIsMap {
+			diffKeys(path, actualValue, desiredValue, paths)
+		} else if !reflect.DeepEqual(actualMap[key], desiredMap[key]) {
+			*paths = append(*paths, path)
+		}
+	}
+}
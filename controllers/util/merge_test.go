@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 )
 
@@ -54,4 +55,65 @@ var _ = Describe("DeepMerge", func() {
 			Expect(mergedJSON).Should(Equal([]byte(resultJSON)))
 		})
 	})
+
+	DescribeTable("Merge the lists of two JSON files",
+		func(defaultJSON, changedJSON, mergedJSON, strategicMergedJSON string) {
+			merged, err := json.Marshal(MergeCR([]byte(defaultJSON), []byte(changedJSON)))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(merged)).Should(Equal(mergedJSON))
+
+			strategicMerged, err := json.Marshal(StrategicMergeCR([]byte(defaultJSON), []byte(changedJSON)))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(strategicMerged)).Should(Equal(strategicMergedJSON))
+		},
+		Entry("Lists of objects with a name",
+			`{"containers":[{"name":"etcd","image":"etcd:3.4","env":[{"name":"A","value":"1"}]},{"name":"proxy","image":"proxy:1"}]}`,
+			`{"containers":[{"name":"etcd","env":[{"name":"B","value":"2"}]},{"name":"backup","image":"backup:1"}]}`,
+			`{"containers":[{"env":[{"name":"B","value":"2"}],"name":"etcd"},{"image":"backup:1","name":"backup"}]}`,
+			`{"containers":[{"env":[{"name":"A","value":"1"},{"name":"B","value":"2"}],"image":"etcd:3.4","name":"etcd"},{"image":"proxy:1","name":"proxy"},{"image":"backup:1","name":"backup"}]}`,
+		),
+		Entry("Lists of objects overriding a field",
+			`{"env":[{"name":"A","value":"1"}]}`,
+			`{"env":[{"name":"A","value":"2"}]}`,
+			`{"env":[{"name":"A","value":"2"}]}`,
+			`{"env":[{"name":"A","value":"2"}]}`,
+		),
+		Entry("Lists of scalars",
+			`{"args":["--debug","--port=80"]}`,
+			`{"args":["--port=8080"]}`,
+			`{"args":["--port=8080"]}`,
+			`{"args":["--port=8080"]}`,
+		),
+		Entry("Lists of objects without a name",
+			`{"tolerations":[{"key":"a","effect":"NoSchedule"}]}`,
+			`{"tolerations":[{"key":"b","effect":"NoSchedule"}]}`,
+			`{"tolerations":[{"effect":"NoSchedule","key":"b"}]}`,
+			`{"tolerations":[{"effect":"NoSchedule","key":"b"}]}`,
+		),
+		Entry("List only in the default",
+			`{"env":[{"name":"A","value":"1"}],"size":1}`,
+			`{"size":3}`,
+			`{"env":[{"name":"A","value":"1"}],"size":3}`,
+			`{"env":[{"name":"A","value":"1"}],"size":3}`,
+		),
+	)
+
+	Context("Diff two JSON files", func() {
+		It("Should list the paths of the differing fields", func() {
+			actualJSON := `{"greetings":{"first":"hey","second":"hello"},"cars":["Ford"],"age":30,"name":"John"}`
+			desiredJSON := `{"greetings":{"first":"hi","second":"hello","third":"howdy"},"cars":["Ford","BMW"],"age":30.0,"name":"John"}`
+
+			Expect(DiffCR([]byte(actualJSON), []byte(desiredJSON))).Should(Equal([]string{"cars", "greetings.first", "greetings.third"}))
+			Expect(DiffCR([]byte(actualJSON), []byte(actualJSON))).Should(BeEmpty())
+		})
+
+		It("Should summarize the paths within the size limit", func() {
+			paths := []string{"cars", "greetings.first", "greetings.third"}
+
+			Expect(SummarizeDiff(paths, 1024)).Should(Equal("cars,greetings.first,greetings.third"))
+			Expect(SummarizeDiff(paths, 30)).Should(Equal("cars,... and 2 more"))
+			Expect(SummarizeDiff(paths, 15)).Should(Equal("... and 3 more"))
+			Expect(len(SummarizeDiff(paths, 30))).Should(BeNumerically("<=", 30))
+		})
+	})
 })
@@ -5,6 +5,7 @@ go 1.16
 require (
 	github.com/IBM/controller-filtered-cache v0.3.0
 	github.com/IBM/ibm-namespace-scope-operator v1.0.0-alpha
+	github.com/blang/semver/v4 v4.0.0
 	github.com/coreos/etcd-operator v0.9.4
 	github.com/deckarep/golang-set v1.7.1
 	github.com/onsi/ginkgo v1.14.1
@@ -12,12 +13,15 @@ require (
 	github.com/operator-framework/api v0.6.2
 	github.com/operator-framework/operator-lifecycle-manager v0.17.0
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.7.1
+	golang.org/x/time v0.0.0-20200630173020-3af7569d3a1e
 	k8s.io/api v0.20.5
 	k8s.io/apimachinery v0.20.5
 	k8s.io/client-go v0.20.5
 	k8s.io/klog v1.0.0
 	sigs.k8s.io/controller-runtime v0.8.0
 	sigs.k8s.io/kubebuilder v1.0.9-0.20200805184228-f7a3b65dd250
+	sigs.k8s.io/yaml v1.2.0
 )
 
 // fix vulnerability: CVE-2021-3121 in github.com/gogo/protobuf v1.2.1
@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
 	"strings"
@@ -25,27 +26,41 @@ import (
 	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	operatorsv1 "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/operators/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/klog"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	cache "github.com/IBM/controller-filtered-cache/filteredcache"
 	nssv1 "github.com/IBM/ibm-namespace-scope-operator/api/v1"
 
 	operatorv1alpha1 "github.com/IBM/operand-deployment-lifecycle-manager/api/v1alpha1"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/audit"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/clusterversion"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/constant"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/k8sutil"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/metrics"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/namespacescope"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/operandbindinfo"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/operandconfig"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/operandregistry"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/operandrequest"
 	deploy "github.com/IBM/operand-deployment-lifecycle-manager/controllers/operator"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/ratelimit"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/refresh"
+	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/startup"
 	"github.com/IBM/operand-deployment-lifecycle-manager/controllers/util"
 	// +kubebuilder:scaffold:imports
 )
@@ -77,9 +92,98 @@ func main() {
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
 	var stepSize = flag.Int("batch-chunk-size", 3, "batch-chunk-size is used to control at most how many subscriptions will be created concurrently")
+	var copyConcurrency = flag.Int("bindinfo-copy-concurrency", operandbindinfo.DefaultCopyConcurrency, "bindinfo-copy-concurrency is used to control at most how many namespaces the OperandBindInfo secrets and configmaps will be copied to concurrently")
+	var namespaceQPS = flag.Float64("namespace-reconcile-qps", 0, "namespace-reconcile-qps is used to control at most how many OperandRequests will be reconciled per second in a namespace, 0 means no limit")
+	var namespaceBurst = flag.Int("namespace-reconcile-burst", 100, "namespace-reconcile-burst is used to control at most how many OperandRequests will be reconciled at once in a namespace before namespace-reconcile-qps applies")
+	var debounceWindow = flag.Duration("reconcile-debounce-window", 0, "reconcile-debounce-window is used to coalesce the updates of an OperandRequest received within the window into one reconcile, 0 means every update is reconciled")
+	var applyDefaults = flag.Bool("apply-defaults", false, "apply-defaults is used to create the custom resources of an OperandConfig service without spec straight from the alm-examples, instead of skipping them")
+	var keepFailedCRs = flag.Bool("keep-failed-crs", false, "keep-failed-crs is used to keep the custom resources failed to be created in configmaps for inspection")
+	var finalizerPolicy = flag.String("finalizer-policy", operandrequest.FinalizerPolicyStrict, "finalizer-policy is used to decide whether the OperandRequest deletion waits for the clean up to succeed (strict), or gives up the clean up after finalizer-timeout (best-effort)")
+	var finalizerTimeout = flag.Duration("finalizer-timeout", operandrequest.DefaultFinalizerTimeout, "finalizer-timeout is used to control how long the best-effort finalizer retries the clean up before removing the finalizer anyway")
+	var auditSinkType = flag.String("audit-sink", "", "audit-sink is used to write an audit record of each mutation performed by ODLM, either to the standard output in JSON (log) or to audit-webhook-url (webhook), it is disabled by default")
+	var auditWebhookURL = flag.String("audit-webhook-url", "", "audit-webhook-url is the URL the audit records are posted to when audit-sink is webhook")
+	var installTimeout = flag.Duration("install-timeout", 0, "install-timeout is used to mark the OperandRequests Failed when they aren't Running within the timeout, it can be overridden by the installTimeout of the OperandRequest, 0 means no timeout")
+	var csvWaitTimeout = flag.Duration("csv-wait-timeout", operandrequest.DefaultCSVWaitTimeout, "csv-wait-timeout is used to mark the operands Failed when the ClusterServiceVersions of their Subscriptions aren't resolved within the timeout, 0 means no timeout")
+	var phasePrecedence = flag.String("phase-precedence", "Failed,WaitingForApproval,Installing,Creating,Running", "phase-precedence is the order the phases of the members take precedence in the phase of the OperandRequests, the Updating phase can be added to report the operators being updated")
+	var retryBudget = flag.Int("retry-budget", 0, "retry-budget is the number of the failed reconciles in a row before an OperandRequest isn't retried until its spec is changed, it can be overridden by the retryBudget of the OperandRequest, 0 means no budget")
+	var exportBundle = flag.String("export-bundle", "", "export-bundle is used to print the OperandRegistries, OperandConfigs and OperandBindInfos referenced by the OperandRequest <namespace>/<name>, and the ClusterServiceVersions resolved for its operands, as a single manifest and exit")
+	var importSubscriptions = flag.String("import-subscriptions", "", "import-subscriptions is used to print a draft OperandRegistry, OperandConfig and OperandRequest <namespace>/<name> for the Subscriptions not managed by ODLM, with the services derived from the alm-examples of their ClusterServiceVersions, as a single manifest and exit")
+	var reportVersions = flag.Bool("report-versions", false, "report-versions is used to print the installed versions of the operators managed by ODLM, with the namespaces they are installed in, and exit")
+	var defaultTargetNamespace = flag.String("default-target-namespace", "", "default-target-namespace is used to create the custom resources of the operators installed in AllNamespaces mode in one namespace instead of the namespace of the operator, the targetNamespace of the OperandConfig service overrides it")
+	var crDeletionPropagation = flag.String("cr-deletion-propagation", string(metav1.DeletePropagationBackground), "cr-deletion-propagation is used to delete the custom resources with the propagation policy Foreground, Background or Orphan, it can be overridden by the deletionPropagation of the OperandConfig service")
+	var configRequeueBase = flag.Duration("config-requeue-base", constant.DefaultRequeueDuration, "config-requeue-base is used to control the first delay to requeue an OperandConfig waiting for its services, the delay doubles on every wait in a row")
+	var configRequeueMax = flag.Duration("config-requeue-max", operandconfig.DefaultMaxRequeueDuration, "config-requeue-max is used to cap the delay to requeue an OperandConfig waiting for its services")
+	var startupGateTimeout = flag.Duration("startup-gate-timeout", startup.DefaultTimeout, "startup-gate-timeout is used to control how long the OperandConfigs wait for the OperandRegistries existing at startup to be reconciled, 0 reconciles them right away")
+	var enableDriftEndpoint = flag.Bool("enable-drift-endpoint", false, "enable-drift-endpoint is used to serve the unauthenticated GET /drift endpoint on the metrics address, which reports the custom resources drifted from the spec desired by ODLM")
+	var enableRefreshEndpoint = flag.Bool("enable-refresh-endpoint", false, "enable-refresh-endpoint is used to serve the unauthenticated POST /refresh endpoint on the metrics address, which enqueues all the OperandRequests, OperandConfigs and OperandRegistries for an immediate reconcile")
+	var suspendUpgrades = flag.Bool("suspend-upgrades", false, "suspend-upgrades is used to withhold the upgrades of the installed operators, while still allowing new installs")
 
 	flag.Parse()
 
+	if *exportBundle != "" {
+		code := exportBundleManifest(*exportBundle)
+		klog.Flush()
+		os.Exit(code)
+	}
+
+	if *importSubscriptions != "" {
+		code := importSubscriptionsManifest(*importSubscriptions)
+		klog.Flush()
+		os.Exit(code)
+	}
+
+	if *reportVersions {
+		code := reportOperatorVersions()
+		klog.Flush()
+		os.Exit(code)
+	}
+
+	if *finalizerPolicy != operandrequest.FinalizerPolicyStrict && *finalizerPolicy != operandrequest.FinalizerPolicyBestEffort {
+		klog.Errorf("invalid finalizer-policy %q, must be %s or %s", *finalizerPolicy, operandrequest.FinalizerPolicyStrict, operandrequest.FinalizerPolicyBestEffort)
+		os.Exit(1)
+	}
+
+	if *configRequeueBase <= 0 || *configRequeueMax < *configRequeueBase {
+		klog.Errorf("invalid config-requeue-base %v and config-requeue-max %v, the base must be positive and not above the max", *configRequeueBase, *configRequeueMax)
+		os.Exit(1)
+	}
+
+	switch metav1.DeletionPropagation(*crDeletionPropagation) {
+	case metav1.DeletePropagationForeground, metav1.DeletePropagationBackground, metav1.DeletePropagationOrphan:
+	default:
+		klog.Errorf("invalid cr-deletion-propagation %q, must be %s, %s or %s", *crDeletionPropagation, metav1.DeletePropagationForeground, metav1.DeletePropagationBackground, metav1.DeletePropagationOrphan)
+		os.Exit(1)
+	}
+
+	if *defaultTargetNamespace != "" {
+		if msgs := validation.IsDNS1123Label(*defaultTargetNamespace); len(msgs) != 0 {
+			klog.Errorf("invalid default-target-namespace %q: %s", *defaultTargetNamespace, strings.Join(msgs, ", "))
+			os.Exit(1)
+		}
+	}
+
+	precedence, err := operatorv1alpha1.ParseClusterPhasePrecedence(*phasePrecedence)
+	if err != nil {
+		klog.Errorf("invalid phase-precedence: %v", err)
+		os.Exit(1)
+	}
+
+	var auditSink audit.Sink
+	switch *auditSinkType {
+	case "":
+	case "log":
+		auditSink = audit.NewLogSink(os.Stdout)
+	case "webhook":
+		if *auditWebhookURL == "" {
+			klog.Error("audit-webhook-url is required when audit-sink is webhook")
+			os.Exit(1)
+		}
+		auditSink = audit.NewWebhookSink(*auditWebhookURL)
+	default:
+		klog.Errorf("invalid audit-sink %q, must be log or webhook", *auditSinkType)
+		os.Exit(1)
+	}
+
 	gvkLabelMap := map[schema.GroupVersionKind]cache.Selector{
 		corev1.SchemeGroupVersion.WithKind("Secret"): {
 			LabelSelector: constant.OpbiTypeLabel,
@@ -118,27 +222,113 @@ func main() {
 		klog.Errorf("unable to start manager: %v", err)
 		os.Exit(1)
 	}
-	if err = (&operandrequest.Reconciler{
-		ODLMOperator: deploy.NewODLMOperator(mgr, "OperandRequest"),
-		StepSize:     *stepSize,
-	}).SetupWithManager(mgr); err != nil {
+	if *defaultTargetNamespace != "" {
+		if err := mgr.GetAPIReader().Get(context.Background(), types.NamespacedName{Name: *defaultTargetNamespace}, &corev1.Namespace{}); err != nil {
+			klog.Errorf("unable to get the default-target-namespace %s: %v", *defaultTargetNamespace, err)
+			os.Exit(1)
+		}
+	}
+	// Audit the mutations of all the controllers when the audit sink is enabled
+	newODLMOperator := func(name string) *deploy.ODLMOperator {
+		operator := deploy.NewODLMOperator(mgr, name)
+		operator.DefaultTargetNamespace = *defaultTargetNamespace
+		if auditSink != nil {
+			operator.Client = audit.NewClient(operator.Client, auditSink)
+		}
+		return operator
+	}
+	// Serve the endpoint to refresh all the ODLM resources on demand, the endpoint isn't authenticated
+	refresher := refresh.NewRefresher(mgr.GetAPIReader(), refresh.DefaultInterval)
+	if *enableRefreshEndpoint {
+		if err := mgr.AddMetricsExtraHandler(refresh.Path, refresher); err != nil {
+			klog.Errorf("unable to set up refresh endpoint: %v", err)
+			os.Exit(1)
+		}
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		klog.Errorf("unable to create discovery client: %v", err)
+		os.Exit(1)
+	}
+	var namespaceLimiter *ratelimit.NamespaceLimiter
+	if *namespaceQPS > 0 {
+		namespaceLimiter = ratelimit.NewNamespaceLimiter(*namespaceQPS, *namespaceBurst)
+	}
+	// Export the phases on the metrics endpoint of the manager
+	phaseMetrics := metrics.NewPhaseCollector()
+	ctrlmetrics.Registry.MustRegister(phaseMetrics)
+	requestReconciler := &operandrequest.Reconciler{
+		ODLMOperator:           newODLMOperator("OperandRequest"),
+		StepSize:               *stepSize,
+		SuspendUpgrades:        *suspendUpgrades,
+		KeepFailedCRs:          *keepFailedCRs,
+		ApplyDefaults:          *applyDefaults,
+		FinalizerPolicy:        *finalizerPolicy,
+		FinalizerTimeout:       *finalizerTimeout,
+		InstallTimeout:         *installTimeout,
+		CSVWaitTimeout:         *csvWaitTimeout,
+		RetryBudget:            int32(*retryBudget),
+		PhasePrecedence:        precedence,
+		DeletionPropagation:    metav1.DeletionPropagation(*crDeletionPropagation),
+		NamespaceLimiter:       namespaceLimiter,
+		DebounceWindow:         *debounceWindow,
+		RefreshEvents:          refresher.OperandRequestEvents(),
+		ClusterVersionDetector: clusterversion.NewCachedDetector(clusterversion.NewDetector(mgr.GetAPIReader(), dc), clusterversion.DefaultTTL),
+		Discovery:              dc,
+		PhaseMetrics:           phaseMetrics,
+		Summary:                operandrequest.NewRequestSummary(),
+	}
+	if err = requestReconciler.SetupWithManager(mgr); err != nil {
 		klog.Errorf("unable to create controller OperandRequest: %v", err)
 		os.Exit(1)
 	}
+	// Serve the report of the custom resources drifted from the spec desired by ODLM, the endpoint isn't authenticated
+	if *enableDriftEndpoint {
+		if err := mgr.AddMetricsExtraHandler(operandrequest.DriftReportPath, requestReconciler.DriftReportHandler()); err != nil {
+			klog.Errorf("unable to set up drift report endpoint: %v", err)
+			os.Exit(1)
+		}
+	}
+	// Serve the counts of the Subscriptions managed by ODLM by their state
+	if err := mgr.AddMetricsExtraHandler(operandrequest.SubscriptionStatesPath, requestReconciler.SubscriptionStatesHandler()); err != nil {
+		klog.Errorf("unable to set up subscription states endpoint: %v", err)
+		os.Exit(1)
+	}
+	// Serve the summary of the phases of the OperandRequests across the namespaces
+	if err := mgr.AddMetricsExtraHandler(operandrequest.RequestSummaryPath, requestReconciler.Summary); err != nil {
+		klog.Errorf("unable to set up operandrequest summary endpoint: %v", err)
+		os.Exit(1)
+	}
+	// The OperandConfigs wait for the OperandRegistries at startup, their status is computed from the OperandRegistries
+	var startupGate *startup.Gate
+	if *startupGateTimeout > 0 {
+		startupGate = startup.NewGate(mgr.GetAPIReader(), *startupGateTimeout)
+		if err := mgr.Add(startupGate); err != nil {
+			klog.Errorf("unable to set up startup gate: %v", err)
+			os.Exit(1)
+		}
+	}
 	if err = (&operandconfig.Reconciler{
-		ODLMOperator: deploy.NewODLMOperator(mgr, "OperandConfig"),
+		ODLMOperator:  newODLMOperator("OperandConfig"),
+		RefreshEvents: refresher.OperandConfigEvents(),
+		WaitBackoff:   flowcontrol.NewBackOff(*configRequeueBase, *configRequeueMax),
+		StartupGate:   startupGate,
+		PhaseMetrics:  phaseMetrics,
 	}).SetupWithManager(mgr); err != nil {
 		klog.Errorf("unable to create controller OperandConfig: %v", err)
 		os.Exit(1)
 	}
 	if err = (&operandbindinfo.Reconciler{
-		ODLMOperator: deploy.NewODLMOperator(mgr, "OperandBindInfo"),
+		ODLMOperator:    newODLMOperator("OperandBindInfo"),
+		CopyConcurrency: *copyConcurrency,
 	}).SetupWithManager(mgr); err != nil {
 		klog.Errorf("unable to create controller OperandBindInfo: %v", err)
 		os.Exit(1)
 	}
 	if err = (&operandregistry.Reconciler{
-		ODLMOperator: deploy.NewODLMOperator(mgr, "OperandRegistry"),
+		ODLMOperator:  newODLMOperator("OperandRegistry"),
+		RefreshEvents: refresher.OperandRegistryEvents(),
+		StartupGate:   startupGate,
 	}).SetupWithManager(mgr); err != nil {
 		klog.Errorf("unable to create controller OperandRegistry: %v", err)
 		os.Exit(1)
@@ -146,12 +336,22 @@ func main() {
 	// Single instance case, disable it on SaaS or on-prem multi instances case
 	if !isolatedModeEnable {
 		if err = (&namespacescope.Reconciler{
-			ODLMOperator: deploy.NewODLMOperator(mgr, "NamespaceScope"),
+			ODLMOperator: newODLMOperator("NamespaceScope"),
 		}).SetupWithManager(mgr); err != nil {
 			klog.Errorf("unable to create controller NamespaceScope: %v", err)
 			os.Exit(1)
 		}
 	}
+	// Webhooks require a serving certificate, only register them when enabled
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" {
+		if err = (&operatorv1alpha1.OperandConfig{}).SetupWebhookWithManager(mgr); err != nil {
+			klog.Errorf("unable to create webhook OperandConfig: %v", err)
+			os.Exit(1)
+		}
+		mgr.GetWebhookServer().Register(operandregistry.ChannelDefaulterPath, &webhook.Admission{Handler: &operandregistry.ChannelDefaulter{Reader: mgr.GetAPIReader()}})
+		mgr.GetWebhookServer().Register(operandregistry.ValidatorPath, &webhook.Admission{Handler: &operandregistry.Validator{Reader: mgr.GetAPIReader()}})
+		mgr.GetWebhookServer().Register(operandbindinfo.ValidatorPath, &webhook.Admission{Handler: &operandbindinfo.Validator{Reader: mgr.GetAPIReader()}})
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("health", healthz.Ping); err != nil {
@@ -169,3 +369,109 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// exportBundleManifest prints the manifest of the objects driving the OperandRequest <namespace>/<name>,
+// it returns the exit code of the command
+func exportBundleManifest(request string) int {
+	parts := strings.SplitN(request, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		klog.Errorf("invalid export-bundle %q, must be <namespace>/<name>", request)
+		return 1
+	}
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		klog.Errorf("unable to create client: %v", err)
+		return 1
+	}
+	r := &operandrequest.Reconciler{
+		ODLMOperator: &deploy.ODLMOperator{
+			Client: c,
+			Reader: c,
+			Scheme: scheme,
+		},
+	}
+	bundle, err := r.ExportBundle(context.Background(), types.NamespacedName{Namespace: parts[0], Name: parts[1]})
+	if err != nil {
+		klog.Errorf("unable to export the bundle of the OperandRequest %s: %v", request, err)
+		return 1
+	}
+	manifest, err := bundle.Manifest()
+	if err != nil {
+		klog.Errorf("unable to render the bundle of the OperandRequest %s: %v", request, err)
+		return 1
+	}
+	if _, err := os.Stdout.Write(manifest); err != nil {
+		klog.Errorf("unable to write the bundle of the OperandRequest %s: %v", request, err)
+		return 1
+	}
+	return 0
+}
+
+// importSubscriptionsManifest prints the manifest of the ODLM objects <namespace>/<name> drafted from the Subscriptions,
+// it returns the exit code of the command
+func importSubscriptionsManifest(key string) int {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		klog.Errorf("invalid import-subscriptions %q, must be <namespace>/<name>", key)
+		return 1
+	}
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		klog.Errorf("unable to create client: %v", err)
+		return 1
+	}
+	r := &operandrequest.Reconciler{
+		ODLMOperator: &deploy.ODLMOperator{
+			Client: c,
+			Reader: c,
+			Scheme: scheme,
+		},
+	}
+	bundle, err := r.ImportSubscriptions(context.Background(), types.NamespacedName{Namespace: parts[0], Name: parts[1]})
+	if err != nil {
+		klog.Errorf("unable to import the Subscriptions: %v", err)
+		return 1
+	}
+	manifest, err := bundle.Manifest()
+	if err != nil {
+		klog.Errorf("unable to render the imported Subscriptions: %v", err)
+		return 1
+	}
+	if _, err := os.Stdout.Write(manifest); err != nil {
+		klog.Errorf("unable to write the imported Subscriptions: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// reportOperatorVersions prints the installed versions of the operators managed by ODLM across the cluster,
+// it returns the exit code of the command
+func reportOperatorVersions() int {
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		klog.Errorf("unable to create client: %v", err)
+		return 1
+	}
+	r := &operandrequest.Reconciler{
+		ODLMOperator: &deploy.ODLMOperator{
+			Client: c,
+			Reader: c,
+			Scheme: scheme,
+		},
+	}
+	report, err := r.ReportOperatorVersions(context.Background())
+	if err != nil {
+		klog.Errorf("unable to report the operator versions: %v", err)
+		return 1
+	}
+	manifest, err := report.Manifest()
+	if err != nil {
+		klog.Errorf("unable to render the operator versions: %v", err)
+		return 1
+	}
+	if _, err := os.Stdout.Write(manifest); err != nil {
+		klog.Errorf("unable to write the operator versions: %v", err)
+		return 1
+	}
+	return 0
+}